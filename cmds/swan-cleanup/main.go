@@ -0,0 +1,138 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// swan-cleanup reclaims resources leaked by crashed or killed experiments.
+// Resources are read from experiment journals and (optionally) found by scanning for swan-labelled resources.
+// By default only a dry-run listing is printed; resources are removed when -cleanup_force flag is set.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/intelsdi-x/swan/pkg/cleanup"
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/experiment"
+	"github.com/intelsdi-x/swan/pkg/journal"
+	"github.com/intelsdi-x/swan/pkg/snap"
+	"github.com/intelsdi-x/swan/pkg/utils/errutil"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	experimentIDFlag = conf.NewStringFlag("cleanup_experiment_id", "Reclaim resources of given experiment only (journals of all experiments which are not running are used by default).", "")
+	scanFlag         = conf.NewBoolFlag("cleanup_scan", "Scan for swan-labelled resources (cgroups, pods, containers and Snap tasks) in addition to journals.", false)
+	forceFlag        = conf.NewBoolFlag("cleanup_force", "Remove resources. Only dry-run listing is printed when not set.", false)
+	cgroupPrefixFlag = conf.NewStringFlag("cleanup_cgroup_prefix", "Prefix of top level cgroups found by scanning.", "swan")
+)
+
+func main() {
+	experiment.Configure()
+
+	cleaner := cleanup.NewCleaner(
+		cleanup.NewCgroupReclaimer(cgroupPrefixFlag.Value()),
+		cleanup.NewRDTReclaimer(),
+		cleanup.NewResctrlGroupReclaimer(),
+		cleanup.NewKubernetesPodReclaimer(executor.DefaultKubernetesConfig()),
+		cleanup.NewDockerContainerReclaimer(executor.DefaultDockerConfig().Host),
+		cleanup.NewOpenstackInstanceReclaimer(),
+		cleanup.NewSnapTaskReclaimer(snap.SnapteldAddress.Value()),
+	)
+
+	directory := experiment.JournalDirectoryFlag.Value()
+	experimentIDs := []string{experimentIDFlag.Value()}
+	// Resources of running experiments are not reclaimed, even when they are found by scanning.
+	var running []journal.Resource
+	if experimentIDFlag.Value() == "" {
+		journals, err := journal.List(directory)
+		errutil.CheckWithContext(err, "Cannot list experiment journals")
+
+		// Resources of running experiments are reclaimed only when experiment is explicitly named.
+		experimentIDs = nil
+		for _, experimentID := range journals {
+			inUse, err := journal.InUse(directory, experimentID)
+			errutil.CheckWithContext(err, fmt.Sprintf("Cannot check journal of experiment %q", experimentID))
+			if inUse {
+				fmt.Printf("Experiment %s is running: skipping its journal (use -%s flag to reclaim its resources).\n", experimentID, experimentIDFlag.Name)
+				resources, err := journal.Outstanding(journal.Path(directory, experimentID))
+				errutil.CheckWithContext(err, fmt.Sprintf("Cannot read journal of experiment %q", experimentID))
+				running = append(running, resources...)
+				continue
+			}
+			experimentIDs = append(experimentIDs, experimentID)
+		}
+	} else if inUse, err := journal.InUse(directory, experimentIDFlag.Value()); err == nil && inUse {
+		logrus.Warnf("Experiment %q is still running: its resources might be in use", experimentIDFlag.Value())
+	}
+
+	// Resources from journals, by experiment ID.
+	journaled := map[string][]journal.Resource{}
+	var all []journal.Resource
+	for _, experimentID := range experimentIDs {
+		resources, err := journal.Outstanding(journal.Path(directory, experimentID))
+		errutil.CheckWithContext(err, fmt.Sprintf("Cannot read journal of experiment %q", experimentID))
+		journaled[experimentID] = resources
+		all = append(all, resources...)
+
+		fmt.Printf("Experiment %s (%d resources):\n", experimentID, len(resources))
+		for _, resource := range resources {
+			fmt.Printf("\t%s\n", resource)
+		}
+	}
+
+	var scanned []journal.Resource
+	if scanFlag.Value() {
+		found, err := cleaner.Scan()
+		if err != nil {
+			logrus.Warnf("Scanning for resources was not complete: %s", err.Error())
+		}
+		// Skip resources which are already known from journals or used by running experiments.
+		scanned = cleanup.Without(found, append(all, running...))
+
+		fmt.Printf("Found by scanning (%d resources):\n", len(scanned))
+		for _, resource := range scanned {
+			fmt.Printf("\t%s\n", resource)
+		}
+	}
+
+	if !forceFlag.Value() {
+		fmt.Printf("Dry run: nothing has been removed (use -%s flag to remove listed resources).\n", forceFlag.Name)
+		return
+	}
+
+	failed := false
+	for _, experimentID := range experimentIDs {
+		err := cleaner.Reclaim(journaled[experimentID])
+		if err != nil {
+			logrus.Errorf("Cannot reclaim all resources of experiment %q: %s", experimentID, err.Error())
+			failed = true
+			continue
+		}
+		err = journal.Remove(directory, experimentID)
+		if err != nil {
+			logrus.Errorf("Cannot remove journal of experiment %q: %s", experimentID, err.Error())
+			failed = true
+		}
+	}
+
+	if err := cleaner.Reclaim(scanned); err != nil {
+		logrus.Errorf("Cannot reclaim all scanned resources: %s", err.Error())
+		failed = true
+	}
+
+	if failed {
+		os.Exit(experiment.ExSoftware)
+	}
+}
@@ -57,6 +57,11 @@ func main() {
 	// Initialize logger (and log some basic information: experiment name, UUID generated above etc).
 	logger.Initialize(appName, uid)
 
+	// Record resources created by experiment, so they can be reclaimed by swan-cleanup when experiment crashes.
+	closeJournal, err := experiment.OpenJournal(uid)
+	errutil.CheckWithContext(err, "Cannot open experiment journal")
+	defer closeJournal()
+
 	// Connect to metadata database (Cassandra is the default database).
 	// Besides experiment results and platform metrics (we use Snap to gather them) we save certain deta about experiment configuration and environment (metadata).
 	metaData, err := metadata.NewDefault(uid)
@@ -0,0 +1,189 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/controller"
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/experiment"
+	"github.com/intelsdi-x/swan/pkg/experiment/sensitivity"
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/isolation/cgroup"
+	"github.com/intelsdi-x/swan/pkg/metadata"
+	mutilatesession "github.com/intelsdi-x/swan/pkg/snap/sessions/mutilate"
+	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
+	"github.com/intelsdi-x/swan/pkg/workloads/mutilate"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	controllerFlag         = conf.NewBoolFlag("cat_controller", "Protect Memcached SLO with closed-loop controller which shrinks and grows BE cache ways and memory bandwidth (up to allocation of the phase) instead of measuring static allocation.", false)
+	controllerIntervalFlag = conf.NewDurationFlag("cat_controller_interval", "Duration of load generated to measure Memcached SLI in every step of controller.", 5*time.Second)
+)
+
+// controllerWindowKey is Snap tag with number of load generator window results come from.
+const controllerWindowKey = "controller_window"
+
+// runController generates load of the phase in windows of controller interval for loadDuration.
+// After every window controller compares measured SLI with SLO and changes cache ways and memory bandwidth
+// of BE resctrl group; BE task is paused with beFreezer and killed when SLO violation cannot be handled otherwise.
+// Decisions are recorded in metadata and load generator results of every window are published with Snap.
+func runController(ctx context.Context, phaseName string, loadGenerator executor.LoadGenerator, qps int, loadDuration time.Duration,
+	beResctrl *isolation.Resctrl, minCacheWays, beCacheWays int, beMBAPercents []int,
+	beHandle executor.TaskHandle, beFreezer cgroup.Cgroup, snapTags map[string]interface{}, metaData metadata.Metadata) error {
+
+	config := controller.DefaultConfig(sensitivity.SLOFlag.Value())
+	config.Name = phaseName
+	config.Interval = controllerIntervalFlag.Value()
+
+	cacheWays, err := controller.NewCacheWaysActuator(beResctrl, 0, minCacheWays, beCacheWays)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create cache ways actuator in %s", phaseName)
+	}
+	actuators := []controller.Actuator{cacheWays}
+	if len(beMBAPercents) > 1 {
+		memoryBandwidth, err := controller.NewMemoryBandwidthActuator(beResctrl, beMBAPercents)
+		if err != nil {
+			return errors.Wrapf(err, "cannot create memory bandwidth actuator in %s", phaseName)
+		}
+		actuators = append(actuators, memoryBandwidth)
+	}
+
+	shutdown := experiment.Shutdown()
+	sli := controller.NewLoadGeneratorSLI(loadGenerator, qps, config.Interval, mutilate.ParseSLI)
+	sli.SetTaskTracker(shutdown.TrackTaskHandle)
+	var windows []executor.TaskHandle
+	sli.SetResultsHandler(func(handle executor.TaskHandle) {
+		windows = append(windows, handle)
+	})
+	defer func() {
+		for _, window := range windows {
+			window.EraseOutput()
+		}
+	}()
+
+	c, err := controller.NewController(config, sli, actuators...)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create controller in %s", phaseName)
+	}
+	c.SetMetadata(metaData)
+	if beHandle != nil {
+		c.SetBestEffortTasks([]executor.TaskHandle{beHandle})
+	}
+	if beFreezer != nil {
+		freezer := controller.NewCgroupFreezer(beFreezer)
+		c.SetPauser(freezer)
+		// BE task cannot be stopped when it is frozen.
+		defer func() {
+			if err := freezer.Resume(); err != nil {
+				logrus.Errorf("Cannot resume BE tasks in %s: %s", phaseName, err.Error())
+			}
+		}()
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, loadDuration)
+	c.Run(runCtx)
+	cancel()
+	if err := ctx.Err(); err != nil {
+		return errors.Wrapf(err, "controller has been interrupted in %s", phaseName)
+	}
+	if len(windows) == 0 {
+		return errors.Errorf("no load has been generated in %s", phaseName)
+	}
+
+	return publishWindows(ctx, phaseName, windows, snapTags)
+}
+
+// publishWindows publishes results of load generator tasks run by controller with Snap.
+// Error is returned when any of them failed.
+func publishWindows(ctx context.Context, phaseName string, windows []executor.TaskHandle, snapTags map[string]interface{}) error {
+	shutdown := experiment.Shutdown()
+	errColl := &errcollection.ErrorCollection{}
+	var snapHandles []executor.TaskHandle
+	for i, window := range windows {
+		exitCode, err := window.ExitCode()
+		if err != nil {
+			errColl.Add(errors.Wrapf(err, "cannot read exit code of Load Generator in %s", phaseName))
+			continue
+		}
+		if exitCode != 0 {
+			errColl.Add(errors.Errorf("executing Load Generator returned with exit code %d in window %d of %s", exitCode, i, phaseName))
+			continue
+		}
+
+		mutilateOutput, err := window.StdoutFile()
+		if err != nil {
+			errColl.Add(errors.Wrap(err, "cannot get mutilate stdout file"))
+			continue
+		}
+		defer mutilateOutput.Close()
+
+		windowTags := map[string]interface{}{controllerWindowKey: i}
+		for key, value := range snapTags {
+			windowTags[key] = value
+		}
+		mutilateConfig := mutilatesession.DefaultConfig()
+		mutilateConfig.Tags = windowTags
+		mutilateSnapSession, err := mutilatesession.NewSessionLauncher(mutilateOutput.Name(), mutilateConfig)
+		if err != nil {
+			errColl.Add(errors.Wrapf(err, "cannot create Mutilate snap session during phase %q", phaseName))
+			continue
+		}
+
+		snapHandle, err := executor.LaunchContext(ctx, mutilateSnapSession)
+		if err != nil {
+			errColl.Add(errors.Wrapf(err, "cannot launch mutilate Snap session in phase %s", phaseName))
+			continue
+		}
+		defer shutdown.TrackTaskHandle(snapHandle)()
+		snapHandles = append(snapHandles, snapHandle)
+	}
+
+	if len(snapHandles) > 0 {
+		// It is ugly but there is no other way to make sure that data is written to Cassandra as of now.
+		time.Sleep(5 * time.Second)
+		for _, snapHandle := range snapHandles {
+			errColl.Add(snapHandle.Stop())
+		}
+	}
+
+	return errColl.GetErrIfAny()
+}
+
+// controlledMBAPercents returns ascending percentages of memory bandwidth controller can give to BE workload
+// in phase which allocates beMBAPercent (none when memory bandwidth is not throttled).
+func controlledMBAPercents(percents []int, beMBAPercent int, mbaUsed bool) []int {
+	if !mbaUsed {
+		return nil
+	}
+	unique := map[int]bool{}
+	for _, percent := range percents {
+		if percent <= beMBAPercent {
+			unique[percent] = true
+		}
+	}
+	controlled := []int{}
+	for percent := range unique {
+		controlled = append(controlled, percent)
+	}
+	sort.Ints(controlled)
+	return controlled
+}
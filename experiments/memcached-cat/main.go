@@ -29,11 +29,11 @@ import (
 	"github.com/intelsdi-x/swan/pkg/experiment/sensitivity"
 	"github.com/intelsdi-x/swan/pkg/experiment/sensitivity/validate"
 	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/isolation/cgroup"
 	"github.com/intelsdi-x/swan/pkg/metadata"
 	mutilatesession "github.com/intelsdi-x/swan/pkg/snap/sessions/mutilate"
 	"github.com/intelsdi-x/swan/pkg/snap/sessions/rdt"
 	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
-	"github.com/intelsdi-x/swan/pkg/utils/errutil"
 	_ "github.com/intelsdi-x/swan/pkg/utils/unshare"
 	"github.com/intelsdi-x/swan/pkg/utils/uuid"
 	"github.com/pkg/errors"
@@ -47,10 +47,14 @@ var (
 	cacheParitioningFlag     = conf.NewBoolFlag("cat_cache_paritioning", "Enables dedicated sets of cache ways for HP and BE workloads (if disabled then HP workload uses all cache ways all the time).", false)
 	minNumberOfBECPUsFlag    = conf.NewIntFlag("cat_min_be_cpus", "Minimum number of CPUs available to BE job.", 1)
 	maxNumberOfBECPUsFlag    = conf.NewIntFlag("cat_max_be_cpus", "Maximum number of CPUs available to BE job. If set to zero then all available cores will be used (taking isolation defined into consideration).", 0)
+	beMBAPercentsFlag        = conf.NewStringFlag("cat_be_mba_percents", "Comma-separated list of memory bandwidth percentages (1-100) available to BE job. Bandwidth is throttled with Memory Bandwidth Allocation for values lower than 100.", "100")
 	useRDTCollectorFlag      = conf.NewBoolFlag("use_rdt_collector", "Collects Intel RDT metrics.", false)
 	appName                  = os.Args[0]
 )
 
+// phaseMetadataKind is kind of metadata allocations of every phase are recorded with.
+const phaseMetadataKind = "phase"
+
 func main() {
 	// Preparing application - setting name, help, parsing flags etc.
 	experimentStart := time.Now()
@@ -62,16 +66,48 @@ func main() {
 	// Initialize logger.
 	logger.Initialize(appName, uid)
 
+	// Validate preconditions.
+	validate.OS()
+
+	// Resources of the experiment are released by run (also when it fails), so the process exits only here.
+	err := run(uid, experimentStart)
+	sensitivity.PrintDryRunPlan()
+	if err != nil {
+		logrus.Errorf("Experiment %s with uid %s failed: %s", appName, uid, err.Error())
+		os.Exit(experiment.ExSoftware)
+	}
+	logrus.Infof("Ended experiment %s with uid %s in %s", appName, uid, time.Since(experimentStart).String())
+}
+
+// run executes all phases of the experiment. Errors are returned instead of terminating the process,
+// so resctrl groups, journal and shutdown coordinator are always released by deferred calls.
+func run(uid string, experimentStart time.Time) error {
+	// Record resources created by experiment, so they can be reclaimed by swan-cleanup when experiment crashes.
+	closeJournal, err := experiment.OpenJournal(uid)
+	if err != nil {
+		return errors.Wrap(err, "cannot open experiment journal")
+	}
+	defer closeJournal()
+
 	// Connect to metadata database
 	metaData, err := metadata.NewDefault(uid)
-	errutil.CheckWithContext(err, "Cannot connect to Cassandra Metadata Database")
+	if err != nil {
+		return errors.Wrap(err, "cannot connect to Cassandra Metadata Database")
+	}
 
 	// Save experiment runtime environment (configuration, environmental variables, etc).
 	err = metadata.RecordRuntimeEnv(metaData, experimentStart)
-	errutil.CheckWithContext(err, "Cannot save runtime environment in Cassandra Metadata Database")
+	if err != nil {
+		return errors.Wrap(err, "cannot save runtime environment in Cassandra Metadata Database")
+	}
 
-	// Validate preconditions.
-	validate.OS()
+	// Release all resources and record interruption when experiment is interrupted.
+	shutdown := experiment.Shutdown()
+	shutdown.SetMetadata(metaData)
+	shutdown.Start()
+	defer shutdown.Stop()
+	// Tasks are stopped as soon as experiment is interrupted.
+	ctx := shutdown.Context()
 
 	// Read configuration.
 	stopOnError := sensitivity.StopOnErrorFlag.Value()
@@ -85,10 +121,32 @@ func main() {
 	var qpsList []int
 	for _, v := range strings.Split(qps, ",") {
 		vInt, err := strconv.Atoi(strings.TrimSpace(v))
-		errutil.CheckWithContext(err, fmt.Sprintf("Failed converting %s to integer", v))
+		if err != nil {
+			return errors.Wrapf(err, "failed converting %s to integer", v)
+		}
 		qpsList = append(qpsList, vInt)
 	}
 
+	// Read MBA flag and convert to integers.
+	beMBAPercents := beMBAPercentsFlag.Value()
+	var beMBAPercentsList []int
+	for _, v := range strings.Split(beMBAPercents, ",") {
+		vInt, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return errors.Wrapf(err, "failed converting %s to integer", v)
+		}
+		if vInt < 1 || vInt > 100 {
+			return errors.Errorf("memory bandwidth percentage %d is out of range 1-100", vInt)
+		}
+		beMBAPercentsList = append(beMBAPercentsList, vInt)
+	}
+
+	// Memory bandwidth is left unrestricted when it is not throttled in any phase, so platforms without MBA are supported.
+	mbaUsed := false
+	for _, percent := range beMBAPercentsList {
+		mbaUsed = mbaUsed || percent < 100
+	}
+
 	hpThreads, _, beThreads := sensitivity.GetWorkloadCPUThreads()
 
 	// Record metadata.
@@ -103,9 +161,12 @@ func main() {
 		"min_be_cache_mask":            strconv.FormatUint(minCacheWaysToAssign, 10),
 		"max_be_cpu_count":             strconv.Itoa(maxBECPUsCount),
 		"min_be_cpu_count":             strconv.Itoa(minBECPUsCount),
+		"be_mba_percents":              beMBAPercents,
 	}
 	err = metaData.RecordMap(records, metadata.TypeEmpty)
-	errutil.CheckWithContext(err, "Cannot save metadata in Cassandra Metadata Database")
+	if err != nil {
+		return errors.Wrap(err, "cannot save metadata in Cassandra Metadata Database")
+	}
 	logrus.Debugf("IntSet with all BE cores: %v", beThreads)
 
 	// Include baseline phase if necessary.
@@ -115,10 +176,49 @@ func main() {
 	numberOfAvailableCacheWays := uint64(maxCacheWaysToAssign + minCacheWaysToAssign)
 	wholeCacheMask := 1<<numberOfAvailableCacheWays - 1
 
+	// Cache ways and memory bandwidth are allocated to HP and BE workloads with resctrl groups
+	// which allocations are changed in every phase.
+	hpResctrl := isolation.NewResctrl("swan-hp-"+uid, int(wholeCacheMask), 0).(*isolation.Resctrl)
+	beResctrl := isolation.NewResctrl("swan-be-"+uid, int(wholeCacheMask), 0).(*isolation.Resctrl)
+	for _, group := range []*isolation.Resctrl{hpResctrl, beResctrl} {
+		if err := group.Create(); err != nil {
+			return errors.Wrap(err, "cannot create resctrl group")
+		}
+		untrack := shutdown.TrackIsolation(group)
+		defer func(group *isolation.Resctrl) {
+			if err := group.Clean(); err != nil {
+				logrus.Errorf("Cannot clean resctrl group: %s", err.Error())
+			}
+			untrack()
+		}(group)
+	}
+
+	// BE tasks are paused by controller with freezer cgroup.
+	var beFreezer cgroup.Cgroup
+	if controllerFlag.Value() {
+		beFreezer, err = cgroup.NewCgroup([]string{cgroup.FreezerController}, "swan-be-"+uid)
+		if err != nil {
+			return errors.Wrap(err, "cannot create BE freezer cgroup")
+		}
+		if err := beFreezer.Create(); err != nil {
+			return errors.Wrap(err, "cannot create BE freezer cgroup")
+		}
+		untrack := shutdown.TrackIsolation(beFreezer)
+		defer func() {
+			if err := beFreezer.Clean(); err != nil {
+				logrus.Errorf("Cannot clean BE freezer cgroup: %s", err.Error())
+			}
+			untrack()
+		}()
+	}
+
 	if experiment.ShouldLaunchKubernetesCluster() {
 		handle, err := experiment.LaunchKubernetesCluster()
-		errutil.CheckWithContext(err, "Could not launch Kubernetes cluster")
+		if err != nil {
+			return errors.Wrap(err, "could not launch Kubernetes cluster")
+		}
 		defer handle.Stop()
+		defer shutdown.TrackTaskHandle(handle)()
 	}
 
 	for _, aggressorName := range aggressors {
@@ -131,7 +231,9 @@ func main() {
 				logrus.Debugf("starting cores: %d with limit of %d", BECPUsCount, minBECPUsCount)
 				// Chose CPUs to be used.
 				beThreads, err := beThreads.Take(BECPUsCount)
-				errutil.CheckWithContext(err, fmt.Sprintf("unable to subtract cores for aggressor %q, number of cores %d, QpS %d", aggressorName, BECPUsCount, qps))
+				if err != nil {
+					return errors.Wrapf(err, "unable to subtract cores for aggressor %q, number of cores %d, QpS %d", aggressorName, BECPUsCount, qps)
+				}
 				beThreadsRange := beThreads.AsRangeString()
 				hpThreadsRange := hpThreads.AsRangeString()
 				logrus.Debugf("Subtracted %d cores and got: %v", BECPUsCount, beThreadsRange)
@@ -154,186 +256,237 @@ func main() {
 					logrus.Debugf("Current L3 HP mask: %d, %b (%d)", hpCacheMask, hpCacheMask, hpCacheWays)
 					logrus.Debugf("Current L3 BE mask: %d, %b (%d)", beCacheMask, beCacheMask, beCacheWays)
 
-					hpIsolation := isolation.Rdtset{Mask: hpCacheMask, CPURange: hpThreadsRange}
-					beIsolation := isolation.Rdtset{Mask: beCacheMask, CPURange: beThreadsRange}
-					logrus.Debugf("HP isolation: %+v, BE isolation: %+v", hpIsolation, beIsolation)
-
-					workloadFactory := sensitivity.NewWorkloadFactoryWithIsolation(
-						sensitivity.NewExecutorFactory(),
-						hpIsolation,
-						beIsolation,
-						beIsolation,
-					)
+					for _, beMBAPercent := range beMBAPercentsList {
+						phaseName := fmt.Sprintf("Aggressor %s (at %d QPS) - BE LLC %b - BE MBA %d%%", aggressorName, qps, beCacheMask, beMBAPercent)
 
-					phaseName := fmt.Sprintf("Aggressor %s (at %d QPS) - BE LLC %b", aggressorName, qps, beCacheMask)
-
-					// Building snap workload tags.
-					snapTags := make(map[string]interface{})
-					snapTags[experiment.ExperimentKey] = uid
-					snapTags[experiment.PhaseKey] = phaseName
-					snapTags[experiment.AggressorNameKey] = aggressorName
-					snapTags[experiment.LoadPointQPSKey] = qps
-					snapTags["be_l3_cache_ways"] = beCacheWays
-					snapTags["be_number_of_cores"] = BECPUsCount
-					snapTags["be_cores_range"] = beThreadsRange
-					snapTags["hp_cores_range"] = hpThreadsRange
-
-					// Create HP workload.
-					hpLauncher, err := workloadFactory.BuildDefaultHighPriorityLauncher(sensitivity.Memcached, snapTags)
-					errutil.CheckWithContext(err, fmt.Sprintf("Cannot create Memcached Launcher during phase %q", phaseName))
-
-					// Create BE workloads.
-					beLauncher, err := workloadFactory.BuildDefaultBestEffortLauncher(aggressorName, snapTags)
-					errutil.CheckWithContext(err, fmt.Sprintf("Cannot create best effort workload %q", aggressorName))
-
-					// Clean any RDT assignments from previous phases.
-					pqosOutput, err := isolation.CleanRDTAssingments()
-					logrus.Debugf("pqos -R has been run and produced following output: %q", pqosOutput)
-					errutil.CheckWithContext(err, fmt.Sprintf("cleaning rdt assigments failed (pqos -R) during phase %q", phaseName))
-
-					// Create load generator.
-					loadGenerator, err := common.PrepareDefaultMutilateGenerator()
-					errutil.CheckWithContext(err, fmt.Sprintf("Cannot create Mutilate load generator during phase %q", phaseName))
-
-					useRDTCollector := useRDTCollectorFlag.Value()
-					var rdtSession executor.Launcher
-					if useRDTCollector {
-						rdtConfig := rdt.DefaultConfig()
-						rdtConfig.Tags = snapTags
-						rdtSession, err = rdt.NewSessionLauncher(rdtConfig)
-						errutil.CheckWithContext(err, "Cannot create rdt snap session")
-					}
-
-					// We need to collect all the TaskHandles created in order to cleanup after repetition finishes.
-					var processes []executor.TaskHandle
+						// Apply allocations of the phase.
+						if err := hpResctrl.SetL3Mask(hpCacheMask); err != nil {
+							return errors.Wrapf(err, "cannot set HP cache mask during phase %q", phaseName)
+						}
+						if err := beResctrl.SetL3Mask(beCacheMask); err != nil {
+							return errors.Wrapf(err, "cannot set BE cache mask during phase %q", phaseName)
+						}
+						if mbaUsed {
+							if err := beResctrl.SetMBPercent(beMBAPercent); err != nil {
+								return errors.Wrapf(err, "cannot set BE memory bandwidth during phase %q", phaseName)
+							}
+						}
 
-					// Using a closure allows us to defer cleanup functions. Otherwise handling cleanup might get much more complicated.
-					// This is the easiest and most golangish way. Deferring cleanup in case of errors to main() termination could cause panics.
-					executeRepetition := func() error {
-						logrus.Infof("Starting %s", phaseName)
+						hpIsolation := isolation.Decorators{isolation.Taskset{CPUList: hpThreads}, hpResctrl}
+						beIsolation := isolation.Decorators{isolation.Taskset{CPUList: beThreads}, beResctrl}
+						if beFreezer != nil {
+							beIsolation = append(beIsolation, beFreezer)
+						}
+						logrus.Debugf("HP isolation: %q, BE isolation: %q", hpIsolation.Decorate(""), beIsolation.Decorate(""))
+
+						workloadFactory := sensitivity.NewWorkloadFactoryWithIsolation(
+							sensitivity.NewExecutorFactory(),
+							hpIsolation,
+							beIsolation,
+							beIsolation,
+						)
+
+						// Building snap workload tags.
+						snapTags := make(map[string]interface{})
+						snapTags[experiment.ExperimentKey] = uid
+						snapTags[experiment.PhaseKey] = phaseName
+						snapTags[experiment.AggressorNameKey] = aggressorName
+						snapTags[experiment.LoadPointQPSKey] = qps
+						snapTags["be_l3_cache_ways"] = beCacheWays
+						snapTags["be_mba_percent"] = beMBAPercent
+						snapTags["be_number_of_cores"] = BECPUsCount
+						snapTags["be_cores_range"] = beThreadsRange
+						snapTags["hp_cores_range"] = hpThreadsRange
+
+						// Create HP workload.
+						hpLauncher, err := workloadFactory.BuildDefaultHighPriorityLauncher(sensitivity.Memcached, snapTags)
+						if err != nil {
+							return errors.Wrapf(err, "cannot create Memcached Launcher during phase %q", phaseName)
+						}
 
-						err = experiment.CreateRepetitionDir(appName, uid, phaseName, 0)
+						// Create BE workloads.
+						beLauncher, err := workloadFactory.BuildDefaultBestEffortLauncher(aggressorName, snapTags)
 						if err != nil {
-							return errors.Wrapf(err, "cannot create repetition log directory in %s", phaseName)
+							return errors.Wrapf(err, "cannot create best effort workload %q", aggressorName)
 						}
 
-						hpHandle, err := hpLauncher.Launch()
+						// Record allocations of the phase.
+						err = metaData.RecordMap(map[string]string{
+							phaseName + " be_l3_cache_ways":   strconv.FormatUint(beCacheWays, 10),
+							phaseName + " hp_l3_cache_ways":   strconv.FormatUint(hpCacheWays, 10),
+							phaseName + " be_mba_percent":     strconv.Itoa(beMBAPercent),
+							phaseName + " be_number_of_cores": strconv.Itoa(BECPUsCount),
+						}, phaseMetadataKind)
 						if err != nil {
-							return errors.Wrapf(err, "cannot launch memcached in %s", phaseName)
+							return errors.Wrapf(err, "cannot save metadata of phase %q", phaseName)
 						}
-						processes = append(processes, hpHandle)
 
-						err = loadGenerator.Populate()
+						// Create load generator.
+						loadGenerator, err := common.PrepareDefaultMutilateGenerator()
 						if err != nil {
-							return errors.Wrapf(err, "cannot populate memcached in %s", phaseName)
+							return errors.Wrapf(err, "cannot create Mutilate load generator during phase %q", phaseName)
 						}
 
-						var beHandle executor.TaskHandle
-						// Start BE job (and its session if it exists)
-						if beLauncher != nil {
-							beHandle, err = beLauncher.Launch()
+						useRDTCollector := useRDTCollectorFlag.Value()
+						var rdtSession executor.Launcher
+						if useRDTCollector {
+							rdtConfig := rdt.DefaultConfig()
+							rdtConfig.Tags = snapTags
+							rdtSession, err = rdt.NewSessionLauncher(rdtConfig)
 							if err != nil {
-								return errors.Wrapf(err, "cannot launch aggressor %s in %s", beLauncher, phaseName)
+								return errors.Wrap(err, "cannot create rdt snap session")
 							}
-							processes = append(processes, beHandle)
 						}
 
-						var rdtSessionHandle executor.TaskHandle
-						if useRDTCollector {
-							rdtSessionHandle, err = rdtSession.Launch()
-							errutil.PanicWithContext(err, "Cannot launch Snap RDT Collection session")
-							defer rdtSessionHandle.Stop()
-						}
+						// We need to collect all the TaskHandles created in order to cleanup after repetition finishes.
+						var processes []executor.TaskHandle
+						var untrackProcesses []func()
 
-						logrus.Debugf("Launching Load Generator with BE cache mask: %b and HP cache mask: %b", beCacheMask, hpCacheMask)
-						loadGeneratorHandle, err := loadGenerator.Load(qps, loadDuration)
-						if err != nil {
-							return errors.Wrapf(err, "Unable to start load generation in %s", phaseName)
-						}
-						mutilateTerminated, err := loadGeneratorHandle.Wait(sensitivity.LoadGeneratorWaitTimeoutFlag.Value())
-						if err != nil {
-							logrus.Errorf("Mutilate cluster failed: %q", err)
-							return errors.Wrap(err, "mutilate cluster failed")
-						}
+						// Using a closure allows us to defer cleanup functions. Otherwise handling cleanup might get much more complicated.
+						// This is the easiest and most golangish way. Deferring cleanup in case of errors to main() termination could cause panics.
+						executeRepetition := func() error {
+							logrus.Infof("Starting %s", phaseName)
 
-						if !mutilateTerminated {
-							logrus.Warn("Mutilate cluster failed to stop on its own. Attempting to stop...")
-							err := loadGeneratorHandle.Stop()
+							err = experiment.CreateRepetitionDir(appName, uid, phaseName, 0)
 							if err != nil {
-								logrus.Errorf("Stopping mutilate cluster errored: %q", err)
-								return errors.Wrap(err, "stopping mutilate cluster errored")
+								return errors.Wrapf(err, "cannot create repetition log directory in %s", phaseName)
 							}
-						}
 
-						if useRDTCollector {
-							err = rdtSessionHandle.Stop()
+							hpHandle, err := executor.LaunchContext(ctx, hpLauncher)
 							if err != nil {
-								return errors.Wrapf(err, "errors while stopping RDT session in phase %s", phaseName)
+								return errors.Wrapf(err, "cannot launch memcached in %s", phaseName)
 							}
-						}
+							processes = append(processes, hpHandle)
+							untrackProcesses = append(untrackProcesses, shutdown.TrackTaskHandle(hpHandle))
 
-						if beHandle != nil {
-							err = beHandle.Stop()
+							err = loadGenerator.Populate()
 							if err != nil {
-								return errors.Wrapf(err, "best effort task has failed in phase %s", phaseName)
+								return errors.Wrapf(err, "cannot populate memcached in %s", phaseName)
 							}
-						}
 
-						mutilateOutput, err := loadGeneratorHandle.StdoutFile()
-						if err != nil {
-							return errors.Wrapf(err, "cannot get mutilate stdout file")
-						}
-						defer mutilateOutput.Close()
+							var beHandle executor.TaskHandle
+							// Start BE job (and its session if it exists)
+							if beLauncher != nil {
+								beHandle, err = executor.LaunchContext(ctx, beLauncher)
+								if err != nil {
+									return errors.Wrapf(err, "cannot launch aggressor %s in %s", beLauncher, phaseName)
+								}
+								processes = append(processes, beHandle)
+								untrackProcesses = append(untrackProcesses, shutdown.TrackTaskHandle(beHandle))
+							}
 
-						// Create snap session launcher
-						mutilateConfig := mutilatesession.DefaultConfig()
-						mutilateConfig.Tags = snapTags
-						mutilateSnapSession, err := mutilatesession.NewSessionLauncher(
-							mutilateOutput.Name(), mutilateConfig)
-						if err != nil {
-							return errors.Wrapf(err, fmt.Sprintf("Cannot create Mutilate snap session during phase %q", phaseName))
-						}
+							var rdtSessionHandle executor.TaskHandle
+							if useRDTCollector {
+								rdtSessionHandle, err = executor.LaunchContext(ctx, rdtSession)
+								if err != nil {
+									return errors.Wrapf(err, "cannot launch Snap RDT Collection session in phase %s", phaseName)
+								}
+								defer rdtSessionHandle.Stop()
+								defer shutdown.TrackTaskHandle(rdtSessionHandle)()
+							}
 
-						snapHandle, err := mutilateSnapSession.Launch()
-						if err != nil {
-							return errors.Wrapf(err, "cannot launch mutilate Snap session in phase %s", phaseName)
+							if controllerFlag.Value() {
+								logrus.Debugf("Launching controller with BE cache mask: %b (memory bandwidth %d%%) and HP cache mask: %b", beCacheMask, beMBAPercent, hpCacheMask)
+								return runController(ctx, phaseName, loadGenerator, qps, loadDuration,
+									beResctrl, int(minCacheWaysToAssign), int(beCacheWays), controlledMBAPercents(beMBAPercentsList, beMBAPercent, mbaUsed),
+									beHandle, beFreezer, snapTags, metaData)
+							}
+
+							logrus.Debugf("Launching Load Generator with BE cache mask: %b (memory bandwidth %d%%) and HP cache mask: %b", beCacheMask, beMBAPercent, hpCacheMask)
+							loadGeneratorHandle, err := executor.LoadContext(ctx, loadGenerator, qps, loadDuration)
+							if err != nil {
+								return errors.Wrapf(err, "Unable to start load generation in %s", phaseName)
+							}
+							defer shutdown.TrackTaskHandle(loadGeneratorHandle)()
+							mutilateTerminated, err := executor.WaitTimeoutContext(ctx, loadGeneratorHandle, sensitivity.LoadGeneratorWaitTimeoutFlag.Value())
+							if err != nil {
+								logrus.Errorf("Mutilate cluster failed: %q", err)
+								return errors.Wrap(err, "mutilate cluster failed")
+							}
+
+							if !mutilateTerminated {
+								logrus.Warn("Mutilate cluster failed to stop on its own. Attempting to stop...")
+								err := loadGeneratorHandle.Stop()
+								if err != nil {
+									logrus.Errorf("Stopping mutilate cluster errored: %q", err)
+									return errors.Wrap(err, "stopping mutilate cluster errored")
+								}
+							}
+
+							if useRDTCollector {
+								err = rdtSessionHandle.Stop()
+								if err != nil {
+									return errors.Wrapf(err, "errors while stopping RDT session in phase %s", phaseName)
+								}
+							}
+
+							if beHandle != nil {
+								err = beHandle.Stop()
+								if err != nil {
+									return errors.Wrapf(err, "best effort task has failed in phase %s", phaseName)
+								}
+							}
+
+							mutilateOutput, err := loadGeneratorHandle.StdoutFile()
+							if err != nil {
+								return errors.Wrapf(err, "cannot get mutilate stdout file")
+							}
+							defer mutilateOutput.Close()
+
+							// Create snap session launcher
+							mutilateConfig := mutilatesession.DefaultConfig()
+							mutilateConfig.Tags = snapTags
+							mutilateSnapSession, err := mutilatesession.NewSessionLauncher(
+								mutilateOutput.Name(), mutilateConfig)
+							if err != nil {
+								return errors.Wrapf(err, fmt.Sprintf("Cannot create Mutilate snap session during phase %q", phaseName))
+							}
+
+							snapHandle, err := executor.LaunchContext(ctx, mutilateSnapSession)
+							if err != nil {
+								return errors.Wrapf(err, "cannot launch mutilate Snap session in phase %s", phaseName)
+							}
+							defer shutdown.TrackTaskHandle(snapHandle)()
+							defer func() {
+								// It is ugly but there is no other way to make sure that data is written to Cassandra as of now.
+								time.Sleep(5 * time.Second)
+								snapHandle.Stop()
+							}()
+
+							exitCode, err := loadGeneratorHandle.ExitCode()
+							if exitCode != 0 {
+								return errors.Errorf("executing Load Generator returned with exit code %d in %s", exitCode, phaseName)
+							}
+
+							return nil
 						}
-						defer func() {
-							// It is ugly but there is no other way to make sure that data is written to Cassandra as of now.
-							time.Sleep(5 * time.Second)
-							snapHandle.Stop()
-						}()
-
-						exitCode, err := loadGeneratorHandle.ExitCode()
-						if exitCode != 0 {
-							return errors.Errorf("executing Load Generator returned with exit code %d in %s", exitCode, phaseName)
+						// Call repetition function.
+						err = executeRepetition()
+
+						// Collecting all the errors that might have been encountered.
+						errColl := &errcollection.ErrorCollection{}
+						errColl.Add(err)
+						for _, th := range processes {
+							errColl.Add(th.Stop())
+						}
+						for _, untrack := range untrackProcesses {
+							untrack()
 						}
 
-						return nil
-					}
-					// Call repetition function.
-					err = executeRepetition()
-
-					// Collecting all the errors that might have been encountered.
-					errColl := &errcollection.ErrorCollection{}
-					errColl.Add(err)
-					for _, th := range processes {
-						errColl.Add(th.Stop())
-					}
-
-					// If any error was found then we should log details and terminate the experiment if stopOnError is set.
-					err = errColl.GetErrIfAny()
-					if err != nil {
-						logrus.Errorf("Experiment failed (%s): %+v", phaseName, err)
-						if stopOnError {
-							os.Exit(experiment.ExSoftware)
+						// If any error was found then we should log details and terminate the experiment if stopOnError is set.
+						err = errColl.GetErrIfAny()
+						if err != nil {
+							logrus.Errorf("Experiment failed (%s): %+v", phaseName, err)
+							if stopOnError {
+								return err
+							}
 						}
+						totalIteration++
 					}
-					totalIteration++
 				}
 			}
 			beIteration++
 		}
 	}
-	logrus.Infof("Ended experiment %s with uid %s in %s", appName, uid, time.Since(experimentStart).String())
+	return nil
 }
@@ -15,10 +15,7 @@
 package main
 
 import (
-	"fmt"
 	"os"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/intelsdi-x/swan/experiments/memcached-sensitivity-profile/common"
@@ -29,10 +26,9 @@ import (
 	"github.com/intelsdi-x/swan/pkg/experiment/sensitivity/validate"
 	"github.com/intelsdi-x/swan/pkg/metadata"
 	mutilatesession "github.com/intelsdi-x/swan/pkg/snap/sessions/mutilate"
-	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
 	"github.com/intelsdi-x/swan/pkg/utils/errutil"
 	_ "github.com/intelsdi-x/swan/pkg/utils/unshare"
-	"github.com/intelsdi-x/swan/pkg/utils/uuid"
+	"github.com/intelsdi-x/swan/pkg/workloads/mutilate"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -46,12 +42,25 @@ func main() {
 	experimentStart := time.Now()
 	experiment.Configure()
 
-	// Generate an experiment ID and start the metadata session.
-	uid := uuid.New()
+	// Generate an experiment ID (or reuse ID of resumed experiment) and start the metadata session.
+	uid := experiment.ExperimentID()
 
 	// Initialize logger.
 	logger.Initialize(appName, uid)
 
+	// Experiment specification (when given) overrides flags.
+	spec, err := sensitivity.SpecFromFlags()
+	errutil.CheckWithContext(err, "Cannot load experiment specification")
+	if spec != nil {
+		errutil.CheckWithContext(spec.CheckWorkloads(sensitivity.Memcached, "mutilate"), "Unsupported experiment specification")
+		errutil.CheckWithContext(spec.Apply(), "Cannot apply experiment specification")
+	}
+
+	// Record resources created by experiment, so they can be reclaimed by swan-cleanup when experiment crashes.
+	closeJournal, err := experiment.OpenJournal(uid)
+	errutil.CheckWithContext(err, "Cannot open experiment journal")
+	defer closeJournal()
+
 	metaData, err := metadata.NewDefault(uid)
 
 	errutil.CheckWithContext(err, "Cannot connect to Cassandra Metadata Database")
@@ -60,6 +69,12 @@ func main() {
 	err = metadata.RecordRuntimeEnv(metaData, experimentStart)
 	errutil.CheckWithContext(err, "Cannot save runtime environment in Cassandra Metadata Database")
 
+	// Release all resources and record interruption when experiment is interrupted.
+	shutdown := experiment.Shutdown()
+	shutdown.SetMetadata(metaData)
+	shutdown.Start()
+	defer shutdown.Stop()
+
 	// Validate preconditions.
 	validate.OS()
 
@@ -68,182 +83,58 @@ func main() {
 		handle, err := experiment.LaunchKubernetesCluster()
 		errutil.CheckWithContext(err, "Could not launch Kubernetes cluster")
 		defer handle.Stop()
+		defer shutdown.TrackTaskHandle(handle)()
 	}
 
-	tuningTags := make(map[string]interface{})
-	tuningTags[experiment.ExperimentKey] = uid
-	tuningTags[experiment.PhaseKey] = "tuning"
-
 	factory := sensitivity.NewDefaultWorkloadFactory()
 
-	hpLauncher, err := factory.BuildDefaultHighPriorityLauncher(sensitivity.Memcached, tuningTags)
-	errutil.CheckWithContext(err, "cannot prepare memcached")
-
 	// Load generator.
 	loadGenerator, err := common.PrepareDefaultMutilateGenerator()
 	errutil.CheckWithContext(err, "cannot prepare load generator")
 
-	// Retrieve peak load from flags and overwrite it when required.
-	load := sensitivity.PeakLoadFlag.Value()
-	if load == sensitivity.RunTuningPhase {
-		logrus.Info("Tuning phase...")
-		load, err = experiment.GetPeakLoad(hpLauncher, loadGenerator, sensitivity.SLOFlag.Value())
-		errutil.CheckWithContext(err, "cannot retrieve peak load during tuning")
-		logrus.Infof("Ran tuning and achieved load of %d", load)
-	} else {
-		logrus.Infof("Skipping tuning phase, using peakload %d", load)
+	// Memcached crashed during repetition is restarted according to supervisor flags.
+	config, err := sensitivity.DefaultRunnerConfig(uid, appName, sensitivity.Memcached, metaData)
+	errutil.CheckWithContext(err, "cannot configure experiment")
+	// Isolations of HP and BE workloads are created for every repetition and released on shutdown.
+	config.Isolations = factory.Isolations()
+
+	// Aggressors are built according to specification (when given).
+	var launcherFactory sensitivity.LauncherFactory = &factory
+	if spec != nil {
+		spec.Configure(&config)
+		launcherFactory = spec.LauncherFactory(&factory)
+		errutil.CheckWithContext(spec.Record(metaData), "Cannot save experiment specification")
 	}
 
-	// Read configuration.
-	stopOnError := sensitivity.StopOnErrorFlag.Value()
-	loadPoints := sensitivity.LoadPointsCountFlag.Value()
-	repetitions := sensitivity.RepetitionsFlag.Value()
-	loadDuration := sensitivity.LoadDurationFlag.Value()
-
-	// Record metadata.
-	records := map[string]string{
-		"command_arguments": strings.Join(os.Args, ","),
-		"experiment_name":   appName,
-		"peak_load":         strconv.Itoa(load),
-		"load_points":       strconv.Itoa(loadPoints),
-		"repetitions":       strconv.Itoa(repetitions),
-		"load_duration":     loadDuration.String(),
+	runner := sensitivity.NewRunner(config, launcherFactory, loadGenerator, []sensitivity.SessionLauncherFactory{newMutilateSession}, sensitivity.RunnerHooks{
+		// SLI reported by Mutilate drives adaptive load schedule.
+		MeasureSLI: sensitivity.LoadGeneratorSLI(mutilate.ParseSLI),
+	})
+	runErr := runner.Run()
+	if runErr != nil {
+		logrus.Errorf("Experiment failed: %s", runErr.Error())
 	}
+	logrus.Infof("Experiment %s with uid %s has ended in %s", appName, uid, time.Since(experimentStart).String())
 
-	err = metaData.RecordMap(records, metadata.TypeEmpty)
-	errutil.CheckWithContext(err, "cannot save metadata")
-
-	bestEfforts := sensitivity.AggressorsFlag.Value()
-	for _, bestEffortWorkloadName := range bestEfforts {
-		for loadPoint := 0; loadPoint < loadPoints; loadPoint++ {
-			// Calculate number of QPS in phase.
-			phaseQPS := int(int(load) / sensitivity.LoadPointsCountFlag.Value() * (loadPoint + 1))
-
-			for repetition := 0; repetition < repetitions; repetition++ {
-				phaseName := fmt.Sprintf("Aggressor %s; load point %d; repetition %d", bestEffortWorkloadName, loadPoint, repetition)
-				// We need to collect all the TaskHandles created in order to cleanup after repetition finishes.
-				var processes []executor.TaskHandle
-				// Using a closure allows us to defer cleanup functions. Otherwise handling cleanup might get much more complicated.
-				// This is the easiest and most golangish way. Deferring cleanup in case of errors to main() termination could cause panics.
-				executeRepetition := func() error {
-					logrus.Infof("Starting phase: %s", phaseName)
-
-					snapTags := make(map[string]interface{})
-					snapTags[experiment.ExperimentKey] = uid
-					snapTags[experiment.PhaseKey] = phaseName
-					snapTags[experiment.RepetitionKey] = repetition
-					snapTags[experiment.LoadPointQPSKey] = phaseQPS
-					snapTags[experiment.AggressorNameKey] = bestEffortWorkloadName
-
-					err := experiment.CreateRepetitionDir(appName, uid, phaseName, repetition)
-					if err != nil {
-						return errors.Wrapf(err, "cannot create repetition log directory in phase %q", phaseName)
-					}
-
-					hpLauncher, err := factory.BuildDefaultHighPriorityLauncher(sensitivity.Memcached, snapTags)
-					errutil.CheckWithContext(err, "cannot prepare memcached")
-					hpHandle, err := hpLauncher.Launch()
-					if err != nil {
-						return errors.Wrapf(err, "cannot launch memcached in %s", phaseName)
-					}
-					processes = append(processes, hpHandle)
-
-					err = loadGenerator.Populate()
-					if err != nil {
-						return errors.Wrapf(err, "cannot populate memcached in %s", phaseName)
-					}
-
-					beLauncher, err := factory.BuildDefaultBestEffortLauncher(bestEffortWorkloadName, snapTags)
-					errutil.CheckWithContext(err, fmt.Sprintf("cannot prepare best effort workload %q", bestEffortWorkloadName))
-					// Launch BE tasks when we are not in baseline.
-					var beHandle executor.TaskHandle
-					if beLauncher != nil {
-						beHandle, err := beLauncher.Launch()
-						if err != nil {
-							return errors.Wrapf(err, "cannot launch aggressor %q, in phase %q", beLauncher, phaseName)
-						}
-						processes = append(processes, beHandle)
-					}
-
-					logrus.Debugf("Launching Load Generator with load point %d", loadPoint)
-					loadGeneratorHandle, err := loadGenerator.Load(phaseQPS, loadDuration)
-					if err != nil {
-						return errors.Wrapf(err, "Unable to start load generation in phase %q", phaseName)
-					}
-
-					mutilateTerminated, err := loadGeneratorHandle.Wait(sensitivity.LoadGeneratorWaitTimeoutFlag.Value())
-					if err != nil {
-						logrus.Errorf("Mutilate cluster failed: %q", err)
-						return errors.Wrap(err, "mutilate cluster failed")
-					}
-					if !mutilateTerminated {
-						logrus.Warn("Mutilate cluster failed to stop on its own. Attempting to stop...")
-						err := loadGeneratorHandle.Stop()
-						if err != nil {
-							logrus.Errorf("Stopping mutilate cluster errored: %q", err)
-							return errors.Wrap(err, "stopping mutilate cluster errored")
-						}
-					}
-
-					if beHandle != nil {
-						err = beHandle.Stop()
-						if err != nil {
-							return errors.Wrapf(err, "best effort task has failed in phase %q", phaseName)
-						}
-					}
-
-					mutilateOutput, err := loadGeneratorHandle.StdoutFile()
-					if err != nil {
-						return errors.Wrapf(err, "cannot get mutilate stdout file")
-					}
-					defer mutilateOutput.Close()
-
-					// Create snap session launcher
-					mutilateConfig := mutilatesession.DefaultConfig()
-					mutilateConfig.Tags = snapTags
-					mutilateSnapSession, err := mutilatesession.NewSessionLauncher(
-						mutilateOutput.Name(), mutilateConfig)
-					if err != nil {
-						return errors.Wrapf(err, fmt.Sprintf("Cannot create Mutilate snap session during phase %q", phaseName))
-					}
-
-					snapHandle, err := mutilateSnapSession.Launch()
-					if err != nil {
-						return errors.Wrapf(err, "cannot launch mutilate Snap session in phase %s", phaseName)
-					}
-					defer func() {
-						// It is ugly but there is no other way to make sure that data is written to Cassandra as of now.
-						time.Sleep(5 * time.Second)
-						snapHandle.Stop()
-					}()
-
-					exitCode, err := loadGeneratorHandle.ExitCode()
-					if exitCode != 0 {
-						return errors.Errorf("executing Load Generator returned with exit code %d in phase %q", exitCode, phaseName)
-					}
-
-					return nil
-				}
-				// Call repetition function.
-				err := executeRepetition()
-
-				// Collecting all the errors that might have been encountered.
-				errColl := &errcollection.ErrorCollection{}
-				errColl.Add(err)
-				for _, th := range processes {
-					errColl.Add(th.Stop())
-				}
-
-				// If any error was found then we should log details and terminate the experiment if stopOnError is set.
-				err = errColl.GetErrIfAny()
-				if err != nil {
-					logrus.Errorf("Experiment failed (%s): %q", phaseName, err.Error())
-					if stopOnError {
-						os.Exit(experiment.ExSoftware)
-					}
-				}
-			}
-		}
+	sensitivity.PrintDryRunPlan()
+	if runErr != nil && config.StopOnError {
+		os.Exit(experiment.ExSoftware)
 	}
-	logrus.Infof("Experiment %s with uid %s has ended in %s", appName, uid, time.Since(experimentStart).String())
+}
+
+// newMutilateSession returns Snap session publishing Mutilate results of repetition.
+func newMutilateSession(repetition sensitivity.Repetition) (executor.Launcher, error) {
+	mutilateOutput, err := repetition.LoadGenerator.StdoutFile()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get mutilate stdout file")
+	}
+	defer mutilateOutput.Close()
+
+	mutilateConfig := mutilatesession.DefaultConfig()
+	mutilateConfig.Tags = repetition.Tags
+	mutilateSnapSession, err := mutilatesession.NewSessionLauncher(mutilateOutput.Name(), mutilateConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create Mutilate snap session")
+	}
+	return mutilateSnapSession, nil
 }
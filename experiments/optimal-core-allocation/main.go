@@ -59,6 +59,11 @@ func main() {
 	// Initialize logger.
 	logger.Initialize(appName, uid)
 
+	// Record resources created by experiment, so they can be reclaimed by swan-cleanup when experiment crashes.
+	closeJournal, err := experiment.OpenJournal(uid)
+	errutil.CheckWithContext(err, "Cannot open experiment journal")
+	defer closeJournal()
+
 	// connect to metadata database
 	metaData, err := metadata.NewDefault(uid)
 	errutil.CheckWithContext(err, "Cannot connect to Cassandra Metadata Database")
@@ -67,6 +72,14 @@ func main() {
 	err = metadata.RecordRuntimeEnv(metaData, experimentStart)
 	errutil.CheckWithContext(err, "Cannot save runtime environment in Cassandra Metadata Database")
 
+	// Release all resources and record interruption when experiment is interrupted.
+	shutdown := experiment.Shutdown()
+	shutdown.SetMetadata(metaData)
+	shutdown.Start()
+	defer shutdown.Stop()
+	// Tasks are stopped as soon as experiment is interrupted.
+	ctx := shutdown.Context()
+
 	// Read configuration.
 	loadDuration := sensitivity.LoadDurationFlag.Value()
 	loadPoints := sensitivity.LoadPointsCountFlag.Value()
@@ -108,6 +121,7 @@ func main() {
 		handle, err := experiment.LaunchKubernetesCluster()
 		errutil.CheckWithContext(err, "Could not launch Kubernetes cluster")
 		defer handle.Stop()
+		defer shutdown.TrackTaskHandle(handle)()
 	}
 
 	// Calculate value to increase QPS by on every iteration.
@@ -152,9 +166,10 @@ func main() {
 				memcachedConfiguration := memcached.DefaultMemcachedConfig()
 				memcachedConfiguration.NumThreads = numberOfThreads
 				memcachedLauncher := executor.ServiceLauncher{Launcher: memcached.New(memcachedExecutor, memcachedConfiguration)}
-				memcachedTask, err := memcachedLauncher.Launch()
+				memcachedTask, err := executor.LaunchContext(ctx, memcachedLauncher)
 				errutil.PanicWithContext(err, "Memcached has not been launched successfully")
 				defer memcachedTask.Stop()
+				defer shutdown.TrackTaskHandle(memcachedTask)()
 
 				// Create mutilate load generator.
 				loadGenerator, err := common.PrepareMutilateGenerator(memcachedConfiguration.IP, memcachedConfiguration.Port)
@@ -184,17 +199,19 @@ func main() {
 					useConfig.Tags = snapTags
 					useSession, err := use.NewSessionLauncher(useConfig)
 					errutil.CheckWithContext(err, "Cannot create USE snap session")
-					useSessionHandle, err = useSession.Launch()
+					useSessionHandle, err = executor.LaunchContext(ctx, useSession)
 					errutil.PanicWithContext(err, "Cannot launch Snap USE Collection session")
 					defer useSessionHandle.Stop()
+					defer shutdown.TrackTaskHandle(useSessionHandle)()
 				}
 
 				// Start sending traffic from mutilate cluster to memcached.
-				mutilateHandle, err := loadGenerator.Load(qps, loadDuration)
+				mutilateHandle, err := executor.LoadContext(ctx, loadGenerator, qps, loadDuration)
 				errutil.PanicWithContext(err, "Cannot start load generator")
+				defer shutdown.TrackTaskHandle(mutilateHandle)()
 				mutilateClusterMaxExecution := sensitivity.LoadGeneratorWaitTimeoutFlag.Value()
 
-				mutilateTerminated, err := mutilateHandle.Wait(mutilateClusterMaxExecution)
+				mutilateTerminated, err := executor.WaitTimeoutContext(ctx, mutilateHandle, mutilateClusterMaxExecution)
 				if err != nil {
 					logrus.Errorf("Mutilate cluster failed: %q", err)
 					logrus.Panic("mutilate cluster failed " + err.Error())
@@ -232,10 +249,11 @@ func main() {
 					mutilateOutput.Name(), mutilateConfig)
 				errutil.CheckWithContext(err, fmt.Sprintf("Cannot create Mutilate snap session during phase %q", phaseName))
 
-				snapHandle, err := mutilateSnapSession.Launch()
+				snapHandle, err := executor.LaunchContext(ctx, mutilateSnapSession)
 				if err != nil {
 					errutil.CheckWithContext(err, fmt.Sprintf("cannot launch mutilate Snap session in phase %s", phaseName))
 				}
+				defer shutdown.TrackTaskHandle(snapHandle)()
 
 				defer func() {
 					err = snapHandle.Stop()
@@ -243,7 +261,7 @@ func main() {
 						logrus.Errorf("Cannot stop mutilate session: %v", err)
 					}
 				}()
-				_, err = snapHandle.Wait(0)
+				_, err = executor.WaitContext(ctx, snapHandle)
 				errutil.PanicWithContext(err, "Snap mutilate session has not collected metrics!")
 
 				// It is ugly but there is no other way to make sure that data is written to Cassandra as of now.
@@ -251,4 +269,5 @@ func main() {
 			}()
 		}
 	}
+	sensitivity.PrintDryRunPlan()
 }
@@ -15,10 +15,7 @@
 package main
 
 import (
-	"fmt"
 	"os"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/intelsdi-x/swan/experiments/specjbb-sensitivity-profile/common"
@@ -30,12 +27,9 @@ import (
 	"github.com/intelsdi-x/swan/pkg/experiment/sensitivity/validate"
 	"github.com/intelsdi-x/swan/pkg/metadata"
 	specjbbsession "github.com/intelsdi-x/swan/pkg/snap/sessions/specjbb"
-	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
 	"github.com/intelsdi-x/swan/pkg/utils/errutil"
-	"github.com/intelsdi-x/swan/pkg/utils/uuid"
 	"github.com/intelsdi-x/swan/pkg/workloads/specjbb"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 )
 
 var (
@@ -48,9 +42,23 @@ func main() {
 	experimentStart := time.Now()
 	experiment.Configure()
 
-	// Generate an experiment ID and start the metadata session.
-	uid := uuid.New() // Initialize logger.
+	// Generate an experiment ID (or reuse ID of resumed experiment) and start the metadata session.
+	uid := experiment.ExperimentID()
+	// Initialize logger.
 	logger.Initialize(appName, uid)
+
+	// Experiment specification (when given) overrides flags.
+	spec, err := sensitivity.SpecFromFlags()
+	errutil.CheckWithContext(err, "Cannot load experiment specification")
+	if spec != nil {
+		errutil.CheckWithContext(spec.CheckWorkloads(sensitivity.Specjbb, "specjbb"), "Unsupported experiment specification")
+		errutil.CheckWithContext(spec.Apply(), "Cannot apply experiment specification")
+	}
+
+	// Record resources created by experiment, so they can be reclaimed by swan-cleanup when experiment crashes.
+	closeJournal, err := experiment.OpenJournal(uid)
+	errutil.CheckWithContext(err, "Cannot open experiment journal")
+	defer closeJournal()
 	// Create metadata associated with experiment
 	metaData, err := metadata.NewDefault(uid)
 	errutil.Check(err)
@@ -58,160 +66,55 @@ func main() {
 	err = metadata.RecordRuntimeEnv(metaData, experimentStart)
 	errutil.CheckWithContext(err, "Cannot save runtime environment details to Cassandra metadata database.")
 
+	// Release all resources and record interruption when experiment is interrupted.
+	shutdown := experiment.Shutdown()
+	shutdown.SetMetadata(metaData)
+	shutdown.Start()
+	defer shutdown.Stop()
+
 	// Validate preconditions: for SPECjbb we only check if CPU governor is set to performance.
 	validate.CheckCPUPowerGovernor()
 
-	tuningTags := make(map[string]interface{})
-	tuningTags[experiment.ExperimentKey] = uid
-	tuningTags[experiment.PhaseKey] = "tuning"
-
 	workloadsFactory := sensitivity.NewDefaultWorkloadFactory()
 
-	specjbbBackendLauncher, err := workloadsFactory.BuildDefaultHighPriorityLauncher(sensitivity.Specjbb, tuningTags)
-	errutil.Check(err)
-
 	// Prepare load generator for hp task (in case of the specjbb it is a controller with transaction injectors).
 	specjbbLoadGenerator, err := common.PrepareSpecjbbLoadGenerator(specjbb.ControllerAddress.Value(), specjbbTxICountFlag.Value())
 	errutil.Check(err)
 
-	// Retrieve peak load from flags and overwrite it when required.
-	load := sensitivity.PeakLoadFlag.Value()
-	if load == sensitivity.RunTuningPhase {
-		load, err = experiment.GetPeakLoad(specjbbBackendLauncher, specjbbLoadGenerator, sensitivity.SLOFlag.Value())
-		errutil.Check(err)
-		logrus.Infof("Ran tuning and achieved load of %d", load)
-	} else {
-		logrus.Infof("Skipping tuning phase, using peakload %d", load)
+	config, err := sensitivity.DefaultRunnerConfig(uid, appName, sensitivity.Specjbb, metaData)
+	errutil.Check(err)
+	// Experiment is always terminated on first error.
+	config.StopOnError = true
+	// Isolations of HP and BE workloads are created for every repetition and released on shutdown.
+	config.Isolations = workloadsFactory.Isolations()
+
+	// Aggressors are built according to specification (when given).
+	var launcherFactory sensitivity.LauncherFactory = &workloadsFactory
+	if spec != nil {
+		spec.Configure(&config)
+		launcherFactory = spec.LauncherFactory(&workloadsFactory)
+		errutil.CheckWithContext(spec.Record(metaData), "Cannot save experiment specification")
+	}
+
+	runner := sensitivity.NewRunner(config, launcherFactory, specjbbLoadGenerator, []sensitivity.SessionLauncherFactory{newSpecjbbSession}, sensitivity.RunnerHooks{})
+	runErr := runner.Run()
+	sensitivity.PrintDryRunPlan()
+	errutil.Check(runErr)
+}
+
+// newSpecjbbSession returns Snap session publishing SPECjbb results (read from backend output) of repetition.
+func newSpecjbbSession(repetition sensitivity.Repetition) (executor.Launcher, error) {
+	specjbbOutput, err := repetition.HighPriority.StdoutFile()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get specjbb stdout file")
 	}
+	defer specjbbOutput.Close()
 
-	loadPoints := sensitivity.LoadPointsCountFlag.Value()
-	repetitions := sensitivity.RepetitionsFlag.Value()
-	loadDuration := sensitivity.LoadDurationFlag.Value()
-
-	// Record metadata.
-	records := map[string]string{
-		"command_arguments": strings.Join(os.Args, ","),
-		"experiment_name":   appName,
-		"peak_load":         strconv.Itoa(load),
-		"load_points":       strconv.Itoa(loadPoints),
-		"repetitions":       strconv.Itoa(repetitions),
-		"load_duration":     loadDuration.String(),
+	specjbbConfig := specjbbsession.DefaultConfig()
+	specjbbConfig.Tags = repetition.Tags
+	specjbbSnapSession, err := specjbbsession.NewSessionLauncher(specjbbOutput.Name(), specjbbConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create specjbb telemetry collection")
 	}
-	errutil.Check(metaData.RecordMap(records, metadata.TypeEmpty))
-
-	// Iterate over aggressors
-	bestEfforts := sensitivity.AggressorsFlag.Value()
-	for _, beWorkloadName := range bestEfforts {
-		// For each aggressor iterate over defined loadpoints
-		for loadPoint := 0; loadPoint < loadPoints; loadPoint++ {
-			phaseQPS := int(int(load) / sensitivity.LoadPointsCountFlag.Value() * (loadPoint + 1))
-
-			// Repeat measurement to check if it is consistent
-			for repetition := 0; repetition < repetitions; repetition++ {
-				phaseName := fmt.Sprintf("Aggressor %s; load point %d; repetition: %d", beWorkloadName, loadPoint, repetition)
-
-				snapTags := make(map[string]interface{})
-				snapTags[experiment.ExperimentKey] = uid
-				snapTags[experiment.PhaseKey] = phaseName
-				snapTags[experiment.RepetitionKey] = repetition
-				snapTags[experiment.LoadPointQPSKey] = phaseQPS
-				snapTags[experiment.AggressorNameKey] = beWorkloadName
-
-				specjbbBackendLauncher, err := workloadsFactory.BuildDefaultHighPriorityLauncher(sensitivity.Specjbb, snapTags)
-				errutil.Check(err)
-
-				beLauncher, err := workloadsFactory.BuildDefaultBestEffortLauncher(beWorkloadName, snapTags)
-				errutil.Check(err)
-
-				// We need to collect all the TaskHandles created in order to cleanup after repetition finishes.
-				var processes []executor.TaskHandle
-				// Using a closure allows us to defer cleanup functions. Otherwise handling cleanup might get much more complicated.
-				// This is the easiest and most golangish way. Deferring cleanup in case of errors to main() termination could cause panics.
-				executeRepetition := func() error {
-					logrus.Infof("Starting %s", phaseName)
-
-					err := experiment.CreateRepetitionDir(appName, uid, phaseName, repetition)
-					if err != nil {
-						return errors.Wrapf(err, "cannot create repetition log directory in %s", phaseName)
-					}
-
-					// Launch specjbb backend (high priority job)
-					hpHandle, err := specjbbBackendLauncher.Launch()
-					if err != nil {
-						return errors.Wrapf(err, "cannot launch memcached in %s", phaseName)
-					}
-					processes = append(processes, hpHandle)
-
-					var beHandle executor.TaskHandle
-					// Launch aggressor task(s) when we are not in baseline.
-					if beLauncher != nil {
-						beHandle, err = beLauncher.Launch()
-						if err != nil {
-							return errors.Wrapf(err, "cannot launch aggressor %q, in %s", beLauncher, phaseName)
-						}
-						processes = append(processes, beHandle)
-					}
-
-					// After high priority job and aggressors are launched Load Generator may start it's job to stress HP
-					logrus.Debugf("Launching Load Generator with load point %d", loadPoint)
-					loadGeneratorHandle, err := specjbbLoadGenerator.Load(phaseQPS, loadDuration)
-					if err != nil {
-						return errors.Wrapf(err, "Unable to start load generation in %s.", phaseName)
-					}
-					loadGeneratorHandle.Wait(0)
-
-					if beHandle != nil {
-						err = beHandle.Stop()
-						if err != nil {
-							return errors.Wrapf(err, "best effort task has failed in phase %s", phaseName)
-						}
-					}
-
-					specjbbOutput, err := hpHandle.StdoutFile()
-					if err != nil {
-						errutil.CheckWithContext(err, "cannot get specjbb stdout file")
-					}
-					defer specjbbOutput.Close()
-
-					specjbbConfig := specjbbsession.DefaultConfig()
-					specjbbConfig.Tags = snapTags
-					specjbbSnapSession, err := specjbbsession.NewSessionLauncher(
-						specjbbOutput.Name(),
-						specjbbConfig)
-					errutil.CheckWithContext(err, "cannot create specjbb telemetry collection")
-
-					// Grap results from Load Generator
-					snapHandle, err := specjbbSnapSession.Launch()
-					if err != nil {
-						return errors.Wrapf(err, "cannot launch specjbb load generator Snap session in %s", phaseName)
-					}
-					defer func() {
-						// It is ugly but there is no other way to make sure that data is written to Cassandra as of now.
-						time.Sleep(5 * time.Second)
-						snapHandle.Stop()
-					}()
-
-					exitCode, err := loadGeneratorHandle.ExitCode()
-					if exitCode != 0 {
-						return errors.Errorf("executing Load Generator returned with exit code %d in %s", exitCode, phaseName)
-					}
-
-					return nil
-				}
-				// Call repetition function.
-				err = executeRepetition()
-
-				// Collecting all the errors that might have been encountered.
-				errColl := &errcollection.ErrorCollection{}
-				errColl.Add(err)
-				for _, th := range processes {
-					errColl.Add(th.Stop())
-				}
-
-				// If any error was found then we should log details and terminate the experiment if stopOnError is set.
-				err = errColl.GetErrIfAny()
-				errutil.Check(err)
-			} // repetition
-		} // loadpoints
-	} // aggressors
+	return specjbbSnapSession, nil
 }
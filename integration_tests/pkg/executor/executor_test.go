@@ -15,6 +15,7 @@
 package executor
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"path"
@@ -279,4 +280,42 @@ func testExecutor(t *testing.T, executor Executor) {
 		So(stderrErr, ShouldNotBeNil)
 		So(outputDirErr, ShouldNotBeNil)
 	})
+
+	Convey("When blocking infinitively sleep command is executed with context", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		taskHandle, err := ExecuteContext(ctx, executor, "sleep inf")
+		So(err, ShouldBeNil)
+
+		defer StopAndEraseOutput(taskHandle)
+
+		Convey("WaitContext should give up when its context is done", func() {
+			waitCtx, waitCancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer waitCancel()
+
+			isTaskTerminated, err := WaitContext(waitCtx, taskHandle)
+			So(err, ShouldNotBeNil)
+			So(isTaskTerminated, ShouldBeFalse)
+			So(taskHandle.Status(), ShouldEqual, RUNNING)
+		})
+
+		Convey("Canceling the context should stop the task", func() {
+			cancel()
+
+			isTaskTerminated, err := taskHandle.Wait(10 * time.Second)
+			So(err, ShouldBeNil)
+			So(isTaskTerminated, ShouldBeTrue)
+			So(taskHandle.Status(), ShouldEqual, TERMINATED)
+		})
+	})
+
+	Convey("When context is already done command should not be executed", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		taskHandle, err := ExecuteContext(ctx, executor, "sleep inf")
+		So(err, ShouldNotBeNil)
+		So(taskHandle, ShouldBeNil)
+	})
 }
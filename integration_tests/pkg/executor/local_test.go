@@ -15,9 +15,17 @@
 package executor
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
 	"os/exec"
 	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	. "github.com/intelsdi-x/swan/pkg/executor"
 	"github.com/intelsdi-x/swan/pkg/isolation"
@@ -58,6 +66,107 @@ func TestLocal(t *testing.T) {
 		})
 	})
 
+	Convey("Local Executor should report why task terminated", t, func() {
+		Convey("Task stopped by swan should be reported as stopped", func() {
+			handle, err := NewLocal().Execute("sleep 10")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+
+			So(handle.Stop(), ShouldBeNil)
+			status, err := GetTerminationStatus(handle)
+			So(err, ShouldBeNil)
+			So(status.Stopped, ShouldBeTrue)
+			So(status.Signal, ShouldEqual, syscall.SIGKILL)
+			So(status.Failed(), ShouldBeFalse)
+		})
+
+		Convey("Task killed by someone else should be reported as retryable failure", func() {
+			handle, err := NewLocal().Execute("sleep 0.2; kill -TERM $$")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+
+			handle.Wait(0)
+			status, err := GetTerminationStatus(handle)
+			So(err, ShouldBeNil)
+			So(status.Stopped, ShouldBeFalse)
+			So(status.Signal, ShouldEqual, syscall.SIGTERM)
+			So(status.Retryable(), ShouldBeTrue)
+		})
+	})
+
+	Convey("Local Executor should stop tasks with stop policy", t, func() {
+		pidFile, err := ioutil.TempFile("", "swan-stop-policy")
+		So(err, ShouldBeNil)
+		pidFile.Close()
+		defer os.Remove(pidFile.Name())
+
+		// Background commands of non-interactive shell ignore SIGINT, so policy needs to escalate.
+		policy, err := ParseStopPolicy("SIGINT:200ms,SIGTERM:200ms,SIGKILL")
+		So(err, ShouldBeNil)
+		l := NewLocalWithStopPolicy(policy)
+
+		Convey("Escalation should stop task and all its descendants", func() {
+			handle, err := l.Execute(fmt.Sprintf("sleep 100 & echo $! > %s; sh -c 'sleep 100 & echo $! >> %s; wait' & wait", pidFile.Name(), pidFile.Name()))
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+			descendants := waitForDescendants(pidFile.Name(), 2)
+
+			So(handle.Stop(), ShouldBeNil)
+			status, err := GetTerminationStatus(handle)
+			So(err, ShouldBeNil)
+			So(status.Stopped, ShouldBeTrue)
+			// Shell is interrupted, but its background descendants are terminated by next signals.
+			So(status.Signal, ShouldEqual, syscall.SIGINT)
+			for _, pid := range descendants {
+				So(isProcessRunning(pid), ShouldBeFalse)
+			}
+		})
+
+		Convey("Descendants left behind by terminated task should be stopped", func() {
+			handle, err := l.Execute(fmt.Sprintf("sleep 100 & echo $! > %s", pidFile.Name()))
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+			descendants := waitForDescendants(pidFile.Name(), 1)
+			handle.Wait(0)
+			So(isProcessRunning(descendants[0]), ShouldBeTrue)
+
+			So(handle.Stop(), ShouldBeNil)
+			So(isProcessRunning(descendants[0]), ShouldBeFalse)
+			status, err := GetTerminationStatus(handle)
+			So(err, ShouldBeNil)
+			So(status.Stopped, ShouldBeFalse)
+			So(status.ExitCode, ShouldEqual, 0)
+		})
+
+		Convey("Policy from context should take precedence over executor policy", func() {
+			ctx := ContextWithStopPolicy(context.Background(), StopPolicy{Steps: []StopStep{{Signal: syscall.SIGTERM}}})
+			handle, err := ExecuteContext(ctx, l, "sleep 100")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+
+			So(handle.Stop(), ShouldBeNil)
+			status, err := GetTerminationStatus(handle)
+			So(err, ShouldBeNil)
+			So(status.Signal, ShouldEqual, syscall.SIGTERM)
+		})
+	})
+
+	Convey("Local Executor should account resources used by terminated task", t, func() {
+		handle, err := NewLocal().Execute("sleep 0.2 && dd if=/dev/zero of=/dev/null bs=1M count=100")
+		So(err, ShouldBeNil)
+		defer handle.EraseOutput()
+
+		_, err = GetResourceUsage(handle)
+		So(err, ShouldNotBeNil)
+
+		handle.Wait(0)
+		usage, err := GetResourceUsage(handle)
+		So(err, ShouldBeNil)
+		So(usage.WallTime, ShouldBeGreaterThanOrEqualTo, 200*time.Millisecond)
+		So(usage.MaxRSS, ShouldBeGreaterThan, 0)
+		So(usage.VoluntaryContextSwitches, ShouldBeGreaterThan, 0)
+	})
+
 	Convey("While using Local Shell using cgroups", t, func() {
 		user, err := user.Current()
 		if err != nil {
@@ -176,3 +285,32 @@ func TestLocal(t *testing.T) {
 		})
 	})
 }
+
+// waitForDescendants reads PIDs of descendant processes written by task to pidFile.
+func waitForDescendants(pidFile string, count int) (pids []int) {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		content, _ := ioutil.ReadFile(pidFile)
+		lines := strings.Fields(string(content))
+		if len(lines) < count {
+			continue
+		}
+		for _, line := range lines {
+			pid, err := strconv.Atoi(line)
+			So(err, ShouldBeNil)
+			pids = append(pids, pid)
+		}
+		return pids
+	}
+	So(pids, ShouldHaveLength, count)
+	return pids
+}
+
+// isProcessRunning returns true when process exists and is not a zombie.
+func isProcessRunning(pid int) bool {
+	stat, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return false
+	}
+	fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
@@ -24,6 +24,7 @@ import (
 // This tests required following setup:
 // - id_rsa ssh keys in user home directory. [command ssh-keygen]
 // - no password ssh session. [command ssh-copy-id localhost]
+// - localhost host key in known_hosts file. [command ssh-keyscan 127.0.0.1 >> ~/.ssh/known_hosts]
 func TestRemote(t *testing.T) {
 	Convey("Preparing Remote Executor to be tested on localhost", t, func() {
 
@@ -0,0 +1,195 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cleanup
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/isolation/cgroup"
+	"github.com/intelsdi-x/swan/pkg/journal"
+	"github.com/pkg/errors"
+)
+
+// CgroupReclaimer removes cgroups. Cgroups are found by prefix of their top level directory.
+type CgroupReclaimer struct {
+	executor  executor.Executor
+	timeout   time.Duration
+	prefix    string
+	mountRoot string
+}
+
+// NewCgroupReclaimer is constructor for CgroupReclaimer using local executor.
+func NewCgroupReclaimer(prefix string) *CgroupReclaimer {
+	return &CgroupReclaimer{
+		executor:  executor.NewLocal(),
+		timeout:   cgroup.DefaultCommandTimeout,
+		prefix:    prefix,
+		mountRoot: isolation.DefaultCgroupMountRoot,
+	}
+}
+
+// Kind implements Reclaimer interface.
+func (r *CgroupReclaimer) Kind() string {
+	return journal.KindCgroup
+}
+
+// Scan implements Reclaimer interface.
+func (r *CgroupReclaimer) Scan() ([]journal.Resource, error) {
+	if r.unified() {
+		return r.scanUnified()
+	}
+
+	output, err := r.run("lscgroup")
+	if err != nil {
+		return nil, err
+	}
+	return parseCgroups(output, r.prefix), nil
+}
+
+// Reclaim implements Reclaimer interface.
+func (r *CgroupReclaimer) Reclaim(resource journal.Resource) error {
+	controllers, path, err := parseCgroupSpec(resource.ID)
+	if err != nil {
+		return err
+	}
+
+	var cg cgroup.Cgroup
+	if r.unified() {
+		cg, err = cgroup.NewUnifiedCgroup(controllers, path, r.mountRoot)
+	} else {
+		cg, err = cgroup.NewCgroupWithExecutor(controllers, path, r.executor, r.timeout)
+	}
+	if err != nil {
+		return err
+	}
+
+	exists, err := cg.Exists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
+	return cg.Destroy(true)
+}
+
+func (r *CgroupReclaimer) unified() bool {
+	return isolation.DetectCgroupHierarchy(r.mountRoot) == isolation.CgroupV2
+}
+
+// scanUnified returns top level cgroups with prefix from unified hierarchy.
+// Controllers are not known from the hierarchy, so they are taken from cgroup.controllers of the cgroup.
+func (r *CgroupReclaimer) scanUnified() ([]journal.Resource, error) {
+	files, err := ioutil.ReadDir(r.mountRoot)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot list cgroups in %q", r.mountRoot)
+	}
+
+	resources := []journal.Resource{}
+	for _, file := range files {
+		if !file.IsDir() || !strings.HasPrefix(file.Name(), r.prefix) {
+			continue
+		}
+		controllers, err := ioutil.ReadFile(path.Join(r.mountRoot, file.Name(), isolation.CgroupControllers))
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read controllers of cgroup %q", file.Name())
+		}
+		spec := strings.Join(strings.Fields(string(controllers)), ",")
+		if spec == "" {
+			// Cgroup without controllers still has to be removed.
+			spec = "none"
+		}
+		resources = append(resources, journal.Resource{
+			Kind: journal.KindCgroup,
+			ID:   spec + ":/" + file.Name(),
+		})
+	}
+	return resources, nil
+}
+
+func (r *CgroupReclaimer) run(command string) (string, error) {
+	handle, err := r.executor.Execute(command)
+	if err != nil {
+		return "", err
+	}
+	defer handle.EraseOutput()
+
+	terminated, err := handle.Wait(r.timeout)
+	if err != nil {
+		return "", err
+	}
+	if !terminated {
+		handle.Stop()
+		return "", errors.Errorf("timed out waiting for command %q", command)
+	}
+
+	exitCode, err := handle.ExitCode()
+	if err != nil {
+		return "", err
+	}
+	if exitCode != 0 {
+		return "", errors.Errorf("command %q exited with code %d", command, exitCode)
+	}
+
+	stdout, err := handle.StdoutFile()
+	if err != nil {
+		return "", err
+	}
+	defer stdout.Close()
+
+	output, err := ioutil.ReadAll(stdout)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read output of command %q", command)
+	}
+	return string(output), nil
+}
+
+// parseCgroups returns top most cgroups from lscgroup output which top level directory starts with prefix.
+// Nested cgroups are skipped, because they are removed along with their ancestors.
+func parseCgroups(lscgroupOutput, prefix string) []journal.Resource {
+	resources := []journal.Resource{}
+	for _, line := range strings.Split(lscgroupOutput, "\n") {
+		controllers, path, err := parseCgroupSpec(strings.TrimSpace(line))
+		if err != nil {
+			continue
+		}
+
+		elements := strings.Split(strings.Trim(path, "/"), "/")
+		if len(elements) != 1 || elements[0] == "" || !strings.HasPrefix(elements[0], prefix) {
+			continue
+		}
+
+		resources = append(resources, journal.Resource{
+			Kind: journal.KindCgroup,
+			ID:   strings.Join(controllers, ",") + ":" + path,
+		})
+	}
+	return resources
+}
+
+// parseCgroupSpec parses "controllers:path" cgroup specification.
+func parseCgroupSpec(spec string) (controllers []string, path string, err error) {
+	fields := strings.SplitN(spec, ":", 2)
+	if len(fields) != 2 || fields[0] == "" || fields[1] == "" {
+		return nil, "", errors.Errorf("invalid cgroup specification %q", spec)
+	}
+	return strings.Split(fields[0], ","), fields[1], nil
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cleanup reclaims resources leaked by crashed or killed experiments.
+// Resources are read from experiment journals (see pkg/journal) or found by scanning the platform
+// for swan-labelled resources.
+package cleanup
+
+import (
+	"github.com/intelsdi-x/swan/pkg/journal"
+	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Reclaimer is able to find and remove resources of single kind.
+type Reclaimer interface {
+	// Kind returns kind of resources (see journal.Kind* constants) handled by Reclaimer.
+	Kind() string
+	// Scan returns swan-labelled resources found on the platform.
+	Scan() ([]journal.Resource, error)
+	// Reclaim removes resource.
+	Reclaim(resource journal.Resource) error
+}
+
+// Cleaner dispatches resources to Reclaimers of their kind.
+type Cleaner struct {
+	reclaimers []Reclaimer
+}
+
+// NewCleaner is constructor for Cleaner.
+func NewCleaner(reclaimers ...Reclaimer) *Cleaner {
+	return &Cleaner{reclaimers: reclaimers}
+}
+
+func (c *Cleaner) reclaimer(kind string) Reclaimer {
+	for _, reclaimer := range c.reclaimers {
+		if reclaimer.Kind() == kind {
+			return reclaimer
+		}
+	}
+	return nil
+}
+
+// Scan returns resources found by all Reclaimers.
+// Resources found by Reclaimers that did not fail are returned along with the error.
+func (c *Cleaner) Scan() ([]journal.Resource, error) {
+	var errCollection errcollection.ErrorCollection
+	resources := []journal.Resource{}
+	for _, reclaimer := range c.reclaimers {
+		found, err := reclaimer.Scan()
+		if err != nil {
+			errCollection.Add(errors.Wrapf(err, "cannot scan for %s resources", reclaimer.Kind()))
+			continue
+		}
+		resources = append(resources, found...)
+	}
+	return resources, errCollection.GetErrIfAny()
+}
+
+// Reclaim removes resources in reverse order (resources are expected in order of creation).
+// All resources are attempted even if some of them cannot be removed.
+func (c *Cleaner) Reclaim(resources []journal.Resource) error {
+	var errCollection errcollection.ErrorCollection
+	for i := len(resources) - 1; i >= 0; i-- {
+		resource := resources[i]
+		reclaimer := c.reclaimer(resource.Kind)
+		if reclaimer == nil {
+			errCollection.Add(errors.Errorf("cannot reclaim %s: unsupported kind of resource", resource))
+			continue
+		}
+
+		log.Infof("Reclaiming %s", resource)
+		if err := reclaimer.Reclaim(resource); err != nil {
+			errCollection.Add(errors.Wrapf(err, "cannot reclaim %s", resource))
+		}
+	}
+	return errCollection.GetErrIfAny()
+}
+
+// Without returns resources (without repetitions) that are not present in excluded resources.
+func Without(resources, excluded []journal.Resource) []journal.Resource {
+	seen := map[string]bool{}
+	for _, resource := range excluded {
+		seen[resourceKey(resource)] = true
+	}
+
+	result := []journal.Resource{}
+	for _, resource := range resources {
+		key := resourceKey(resource)
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, resource)
+		}
+	}
+	return result
+}
+
+func resourceKey(resource journal.Resource) string {
+	return resource.Kind + "\x00" + resource.Location + "\x00" + resource.ID
+}
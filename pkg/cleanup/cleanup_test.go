@@ -0,0 +1,94 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cleanup
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/journal"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type fakeReclaimer struct {
+	kind      string
+	found     []journal.Resource
+	reclaimed []journal.Resource
+	err       error
+}
+
+func (r *fakeReclaimer) Kind() string {
+	return r.kind
+}
+
+func (r *fakeReclaimer) Scan() ([]journal.Resource, error) {
+	return r.found, r.err
+}
+
+func (r *fakeReclaimer) Reclaim(resource journal.Resource) error {
+	r.reclaimed = append(r.reclaimed, resource)
+	return r.err
+}
+
+func TestCleaner(t *testing.T) {
+	Convey("When having Cleaner with reclaimers", t, func() {
+		hp := journal.Resource{Kind: journal.KindCgroup, ID: "cpuset:/swan-hp"}
+		be := journal.Resource{Kind: journal.KindCgroup, ID: "cpuset:/swan-be"}
+		task := journal.Resource{Kind: journal.KindSnapTask, ID: "1234", Location: "http://127.0.0.1:8181"}
+
+		cgroups := &fakeReclaimer{kind: journal.KindCgroup, found: []journal.Resource{hp, be}}
+		snapTasks := &fakeReclaimer{kind: journal.KindSnapTask, err: errors.New("snapteld is not running")}
+		cleaner := NewCleaner(cgroups, snapTasks)
+
+		Convey("Resources should be reclaimed in reverse order by reclaimer of their kind", func() {
+			err := cleaner.Reclaim([]journal.Resource{hp, task, be})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "snapteld is not running")
+			So(cgroups.reclaimed, ShouldResemble, []journal.Resource{be, hp})
+			So(snapTasks.reclaimed, ShouldResemble, []journal.Resource{task})
+		})
+
+		Convey("Resources of unsupported kind should not be reclaimed", func() {
+			err := cleaner.Reclaim([]journal.Resource{{Kind: "unknown", ID: "1"}, hp})
+			So(err, ShouldNotBeNil)
+			So(cgroups.reclaimed, ShouldResemble, []journal.Resource{hp})
+		})
+
+		Convey("Scan should return resources found by working reclaimers", func() {
+			found, err := cleaner.Scan()
+			So(err, ShouldNotBeNil)
+			So(found, ShouldResemble, []journal.Resource{hp, be})
+		})
+	})
+
+	Convey("Resources known from journals should be skipped", t, func() {
+		hp := journal.Resource{Kind: journal.KindCgroup, ID: "cpuset:/swan-hp"}
+		be := journal.Resource{Kind: journal.KindCgroup, ID: "cpuset:/swan-be"}
+		So(Without([]journal.Resource{hp, be, be}, []journal.Resource{hp}), ShouldResemble, []journal.Resource{be})
+	})
+}
+
+func TestParseCgroups(t *testing.T) {
+	Convey("When parsing lscgroup output", t, func() {
+		output := "cpuset:/\ncpuset:/swan-hp\ncpuset:/swan-hp/nested\ncpu,cpuacct:/swan-be\nmemory:/user.slice\n"
+
+		Convey("Only top level cgroups with prefix should be returned", func() {
+			So(parseCgroups(output, "swan"), ShouldResemble, []journal.Resource{
+				{Kind: journal.KindCgroup, ID: "cpuset:/swan-hp"},
+				{Kind: journal.KindCgroup, ID: "cpu,cpuacct:/swan-be"},
+			})
+		})
+	})
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cleanup
+
+import (
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/journal"
+)
+
+// DockerContainerReclaimer removes containers. Containers are found by label set by Docker executor.
+type DockerContainerReclaimer struct {
+	host string
+}
+
+// NewDockerContainerReclaimer is constructor for DockerContainerReclaimer scanning Docker Engine API at host.
+func NewDockerContainerReclaimer(host string) *DockerContainerReclaimer {
+	return &DockerContainerReclaimer{host: host}
+}
+
+// Kind implements Reclaimer interface.
+func (r *DockerContainerReclaimer) Kind() string {
+	return journal.KindDockerContainer
+}
+
+// Scan implements Reclaimer interface.
+func (r *DockerContainerReclaimer) Scan() ([]journal.Resource, error) {
+	names, err := executor.ListDockerContainers(r.host)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []journal.Resource{}
+	for _, name := range names {
+		resources = append(resources, journal.Resource{
+			Kind:     journal.KindDockerContainer,
+			ID:       name,
+			Location: r.host,
+		})
+	}
+	return resources, nil
+}
+
+// Reclaim implements Reclaimer interface.
+func (r *DockerContainerReclaimer) Reclaim(resource journal.Resource) error {
+	return executor.RemoveDockerContainer(resource.Location, resource.ID)
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cleanup
+
+import (
+	"strings"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/journal"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// KubernetesPodReclaimer deletes pods. Pods are found by name prefix used by Kubernetes executor.
+type KubernetesPodReclaimer struct {
+	config    executor.KubernetesConfig
+	clientset *kubernetes.Clientset
+}
+
+// NewKubernetesPodReclaimer is constructor for KubernetesPodReclaimer.
+// Connection to API server is established when it is needed for the first time.
+func NewKubernetesPodReclaimer(config executor.KubernetesConfig) *KubernetesPodReclaimer {
+	return &KubernetesPodReclaimer{config: config}
+}
+
+// Kind implements Reclaimer interface.
+func (r *KubernetesPodReclaimer) Kind() string {
+	return journal.KindKubernetesPod
+}
+
+func (r *KubernetesPodReclaimer) connect() error {
+	if r.clientset != nil {
+		return nil
+	}
+
+	clientset, err := executor.NewKubernetesClientset(r.config)
+	if err != nil {
+		return err
+	}
+	r.clientset = clientset
+	return nil
+}
+
+// Scan implements Reclaimer interface.
+func (r *KubernetesPodReclaimer) Scan() ([]journal.Resource, error) {
+	if err := r.connect(); err != nil {
+		return nil, err
+	}
+
+	pods, err := r.clientset.Pods(r.config.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot list pods in namespace %q", r.config.Namespace)
+	}
+
+	resources := []journal.Resource{}
+	for _, pod := range pods.Items {
+		if strings.HasPrefix(pod.Name, r.config.PodNamePrefix+"-") {
+			resources = append(resources, journal.Resource{
+				Kind:     journal.KindKubernetesPod,
+				ID:       pod.Name,
+				Location: r.config.Namespace,
+			})
+		}
+	}
+	return resources, nil
+}
+
+// Reclaim implements Reclaimer interface.
+func (r *KubernetesPodReclaimer) Reclaim(resource journal.Resource) error {
+	if err := r.connect(); err != nil {
+		return err
+	}
+
+	var gracePeriodSeconds int64
+	err := r.clientset.Pods(resource.Location).Delete(resource.ID, &metav1.DeleteOptions{
+		GracePeriodSeconds: &gracePeriodSeconds,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cannot delete pod %q in namespace %q", resource.ID, resource.Location)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cleanup
+
+import (
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/intelsdi-x/swan/pkg/journal"
+	"github.com/pkg/errors"
+)
+
+// OpenstackInstanceReclaimer deletes OpenStack instances.
+// Credentials are taken from OS_* environment variables.
+// Instances are not labelled, so they can be found only in journals.
+type OpenstackInstanceReclaimer struct {
+	client *gophercloud.ServiceClient
+}
+
+// NewOpenstackInstanceReclaimer is constructor for OpenstackInstanceReclaimer.
+// Authentication is done when it is needed for the first time.
+func NewOpenstackInstanceReclaimer() *OpenstackInstanceReclaimer {
+	return &OpenstackInstanceReclaimer{}
+}
+
+// Kind implements Reclaimer interface.
+func (r *OpenstackInstanceReclaimer) Kind() string {
+	return journal.KindOpenstackInstance
+}
+
+func (r *OpenstackInstanceReclaimer) connect() error {
+	if r.client != nil {
+		return nil
+	}
+
+	auth, err := openstack.AuthOptionsFromEnv()
+	if err != nil {
+		return errors.Wrap(err, "cannot read OpenStack credentials from environment")
+	}
+
+	provider, err := openstack.AuthenticatedClient(auth)
+	if err != nil {
+		return errors.Wrap(err, "cannot authenticate in OpenStack")
+	}
+
+	r.client, err = openstack.NewComputeV2(provider, gophercloud.EndpointOpts{Region: "RegionOne"})
+	if err != nil {
+		return errors.Wrap(err, "cannot get OpenStack compute client")
+	}
+	return nil
+}
+
+// Scan implements Reclaimer interface.
+func (r *OpenstackInstanceReclaimer) Scan() ([]journal.Resource, error) {
+	return []journal.Resource{}, nil
+}
+
+// Reclaim implements Reclaimer interface.
+func (r *OpenstackInstanceReclaimer) Reclaim(resource journal.Resource) error {
+	if err := r.connect(); err != nil {
+		return err
+	}
+
+	err := servers.Delete(r.client, resource.ID).ExtractErr()
+	if err != nil {
+		return errors.Wrapf(err, "cannot delete instance %q", resource.ID)
+	}
+	return nil
+}
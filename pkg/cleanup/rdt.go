@@ -0,0 +1,58 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cleanup
+
+import (
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/journal"
+	log "github.com/sirupsen/logrus"
+)
+
+// RDTReclaimer resets RDT assignments (pqos -R).
+// RDT classes of service are not labelled, so they can be found only in journals.
+type RDTReclaimer struct {
+	reset bool
+}
+
+// NewRDTReclaimer is constructor for RDTReclaimer.
+func NewRDTReclaimer() *RDTReclaimer {
+	return &RDTReclaimer{}
+}
+
+// Kind implements Reclaimer interface.
+func (r *RDTReclaimer) Kind() string {
+	return journal.KindRDT
+}
+
+// Scan implements Reclaimer interface.
+func (r *RDTReclaimer) Scan() ([]journal.Resource, error) {
+	return []journal.Resource{}, nil
+}
+
+// Reclaim implements Reclaimer interface.
+// All assignments are reset at once, so subsequent calls do nothing.
+func (r *RDTReclaimer) Reclaim(resource journal.Resource) error {
+	if r.reset {
+		return nil
+	}
+
+	output, err := isolation.CleanRDTAssingments()
+	if err != nil {
+		return err
+	}
+	log.Debugf("pqos -R has been run and produced following output: %q", output)
+	r.reset = true
+	return nil
+}
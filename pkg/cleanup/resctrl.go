@@ -0,0 +1,55 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cleanup
+
+import (
+	"os"
+	"path"
+
+	"github.com/intelsdi-x/swan/pkg/journal"
+	"github.com/pkg/errors"
+)
+
+// ResctrlGroupReclaimer removes resctrl groups.
+// Resctrl groups are not labelled, so they can be found only in journals.
+type ResctrlGroupReclaimer struct{}
+
+// NewResctrlGroupReclaimer is constructor for ResctrlGroupReclaimer.
+func NewResctrlGroupReclaimer() *ResctrlGroupReclaimer {
+	return &ResctrlGroupReclaimer{}
+}
+
+// Kind implements Reclaimer interface.
+func (r *ResctrlGroupReclaimer) Kind() string {
+	return journal.KindResctrlGroup
+}
+
+// Scan implements Reclaimer interface.
+func (r *ResctrlGroupReclaimer) Scan() ([]journal.Resource, error) {
+	return []journal.Resource{}, nil
+}
+
+// Reclaim implements Reclaimer interface.
+func (r *ResctrlGroupReclaimer) Reclaim(resource journal.Resource) error {
+	if resource.ID == "" || resource.Location == "" {
+		return errors.Errorf("invalid resctrl group %s", resource)
+	}
+	directory := path.Join(resource.Location, resource.ID)
+	err := os.Remove(directory)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "cannot remove resctrl group %q", directory)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cleanup
+
+import (
+	"strings"
+
+	"github.com/intelsdi-x/snap/mgmt/rest/client"
+	"github.com/intelsdi-x/swan/pkg/journal"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// SnapTaskReclaimer stops and removes Snap tasks. Tasks are found by "swan-*-session" name.
+type SnapTaskReclaimer struct {
+	address string
+}
+
+// NewSnapTaskReclaimer is constructor for SnapTaskReclaimer scanning snapteld at given address.
+func NewSnapTaskReclaimer(address string) *SnapTaskReclaimer {
+	return &SnapTaskReclaimer{address: address}
+}
+
+// Kind implements Reclaimer interface.
+func (r *SnapTaskReclaimer) Kind() string {
+	return journal.KindSnapTask
+}
+
+// Scan implements Reclaimer interface.
+func (r *SnapTaskReclaimer) Scan() ([]journal.Resource, error) {
+	snapClient, err := client.New(r.address, "v1", true)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot connect to snapteld at %q", r.address)
+	}
+
+	tasks := snapClient.GetTasks()
+	if tasks.Err != nil {
+		return nil, errors.Wrapf(tasks.Err, "cannot list Snap tasks at %q", r.address)
+	}
+
+	resources := []journal.Resource{}
+	for _, task := range tasks.ScheduledTasks {
+		if isSwanSnapTask(task.Name) {
+			resources = append(resources, journal.Resource{
+				Kind:       journal.KindSnapTask,
+				ID:         task.ID,
+				Location:   r.address,
+				Attributes: map[string]string{"name": task.Name},
+			})
+		}
+	}
+	return resources, nil
+}
+
+// Reclaim implements Reclaimer interface.
+func (r *SnapTaskReclaimer) Reclaim(resource journal.Resource) error {
+	snapClient, err := client.New(resource.Location, "v1", true)
+	if err != nil {
+		return errors.Wrapf(err, "cannot connect to snapteld at %q", resource.Location)
+	}
+
+	// Task might be already stopped, so error is not fatal.
+	if stopped := snapClient.StopTask(resource.ID); stopped.Err != nil {
+		log.Debugf("Cannot stop Snap task %q: %s", resource.ID, stopped.Err.Error())
+	}
+
+	removed := snapClient.RemoveTask(resource.ID)
+	if removed.Err != nil {
+		return errors.Wrapf(removed.Err, "cannot remove Snap task %q", resource.ID)
+	}
+	return nil
+}
+
+func isSwanSnapTask(name string) bool {
+	return strings.HasPrefix(name, "swan-") && strings.HasSuffix(name, "-session")
+}
@@ -134,6 +134,28 @@ func DumpConfigMap(flagMap map[string]string) string {
 	return buffer.String()
 }
 
+// IsRegistered returns true when flag with given name has been defined with this package.
+func IsRegistered(name string) bool {
+	for _, registered := range flagNames {
+		if registered == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SetFlag overrides value of flag defined with this package (e.g. with value from experiment specification).
+func SetFlag(name, value string) error {
+	if !IsRegistered(name) {
+		return errors.Errorf("unknown flag %q", name)
+	}
+	err := flag.Set(name, value)
+	if err != nil {
+		return errors.Wrapf(err, "cannot set %q flag to %q", name, value)
+	}
+	return nil
+}
+
 // GetFlags returns flags as map with current values.
 func GetFlags() map[string]string {
 	flagsMap := map[string]string{}
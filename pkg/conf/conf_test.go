@@ -53,6 +53,21 @@ func TestConf(t *testing.T) {
 			So(err, ShouldNotBeNil)
 		})
 
+		Convey("Registered flags can be overridden", func() {
+			So(IsRegistered(CassandraPort.Name), ShouldBeTrue)
+			So(IsRegistered("foo"), ShouldBeFalse)
+
+			err := SetFlag(CassandraPort.Name, "9043")
+			So(err, ShouldBeNil)
+			So(CassandraPort.Value(), ShouldEqual, 9043)
+			Reset(func() {
+				SetFlag(CassandraPort.Name, "9042")
+			})
+
+			So(SetFlag(CassandraPort.Name, "foo"), ShouldNotBeNil)
+			So(SetFlag("foo", "bar"), ShouldNotBeNil)
+		})
+
 		Convey("Validation for flags loaded from file", func() {
 			const testfile = "testfile"
 			err := ioutil.WriteFile(testfile, []byte("# comment\nfoo=baz"), os.ModePerm)
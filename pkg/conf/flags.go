@@ -94,3 +94,6 @@ var DefaultSnapPublisher = NewStringFlag("default_snap_publisher", "Publisher to
 
 // DefaultMetadataDB sets default database for metadata
 var DefaultMetadataDB = NewStringFlag("default_metadata_db", "Database to which metadata will be stored. Suported: cassandra, influxdb", "cassandra")
+
+// DryRunFlag makes experiments record commands instead of executing them and print execution plan at the end.
+var DryRunFlag = NewBoolFlag("dry_run", "Do not execute any command. Record all commands (with isolation decorations) and print execution plan when experiment ends.", false)
@@ -0,0 +1,164 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/isolation/cgroup"
+	"github.com/pkg/errors"
+)
+
+// Actuator changes amount of single resource given to BE workloads.
+// Resources are ordered in levels: level 0 means the smallest amount of resource
+// and Levels()-1 means the largest one.
+type Actuator interface {
+	// Name returns name of actuator used in decisions log.
+	Name() string
+	// Level returns current level.
+	Level() int
+	// Levels returns number of levels.
+	Levels() int
+	// SetLevel applies resource amount of given level.
+	SetLevel(level int) error
+}
+
+// levelActuator applies levels with a function.
+type levelActuator struct {
+	name   string
+	level  int
+	levels int
+	apply  func(level int) error
+}
+
+// NewActuator returns Actuator with given number of levels that applies them with apply function.
+// Actuator starts at the highest level (all resources available), which is expected to be already set.
+func NewActuator(name string, levels int, apply func(level int) error) Actuator {
+	return &levelActuator{name: name, level: levels - 1, levels: levels, apply: apply}
+}
+
+func (a *levelActuator) Name() string {
+	return a.name
+}
+
+func (a *levelActuator) Level() int {
+	return a.level
+}
+
+func (a *levelActuator) Levels() int {
+	return a.levels
+}
+
+func (a *levelActuator) SetLevel(level int) error {
+	if level < 0 || level >= a.levels {
+		return errors.Errorf("level %d of %s is out of range [0, %d]", level, a.name, a.levels-1)
+	}
+	if err := a.apply(level); err != nil {
+		return errors.Wrapf(err, "cannot set level %d of %s", level, a.name)
+	}
+	a.level = level
+	return nil
+}
+
+// NewCPUSetActuator returns Actuator that gives BE cpuset cgroup from minCpus to all of cpus.
+// Cpus are given in order of preference (first ones are always kept).
+func NewCPUSetActuator(cg cgroup.Cgroup, cpus []int, minCpus int) (Actuator, error) {
+	if minCpus < 1 || minCpus > len(cpus) {
+		return nil, errors.Errorf("minimum of %d cpus is out of range [1, %d]", minCpus, len(cpus))
+	}
+
+	return NewActuator("cpuset", len(cpus)-minCpus+1, func(level int) error {
+		set := isolation.NewIntSet(cpus[:minCpus+level]...)
+		return cg.SetAndCheck(cgroup.CPUSetCpus, set.AsRangeString())
+	}), nil
+}
+
+// L3MaskSetter changes L3 cache mask of live workloads (e.g. isolation.Resctrl).
+// Note that isolation.Rdtset cannot change mask of running workloads.
+type L3MaskSetter interface {
+	SetL3Mask(mask int) error
+}
+
+// NewCacheWaysActuator returns Actuator that gives BE from minWays to maxWays of L3 cache.
+// Ways are contiguous and start at firstWay.
+func NewCacheWaysActuator(setter L3MaskSetter, firstWay, minWays, maxWays int) (Actuator, error) {
+	if minWays < 1 || minWays > maxWays {
+		return nil, errors.Errorf("invalid range of cache ways [%d, %d]", minWays, maxWays)
+	}
+
+	return NewActuator("cache_ways", maxWays-minWays+1, func(level int) error {
+		ways := minWays + level
+		return setter.SetL3Mask(((1 << uint(ways)) - 1) << uint(firstWay))
+	}), nil
+}
+
+// MBPercentSetter changes memory bandwidth of live workloads (e.g. isolation.Resctrl).
+type MBPercentSetter interface {
+	SetMBPercent(percent int) error
+}
+
+// NewMemoryBandwidthActuator returns Actuator that gives BE memory bandwidth percentages in ascending order.
+func NewMemoryBandwidthActuator(setter MBPercentSetter, percents []int) (Actuator, error) {
+	if err := checkAscending(percents); err != nil {
+		return nil, errors.Wrap(err, "invalid memory bandwidth percentages")
+	}
+
+	return NewActuator("memory_bandwidth", len(percents), func(level int) error {
+		return setter.SetMBPercent(percents[level])
+	}), nil
+}
+
+// SharesSetter changes cpu shares of live workloads (e.g. isolation.CPUShares).
+type SharesSetter interface {
+	SetShares(shares int) error
+}
+
+// NewCPUSharesActuator returns Actuator that gives BE cpu shares in ascending order.
+func NewCPUSharesActuator(setter SharesSetter, shares []int) (Actuator, error) {
+	if err := checkAscending(shares); err != nil {
+		return nil, errors.Wrap(err, "invalid cpu shares")
+	}
+
+	return NewActuator("cpu_shares", len(shares), func(level int) error {
+		return setter.SetShares(shares[level])
+	}), nil
+}
+
+// SizeSetter changes memory limit of live workloads (e.g. isolation.MemorySize).
+type SizeSetter interface {
+	SetSize(size int) error
+}
+
+// NewMemorySizeActuator returns Actuator that gives BE memory limits (in bytes) in ascending order.
+func NewMemorySizeActuator(setter SizeSetter, sizes []int) (Actuator, error) {
+	if err := checkAscending(sizes); err != nil {
+		return nil, errors.Wrap(err, "invalid memory sizes")
+	}
+
+	return NewActuator("memory_size", len(sizes), func(level int) error {
+		return setter.SetSize(sizes[level])
+	}), nil
+}
+
+func checkAscending(values []int) error {
+	if len(values) == 0 {
+		return errors.New("no values given")
+	}
+	for i := 1; i < len(values); i++ {
+		if values[i] <= values[i-1] {
+			return errors.Errorf("values %v are not in ascending order", values)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type fakeSetter struct {
+	values []int
+}
+
+func (s *fakeSetter) set(value int) error {
+	s.values = append(s.values, value)
+	return nil
+}
+
+func (s *fakeSetter) SetL3Mask(mask int) error       { return s.set(mask) }
+func (s *fakeSetter) SetMBPercent(percent int) error { return s.set(percent) }
+
+func TestActuators(t *testing.T) {
+	Convey("Actuator should reject levels out of range", t, func() {
+		actuator := NewActuator("test", 2, func(int) error { return nil })
+		So(actuator.Level(), ShouldEqual, 1)
+		So(actuator.SetLevel(2), ShouldNotBeNil)
+		So(actuator.SetLevel(-1), ShouldNotBeNil)
+		So(actuator.SetLevel(0), ShouldBeNil)
+		So(actuator.Level(), ShouldEqual, 0)
+	})
+
+	Convey("Cache ways actuator should set contiguous masks", t, func() {
+		setter := &fakeSetter{}
+		actuator, err := NewCacheWaysActuator(setter, 2, 1, 3)
+		So(err, ShouldBeNil)
+		So(actuator.Levels(), ShouldEqual, 3)
+		So(actuator.SetLevel(0), ShouldBeNil)
+		So(actuator.SetLevel(1), ShouldBeNil)
+		So(setter.values, ShouldResemble, []int{0x4, 0xc})
+
+		_, err = NewCacheWaysActuator(setter, 0, 3, 2)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Memory bandwidth actuator should set percentages", t, func() {
+		setter := &fakeSetter{}
+		actuator, err := NewMemoryBandwidthActuator(setter, []int{10, 50, 100})
+		So(err, ShouldBeNil)
+		So(actuator.SetLevel(1), ShouldBeNil)
+		So(setter.values, ShouldResemble, []int{50})
+
+		_, err = NewMemoryBandwidthActuator(setter, []int{50, 10})
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,303 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package controller implements closed-loop controller which protects SLO of HP workload
+// by dynamically changing resources given to BE workloads.
+//
+// Controller periodically reads HP latency (SLI) and compares it with SLO:
+// when SLO is violated, resources of all BE actuators are shrunk; when there is nothing
+// more to shrink, BE tasks are paused and finally killed if violation persists.
+// When SLI is well below SLO, paused tasks are resumed and BE resources are grown
+// step by step. Every decision is recorded as metadata.
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/metadata"
+	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// MetadataKind is kind of metadata controller decisions are recorded with.
+const MetadataKind = "controller"
+
+// Actions taken by controller.
+const (
+	ActionHold   = "hold"
+	ActionShrink = "shrink"
+	ActionGrow   = "grow"
+	ActionPause  = "pause"
+	ActionResume = "resume"
+	ActionKill   = "kill"
+)
+
+// Config describes controller behaviour.
+type Config struct {
+	// Name prefixes keys of recorded decisions (e.g. phase name), so decisions of many controllers can be recorded.
+	Name string
+	// SLO is target latency of HP workload in microseconds.
+	SLO int
+	// Interval between consecutive steps in Run.
+	Interval time.Duration
+	// GrowThreshold is fraction of SLO below which BE resources are grown.
+	GrowThreshold float64
+	// ViolationsToKill is number of consecutive violations that cannot be handled by
+	// shrinking resources or pausing BE tasks, after which BE tasks are killed.
+	// Zero means that BE tasks are never killed.
+	ViolationsToKill int
+}
+
+// DefaultConfig returns Config for given SLO.
+func DefaultConfig(slo int) Config {
+	return Config{
+		SLO:              slo,
+		Interval:         5 * time.Second,
+		GrowThreshold:    0.8,
+		ViolationsToKill: 3,
+	}
+}
+
+// Decision describes single step of controller.
+type Decision struct {
+	Time   time.Time
+	SLI    int
+	Action string
+	// Levels of actuators after the decision (by actuator name).
+	Levels map[string]int
+}
+
+// String returns user-friendly description of decision.
+func (d Decision) String() string {
+	names := []string{}
+	for name, level := range d.Levels {
+		names = append(names, name+"="+strconv.Itoa(level))
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("time=%s sli=%d action=%s levels=%s",
+		d.Time.Format(time.RFC3339Nano), d.SLI, d.Action, strings.Join(names, ","))
+}
+
+// Controller protects HP SLO by changing BE resources.
+type Controller struct {
+	config    Config
+	sli       SLISource
+	actuators []Actuator
+
+	mutex      sync.Mutex
+	pauser     Pauser
+	tasks      []executor.TaskHandle
+	metadata   metadata.Metadata
+	paused     bool
+	killed     bool
+	violations int
+	decisions  int
+}
+
+// NewController returns controller using sli to measure HP latency and actuators to change BE resources.
+// Actuators are grown in given order and shrunk all at once.
+func NewController(config Config, sli SLISource, actuators ...Actuator) (*Controller, error) {
+	if config.SLO <= 0 {
+		return nil, errors.Errorf("SLO must be positive, got %d", config.SLO)
+	}
+	if config.Interval <= 0 {
+		return nil, errors.Errorf("interval must be positive, got %s", config.Interval)
+	}
+	if config.GrowThreshold <= 0 || config.GrowThreshold > 1 {
+		return nil, errors.Errorf("grow threshold must be in range (0, 1], got %g", config.GrowThreshold)
+	}
+
+	return &Controller{
+		config:    config,
+		sli:       sli,
+		actuators: actuators,
+	}, nil
+}
+
+// SetPauser sets Pauser used to pause BE tasks when resources cannot be shrunk further.
+func (c *Controller) SetPauser(pauser Pauser) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.pauser = pauser
+}
+
+// SetBestEffortTasks sets BE tasks which are killed when SLO violation cannot be handled otherwise.
+func (c *Controller) SetBestEffortTasks(tasks []executor.TaskHandle) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.tasks = tasks
+}
+
+// SetMetadata sets metadata where decisions are recorded.
+func (c *Controller) SetMetadata(metadata metadata.Metadata) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.metadata = metadata
+}
+
+// Run steps immediately and then every interval until context is done.
+// Measurement of SLI is interrupted when context is done (see ContextSLISource).
+// Errors of single steps are logged and do not stop the controller.
+func (c *Controller) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.step(ctx); err != nil && ctx.Err() == nil {
+			logrus.Warnf("Controller step failed: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Step measures SLI and acts on it once.
+func (c *Controller) Step() (Decision, error) {
+	return c.step(context.Background())
+}
+
+func (c *Controller) step(ctx context.Context) (Decision, error) {
+	sli, err := sliContext(ctx, c.sli)
+	if err != nil {
+		return Decision{}, errors.Wrap(err, "cannot measure SLI")
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var action string
+	switch {
+	case sli > c.config.SLO:
+		action, err = c.handleViolation()
+	case float64(sli) < c.config.GrowThreshold*float64(c.config.SLO):
+		c.violations = 0
+		action, err = c.handleSlack()
+	default:
+		c.violations = 0
+		action = ActionHold
+	}
+
+	decision := Decision{Time: time.Now(), SLI: sli, Action: action, Levels: c.levels()}
+	logrus.Debugf("Controller decision: %s", decision)
+	c.record(decision)
+
+	return decision, err
+}
+
+func (c *Controller) handleViolation() (string, error) {
+	if !c.paused && !c.killed {
+		shrunk, err := c.shrink()
+		if shrunk || err != nil {
+			return ActionShrink, err
+		}
+
+		if c.pauser != nil {
+			if err := c.pauser.Pause(); err != nil {
+				return ActionHold, errors.Wrap(err, "cannot pause BE tasks")
+			}
+			c.paused = true
+			return ActionPause, nil
+		}
+	}
+
+	c.violations++
+	if c.killed || c.config.ViolationsToKill == 0 || c.violations < c.config.ViolationsToKill || len(c.tasks) == 0 {
+		return ActionHold, nil
+	}
+
+	return ActionKill, c.kill()
+}
+
+func (c *Controller) handleSlack() (string, error) {
+	if c.killed {
+		return ActionHold, nil
+	}
+
+	if c.paused {
+		if err := c.pauser.Resume(); err != nil {
+			return ActionHold, errors.Wrap(err, "cannot resume BE tasks")
+		}
+		c.paused = false
+		return ActionResume, nil
+	}
+
+	for _, actuator := range c.actuators {
+		if actuator.Level() < actuator.Levels()-1 {
+			return ActionGrow, actuator.SetLevel(actuator.Level() + 1)
+		}
+	}
+	return ActionHold, nil
+}
+
+// shrink lowers level of all actuators that are not at the lowest level.
+func (c *Controller) shrink() (shrunk bool, err error) {
+	var errCollection errcollection.ErrorCollection
+	for _, actuator := range c.actuators {
+		if actuator.Level() > 0 {
+			shrunk = true
+			errCollection.Add(actuator.SetLevel(actuator.Level() - 1))
+		}
+	}
+	return shrunk, errCollection.GetErrIfAny()
+}
+
+// kill stops BE tasks. Paused tasks are resumed first, so they can handle signals.
+func (c *Controller) kill() error {
+	var errCollection errcollection.ErrorCollection
+	if c.paused {
+		errCollection.Add(errors.Wrap(c.pauser.Resume(), "cannot resume BE tasks"))
+		c.paused = false
+	}
+	for _, task := range c.tasks {
+		errCollection.Add(errors.Wrapf(task.Stop(), "cannot stop BE task %s", task))
+	}
+	c.killed = true
+	return errCollection.GetErrIfAny()
+}
+
+func (c *Controller) levels() map[string]int {
+	levels := map[string]int{}
+	for _, actuator := range c.actuators {
+		levels[actuator.Name()] = actuator.Level()
+	}
+	return levels
+}
+
+func (c *Controller) record(decision Decision) {
+	c.decisions++
+	if c.metadata == nil {
+		return
+	}
+
+	key := fmt.Sprintf("controller_decision_%04d", c.decisions)
+	if c.config.Name != "" {
+		key = c.config.Name + " " + key
+	}
+	err := c.metadata.RecordMap(map[string]string{key: decision.String()}, MetadataKind)
+	if err != nil {
+		logrus.Warnf("Cannot record controller decision: %s", err.Error())
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type fakeSLI struct {
+	values []int
+}
+
+func (s *fakeSLI) SLI() (int, error) {
+	if len(s.values) == 0 {
+		return 0, errors.New("no more values")
+	}
+	value := s.values[0]
+	s.values = s.values[1:]
+	return value, nil
+}
+
+type fakePauser struct {
+	paused bool
+}
+
+func (p *fakePauser) Pause() error {
+	p.paused = true
+	return nil
+}
+
+func (p *fakePauser) Resume() error {
+	p.paused = false
+	return nil
+}
+
+type recordingMetadata struct {
+	records []map[string]string
+}
+
+func (m *recordingMetadata) Record(key string, value string, kind string) error {
+	return m.RecordMap(map[string]string{key: value}, kind)
+}
+
+func (m *recordingMetadata) RecordMap(metadata map[string]string, kind string) error {
+	m.records = append(m.records, metadata)
+	return nil
+}
+
+func (m *recordingMetadata) GetByKind(kind string) (map[string]string, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *recordingMetadata) Clear() error {
+	return nil
+}
+
+func actions(c *Controller, steps int) []string {
+	result := []string{}
+	for i := 0; i < steps; i++ {
+		decision, err := c.Step()
+		So(err, ShouldBeNil)
+		result = append(result, decision.Action)
+	}
+	return result
+}
+
+func TestController(t *testing.T) {
+	Convey("When having controller with two actuators", t, func() {
+		var applied []int
+		cpus := NewActuator("cpus", 3, func(level int) error {
+			applied = append(applied, level)
+			return nil
+		})
+		ways := NewActuator("ways", 2, func(level int) error { return nil })
+
+		sli := &fakeSLI{}
+		config := DefaultConfig(1000)
+		config.ViolationsToKill = 2
+		controller, err := NewController(config, sli, cpus, ways)
+		So(err, ShouldBeNil)
+
+		metadata := &recordingMetadata{}
+		controller.SetMetadata(metadata)
+
+		Convey("SLO violations should shrink all actuators down to the lowest level", func() {
+			sli.values = []int{1500, 1500, 1500}
+			So(actions(controller, 3), ShouldResemble, []string{ActionShrink, ActionShrink, ActionHold})
+			So(cpus.Level(), ShouldEqual, 0)
+			So(ways.Level(), ShouldEqual, 0)
+			So(applied, ShouldResemble, []int{1, 0})
+		})
+
+		Convey("SLI within threshold should hold resources", func() {
+			sli.values = []int{900}
+			So(actions(controller, 1), ShouldResemble, []string{ActionHold})
+			So(cpus.Level(), ShouldEqual, 2)
+		})
+
+		Convey("Slack should grow actuators one at a time in order", func() {
+			sli.values = []int{1500, 1500, 100, 100, 100, 100}
+			So(actions(controller, 6), ShouldResemble,
+				[]string{ActionShrink, ActionShrink, ActionGrow, ActionGrow, ActionGrow, ActionHold})
+			So(cpus.Level(), ShouldEqual, 2)
+			So(ways.Level(), ShouldEqual, 1)
+		})
+
+		Convey("BE tasks should be paused, resumed and killed when violation persists", func() {
+			pauser := &fakePauser{}
+			controller.SetPauser(pauser)
+			task := new(executor.MockTaskHandle)
+			task.On("Stop").Return(nil)
+			controller.SetBestEffortTasks([]executor.TaskHandle{task})
+
+			sli.values = []int{1500, 1500, 1500}
+			So(actions(controller, 3), ShouldResemble, []string{ActionShrink, ActionShrink, ActionPause})
+			So(pauser.paused, ShouldBeTrue)
+
+			Convey("Slack should resume paused tasks", func() {
+				sli.values = []int{100}
+				So(actions(controller, 1), ShouldResemble, []string{ActionResume})
+				So(pauser.paused, ShouldBeFalse)
+			})
+
+			Convey("Persisting violation should kill resumed tasks", func() {
+				sli.values = []int{1500, 1500, 1500, 100}
+				So(actions(controller, 4), ShouldResemble, []string{ActionHold, ActionKill, ActionHold, ActionHold})
+				So(pauser.paused, ShouldBeFalse)
+				task.AssertNumberOfCalls(t, "Stop", 1)
+			})
+		})
+
+		Convey("Decisions should be recorded in metadata", func() {
+			sli.values = []int{1500}
+			actions(controller, 1)
+			So(metadata.records, ShouldHaveLength, 1)
+			So(metadata.records[0]["controller_decision_0001"], ShouldContainSubstring, "sli=1500 action=shrink levels=cpus=1,ways=0")
+		})
+
+		Convey("Decisions of named controller should be recorded with its name", func() {
+			config.Name = "phase"
+			named, err := NewController(config, &fakeSLI{values: []int{100}})
+			So(err, ShouldBeNil)
+			named.SetMetadata(metadata)
+			actions(named, 1)
+			So(metadata.records[0], ShouldContainKey, "phase controller_decision_0001")
+		})
+
+		Convey("SLI errors should be returned", func() {
+			_, err := controller.Step()
+			So(err, ShouldNotBeNil)
+			So(metadata.records, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Controller should not accept invalid config", t, func() {
+		_, err := NewController(Config{SLO: 0, Interval: 1, GrowThreshold: 0.5}, &fakeSLI{})
+		So(err, ShouldNotBeNil)
+		_, err = NewController(Config{SLO: 100, Interval: 1, GrowThreshold: 1.5}, &fakeSLI{})
+		So(err, ShouldNotBeNil)
+	})
+}
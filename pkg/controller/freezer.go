@@ -0,0 +1,60 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/isolation/cgroup"
+)
+
+// Pauser stops BE workloads temporarily.
+type Pauser interface {
+	Pause() error
+	Resume() error
+}
+
+const (
+	// FreezerState is the name of the freezer state attribute in cgroup v1.
+	FreezerState = "freezer.state"
+	// CgroupFreeze is the name of the freeze attribute in unified hierarchy.
+	CgroupFreeze = "cgroup.freeze"
+)
+
+// CgroupFreezer pauses all tasks of a cgroup with freezer.
+// Cgroup in v1 hierarchy must have freezer controller.
+type CgroupFreezer struct {
+	cgroup cgroup.Cgroup
+}
+
+// NewCgroupFreezer is constructor for CgroupFreezer.
+func NewCgroupFreezer(cg cgroup.Cgroup) *CgroupFreezer {
+	return &CgroupFreezer{cgroup: cg}
+}
+
+// Pause freezes tasks of the cgroup.
+func (f *CgroupFreezer) Pause() error {
+	if f.cgroup.Hierarchy() == isolation.CgroupV2 {
+		return f.cgroup.Set(CgroupFreeze, "1")
+	}
+	return f.cgroup.Set(FreezerState, "FROZEN")
+}
+
+// Resume thaws tasks of the cgroup.
+func (f *CgroupFreezer) Resume() error {
+	if f.cgroup.Hierarchy() == isolation.CgroupV2 {
+		return f.cgroup.Set(CgroupFreeze, "0")
+	}
+	return f.cgroup.Set(FreezerState, "THAWED")
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/pkg/errors"
+)
+
+// SLISource provides current latency of HP workload (SLI) in microseconds.
+type SLISource interface {
+	SLI() (int, error)
+}
+
+// ContextSLISource is implemented by SLISources which measurement can be interrupted with context.
+type ContextSLISource interface {
+	SLIContext(ctx context.Context) (int, error)
+}
+
+// sliContext measures SLI with source and interrupts measurement when ctx is done (when source supports it).
+func sliContext(ctx context.Context, source SLISource) (int, error) {
+	if contextSource, ok := source.(ContextSLISource); ok {
+		return contextSource.SLIContext(ctx)
+	}
+	return source.SLI()
+}
+
+// SLIFunc adapts a function to SLISource.
+type SLIFunc func() (int, error)
+
+// SLI implements SLISource.
+func (f SLIFunc) SLI() (int, error) {
+	return f()
+}
+
+// LoadGeneratorSLI measures SLI by running short load with a load generator and parsing its output.
+type LoadGeneratorSLI struct {
+	loadGenerator executor.LoadGenerator
+	qps           int
+	window        time.Duration
+	parse         func(io.Reader) (int, error)
+	track         func(executor.TaskHandle) func()
+	results       func(executor.TaskHandle)
+}
+
+// NewLoadGeneratorSLI returns SLISource which generates qps load for window duration on each measurement.
+// Parse is used to read SLI from load generator output (e.g. mutilate.ParseSLI).
+func NewLoadGeneratorSLI(loadGenerator executor.LoadGenerator, qps int, window time.Duration, parse func(io.Reader) (int, error)) *LoadGeneratorSLI {
+	return &LoadGeneratorSLI{
+		loadGenerator: loadGenerator,
+		qps:           qps,
+		window:        window,
+		parse:         parse,
+	}
+}
+
+// SetTaskTracker sets function which registers every load generator task until it is finished
+// (e.g. Shutdown().TrackTaskHandle), so the task is stopped when experiment is interrupted.
+func (s *LoadGeneratorSLI) SetTaskTracker(track func(executor.TaskHandle) func()) {
+	s.track = track
+}
+
+// SetResultsHandler sets function which receives every finished load generator task (e.g. to publish its results).
+// The handler takes ownership of the task output which is not erased then.
+func (s *LoadGeneratorSLI) SetResultsHandler(results func(executor.TaskHandle)) {
+	s.results = results
+}
+
+// SLI implements SLISource.
+func (s *LoadGeneratorSLI) SLI() (int, error) {
+	return s.SLIContext(context.Background())
+}
+
+// SLIContext implements ContextSLISource. Load generator task is stopped when ctx is done.
+func (s *LoadGeneratorSLI) SLIContext(ctx context.Context) (int, error) {
+	handle, err := executor.LoadContext(ctx, s.loadGenerator, s.qps, s.window)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot start load of %d QPS", s.qps)
+	}
+	if s.track != nil {
+		defer s.track(handle)()
+	}
+
+	_, err = executor.WaitContext(ctx, handle)
+	if err != nil {
+		handle.Stop()
+		handle.EraseOutput()
+		return 0, errors.Wrapf(err, "load of %d QPS failed", s.qps)
+	}
+
+	if s.results != nil {
+		s.results(handle)
+	} else {
+		defer handle.EraseOutput()
+	}
+
+	exitCode, err := handle.ExitCode()
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot read load generator exit code")
+	}
+	if exitCode != 0 {
+		return 0, errors.Errorf("load generator returned with exit code %d", exitCode)
+	}
+
+	stdout, err := handle.StdoutFile()
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot read load generator output")
+	}
+	defer stdout.Close()
+
+	sli, err := s.parse(stdout)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot parse load generator output")
+	}
+	return sli, nil
+}
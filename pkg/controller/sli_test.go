@@ -0,0 +1,128 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func parseSLI(reader io.Reader) (int, error) {
+	bytes, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(bytes)))
+}
+
+func TestLoadGeneratorSLI(t *testing.T) {
+	Convey("When measuring SLI with load generator", t, func() {
+		loadGenerator := new(executor.MockLoadGenerator)
+		handle := new(executor.MockTaskHandle)
+		loadGenerator.On("Load", 100, time.Second).Return(handle, nil)
+		sli := NewLoadGeneratorSLI(loadGenerator, 100, time.Second, parseSLI)
+
+		Convey("SLI should be parsed from output of finished task", func() {
+			output, err := ioutil.TempFile("", "sli")
+			So(err, ShouldBeNil)
+			defer os.Remove(output.Name())
+			_, err = output.WriteString("250\n")
+			So(err, ShouldBeNil)
+			output.Close()
+
+			handle.On("Wait", 0*time.Second).Return(true, nil)
+			handle.On("ExitCode").Return(0, nil)
+			handle.On("StdoutFile").Return(os.Open(output.Name()))
+			handle.On("EraseOutput").Return(nil)
+			var tracked, untracked bool
+			sli.SetTaskTracker(func(th executor.TaskHandle) func() {
+				tracked = th == handle
+				return func() { untracked = true }
+			})
+
+			value, err := sli.SLI()
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, 250)
+			So(tracked, ShouldBeTrue)
+			So(untracked, ShouldBeTrue)
+			handle.AssertCalled(t, "EraseOutput")
+		})
+
+		Convey("Failed load generator should be reported", func() {
+			handle.On("Wait", 0*time.Second).Return(true, nil)
+			handle.On("ExitCode").Return(1, nil)
+			var results []executor.TaskHandle
+			sli.SetResultsHandler(func(th executor.TaskHandle) { results = append(results, th) })
+
+			_, err := sli.SLI()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "exit code 1")
+			So(results, ShouldResemble, []executor.TaskHandle{handle})
+			handle.AssertNotCalled(t, "EraseOutput")
+		})
+
+		Convey("Load generator should be stopped when context is done", func() {
+			handle.On("Wait", 0*time.Second).After(time.Second).Return(true, nil)
+			handle.On("String").Return("load generator")
+			handle.On("Stop").Return(nil)
+			handle.On("EraseOutput").Return(nil)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			_, err := sli.SLIContext(ctx)
+			So(err, ShouldNotBeNil)
+			handle.AssertCalled(t, "Stop")
+			handle.AssertNotCalled(t, "StdoutFile")
+		})
+	})
+}
+
+func TestControllerRun(t *testing.T) {
+	Convey("Controller should step immediately and stop when context is done", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		steps := 0
+		sli := SLIFunc(func() (int, error) {
+			steps++
+			cancel()
+			return 100, nil
+		})
+		config := DefaultConfig(1000)
+		config.Interval = time.Hour
+		controller, err := NewController(config, sli)
+		So(err, ShouldBeNil)
+
+		done := make(chan struct{})
+		go func() {
+			controller.Run(ctx)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("controller has not stopped after context was canceled")
+		}
+		So(steps, ShouldEqual, 1)
+	})
+}
@@ -1,8 +1,12 @@
 package executor
 
 import (
+	"context"
 	"sync"
 	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 // ChainedTaskHandle is an links Launchers in a way that
@@ -17,6 +21,11 @@ type ChainedTaskHandle struct {
 	chainFinished chan struct{}
 	stopChain     chan struct{}
 	stopOnce      sync.Once
+
+	// Handles launched so far (guarded by mutex); handleLaunched is closed and replaced when a handle is launched.
+	mutex          sync.Mutex
+	handles        []TaskHandle
+	handleLaunched chan struct{}
 }
 
 // NewChainedTaskHandle returns TaskHandle that executes current handle, and will
@@ -27,6 +36,8 @@ func NewChainedTaskHandle(handle TaskHandle, launchers ...Launcher) TaskHandle {
 		chainedLaunchers: launchers,
 		chainFinished:    make(chan struct{}),
 		stopChain:        make(chan struct{}, 1),
+		handles:          []TaskHandle{handle},
+		handleLaunched:   make(chan struct{}),
 	}
 
 	go chained.watch()
@@ -58,6 +69,7 @@ func (cth *ChainedTaskHandle) watch() {
 			close(cth.chainFinished)
 			return
 		}
+		cth.launched(chainedHandle)
 
 		waitChan = getWaitChannel(chainedHandle)
 		select {
@@ -103,6 +115,26 @@ func (cth *ChainedTaskHandle) Wait(timeout time.Duration) (bool, error) {
 	}
 }
 
+// StopContext stops all execution of ChainedTaskHandle and waits for the chain to stop or ctx to be done.
+func (cth *ChainedTaskHandle) StopContext(ctx context.Context) error {
+	cth.stopOnce.Do(func() {
+		cth.stopChain <- struct{}{}
+	})
+
+	_, err := cth.WaitContext(ctx)
+	return err
+}
+
+// WaitContext waits for all tasks in ChainedTaskHandle to finish or ctx to be done.
+func (cth *ChainedTaskHandle) WaitContext(ctx context.Context) (bool, error) {
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case <-cth.chainFinished:
+		return true, cth.encounteredError
+	}
+}
+
 // Status returns current TaskState.
 func (cth *ChainedTaskHandle) Status() TaskState {
 	select {
@@ -112,3 +144,80 @@ func (cth *ChainedTaskHandle) Status() TaskState {
 		return RUNNING
 	}
 }
+
+// TerminationStatus returns status of the last launched task of the chain.
+func (cth *ChainedTaskHandle) TerminationStatus() (TerminationStatus, error) {
+	if cth.Status() != TERMINATED {
+		return TerminationStatus{}, errors.New("chain of tasks is not terminated")
+	}
+
+	cth.mutex.Lock()
+	last := cth.handles[len(cth.handles)-1]
+	cth.mutex.Unlock()
+	return GetTerminationStatus(last)
+}
+
+func (cth *ChainedTaskHandle) launched(handle TaskHandle) {
+	cth.mutex.Lock()
+	defer cth.mutex.Unlock()
+	cth.handles = append(cth.handles, handle)
+	close(cth.handleLaunched)
+	cth.handleLaunched = make(chan struct{})
+}
+
+// handle returns i-th handle of the chain or channel closed when next handle is launched.
+func (cth *ChainedTaskHandle) handle(i int) (TaskHandle, <-chan struct{}) {
+	cth.mutex.Lock()
+	defer cth.mutex.Unlock()
+	if i < len(cth.handles) {
+		return cth.handles[i], nil
+	}
+	return nil, cth.handleLaunched
+}
+
+// Lines streams lines of output of all tasks in the chain, one task after another.
+func (cth *ChainedTaskHandle) Lines(ctx context.Context, stream OutputStream) (<-chan string, error) {
+	first, err := Lines(ctx, cth.TaskHandle, stream)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+
+		current := first
+		for i := 1; ; i++ {
+			for line := range current {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			handle, launched := cth.handle(i)
+			for handle == nil {
+				select {
+				case <-launched:
+					handle, launched = cth.handle(i)
+				case <-cth.chainFinished:
+					// Handle might have been launched just before the chain finished.
+					if handle, _ = cth.handle(i); handle == nil {
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			current, err = Lines(ctx, handle, stream)
+			if err != nil {
+				log.Errorf("Cannot stream %s of %s: %s", stream, handle, err.Error())
+				return
+			}
+		}
+	}()
+
+	return lines, nil
+}
@@ -15,6 +15,7 @@
 package executor
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
@@ -55,6 +56,21 @@ func (m *ClusterTaskHandle) StderrFile() (*os.File, error) {
 	return m.master.StderrFile()
 }
 
+// TerminationStatus returns status of the master.
+func (m *ClusterTaskHandle) TerminationStatus() (TerminationStatus, error) {
+	return GetTerminationStatus(m.master)
+}
+
+// ResourceUsage returns resources consumed by the master.
+func (m *ClusterTaskHandle) ResourceUsage() (ResourceUsage, error) {
+	return GetResourceUsage(m.master)
+}
+
+// Lines streams lines of the master's output.
+func (m *ClusterTaskHandle) Lines(ctx context.Context, stream OutputStream) (<-chan string, error) {
+	return Lines(ctx, m.master, stream)
+}
+
 // Stop terminates the master firstly and then all the agents.
 func (m *ClusterTaskHandle) Stop() (err error) {
 	var errCollection errcollection.ErrorCollection
@@ -72,6 +88,19 @@ func (m *ClusterTaskHandle) Stop() (err error) {
 	return errCollection.GetErrIfAny()
 }
 
+// StopContext terminates the master firstly and then all the agents.
+// Each of them is given time until ctx is done.
+func (m *ClusterTaskHandle) StopContext(ctx context.Context) error {
+	var errCollection errcollection.ErrorCollection
+
+	errCollection.Add(StopContext(ctx, m.master))
+	for _, handle := range m.agents {
+		errCollection.Add(StopContext(ctx, handle))
+	}
+
+	return errCollection.GetErrIfAny()
+}
+
 // Status returns the state of the master.
 func (m *ClusterTaskHandle) Status() TaskState {
 	return m.master.Status()
@@ -107,6 +136,25 @@ func (m *ClusterTaskHandle) Wait(timeout time.Duration) (isMasterTerminated bool
 	return isMasterTerminated, err
 }
 
+// WaitContext waits for the master completion or ctx to be done.
+// When master is terminated all agents are stopped.
+func (m *ClusterTaskHandle) WaitContext(ctx context.Context) (isMasterTerminated bool, err error) {
+	isMasterTerminated, err = WaitContext(ctx, m.master)
+	if !isMasterTerminated {
+		return false, err
+	}
+
+	agentErrors := m.stopAgents()
+	if agentErrors != nil {
+		var errCol errcollection.ErrorCollection
+		errCol.Add(err)
+		errCol.Add(agentErrors)
+		err = errCol.GetErrIfAny()
+	}
+
+	return true, err
+}
+
 func (m *ClusterTaskHandle) stopAgents() error {
 	var errCol errcollection.ErrorCollection
 	// Stop the agents when master is terminated.
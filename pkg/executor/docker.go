@@ -0,0 +1,493 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/journal"
+	"github.com/intelsdi-x/swan/pkg/utils/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// DockerContainerLabel is a label of all containers created by Docker executor.
+const DockerContainerLabel = "swan"
+
+var (
+	dockerHostFlag                 = conf.NewStringFlag("docker_host", "Address of Docker Engine API: unix socket (unix:///path) or TCP address (tcp://host:port).", "unix:///var/run/docker.sock")
+	dockerContainerImageFlag       = conf.NewStringFlag("docker_container_image", "Name of the container image used by Docker executor. It needs to be available locally or downloadable.", defaultContainerImage)
+	dockerPrivilegedContainersFlag = conf.NewBoolFlag("docker_privileged_containers", "Docker containers will be run as privileged.", false)
+)
+
+// DockerConfig describes containers created by Docker executor.
+type DockerConfig struct {
+	// Host is the address of Docker Engine API (see docker_host flag).
+	Host string
+	// NamePrefix is a prefix of random container names.
+	NamePrefix  string
+	Image       string
+	HostNetwork bool
+	Privileged  bool
+	// CPUSetCPUs is a list of CPUs in format accepted by cpuset cgroup (e.g. "0-3,8").
+	CPUSetCPUs string
+	CPUShares  int64
+	// MemoryLimit is a limit of memory in bytes (0 means no limit).
+	MemoryLimit int64
+	// Binds are bind mounts in format "host path:container path[:ro]".
+	Binds      []string
+	Decorators isolation.Decorators
+	// Init runs init process in container, which forwards signals to the command and reaps zombies.
+	Init bool
+
+	// StopTimeout is the time after SIGTERM when container is killed by Docker (used when StopPolicy is empty).
+	StopTimeout time.Duration
+	// StopPolicy describes signals sent to the container when task is stopped.
+	StopPolicy StopPolicy
+}
+
+// DefaultDockerConfig returns a DockerConfig with image and privileges from flags.
+func DefaultDockerConfig() DockerConfig {
+	return DockerConfig{
+		Host:        dockerHostFlag.Value(),
+		NamePrefix:  "swan",
+		Image:       dockerContainerImageFlag.Value(),
+		HostNetwork: false,
+		Privileged:  dockerPrivilegedContainersFlag.Value(),
+		Decorators:  isolation.Decorators{},
+		Init:        true,
+		StopTimeout: 5 * time.Second,
+	}
+}
+
+// Docker executor runs commands in containers created through Docker Engine API.
+type Docker struct {
+	config DockerConfig
+	client *dockerClient
+}
+
+// NewDocker returns an executor which runs commands in Docker containers on the host given in config.
+func NewDocker(config DockerConfig) (Executor, error) {
+	client, err := newDockerClient(config.Host)
+	if err != nil {
+		return nil, err
+	}
+	return &Docker{config: config, client: client}, nil
+}
+
+// ListDockerContainers returns names of all containers created by Docker executor on host (see DockerConfig.Host).
+func ListDockerContainers(host string) ([]string, error) {
+	client, err := newDockerClient(host)
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := client.listContainers(context.Background(), DockerContainerLabel)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot list containers on %s", host)
+	}
+	names := []string{}
+	for _, container := range containers {
+		for _, name := range container.Names {
+			names = append(names, strings.TrimPrefix(name, "/"))
+		}
+	}
+	return names, nil
+}
+
+// RemoveDockerContainer removes container (killing it when it is running) from host.
+func RemoveDockerContainer(host, name string) error {
+	client, err := newDockerClient(host)
+	if err != nil {
+		return err
+	}
+
+	err = client.removeContainer(context.Background(), name)
+	if err != nil {
+		return errors.Wrapf(err, "cannot remove container %q on %s", name, host)
+	}
+	return nil
+}
+
+// String returns user-friendly name of executor.
+func (d *Docker) String() string {
+	return fmt.Sprintf("Docker Executor (%s)", d.config.Host)
+}
+
+// containerAddress returns address where services of running container are available:
+// address of the host for containers in host network, container address otherwise.
+func (d *Docker) containerAddress(ctx context.Context, id string) (string, error) {
+	if d.config.HostNetwork {
+		return d.address(), nil
+	}
+
+	container, err := d.client.inspectContainer(ctx, id)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot inspect container %q", id)
+	}
+	if address := container.NetworkSettings.IPAddress; address != "" {
+		return address, nil
+	}
+	for _, network := range container.NetworkSettings.Networks {
+		if network.IPAddress != "" {
+			return network.IPAddress, nil
+		}
+	}
+	// Container which has already terminated is not connected to any network.
+	return d.address(), nil
+}
+
+// address returns address of the host where containers are run.
+func (d *Docker) address() string {
+	host, err := url.Parse(d.config.Host)
+	if err != nil || host.Scheme == "unix" {
+		return "127.0.0.1"
+	}
+	if address, _, err := net.SplitHostPort(host.Host); err == nil {
+		return address
+	}
+	return host.Host
+}
+
+func (d *Docker) containerSpec(command string) dockerContainerSpec {
+	networkMode := ""
+	if d.config.HostNetwork {
+		networkMode = "host"
+	}
+
+	return dockerContainerSpec{
+		Image:  d.config.Image,
+		Cmd:    []string{"sh", "-c", command},
+		Labels: map[string]string{DockerContainerLabel: "true"},
+		HostConfig: dockerHostConfig{
+			NetworkMode: networkMode,
+			Privileged:  d.config.Privileged,
+			CpusetCpus:  d.config.CPUSetCPUs,
+			CPUShares:   d.config.CPUShares,
+			Memory:      d.config.MemoryLimit,
+			Binds:       d.config.Binds,
+			Init:        d.config.Init,
+		},
+	}
+}
+
+// createContainer creates container running command. Image is pulled when it is not available.
+func (d *Docker) createContainer(ctx context.Context, name, command string) (string, error) {
+	spec := d.containerSpec(command)
+	id, err := d.client.createContainer(ctx, name, spec)
+	if isDockerNotFound(err) {
+		log.Infof("Docker executor: pulling image %q", spec.Image)
+		if err := d.client.pullImage(ctx, spec.Image); err != nil {
+			return "", err
+		}
+		id, err = d.client.createContainer(ctx, name, spec)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot create container %q", name)
+	}
+	return id, nil
+}
+
+// Execute runs command in a new container. The container is removed when the command terminates.
+func (d *Docker) Execute(command string) (TaskHandle, error) {
+	return d.ExecuteContext(context.Background(), command)
+}
+
+// ExecuteContext runs command in a new container like Execute does.
+// When ctx is done, the container is stopped.
+func (d *Docker) ExecuteContext(ctx context.Context, command string) (TaskHandle, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrapf(err, "container for command %q not created", command)
+	}
+
+	command = d.config.Decorators.Decorate(command)
+	name := fmt.Sprintf("%s-%s", d.config.NamePrefix, uuid.New()[:8])
+	log.Debugf("Starting %q in container %s with image %q", command, name, d.config.Image)
+
+	id, err := d.createContainer(ctx, name, command)
+	if err != nil {
+		return nil, err
+	}
+	journal.Created(journal.Resource{Kind: journal.KindDockerContainer, ID: name, Location: d.config.Host})
+
+	handle := &dockerTaskHandle{
+		client:      d.client,
+		host:        d.config.Host,
+		containerID: id,
+		name:        name,
+		command:     command,
+		stopPolicy:  stopPolicyFromContext(ctx, d.config.StopPolicy),
+		stopTimeout: d.config.StopTimeout,
+		terminated:  make(chan struct{}),
+		status:      TerminationStatus{ExitCode: -1},
+	}
+
+	outputDirectory, err := createOutputDirectory(command, "docker")
+	if err != nil {
+		handle.remove()
+		return nil, errors.Wrapf(err, "cannot create output directory for command %q", command)
+	}
+	stdoutFile, stderrFile, err := createExecutorOutputFiles(outputDirectory)
+	if err != nil {
+		handle.remove()
+		removeDirectory(outputDirectory)
+		return nil, errors.Wrapf(err, "cannot create output files for command %q", command)
+	}
+	handle.stdoutFilePath = stdoutFile.Name()
+	handle.stderrFilePath = stderrFile.Name()
+
+	err = d.client.startContainer(ctx, id)
+	if err == nil {
+		handle.address, err = d.containerAddress(ctx, id)
+	}
+	if err == nil {
+		// Logs are read until container terminates, so they cannot be bound to ctx.
+		handle.logs, err = d.client.containerLogs(context.Background(), id)
+	}
+	if err != nil {
+		stdoutFile.Close()
+		stderrFile.Close()
+		handle.remove()
+		removeDirectory(outputDirectory)
+		return nil, errors.Wrapf(err, "cannot start container %q", name)
+	}
+
+	go handle.watch(stdoutFile, stderrFile)
+	stopWhenDone(ctx, handle)
+
+	// Best effort potential way to check if command has started properly.
+	handle.Wait(100 * time.Millisecond)
+	err = checkIfProcessFailedToExecute(command, d.String(), handle)
+	if err != nil {
+		return nil, err
+	}
+	return handle, nil
+}
+
+// dockerTaskHandle is a TaskHandle of command running in Docker container.
+type dockerTaskHandle struct {
+	client      *dockerClient
+	host        string
+	address     string
+	containerID string
+	name        string
+	command     string
+	stopPolicy  StopPolicy
+	stopTimeout time.Duration
+
+	logs           io.ReadCloser
+	stdoutFilePath string
+	stderrFilePath string
+
+	// terminated is closed when container has terminated, its output has been copied and it has been removed.
+	terminated chan struct{}
+
+	mutex   sync.Mutex
+	status  TerminationStatus
+	stopped bool
+}
+
+// watch copies output of the container to files and waits for it to terminate.
+// Terminated container is removed.
+func (th *dockerTaskHandle) watch(stdoutFile, stderrFile *os.File) {
+	logsCopied := make(chan struct{})
+	go func() {
+		defer close(logsCopied)
+		defer th.logs.Close()
+		defer syncAndClose(stderrFile)
+		defer syncAndClose(stdoutFile)
+
+		if err := demultiplexDockerStream(stdoutFile, stderrFile, th.logs); err != nil {
+			log.Errorf("Docker task handle: cannot copy output of container %s: %s", th.name, err.Error())
+		}
+	}()
+
+	status := TerminationStatus{ExitCode: -1}
+	exitCode, err := th.client.waitContainer(context.Background(), th.containerID)
+	if err != nil {
+		log.Errorf("Docker task handle: cannot wait for container %s: %s", th.name, err.Error())
+	} else {
+		status = statusFromExitCode(exitCode)
+		container, err := th.client.inspectContainer(context.Background(), th.containerID)
+		if err != nil {
+			log.Warnf("Docker task handle: cannot inspect container %s: %s", th.name, err.Error())
+		}
+		status.OOMKilled = container.State.OOMKilled
+	}
+	log.Debugf("Docker task handle: container %s terminated: %s", th.name, status)
+
+	<-logsCopied
+	th.remove()
+
+	th.mutex.Lock()
+	status.Stopped = th.stopped
+	th.status = status
+	th.mutex.Unlock()
+	close(th.terminated)
+}
+
+// remove removes the container from Docker host.
+func (th *dockerTaskHandle) remove() {
+	err := th.client.removeContainer(context.Background(), th.containerID)
+	if err != nil {
+		log.Warnf("Docker task handle: cannot remove container %s: %s", th.name, err.Error())
+		return
+	}
+	journal.Released(journal.Resource{Kind: journal.KindDockerContainer, ID: th.name, Location: th.host})
+}
+
+func (th *dockerTaskHandle) isTerminated() bool {
+	select {
+	case <-th.terminated:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop stops the container and waits until it is removed.
+func (th *dockerTaskHandle) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), th.stopTimeout+th.stopPolicy.Timeout()+dockerRemoveTimeout)
+	defer cancel()
+
+	return th.StopContext(ctx)
+}
+
+// dockerRemoveTimeout is the time given to Docker for killing and removing the container after stop timeout.
+const dockerRemoveTimeout = 10 * time.Second
+
+// StopContext stops the container and waits until it is removed or ctx is done.
+// Without stop policy Docker sends SIGTERM and kills the container after stop timeout,
+// otherwise signals of the policy are sent to the container.
+func (th *dockerTaskHandle) StopContext(ctx context.Context) error {
+	if th.isTerminated() {
+		return nil
+	}
+
+	th.mutex.Lock()
+	th.stopped = true
+	th.mutex.Unlock()
+
+	if !th.stopPolicy.IsEmpty() {
+		signal := func(signal syscall.Signal) error {
+			err := th.client.killContainer(ctx, th.containerID, signal)
+			if err != nil && th.isTerminated() {
+				return nil
+			}
+			return err
+		}
+		err := th.stopPolicy.escalate(ctx, signal, th.WaitContext)
+		if err != nil {
+			return errors.Wrapf(err, "cannot stop container %s", th.name)
+		}
+		return nil
+	}
+
+	timeout := int(math.Ceil(th.stopTimeout.Seconds()))
+	err := th.client.stopContainer(ctx, th.containerID, timeout)
+	if err != nil && !th.isTerminated() && !isDockerNotFound(err) {
+		return errors.Wrapf(err, "cannot stop container %s", th.name)
+	}
+	_, err = th.WaitContext(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "container %s has not been stopped", th.name)
+	}
+	return nil
+}
+
+// Status returns a state of the task.
+func (th *dockerTaskHandle) Status() TaskState {
+	if th.isTerminated() {
+		return TERMINATED
+	}
+	return RUNNING
+}
+
+// ExitCode returns exit code of the command run in container.
+func (th *dockerTaskHandle) ExitCode() (int, error) {
+	status, err := th.TerminationStatus()
+	return status.ExitCode, err
+}
+
+// TerminationStatus returns status of the terminated container.
+// Exit code is -1 when it could not be retrieved from Docker.
+func (th *dockerTaskHandle) TerminationStatus() (TerminationStatus, error) {
+	if !th.isTerminated() {
+		return TerminationStatus{}, errors.Errorf("container %s is not terminated", th.name)
+	}
+
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	return th.status, nil
+}
+
+// Wait blocks until the container terminates or timeout passes (0 means no timeout).
+func (th *dockerTaskHandle) Wait(timeout time.Duration) (bool, error) {
+	select {
+	case <-th.terminated:
+		return true, nil
+	case <-getTimeoutChan(timeout):
+		return false, nil
+	}
+}
+
+// WaitContext blocks until the container terminates or ctx is done.
+func (th *dockerTaskHandle) WaitContext(ctx context.Context) (bool, error) {
+	select {
+	case <-th.terminated:
+		return true, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// EraseOutput deletes the directory where output files reside.
+func (th *dockerTaskHandle) EraseOutput() error {
+	return removeDirectory(filepath.Dir(th.stdoutFilePath))
+}
+
+// String returns user-friendly name of the task.
+func (th *dockerTaskHandle) String() string {
+	return fmt.Sprintf("Docker container %s with command %q on %s", th.name, th.command, th.address)
+}
+
+// Address returns address of the host where container runs (or address of the container
+// when it is not run in host network).
+func (th *dockerTaskHandle) Address() string {
+	return th.address
+}
+
+// StdoutFile returns a file handle to the stdout file of the container.
+func (th *dockerTaskHandle) StdoutFile() (*os.File, error) {
+	return openFile(th.stdoutFilePath)
+}
+
+// StderrFile returns a file handle to the stderr file of the container.
+func (th *dockerTaskHandle) StderrFile() (*os.File, error) {
+	return openFile(th.stderrFilePath)
+}
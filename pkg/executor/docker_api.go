@@ -0,0 +1,319 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// dockerAPIVersion is the version of Docker Engine API used by Docker executor (Docker 1.13 or newer).
+const dockerAPIVersion = "v1.25"
+
+// Stream types of multiplexed container logs.
+const (
+	dockerStreamStdout = 1
+	dockerStreamStderr = 2
+)
+
+// dockerAPIError is an error response from Docker Engine API.
+type dockerAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (err *dockerAPIError) Error() string {
+	return fmt.Sprintf("docker engine responded with status %d: %s", err.StatusCode, err.Message)
+}
+
+// isDockerNotFound returns true when err is "not found" response from Docker Engine API.
+func isDockerNotFound(err error) bool {
+	apiErr, ok := errors.Cause(err).(*dockerAPIError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// dockerContainerSpec is a body of create container request.
+type dockerContainerSpec struct {
+	Image      string
+	Cmd        []string
+	Labels     map[string]string `json:",omitempty"`
+	HostConfig dockerHostConfig
+}
+
+// dockerHostConfig describes resources and privileges of a container.
+type dockerHostConfig struct {
+	NetworkMode string   `json:",omitempty"`
+	Privileged  bool     `json:",omitempty"`
+	CpusetCpus  string   `json:",omitempty"`
+	CPUShares   int64    `json:"CpuShares,omitempty"`
+	Memory      int64    `json:",omitempty"`
+	Binds       []string `json:",omitempty"`
+	Init        bool     `json:",omitempty"`
+}
+
+// dockerContainerState is a state of container returned by inspect request.
+type dockerContainerState struct {
+	Running   bool
+	OOMKilled bool
+	ExitCode  int
+}
+
+// dockerNetworkSettings describes networks of a container returned by inspect request.
+type dockerNetworkSettings struct {
+	// IPAddress is an address in default bridge network.
+	IPAddress string
+	Networks  map[string]struct {
+		IPAddress string
+	}
+}
+
+// dockerContainerInfo is a container returned by inspect request.
+type dockerContainerInfo struct {
+	State           dockerContainerState
+	NetworkSettings dockerNetworkSettings
+}
+
+// dockerContainer is an entry of containers list.
+type dockerContainer struct {
+	ID    string `json:"Id"`
+	Names []string
+}
+
+// dockerClient is a minimal client of Docker Engine API.
+type dockerClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// newDockerClient returns client of Docker Engine API available at host,
+// which is a unix socket ("unix:///var/run/docker.sock") or TCP address ("tcp://host:port").
+func newDockerClient(host string) (*dockerClient, error) {
+	address, err := url.Parse(host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid docker host %q", host)
+	}
+
+	switch address.Scheme {
+	case "unix":
+		socket := address.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &dockerClient{baseURL: "http://docker", client: &http.Client{Transport: transport}}, nil
+	case "tcp", "http":
+		return &dockerClient{baseURL: "http://" + address.Host, client: &http.Client{}}, nil
+	default:
+		return nil, errors.Errorf("unsupported docker host %q (expected unix:// or tcp:// address)", host)
+	}
+}
+
+// request sends request to the API and returns response with successful status.
+// Body is encoded as JSON.
+func (c *dockerClient) request(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot encode body of %s %s", method, path)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	address := c.baseURL + "/" + dockerAPIVersion + path
+	if len(query) > 0 {
+		address += "?" + query.Encode()
+	}
+	request, err := http.NewRequest(method, address, reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot create request %s %s", method, path)
+	}
+	request = request.WithContext(ctx)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s failed", method, path)
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		defer response.Body.Close()
+		apiErr := &dockerAPIError{StatusCode: response.StatusCode}
+		var message struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(response.Body).Decode(&message) == nil {
+			apiErr.Message = message.Message
+		}
+		return nil, errors.Wrapf(apiErr, "%s %s failed", method, path)
+	}
+	return response, nil
+}
+
+// do sends request to the API and decodes JSON response into result (when it is not nil).
+func (c *dockerClient) do(ctx context.Context, method, path string, query url.Values, body, result interface{}) error {
+	response, err := c.request(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if result == nil {
+		_, err = io.Copy(ioutil.Discard, response.Body)
+		return errors.Wrapf(err, "cannot read response of %s %s", method, path)
+	}
+	return errors.Wrapf(json.NewDecoder(response.Body).Decode(result), "cannot decode response of %s %s", method, path)
+}
+
+func (c *dockerClient) createContainer(ctx context.Context, name string, spec dockerContainerSpec) (string, error) {
+	var created struct {
+		ID string `json:"Id"`
+	}
+	err := c.do(ctx, http.MethodPost, "/containers/create", url.Values{"name": {name}}, spec, &created)
+	return created.ID, err
+}
+
+// pullImage pulls image (with optional tag) and waits until it is pulled.
+func (c *dockerClient) pullImage(ctx context.Context, image string) error {
+	query := url.Values{"fromImage": {image}, "tag": {"latest"}}
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		query = url.Values{"fromImage": {image[:colon]}, "tag": {image[colon+1:]}}
+	}
+
+	response, err := c.request(ctx, http.MethodPost, "/images/create", query, nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// Progress of pulling is streamed as JSON messages; failure is reported in a message, too.
+	decoder := json.NewDecoder(response.Body)
+	for {
+		var message struct {
+			Error string `json:"error"`
+		}
+		err := decoder.Decode(&message)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "cannot read progress of pulling image %q", image)
+		}
+		if message.Error != "" {
+			return errors.Errorf("cannot pull image %q: %s", image, message.Error)
+		}
+	}
+}
+
+func (c *dockerClient) startContainer(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/containers/"+id+"/start", nil, nil, nil)
+}
+
+// stopContainer sends SIGTERM to the container and kills it after timeout.
+func (c *dockerClient) stopContainer(ctx context.Context, id string, timeoutSeconds int) error {
+	return c.do(ctx, http.MethodPost, "/containers/"+id+"/stop", url.Values{"t": {strconv.Itoa(timeoutSeconds)}}, nil, nil)
+}
+
+func (c *dockerClient) killContainer(ctx context.Context, id string, signal syscall.Signal) error {
+	return c.do(ctx, http.MethodPost, "/containers/"+id+"/kill", url.Values{"signal": {strconv.Itoa(int(signal))}}, nil, nil)
+}
+
+// waitContainer blocks until container terminates and returns its exit code.
+func (c *dockerClient) waitContainer(ctx context.Context, id string) (int, error) {
+	var result struct {
+		StatusCode int
+	}
+	err := c.do(ctx, http.MethodPost, "/containers/"+id+"/wait", nil, nil, &result)
+	return result.StatusCode, err
+}
+
+func (c *dockerClient) inspectContainer(ctx context.Context, id string) (dockerContainerInfo, error) {
+	var container dockerContainerInfo
+	err := c.do(ctx, http.MethodGet, "/containers/"+id+"/json", nil, nil, &container)
+	return container, err
+}
+
+// containerLogs returns multiplexed stream of container output (see demultiplexDockerStream),
+// which is followed until container terminates.
+func (c *dockerClient) containerLogs(ctx context.Context, id string) (io.ReadCloser, error) {
+	query := url.Values{"follow": {"1"}, "stdout": {"1"}, "stderr": {"1"}}
+	response, err := c.request(ctx, http.MethodGet, "/containers/"+id+"/logs", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+// removeContainer removes container (killing it when it is running) with its anonymous volumes.
+func (c *dockerClient) removeContainer(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/containers/"+id, url.Values{"force": {"1"}, "v": {"1"}}, nil, nil)
+}
+
+// listContainers returns all (also terminated) containers having given label.
+func (c *dockerClient) listContainers(ctx context.Context, label string) ([]dockerContainer, error) {
+	filters, err := json.Marshal(map[string][]string{"label": {label}})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot encode containers filter")
+	}
+	var containers []dockerContainer
+	err = c.do(ctx, http.MethodGet, "/containers/json", url.Values{"all": {"1"}, "filters": {string(filters)}}, nil, &containers)
+	return containers, err
+}
+
+// demultiplexDockerStream copies stream of container logs (without TTY) to stdout and stderr.
+// Every frame of the stream starts with 8 bytes header: stream type, 3 zero bytes and big endian size of payload.
+func demultiplexDockerStream(stdout, stderr io.Writer, stream io.Reader) error {
+	header := make([]byte, 8)
+	for {
+		_, err := io.ReadFull(stream, header)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "cannot read header of docker stream frame")
+		}
+
+		var destination io.Writer
+		switch header[0] {
+		case dockerStreamStdout:
+			destination = stdout
+		case dockerStreamStderr:
+			destination = stderr
+		default:
+			return errors.Errorf("unknown docker stream type %d", header[0])
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(destination, stream, size); err != nil {
+			return errors.Wrap(err, "cannot copy docker stream frame")
+		}
+	}
+}
@@ -0,0 +1,446 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeDockerContainer runs command of the container as local process.
+type fakeDockerContainer struct {
+	name     string
+	spec     dockerContainerSpec
+	cmd      *exec.Cmd
+	stdout   bytes.Buffer
+	stderr   bytes.Buffer
+	done     chan struct{}
+	exitCode int
+}
+
+func (c *fakeDockerContainer) signal(signal syscall.Signal) {
+	if c.cmd != nil && c.cmd.Process != nil {
+		syscall.Kill(-c.cmd.Process.Pid, signal)
+	}
+}
+
+// fakeDockerEngine implements subset of Docker Engine API used by Docker executor.
+type fakeDockerEngine struct {
+	mutex      sync.Mutex
+	images     map[string]bool
+	containers map[string]*fakeDockerContainer
+	pulled     []string
+	lastID     int
+}
+
+func newFakeDockerEngine(images ...string) *fakeDockerEngine {
+	engine := &fakeDockerEngine{images: map[string]bool{}, containers: map[string]*fakeDockerContainer{}}
+	for _, image := range images {
+		engine.images[image] = true
+	}
+	return engine
+}
+
+func (e *fakeDockerEngine) container(id string) *fakeDockerContainer {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if container, ok := e.containers[id]; ok {
+		return container
+	}
+	for _, container := range e.containers {
+		if container.name == id {
+			return container
+		}
+	}
+	return nil
+}
+
+func (e *fakeDockerEngine) count() int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return len(e.containers)
+}
+
+func writeDockerError(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+func writeDockerFrame(w http.ResponseWriter, stream byte, payload []byte) {
+	if len(payload) == 0 {
+		return
+	}
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	w.Write(header)
+	w.Write(payload)
+}
+
+func (e *fakeDockerEngine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prefix := "/" + dockerAPIVersion
+	if !strings.HasPrefix(r.URL.Path, prefix+"/") {
+		writeDockerError(w, http.StatusBadRequest, "unsupported API version")
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, prefix+"/"), "/")
+	query := r.URL.Query()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == prefix+"/images/create":
+		image := query.Get("fromImage") + ":" + query.Get("tag")
+		e.mutex.Lock()
+		e.images[image] = true
+		e.pulled = append(e.pulled, image)
+		e.mutex.Unlock()
+		fmt.Fprintln(w, `{"status": "Pulling from library"}`)
+
+	case r.Method == http.MethodPost && r.URL.Path == prefix+"/containers/create":
+		var spec dockerContainerSpec
+		json.NewDecoder(r.Body).Decode(&spec)
+		image := spec.Image
+		if !strings.Contains(image, ":") {
+			image += ":latest"
+		}
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		if !e.images[image] {
+			writeDockerError(w, http.StatusNotFound, "No such image: "+spec.Image)
+			return
+		}
+		e.lastID++
+		id := strconv.Itoa(e.lastID)
+		e.containers[id] = &fakeDockerContainer{name: query.Get("name"), spec: spec, done: make(chan struct{})}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"Id": %q}`, id)
+
+	case r.Method == http.MethodGet && r.URL.Path == prefix+"/containers/json":
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		list := []dockerContainer{}
+		for id, container := range e.containers {
+			list = append(list, dockerContainer{ID: id, Names: []string{"/" + container.name}})
+		}
+		json.NewEncoder(w).Encode(list)
+
+	case len(parts) >= 2 && parts[0] == "containers":
+		container := e.container(parts[1])
+		if container == nil {
+			writeDockerError(w, http.StatusNotFound, "No such container: "+parts[1])
+			return
+		}
+		e.serveContainer(w, r, container, strings.Join(parts[2:], "/"))
+
+	default:
+		writeDockerError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+func (e *fakeDockerEngine) serveContainer(w http.ResponseWriter, r *http.Request, container *fakeDockerContainer, action string) {
+	switch {
+	case r.Method == http.MethodPost && action == "start":
+		container.cmd = exec.Command(container.spec.Cmd[0], container.spec.Cmd[1:]...)
+		container.cmd.Stdout = &container.stdout
+		container.cmd.Stderr = &container.stderr
+		container.cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		if err := container.cmd.Start(); err != nil {
+			writeDockerError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		go func() {
+			container.cmd.Wait()
+			status := container.cmd.ProcessState.Sys().(syscall.WaitStatus)
+			container.exitCode = status.ExitStatus()
+			if status.Signaled() {
+				container.exitCode = 128 + int(status.Signal())
+			}
+			close(container.done)
+		}()
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodGet && action == "logs":
+		// Like Docker, send headers before container terminates.
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-container.done
+		writeDockerFrame(w, dockerStreamStdout, container.stdout.Bytes())
+		writeDockerFrame(w, dockerStreamStderr, container.stderr.Bytes())
+
+	case r.Method == http.MethodPost && action == "wait":
+		<-container.done
+		fmt.Fprintf(w, `{"StatusCode": %d}`, container.exitCode)
+
+	case r.Method == http.MethodGet && action == "json":
+		info := dockerContainerInfo{State: dockerContainerState{ExitCode: container.exitCode}}
+		if container.spec.HostConfig.NetworkMode != "host" {
+			info.NetworkSettings.IPAddress = "172.17.0.2"
+		}
+		json.NewEncoder(w).Encode(info)
+
+	case r.Method == http.MethodPost && action == "stop":
+		timeout, _ := strconv.Atoi(r.URL.Query().Get("t"))
+		container.signal(syscall.SIGTERM)
+		select {
+		case <-container.done:
+		case <-time.After(time.Duration(timeout) * time.Second):
+			container.signal(syscall.SIGKILL)
+			<-container.done
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodPost && action == "kill":
+		signal, _ := strconv.Atoi(r.URL.Query().Get("signal"))
+		container.signal(syscall.Signal(signal))
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodDelete && action == "":
+		container.signal(syscall.SIGKILL)
+		e.mutex.Lock()
+		for id, c := range e.containers {
+			if c == container {
+				delete(e.containers, id)
+			}
+		}
+		e.mutex.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeDockerError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+func TestDockerExecutor(t *testing.T) {
+	Convey("Having Docker executor connected to Docker engine over unix socket", t, func() {
+		directory, err := ioutil.TempDir("", "swan-docker")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(directory)
+
+		socket := path.Join(directory, "docker.sock")
+		listener, err := net.Listen("unix", socket)
+		So(err, ShouldBeNil)
+		engine := newFakeDockerEngine("swan-test:latest")
+		server := &http.Server{Handler: engine}
+		go server.Serve(listener)
+		defer listener.Close()
+
+		config := DefaultDockerConfig()
+		config.Host = "unix://" + socket
+		config.Image = "swan-test"
+		config.HostNetwork = true
+		config.Privileged = true
+		config.CPUSetCPUs = "0-1"
+		config.CPUShares = 512
+		config.MemoryLimit = 1 << 30
+		config.Binds = []string{"/tmp:/tmp:ro"}
+		config.StopTimeout = 5 * time.Second
+		docker, err := NewDocker(config)
+		So(err, ShouldBeNil)
+
+		Convey("Command should be run in container with configured resources", func() {
+			handle, err := docker.Execute("echo out; echo err >&2; sleep 0.3")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+			So(handle.Address(), ShouldEqual, "127.0.0.1")
+
+			container := engine.container(handle.(*dockerTaskHandle).containerID)
+			So(container, ShouldNotBeNil)
+			So(container.name, ShouldStartWith, "swan-")
+			So(container.spec.Cmd, ShouldResemble, []string{"sh", "-c", "echo out; echo err >&2; sleep 0.3"})
+			So(container.spec.Labels, ShouldResemble, map[string]string{DockerContainerLabel: "true"})
+			So(container.spec.HostConfig, ShouldResemble, dockerHostConfig{
+				NetworkMode: "host",
+				Privileged:  true,
+				CpusetCpus:  "0-1",
+				CPUShares:   512,
+				Memory:      1 << 30,
+				Binds:       []string{"/tmp:/tmp:ro"},
+				Init:        true,
+			})
+
+			terminated, err := handle.Wait(5 * time.Second)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+
+			exitCode, err := handle.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, 0)
+
+			stdout, err := handle.StdoutFile()
+			So(err, ShouldBeNil)
+			defer stdout.Close()
+			output, err := ioutil.ReadAll(stdout)
+			So(err, ShouldBeNil)
+			So(string(output), ShouldEqual, "out\n")
+
+			stderr, err := handle.StderrFile()
+			So(err, ShouldBeNil)
+			defer stderr.Close()
+			output, err = ioutil.ReadAll(stderr)
+			So(err, ShouldBeNil)
+			So(string(output), ShouldEqual, "err\n")
+
+			Convey("Terminated container should be removed", func() {
+				So(engine.count(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("Exit status of container should be mapped to exit code", func() {
+			handle, err := docker.Execute("sleep 0.3; exit 3")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+
+			handle.Wait(0)
+			exitCode, err := handle.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, 3)
+		})
+
+		Convey("Command failing immediately should be reported", func() {
+			_, err := docker.Execute("exit 5")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Missing image should be pulled", func() {
+			config.Image = "other:1.0"
+			docker, err := NewDocker(config)
+			So(err, ShouldBeNil)
+
+			handle, err := docker.Execute("true")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+			So(engine.pulled, ShouldResemble, []string{"other:1.0"})
+		})
+
+		Convey("Address of container outside host network should be returned", func() {
+			config.HostNetwork = false
+			docker, err := NewDocker(config)
+			So(err, ShouldBeNil)
+
+			handle, err := docker.Execute("sleep 30")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+			defer handle.Stop()
+			So(handle.Address(), ShouldEqual, "172.17.0.2")
+		})
+
+		Convey("Stopped container should be terminated and removed", func() {
+			handle, err := docker.Execute("sleep 30")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+			So(handle.Status(), ShouldEqual, RUNNING)
+
+			names, err := ListDockerContainers(config.Host)
+			So(err, ShouldBeNil)
+			So(names, ShouldHaveLength, 1)
+
+			So(handle.Stop(), ShouldBeNil)
+			So(handle.Status(), ShouldEqual, TERMINATED)
+			status, err := GetTerminationStatus(handle)
+			So(err, ShouldBeNil)
+			So(status.Stopped, ShouldBeTrue)
+			So(status.Signal, ShouldEqual, syscall.SIGTERM)
+			So(engine.count(), ShouldEqual, 0)
+		})
+
+		Convey("Container should be stopped with stop policy", func() {
+			config.StopPolicy = NewStopPolicy(StopStep{Signal: syscall.SIGINT, Grace: time.Second}, StopStep{Signal: syscall.SIGKILL})
+			docker, err := NewDocker(config)
+			So(err, ShouldBeNil)
+
+			handle, err := docker.Execute("sleep 30")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+
+			So(handle.Stop(), ShouldBeNil)
+			status, err := GetTerminationStatus(handle)
+			So(err, ShouldBeNil)
+			So(status.Signal, ShouldEqual, syscall.SIGINT)
+		})
+
+		Convey("Container should be stopped when context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			handle, err := docker.(ContextExecutor).ExecuteContext(ctx, "sleep 30")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+
+			cancel()
+			terminated, err := handle.Wait(10 * time.Second)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+		})
+
+		Convey("Leftover containers should be listed and removed", func() {
+			handle, err := docker.Execute("sleep 30")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+
+			names, err := ListDockerContainers(config.Host)
+			So(err, ShouldBeNil)
+			So(names, ShouldResemble, []string{handle.(*dockerTaskHandle).name})
+
+			So(RemoveDockerContainer(config.Host, names[0]), ShouldBeNil)
+			terminated, err := handle.Wait(10 * time.Second)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+		})
+	})
+
+	Convey("Docker host address should be validated", t, func() {
+		_, err := NewDocker(DockerConfig{Host: "ftp://host"})
+		So(err, ShouldNotBeNil)
+
+		docker, err := NewDocker(DockerConfig{Host: "tcp://10.0.0.1:2375"})
+		So(err, ShouldBeNil)
+		So(docker.(*Docker).address(), ShouldEqual, "10.0.0.1")
+	})
+
+	Convey("Multiplexed docker stream should be split into stdout and stderr", t, func() {
+		var stream bytes.Buffer
+		for _, frame := range []struct {
+			stream  byte
+			payload string
+		}{{dockerStreamStdout, "first "}, {dockerStreamStderr, "error"}, {dockerStreamStdout, "second"}} {
+			header := make([]byte, 8)
+			header[0] = frame.stream
+			binary.BigEndian.PutUint32(header[4:], uint32(len(frame.payload)))
+			stream.Write(header)
+			stream.WriteString(frame.payload)
+		}
+
+		var stdout, stderr bytes.Buffer
+		So(demultiplexDockerStream(&stdout, &stderr, &stream), ShouldBeNil)
+		So(stdout.String(), ShouldEqual, "first second")
+		So(stderr.String(), ShouldEqual, "error")
+
+		So(demultiplexDockerStream(&stdout, &stderr, bytes.NewReader([]byte{1, 0, 0, 0, 0, 0, 0, 9, 'x'})), ShouldNotBeNil)
+	})
+}
@@ -14,7 +14,10 @@
 
 package executor
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // Executor is responsible for creating execution environment for given workload.
 // It returns Task handle when workload started gracefully.
@@ -26,3 +29,32 @@ type Executor interface {
 	// Returns error if command exited immediately with non-zero exit status.
 	Execute(command string) (TaskHandle, error)
 }
+
+// ContextExecutor is an Executor which is able to bind lifetime of executed task to a context.
+type ContextExecutor interface {
+	Executor
+	// ExecuteContext executes command on underlying platform like Execute does.
+	// When ctx is done before task terminates, the task is stopped.
+	// Returns ctx.Err() if ctx is done before the task has been started.
+	ExecuteContext(ctx context.Context, command string) (TaskHandle, error)
+}
+
+// ExecuteContext executes command using given executor and binds lifetime of the task to ctx.
+// Executors not implementing ContextExecutor are supported: task is stopped when ctx is done.
+func ExecuteContext(ctx context.Context, executor Executor, command string) (TaskHandle, error) {
+	if contextExecutor, ok := executor.(ContextExecutor); ok {
+		return contextExecutor.ExecuteContext(ctx, command)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	handle, err := executor.Execute(command)
+	if err != nil {
+		return nil, err
+	}
+	stopWhenDone(ctx, handle)
+
+	return handle, nil
+}
@@ -15,6 +15,7 @@
 package executor
 
 import (
+	"context"
 	"time"
 
 	"github.com/pkg/errors"
@@ -30,22 +31,22 @@ const killWaitTimeout = 1 * time.Second
 // Commands usually fail because wrong parameters or binary that should be executed is not installed properly.
 func checkIfProcessFailedToExecute(command string, executorName string, handle TaskHandle) error {
 	if handle.Status() == TERMINATED {
-		exitCode, err := handle.ExitCode()
+		status, err := GetTerminationStatus(handle)
 		if err != nil {
 			// Something really wrong happened, print error message + logs
 			log.Errorf("Task %q launched on %q on address %q has failed, cannot get exit code: %s", command, executorName, handle.Address(), err.Error())
 			logOutput(handle)
 			return errors.Errorf("task %q launched using %q on address %q has failed, cannot get exit code: %s", command, executorName, handle.Address(), err.Error())
 		}
-		if exitCode != 0 {
-			// Task failed, log.Error exit code & stdout/err
-			log.Errorf("Task %q launched using %q on address %q has failed: exit code %d", command, executorName, handle.Address(), exitCode)
+		if status.Failed() {
+			// Task failed, log.Error termination status & stdout/err
+			log.Errorf("Task %q launched using %q on address %q has failed: %s", command, executorName, handle.Address(), status)
 			logOutput(handle)
-			return errors.Errorf("task %q launched using %q on address %q has failed with exit code %d", command, executorName, handle.Address(), exitCode)
+			return errors.Errorf("task %q launched using %q on address %q has failed: %s", command, executorName, handle.Address(), status)
 		}
 
-		// Exit code is zero, so task ended successfully.
-		log.Debugf("task %q launched using %q on address %q has ended successfully (exit code: 0)", command, executorName, handle.Address())
+		// Task ended successfully (or has been stopped).
+		log.Debugf("task %q launched using %q on address %q has ended: %s", command, executorName, handle.Address(), status)
 		return nil
 	}
 
@@ -66,7 +67,8 @@ func getTimeoutChan(timeout time.Duration) <-chan time.Time {
 // getWaitChannel returns channel that will return result (any encountered error) of
 // Wait() method in provided handle.
 func getWaitChannel(handle TaskControl) <-chan error {
-	result := make(chan error)
+	// Buffered, so the goroutine can finish even when nobody receives the result.
+	result := make(chan error, 1)
 	go func() {
 		_, err := handle.Wait(0)
 		result <- err
@@ -74,3 +76,22 @@ func getWaitChannel(handle TaskControl) <-chan error {
 	}()
 	return result
 }
+
+// stopWhenDone stops the task when ctx is done before task terminates.
+// Contexts that are never done (e.g. context.Background()) are ignored.
+func stopWhenDone(ctx context.Context, handle TaskHandle) {
+	if ctx.Done() == nil {
+		return
+	}
+
+	go func() {
+		select {
+		case <-getWaitChannel(handle):
+		case <-ctx.Done():
+			log.Debugf("Context of task %q is done (%s): stopping task", handle, ctx.Err())
+			if err := handle.Stop(); err != nil {
+				log.Errorf("Stopping task %q after context was done failed: %s", handle, err.Error())
+			}
+		}
+	}()
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FileTransfer is implemented by executors and task handles that are able to copy files
+// between local host and the host (or container) where tasks are executed.
+// Paths are copied recursively and destination path is the path of the copy (like in `cp -r src dst`).
+type FileTransfer interface {
+	// Push copies local file or directory to the target.
+	Push(ctx context.Context, localPath, targetPath string) error
+	// Pull copies file or directory from the target to local host.
+	Pull(ctx context.Context, targetPath, localPath string) error
+}
+
+// PushFiles copies local file or directory to the target (executor or task handle).
+// Returns error if target does not implement FileTransfer.
+func PushFiles(ctx context.Context, target fmt.Stringer, localPath, targetPath string) error {
+	transfer, ok := target.(FileTransfer)
+	if !ok {
+		return errors.Errorf("%s does not support file transfer", target)
+	}
+	return transfer.Push(ctx, localPath, targetPath)
+}
+
+// PullFiles copies file or directory from the target (executor or task handle) to local host.
+// Returns error if target does not implement FileTransfer.
+func PullFiles(ctx context.Context, target fmt.Stringer, targetPath, localPath string) error {
+	transfer, ok := target.(FileTransfer)
+	if !ok {
+		return errors.Errorf("%s does not support file transfer", target)
+	}
+	return transfer.Pull(ctx, targetPath, localPath)
+}
+
+// fileSystem is the subset of file operations needed to copy files between hosts.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadDir(name string) ([]os.FileInfo, error)
+	Open(name string) (io.ReadCloser, error)
+	Create(name string, mode os.FileMode) (io.WriteCloser, error)
+	Mkdir(name string, mode os.FileMode) error
+}
+
+// localFileSystem is a fileSystem of the local host.
+type localFileSystem struct{}
+
+func (localFileSystem) Stat(name string) (os.FileInfo, error)      { return os.Stat(name) }
+func (localFileSystem) ReadDir(name string) ([]os.FileInfo, error) { return ioutil.ReadDir(name) }
+func (localFileSystem) Open(name string) (io.ReadCloser, error)    { return os.Open(name) }
+func (localFileSystem) Mkdir(name string, mode os.FileMode) error  { return os.MkdirAll(name, mode) }
+
+func (localFileSystem) Create(name string, mode os.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+}
+
+// copyTree copies file or directory srcPath from src to dstPath on dst.
+// Copying is aborted (between files) when ctx is done.
+func copyTree(ctx context.Context, src fileSystem, srcPath string, dst fileSystem, dstPath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	info, err := src.Stat(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "cannot stat %q", srcPath)
+	}
+
+	if !info.IsDir() {
+		return copyFile(src, srcPath, dst, dstPath, info.Mode().Perm())
+	}
+
+	err = dst.Mkdir(dstPath, info.Mode().Perm())
+	if err != nil {
+		return errors.Wrapf(err, "cannot create directory %q", dstPath)
+	}
+	entries, err := src.ReadDir(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read directory %q", srcPath)
+	}
+	for _, entry := range entries {
+		err = copyTree(ctx, src, path.Join(srcPath, entry.Name()), dst, path.Join(dstPath, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src fileSystem, srcPath string, dst fileSystem, dstPath string, mode os.FileMode) error {
+	reader, err := src.Open(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "cannot open %q", srcPath)
+	}
+	defer reader.Close()
+
+	writer, err := dst.Create(dstPath, mode)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create %q", dstPath)
+	}
+	_, err = io.Copy(writer, reader)
+	if err != nil {
+		writer.Close()
+		return errors.Wrapf(err, "cannot copy %q to %q", srcPath, dstPath)
+	}
+	return errors.Wrapf(writer.Close(), "cannot close %q", dstPath)
+}
+
+// writeTar writes file or directory srcPath from src to tar stream.
+// Names of archived files start with name instead of srcPath.
+func writeTar(ctx context.Context, writer io.Writer, src fileSystem, srcPath, name string) error {
+	archive := tar.NewWriter(writer)
+	err := writeTarEntry(ctx, archive, src, srcPath, name)
+	if err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func writeTarEntry(ctx context.Context, archive *tar.Writer, src fileSystem, srcPath, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	info, err := src.Stat(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "cannot stat %q", srcPath)
+	}
+
+	header := &tar.Header{
+		Name:    name,
+		Mode:    int64(info.Mode().Perm()),
+		ModTime: info.ModTime(),
+	}
+	if info.IsDir() {
+		header.Typeflag = tar.TypeDir
+		header.Name += "/"
+	} else {
+		header.Typeflag = tar.TypeReg
+		header.Size = info.Size()
+	}
+	err = archive.WriteHeader(header)
+	if err != nil {
+		return errors.Wrapf(err, "cannot archive %q", srcPath)
+	}
+
+	if !info.IsDir() {
+		reader, err := src.Open(srcPath)
+		if err != nil {
+			return errors.Wrapf(err, "cannot open %q", srcPath)
+		}
+		defer reader.Close()
+		_, err = io.CopyN(archive, reader, info.Size())
+		return errors.Wrapf(err, "cannot archive %q", srcPath)
+	}
+
+	entries, err := src.ReadDir(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read directory %q", srcPath)
+	}
+	for _, entry := range entries {
+		err = writeTarEntry(ctx, archive, src, path.Join(srcPath, entry.Name()), path.Join(name, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractTar extracts tar stream with single file or directory (named name) to dstPath on dst.
+// Entries outside of name are rejected.
+func extractTar(ctx context.Context, reader io.Reader, dst fileSystem, name, dstPath string) error {
+	archive := tar.NewReader(reader)
+	name = path.Clean(name)
+	extracted := false
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "cannot read archive")
+		}
+
+		entryName := path.Clean(header.Name)
+		if entryName != name && !strings.HasPrefix(entryName, name+"/") {
+			return errors.Errorf("archive entry %q is outside of %q", header.Name, name)
+		}
+		target := path.Join(dstPath, strings.TrimPrefix(entryName, name))
+		mode := os.FileMode(header.Mode).Perm()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = dst.Mkdir(target, mode)
+			if err != nil {
+				return errors.Wrapf(err, "cannot create directory %q", target)
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			writer, err := dst.Create(target, mode)
+			if err != nil {
+				return errors.Wrapf(err, "cannot create %q", target)
+			}
+			_, err = io.Copy(writer, archive)
+			writer.Close()
+			if err != nil {
+				return errors.Wrapf(err, "cannot extract %q", target)
+			}
+		default:
+			// Links and special files are not copied.
+			continue
+		}
+		extracted = true
+	}
+
+	if !extracted {
+		return errors.Errorf("%q not found in archive", name)
+	}
+	return nil
+}
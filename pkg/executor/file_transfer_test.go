@@ -0,0 +1,105 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFileTransfer(t *testing.T) {
+	Convey("Having directory with files", t, func() {
+		directory, err := ioutil.TempDir("", "swan-transfer")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(directory)
+
+		source := path.Join(directory, "source")
+		So(os.MkdirAll(path.Join(source, "nested"), 0750), ShouldBeNil)
+		So(ioutil.WriteFile(path.Join(source, "file"), []byte("first"), 0600), ShouldBeNil)
+		So(ioutil.WriteFile(path.Join(source, "nested", "file"), []byte("second"), 0644), ShouldBeNil)
+
+		shouldBeCopied := func(copied string) {
+			content, err := ioutil.ReadFile(path.Join(copied, "file"))
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "first")
+			content, err = ioutil.ReadFile(path.Join(copied, "nested", "file"))
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "second")
+
+			info, err := os.Stat(path.Join(copied, "file"))
+			So(err, ShouldBeNil)
+			So(info.Mode().Perm(), ShouldEqual, os.FileMode(0600))
+			info, err = os.Stat(path.Join(copied, "nested"))
+			So(err, ShouldBeNil)
+			So(info.IsDir(), ShouldBeTrue)
+		}
+
+		Convey("Local executor should push and pull directory", func() {
+			pushed := path.Join(directory, "pushed")
+			So(PushFiles(context.Background(), NewLocal(), source, pushed), ShouldBeNil)
+			shouldBeCopied(pushed)
+
+			pulled := path.Join(directory, "pulled")
+			So(PullFiles(context.Background(), NewLocal(), pushed, pulled), ShouldBeNil)
+			shouldBeCopied(pulled)
+		})
+
+		Convey("Copying should be aborted when context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			err := PushFiles(ctx, NewLocal(), source, path.Join(directory, "pushed"))
+			So(err, ShouldEqual, context.Canceled)
+		})
+
+		Convey("Executor not supporting file transfer should return error", func() {
+			executor := new(MockExecutor)
+			executor.On("String").Return("mocked executor")
+			err := PushFiles(context.Background(), executor, source, path.Join(directory, "pushed"))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "does not support file transfer")
+		})
+
+		Convey("Directory should be archived and extracted with different name", func() {
+			archive := &bytes.Buffer{}
+			So(writeTar(context.Background(), archive, localFileSystem{}, source, "renamed"), ShouldBeNil)
+
+			extracted := path.Join(directory, "extracted")
+			So(extractTar(context.Background(), archive, localFileSystem{}, "renamed", extracted), ShouldBeNil)
+			shouldBeCopied(extracted)
+		})
+
+		Convey("Entries outside of extracted path should be rejected", func() {
+			archive := &bytes.Buffer{}
+			writer := tar.NewWriter(archive)
+			content := []byte("evil")
+			So(writer.WriteHeader(&tar.Header{Name: "renamed/../../evil", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}), ShouldBeNil)
+			_, err := writer.Write(content)
+			So(err, ShouldBeNil)
+			So(writer.Close(), ShouldBeNil)
+
+			err = extractTar(context.Background(), archive, localFileSystem{}, "renamed", path.Join(directory, "extracted"))
+			So(err, ShouldNotBeNil)
+			_, err = os.Stat(path.Join(directory, "..", "evil"))
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+	})
+}
@@ -56,15 +56,22 @@ Additionally every handler or action is protected by sync.Once to make sure that
 package executor
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math"
 	"os"
+	"path"
 	"path/filepath"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/intelsdi-x/swan/pkg/conf"
 	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/journal"
 	"github.com/intelsdi-x/swan/pkg/k8sports"
 	"github.com/intelsdi-x/swan/pkg/utils/uuid"
 	"github.com/pkg/errors"
@@ -72,6 +79,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	remotecommandconsts "k8s.io/apimachinery/pkg/util/remotecommand"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -79,6 +87,7 @@ import (
 	"k8s.io/client-go/pkg/api/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/remotecommand"
 )
 
 const (
@@ -86,12 +95,16 @@ const (
 )
 
 var (
-	kubeconfigFlag                = conf.NewStringFlag("kubernetes_kubeconfig", "(optional) Absolute path to the kubeconfig file. Overrides pod configuration passed through flags. ", "")
-	kubernetesPrivilegedPodsFlag  = conf.NewBoolFlag("kubernetes_privileged_pods", "Kubernetes containers will be run as privileged.", false)
-	kubernetesPodLunchTimeoutFlag = conf.NewDurationFlag("kubernetes_pod_launch_timeout", "Kubernetes Pod launch timeout.", 30*time.Second)
-	kubernetesContainerImageFlag  = conf.NewStringFlag("kubernetes_container_image", "Name of the container image to be used. It needs to be available locally or downloadable.", defaultContainerImage)
+	kubeconfigFlag                   = conf.NewStringFlag("kubernetes_kubeconfig", "(optional) Absolute path to the kubeconfig file. Overrides pod configuration passed through flags. ", "")
+	kubernetesPrivilegedPodsFlag     = conf.NewBoolFlag("kubernetes_privileged_pods", "Kubernetes containers will be run as privileged.", false)
+	kubernetesPodLunchTimeoutFlag    = conf.NewDurationFlag("kubernetes_pod_launch_timeout", "Kubernetes Pod launch timeout.", 30*time.Second)
+	kubernetesContainerImageFlag     = conf.NewStringFlag("kubernetes_container_image", "Name of the container image to be used. It needs to be available locally or downloadable.", defaultContainerImage)
+	kubernetesResourceAccountingFlag = conf.NewBoolFlag("kubernetes_resource_accounting", "Account resources used by pods from their cgroups (pods must run on the node where experiment runs).", false)
 )
 
+// resourceUsageSamplingInterval is the interval of reading pod cgroup statistics.
+var resourceUsageSamplingInterval = 1 * time.Second
+
 // KubernetesConfig describes the necessary information to connect to a Kubernetes cluster.
 type KubernetesConfig struct {
 	// PodName vs PodNamePrefix:
@@ -117,6 +130,15 @@ type KubernetesConfig struct {
 	Privileged     bool
 	HostNetwork    bool
 	LaunchTimeout  time.Duration
+
+	// ResourceAccounting samples cgroup statistics of pods running on local node
+	// (mounted at CgroupMountRoot), so resource usage of pods is available.
+	ResourceAccounting bool
+	CgroupMountRoot    string
+
+	// StopPolicy describes signals sent to processes in container (by pre-stop hook) when pod is deleted.
+	// By default (empty policy) kubelet sends SIGTERM to the container and kills it after 5 seconds.
+	StopPolicy StopPolicy
 }
 
 // LaunchTimedOutError is the error type returned when launching new pods exceed
@@ -149,38 +171,67 @@ func DefaultKubernetesConfig() KubernetesConfig {
 		Privileged:     kubernetesPrivilegedPodsFlag.Value(),
 		HostNetwork:    false,
 		LaunchTimeout:  kubernetesPodLunchTimeoutFlag.Value(),
+
+		ResourceAccounting: kubernetesResourceAccountingFlag.Value(),
+		CgroupMountRoot:    isolation.DefaultCgroupMountRoot,
 	}
 }
 
 type k8s struct {
 	config    KubernetesConfig
 	clientset *kubernetes.Clientset
+	// restConfig is used to execute commands in pods (e.g. to transfer files).
+	restConfig *rest.Config
 }
 
 // NewKubernetes returns an executor which lets the user run commands in pods in a
 // kubernetes cluster.
 func NewKubernetes(config KubernetesConfig) (Executor, error) {
-	k8s := &k8s{
-		config: config,
+	restConfig, err := newKubernetesRestConfig(config)
+	if err != nil {
+		return nil, err
 	}
 
-	var err error
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't initilize kubernetes clientset for host '%s'", config.Address)
+	}
+
+	return &k8s{
+		config:     config,
+		clientset:  clientset,
+		restConfig: restConfig,
+	}, nil
+}
+
+// NewKubernetesClientset returns clientset for API server from config (or kubeconfig file when provided by flag).
+func NewKubernetesClientset(config KubernetesConfig) (clientset *kubernetes.Clientset, err error) {
+	restConfig, err := newKubernetesRestConfig(config)
+	if err == nil {
+		clientset, err = kubernetes.NewForConfig(restConfig)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't initilize kubernetes clientset for host '%s'", config.Address)
+	}
+
+	return clientset, nil
+}
+
+// newKubernetesRestConfig returns configuration of API server client from config (or kubeconfig file when provided by flag).
+func newKubernetesRestConfig(config KubernetesConfig) (*rest.Config, error) {
 	kubeConfigPath := kubeconfigFlag.Value()
 	if kubeConfigPath == "" {
-		k8s.clientset, err = kubernetes.NewForConfig(&rest.Config{
+		return &rest.Config{
 			Host: config.Address,
-		})
-	} else {
-		var kubeconfig *rest.Config
-		kubeconfig, err = clientcmd.BuildConfigFromFlags("", kubeConfigPath)
-		k8s.clientset, err = kubernetes.NewForConfig(kubeconfig)
+		}, nil
 	}
 
+	kubeconfig, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
 	if err != nil {
-		return nil, errors.Wrapf(err, "can't initilize kubernetes clientset for host '%s'", config.Address)
+		return nil, errors.Wrapf(err, "can't read kubeconfig %q", kubeConfigPath)
 	}
-
-	return k8s, nil
+	return kubeconfig, nil
 }
 
 // containerResources helper to create ResourceRequirements for the container.
@@ -231,11 +282,20 @@ func (k8s *k8s) generatePodName() string {
 // newPod is a helper to build in-memory structure representing pod
 // before sending it as request to API server. It can returns also
 // error if cannot generate Pod name.
-func (k8s *k8s) newPod(command string) (*v1.Pod, error) {
+func (k8s *k8s) newPod(command string, stopPolicy StopPolicy) (*v1.Pod, error) {
 
 	resources := k8s.containerResources()
 	podName := k8s.generatePodName()
 
+	var lifecycle *v1.Lifecycle
+	if !stopPolicy.IsEmpty() {
+		lifecycle = &v1.Lifecycle{
+			PreStop: &v1.Handler{
+				Exec: &v1.ExecAction{Command: []string{"sh", "-c", stopPolicy.script()}},
+			},
+		}
+	}
+
 	var zero int64
 	return &v1.Pod{
 		TypeMeta: metav1.TypeMeta{},
@@ -258,6 +318,7 @@ func (k8s *k8s) newPod(command string) (*v1.Pod, error) {
 					Resources:       resources,
 					ImagePullPolicy: v1.PullIfNotPresent, // Default because swan image is not published yet.
 					SecurityContext: &v1.SecurityContext{Privileged: &k8s.config.Privileged},
+					Lifecycle:       lifecycle,
 				},
 			},
 		},
@@ -267,6 +328,16 @@ func (k8s *k8s) newPod(command string) (*v1.Pod, error) {
 // Execute creates a pod and runs the provided command in it. When the command completes, the pod
 // is stopped i.e. the container is not restarted automatically.
 func (k8s *k8s) Execute(command string) (TaskHandle, error) {
+	return k8s.ExecuteContext(context.Background(), command)
+}
+
+// ExecuteContext creates a pod and runs the provided command in it like Execute does.
+// When ctx is done (also while pod is being launched), the pod is deleted.
+func (k8s *k8s) ExecuteContext(ctx context.Context, command string) (TaskHandle, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrapf(err, "pod for command %q not created", command)
+	}
+
 	podsAPI := k8s.clientset.Pods(k8s.config.Namespace)
 	command = k8s.config.Decorators.Decorate(command)
 
@@ -277,7 +348,8 @@ func (k8s *k8s) Execute(command string) (TaskHandle, error) {
 	wrappedCommand := "echo;" + command
 
 	// See http://kubernetes.io/docs/api-reference/v1/definitions/ for definition of the pod manifest.
-	podManifest, err := k8s.newPod(wrappedCommand)
+	stopPolicy := stopPolicyFromContext(ctx, k8s.config.StopPolicy)
+	podManifest, err := k8s.newPod(wrappedCommand, stopPolicy)
 	if err != nil {
 		log.Errorf("K8s executor: cannot create pod manifest")
 		return nil, errors.Wrapf(err, "cannot create pod manifest")
@@ -294,6 +366,7 @@ func (k8s *k8s) Execute(command string) (TaskHandle, error) {
 		return nil, errors.Wrapf(err, "cannot schedule pod %q with namespace %q",
 			k8s.config.PodName, k8s.config.Namespace)
 	}
+	journal.Created(journal.Resource{Kind: journal.KindKubernetesPod, ID: pod.Name, Location: pod.Namespace})
 
 	// Prepare local files
 	outputDirectory, err := createOutputDirectory(command, "kubernetes")
@@ -315,6 +388,10 @@ func (k8s *k8s) Execute(command string) (TaskHandle, error) {
 
 	taskHandle := &k8sTaskHandle{
 		podName:         pod.Name,
+		namespace:       pod.Namespace,
+		containerName:   k8s.config.ContainerName,
+		clientset:       k8s.clientset,
+		restConfig:      k8s.restConfig,
 		command:         command,
 		stdoutFilePath:  stdoutFileName,
 		stderrFilePath:  stderrFileName,
@@ -322,6 +399,12 @@ func (k8s *k8s) Execute(command string) (TaskHandle, error) {
 		stopped:         make(chan struct{}),
 		requestDelete:   make(chan struct{}, 1),
 		exitCodeChannel: make(chan int, 1),
+		status:          TerminationStatus{ExitCode: -1},
+	}
+
+	cgroupMountRoot := ""
+	if k8s.config.ResourceAccounting {
+		cgroupMountRoot = k8s.config.CgroupMountRoot
 	}
 
 	taskWatcher := &k8sWatcher{
@@ -341,9 +424,13 @@ func (k8s *k8s) Execute(command string) (TaskHandle, error) {
 		deleted:         make(chan struct{}),
 		requestDelete:   taskHandle.requestDelete,
 		exitCodeChannel: taskHandle.exitCodeChannel,
+
+		cgroupMountRoot: cgroupMountRoot,
+
+		gracePeriodSeconds: podGracePeriodSeconds(stopPolicy),
 	}
 
-	err = taskWatcher.watch(k8s.config.LaunchTimeout)
+	err = taskWatcher.watch(ctx, k8s.config.LaunchTimeout)
 	if err != nil {
 		removeDirectory(outputDirectory)
 		log.Errorf("K8s executor: cannot create task on pod %q", pod.Name)
@@ -359,6 +446,14 @@ func (k8s *k8s) Execute(command string) (TaskHandle, error) {
 		break
 	}
 
+	if err := ctx.Err(); err != nil && !started {
+		// Pod is deleted by watcher when context is done.
+		taskHandle.Wait(0)
+		removeDirectory(outputDirectory)
+		log.Errorf("K8s executor: launching pod %s has been interrupted: %s", taskHandle.podName, err.Error())
+		return nil, errors.Wrapf(err, "launching pod %q has been interrupted", taskHandle.podName)
+	}
+
 	// Best effort potential way to check if binary is started properly.
 	select {
 	case <-taskWatcher.failed:
@@ -377,6 +472,9 @@ func (k8s *k8s) Execute(command string) (TaskHandle, error) {
 	}
 	// It has been determined that task failed, waiting for Kubernetes to officially acknowledge it.
 	taskHandle.Wait(0)
+	if status, _ := taskHandle.TerminationStatus(); status.LaunchTimedOut {
+		return nil, &LaunchTimedOutError{errorMessage: fmt.Sprintf("pod %q has not been launched in %s", taskHandle.podName, k8s.config.LaunchTimeout)}
+	}
 	err = checkIfProcessFailedToExecute(command, k8s.String(), taskHandle)
 	if err != nil {
 		return nil, err
@@ -400,8 +498,88 @@ type k8sTaskHandle struct {
 	podName   string
 	podHostIP string
 
+	// Used to execute commands in the pod container (e.g. to transfer files).
+	namespace     string
+	containerName string
+	clientset     *kubernetes.Clientset
+	restConfig    *rest.Config
+
 	// Command requested by user. This is how this TaskHandle presents.
 	command string
+
+	// Fields set by watcher (guarded by mutex).
+	mutex      sync.Mutex
+	usage      *ResourceUsage
+	usageErr   error
+	startTime  time.Time
+	finishTime time.Time
+	status     TerminationStatus
+	// finished is true when pod finished on its own.
+	finished bool
+}
+
+// TerminationStatus returns status of the terminated pod.
+// Exit code is -1 when pod has been deleted before its container terminated.
+func (th *k8sTaskHandle) TerminationStatus() (TerminationStatus, error) {
+	if !th.isTerminated() {
+		return TerminationStatus{}, errors.Errorf("pod %q is not terminated", th.podName)
+	}
+
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	return th.status, nil
+}
+
+// updateStatus changes termination status of the pod.
+func (th *k8sTaskHandle) updateStatus(update func(status *TerminationStatus)) {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	update(&th.status)
+}
+
+// ResourceUsage returns resources consumed by the terminated pod, as read from its cgroup before it was removed.
+// Context switches are not accounted. Resource accounting must be enabled in KubernetesConfig.
+func (th *k8sTaskHandle) ResourceUsage() (ResourceUsage, error) {
+	if !th.isTerminated() {
+		return ResourceUsage{}, errors.Errorf("pod %q is not terminated", th.podName)
+	}
+
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	if th.usage == nil {
+		if th.usageErr != nil {
+			return ResourceUsage{}, errors.Wrapf(th.usageErr, "resource usage of pod %q is not available", th.podName)
+		}
+		return ResourceUsage{}, errors.Errorf("resource accounting of pod %q is disabled", th.podName)
+	}
+
+	usage := *th.usage
+	if !th.startTime.IsZero() && !th.finishTime.IsZero() {
+		usage.WallTime = th.finishTime.Sub(th.startTime)
+	}
+	return usage, nil
+}
+
+// sampled records latest sample of pod resource usage. Max RSS is the maximum of all samples.
+func (th *k8sTaskHandle) sampled(usage ResourceUsage, err error) {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	if err != nil {
+		th.usageErr = err
+		return
+	}
+	if th.usage != nil && th.usage.MaxRSS > usage.MaxRSS {
+		usage.MaxRSS = th.usage.MaxRSS
+	}
+	th.usage = &usage
+}
+
+func (th *k8sTaskHandle) setTime(t *time.Time) {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	if t.IsZero() {
+		*t = time.Now()
+	}
 }
 
 func (th *k8sTaskHandle) isTerminated() bool {
@@ -435,6 +613,27 @@ func (th *k8sTaskHandle) Stop() error {
 	return nil
 }
 
+// StopContext will delete the pod and block caller until done or ctx is done.
+func (th *k8sTaskHandle) StopContext(ctx context.Context) error {
+	if th.isTerminated() {
+		return nil
+	}
+
+	log.Debugf("K8s task handle: delete pod %q", th.podName)
+	select {
+	case th.requestDelete <- struct{}{}:
+	default:
+	}
+
+	select {
+	case <-th.stopped:
+		log.Debugf("K8s task handle: pod %q stopped", th.podName)
+		return nil
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "pod %q has not been stopped", th.podName)
+	}
+}
+
 // Status returns the current task state in terms of RUNNING or TERMINATED.
 func (th *k8sTaskHandle) Status() TaskState {
 	if th.isTerminated() {
@@ -487,6 +686,16 @@ func (th *k8sTaskHandle) Wait(timeout time.Duration) (bool, error) {
 	}
 }
 
+// WaitContext blocks until the pod terminates or ctx is done.
+func (th *k8sTaskHandle) WaitContext(ctx context.Context) (bool, error) {
+	select {
+	case <-th.stopped:
+		return true, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
 // EraseOutput deletes the directory where stdout file resides.
 func (th *k8sTaskHandle) EraseOutput() error {
 	outputDir := filepath.Dir(th.stdoutFilePath)
@@ -515,10 +724,81 @@ func (th *k8sTaskHandle) StderrFile() (*os.File, error) {
 	return openFile(th.stderrFilePath)
 }
 
+// Push copies local file or directory to the container of running pod.
+// Files are archived with tar, which must be available in the container image.
+func (th *k8sTaskHandle) Push(ctx context.Context, localPath, targetPath string) error {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeTar(ctx, writer, localFileSystem{}, localPath, path.Base(targetPath)))
+	}()
+	defer reader.Close()
+
+	command := []string{"sh", "-c", `mkdir -p "$0" && tar xf - -C "$0"`, path.Dir(targetPath)}
+	err := th.exec(ctx, command, reader, ioutil.Discard)
+	return errors.Wrapf(err, "cannot copy %q to pod %q", localPath, th.podName)
+}
+
+// Pull copies file or directory from the container of running pod.
+// Files are archived with tar, which must be available in the container image.
+func (th *k8sTaskHandle) Pull(ctx context.Context, targetPath, localPath string) error {
+	reader, writer := io.Pipe()
+	command := []string{"tar", "cf", "-", "-C", path.Dir(targetPath), path.Base(targetPath)}
+	go func() {
+		writer.CloseWithError(th.exec(ctx, command, nil, writer))
+	}()
+	defer reader.Close()
+
+	err := extractTar(ctx, reader, localFileSystem{}, path.Base(targetPath), localPath)
+	return errors.Wrapf(err, "cannot copy %q from pod %q", targetPath, th.podName)
+}
+
+// exec executes command in the container of the pod through API server.
+func (th *k8sTaskHandle) exec(ctx context.Context, command []string, stdin io.Reader, stdout io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	request := th.clientset.Core().RESTClient().Post().
+		Resource("pods").
+		Name(th.podName).
+		Namespace(th.namespace).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Container: th.containerName,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, api.ParameterCodec)
+
+	executor, err := remotecommand.NewExecutor(th.restConfig, "POST", request.URL())
+	if err != nil {
+		return errors.Wrapf(err, "cannot execute %q in pod %q", command, th.podName)
+	}
+
+	stderr := &bytes.Buffer{}
+	err = executor.Stream(remotecommand.StreamOptions{
+		SupportedProtocols: remotecommandconsts.SupportedStreamingProtocols,
+		Stdin:              stdin,
+		Stdout:             stdout,
+		Stderr:             stderr,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "command %q failed in pod %q: %s", command, th.podName, stderr.String())
+	}
+	return nil
+}
+
 type k8sWatcher struct {
 	podsAPI corev1.PodInterface
 	pod     *v1.Pod
 
+	// cgroupMountRoot is empty when resource accounting is disabled.
+	cgroupMountRoot string
+
+	// gracePeriodSeconds is given to kubelet when pod is deleted.
+	gracePeriodSeconds int64
+
 	stdoutFilePath string
 	stderrFilePath string
 
@@ -541,8 +821,9 @@ type k8sWatcher struct {
 	hasBeenRunning               bool
 }
 
-// watch creates instance of TaskHandle and is responsible for keeping it in-sync with k8s cluster
-func (kw *k8sWatcher) watch(timeout time.Duration) error {
+// watch creates instance of TaskHandle and is responsible for keeping it in-sync with k8s cluster.
+// Pod is deleted when ctx is done.
+func (kw *k8sWatcher) watch(ctx context.Context, timeout time.Duration) error {
 	selectorRaw := fmt.Sprintf("name=%s", kw.pod.Name)
 
 	// Prepare events watcher.
@@ -556,6 +837,7 @@ func (kw *k8sWatcher) watch(timeout time.Duration) error {
 		if timeout != 0 {
 			timeoutChannel = time.After(timeout)
 		}
+		contextDone := ctx.Done()
 		for {
 			select {
 			case event, ok := <-watcher.ResultChan():
@@ -617,7 +899,13 @@ func (kw *k8sWatcher) watch(timeout time.Duration) error {
 				}
 
 			case <-kw.requestDelete:
-				kw.deletePod()
+				kw.stopPod()
+
+			case <-contextDone:
+				log.Debugf("K8s task watcher: context of pod %q is done: %s", kw.pod.Name, ctx.Err())
+				// Deletion is requested only once.
+				contextDone = nil
+				kw.stopPod()
 
 			case <-timeoutChannel:
 				// If task has been running then we need to ignore timeout
@@ -625,6 +913,7 @@ func (kw *k8sWatcher) watch(timeout time.Duration) error {
 					continue
 				}
 				log.Errorf("Kubernetes Executor: pod %s has not been created: timeout after %f seconds.", kw.pod.Name, timeout.Seconds())
+				kw.taskHandle.updateStatus(func(status *TerminationStatus) { status.LaunchTimedOut = true })
 				kw.deletePod()
 			}
 		}
@@ -633,6 +922,23 @@ func (kw *k8sWatcher) watch(timeout time.Duration) error {
 	return nil
 }
 
+// sampleResourceUsage reads pod cgroup statistics until pod is finished or deleted.
+// Statistics cannot be read after the pod terminates, because its cgroup is removed,
+// so the last successful sample is kept.
+func (kw *k8sWatcher) sampleResourceUsage() {
+	ticker := time.NewTicker(resourceUsageSamplingInterval)
+	defer ticker.Stop()
+
+	for {
+		kw.taskHandle.sampled(podCgroupUsage(kw.cgroupMountRoot, string(kw.pod.UID)))
+		select {
+		case <-ticker.C:
+		case <-kw.deleted:
+			return
+		}
+	}
+}
+
 // ----------------------------- when pod ready
 // closeStarted close started channel to indicate that pod has just started.
 func (kw *k8sWatcher) whenPodReady() {
@@ -640,6 +946,10 @@ func (kw *k8sWatcher) whenPodReady() {
 		log.Debug("K8s task watcher: Pod ready handler - mark Pod as running.")
 		kw.hasBeenRunning = true
 		kw.setupLogs()
+		kw.taskHandle.setTime(&kw.taskHandle.startTime)
+		if kw.cgroupMountRoot != "" {
+			go kw.sampleResourceUsage()
+		}
 		log.Debug("K8s task watcher: pod started [started]")
 		close(kw.started)
 	})
@@ -649,6 +959,7 @@ func (kw *k8sWatcher) whenPodReady() {
 // Additionally call whenPodReady handler to setupLogs and mark pod as running.
 func (kw *k8sWatcher) whenPodFinished(pod *v1.Pod) {
 	kw.oncePodFinished.Do(func() {
+		kw.taskHandle.setTime(&kw.taskHandle.finishTime)
 		if pod.Status.Phase == v1.PodFailed {
 			close(kw.failed)
 
@@ -675,25 +986,33 @@ func (kw *k8sWatcher) setExitCode(pod *v1.Pod) {
 
 	// Look for an exit status from the container.
 	// If more than one container is present, the last takes precedence.
-	exitCode := -1
-	for _, status := range pod.Status.ContainerStatuses {
-		if status.State.Terminated == nil {
+	status := TerminationStatus{ExitCode: -1}
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		terminated := containerStatus.State.Terminated
+		if terminated == nil {
 			continue
 		}
-		exitCode = int(status.State.Terminated.ExitCode)
+		status = statusFromExitCode(int(terminated.ExitCode))
+		if terminated.Signal != 0 {
+			status.Signal = syscall.Signal(terminated.Signal)
+		}
+		status.OOMKilled = terminated.Reason == "OOMKilled"
 	}
-	if pod.Status.Phase == v1.PodFailed {
+	kw.taskHandle.updateStatus(func(current *TerminationStatus) {
+		kw.taskHandle.finished = true
+		current.ExitCode = status.ExitCode
+		current.Signal = status.Signal
+		current.OOMKilled = status.OOMKilled
+	})
 
+	exitCode := status.ExitCode
+	if pod.Status.Phase == v1.PodFailed {
 		// Depending on how pod was stopped change log level and explanation.
-		switch exitCode {
-		case 128 + 9:
-			log.Warnf("K8s task watcher: pod %q exited with code %d (forced to stop with SIGKILL)", pod.Name, exitCode)
-		case 128 + 15:
-			log.Warnf("K8s task watcher: pod %q exited with code %d (forced to stop with SIGTERM)", pod.Name, exitCode)
-		default:
+		if status.OOMKilled || status.Signal != 0 {
+			log.Warnf("K8s task watcher: pod %q %s", pod.Name, status)
+		} else {
 			log.Errorf("K8s task watcher: pod %q failed with exit code %d", pod.Name, exitCode)
 		}
-
 	} else {
 		log.Debugf("K8s task watcher: exit code retrieved: %d", exitCode)
 	}
@@ -704,6 +1023,7 @@ func (kw *k8sWatcher) setExitCode(pod *v1.Pod) {
 // that pod is "stopped" and optionally setup the logs.
 func (kw *k8sWatcher) whenPodDeleted() {
 	kw.oncePodDeleted.Do(func() {
+		kw.taskHandle.setTime(&kw.taskHandle.finishTime)
 		close(kw.deleted)
 		kw.setupLogs()
 		log.Debug("K8s task watcher: pod stopped [stopped]")
@@ -714,19 +1034,39 @@ func (kw *k8sWatcher) whenPodDeleted() {
 	})
 }
 
+// stopPod deletes pod on request of task handle or when context is done.
+// Pod is marked as stopped by swan unless it has already finished on its own.
+func (kw *k8sWatcher) stopPod() {
+	kw.taskHandle.updateStatus(func(status *TerminationStatus) {
+		if !kw.taskHandle.finished {
+			status.Stopped = true
+		}
+	})
+	kw.deletePod()
+}
+
+// podGracePeriodSeconds returns grace period of pod deletion long enough to apply stop policy by pre-stop hook.
+// Setting grace period to zero will erase pod from API server and won't wait for it to exit.
+// Setting it to (by default) 5 seconds leaves responsibility of deleting the pod to kubelet.
+func podGracePeriodSeconds(stopPolicy StopPolicy) int64 {
+	if stopPolicy.IsEmpty() {
+		return 5
+	}
+	return int64(math.Ceil((stopPolicy.Timeout() + killWaitTimeout).Seconds()))
+}
+
 // deletePod action that body is executed only once to ask kubernetes to deleted pod.
 func (kw *k8sWatcher) deletePod() {
 	kw.onceDeletePod.Do(func() {
 
-		// Setting gracePeriodSeconds to zero will erase pod from API server and won't wait for it to exit.
-		// Setting it to 5 second leaves responsibility of deleting the pod to kubelet.
-		gracePeriodSeconds := int64(5)
 		log.Debugf("deleting pod %q", kw.pod.Name)
 		err := kw.podsAPI.Delete(kw.pod.Name, &metav1.DeleteOptions{
-			GracePeriodSeconds: &gracePeriodSeconds,
+			GracePeriodSeconds: &kw.gracePeriodSeconds,
 		})
 		if err != nil {
 			log.Warnf("unsuccessful attempt to delete pod %q", kw.pod.Name)
+		} else {
+			journal.Released(journal.Resource{Kind: journal.KindKubernetesPod, ID: kw.pod.Name, Location: kw.pod.Namespace})
 		}
 		log.Debugf("K8s task watcher: delete pod %q", kw.pod.Name)
 	})
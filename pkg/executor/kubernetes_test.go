@@ -34,14 +34,14 @@ func TestKubernetes(t *testing.T) {
 	Convey("Kubernetes pod executor pod names", t, func() {
 
 		Convey("have desired name", func() {
-			podExecutor := &k8s{KubernetesConfig{PodName: "foo"}, nil}
+			podExecutor := &k8s{config: KubernetesConfig{PodName: "foo"}}
 			name := podExecutor.generatePodName()
 			So(name, ShouldEqual, "foo")
 
 		})
 
 		Convey("have desired prefix", func() {
-			podExecutor := &k8s{KubernetesConfig{PodNamePrefix: "foo"}, nil}
+			podExecutor := &k8s{config: KubernetesConfig{PodNamePrefix: "foo"}}
 			name := podExecutor.generatePodName()
 			So(name, ShouldStartWith, "foo-")
 
@@ -49,7 +49,7 @@ func TestKubernetes(t *testing.T) {
 
 		Convey("with default config", func() {
 
-			podExecutor := &k8s{DefaultKubernetesConfig(), nil}
+			podExecutor := &k8s{config: DefaultKubernetesConfig()}
 
 			Convey("have default prefix", func() {
 				name := podExecutor.generatePodName()
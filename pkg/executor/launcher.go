@@ -14,7 +14,10 @@
 
 package executor
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // Launcher responsibility is to launch previously configured job.
 type Launcher interface {
@@ -24,3 +27,31 @@ type Launcher interface {
 	// Error is returned when Launcher is unable to start a job.
 	Launch() (TaskHandle, error)
 }
+
+// ContextLauncher is a Launcher which is able to bind lifetime of launched workload to a context.
+type ContextLauncher interface {
+	Launcher
+	// LaunchContext starts the workload like Launch does.
+	// When ctx is done before workload terminates, the workload is stopped.
+	LaunchContext(ctx context.Context) (TaskHandle, error)
+}
+
+// LaunchContext launches workload using given launcher and binds lifetime of the workload to ctx.
+// Launchers not implementing ContextLauncher are supported: workload is stopped when ctx is done.
+func LaunchContext(ctx context.Context, launcher Launcher) (TaskHandle, error) {
+	if contextLauncher, ok := launcher.(ContextLauncher); ok {
+		return contextLauncher.LaunchContext(ctx)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	handle, err := launcher.Launch()
+	if err != nil {
+		return nil, err
+	}
+	stopWhenDone(ctx, handle)
+
+	return handle, nil
+}
@@ -15,6 +15,7 @@
 package executor
 
 import (
+	"context"
 	"time"
 )
 
@@ -32,3 +33,19 @@ type LoadGenerator interface {
 	// Note: Results from Load needs to be fetched out of band e.g using Snap.
 	Load(load int, duration time.Duration) (task TaskHandle, err error)
 }
+
+// LoadContext starts load with given load generator and binds lifetime of the load generator task to ctx:
+// the task is stopped when ctx is done before it terminates.
+func LoadContext(ctx context.Context, loadGenerator LoadGenerator, load int, duration time.Duration) (TaskHandle, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	handle, err := loadGenerator.Load(load, duration)
+	if err != nil {
+		return nil, err
+	}
+	stopWhenDone(ctx, handle)
+
+	return handle, nil
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// LoadMeasurement is a result of load generated by LoadGenerator.Load.
+type LoadMeasurement struct {
+	// QPS is achieved load.
+	QPS int
+	// SLI is achieved tail latency (e.g. 99th percentile in microseconds).
+	SLI int
+}
+
+// LoadMeasurementParser reads measurement from stdout of load generator task started by Load.
+type LoadMeasurementParser interface {
+	ParseMeasurement(stdout io.Reader) (LoadMeasurement, error)
+}
+
+// LoadMeasurementParserFunc is an adapter allowing to use function as LoadMeasurementParser.
+type LoadMeasurementParserFunc func(stdout io.Reader) (LoadMeasurement, error)
+
+// ParseMeasurement implements LoadMeasurementParser interface.
+func (f LoadMeasurementParserFunc) ParseMeasurement(stdout io.Reader) (LoadMeasurement, error) {
+	return f(stdout)
+}
+
+// ConfigurableTuner is implemented by load generators which tuning is configured with LoadTunerConfig
+// (e.g. load generators without built-in tuning which Tune with LoadTuner).
+type ConfigurableTuner interface {
+	TuneWithConfig(slo int, config LoadTunerConfig) (qps int, achievedSLI int, err error)
+}
+
+// LoadTunerConfig configures LoadTuner.
+type LoadTunerConfig struct {
+	// ProbeDuration is duration of load generated to measure single load.
+	ProbeDuration time.Duration
+	// ProbeRepetitions is number of probes of every load; medians of their QPS and SLI are used.
+	ProbeRepetitions int
+	// InitialLoad is the first load probed; it is doubled until SLO is violated.
+	InitialLoad int
+	// MaxLoad limits searched load (0 means no limit; the exponential search then stops
+	// when achieved QPS stops growing).
+	MaxLoad int
+	// Precision is relative width of load range at which search stops.
+	Precision float64
+	// Tolerance is relative noise tolerated when load is checked: SLI can exceed SLO
+	// and achieved QPS can be lower than requested load by this fraction.
+	Tolerance float64
+}
+
+// DefaultLoadTunerConfig returns LoadTunerConfig with 3 probes of 10 seconds per load,
+// searching from 1000 QPS with 2% precision and 5% noise tolerance.
+func DefaultLoadTunerConfig() LoadTunerConfig {
+	return LoadTunerConfig{
+		ProbeDuration:    10 * time.Second,
+		ProbeRepetitions: 3,
+		InitialLoad:      1000,
+		Precision:        0.02,
+		Tolerance:        0.05,
+	}
+}
+
+const (
+	// probeTimeoutFactor bounds time of waiting for single probe to ProbeDuration multiplied by this factor.
+	probeTimeoutFactor = 3
+	// maxTunedLoad is the highest load probed when MaxLoad is not set; it prevents doubling load until overflow.
+	maxTunedLoad = math.MaxInt32
+)
+
+// LoadTuner searches for peak load of any LoadGenerator which output can be parsed by LoadMeasurementParser.
+// It runs short probes with Load: the load is doubled until SLO is violated and then the peak load
+// is found with binary search between the highest load meeting SLO and the lowest one violating it.
+type LoadTuner struct {
+	loadGenerator LoadGenerator
+	parser        LoadMeasurementParser
+	config        LoadTunerConfig
+}
+
+// NewLoadTuner is constructor for LoadTuner.
+func NewLoadTuner(loadGenerator LoadGenerator, parser LoadMeasurementParser, config LoadTunerConfig) LoadTuner {
+	return LoadTuner{loadGenerator: loadGenerator, parser: parser, config: config}
+}
+
+// Tune returns the highest load (and its SLI) meeting slo. It has the same signature as LoadGenerator.Tune,
+// so load generators without built-in tuning can delegate to it.
+func (t LoadTuner) Tune(slo int) (load int, achievedSLI int, err error) {
+	if t.config.InitialLoad <= 0 {
+		return 0, 0, errors.Errorf("initial load should be positive, got %d", t.config.InitialLoad)
+	}
+	if t.config.ProbeRepetitions <= 0 {
+		return 0, 0, errors.Errorf("number of probe repetitions should be positive, got %d", t.config.ProbeRepetitions)
+	}
+
+	// Highest load meeting SLO and lowest load violating it (0 when not found yet).
+	low, high := 0, 0
+	lowSLI, lowQPS := 0, 0
+
+	// Exponential search for load violating SLO.
+	for probe := t.config.InitialLoad; ; probe *= 2 {
+		if t.config.MaxLoad > 0 && probe >= t.config.MaxLoad {
+			probe = t.config.MaxLoad
+		}
+		met, measurement, err := t.check(probe, slo)
+		if err != nil {
+			return 0, 0, err
+		}
+		if !met {
+			high = probe
+			break
+		}
+		if low > 0 && float64(measurement.QPS) <= float64(lowQPS)*(1+t.config.Tolerance) {
+			// Load generator does not generate more load when asked to, so higher loads cannot be achieved.
+			log.Infof("Tuning: achieved QPS stopped growing at load %d (%d QPS)", probe, measurement.QPS)
+			high = probe
+			break
+		}
+		low, lowSLI, lowQPS = probe, measurement.SLI, measurement.QPS
+		if probe == t.config.MaxLoad {
+			log.Infof("Tuning: SLO is met at maximal load %d", probe)
+			return low, lowSLI, nil
+		}
+		if probe > maxTunedLoad/2 {
+			return 0, 0, errors.Errorf("SLO %d is met even with load %d; maximal load should be set", slo, probe)
+		}
+	}
+
+	// Binary search between low and high.
+	for float64(high-low) > t.config.Precision*float64(high) && high-low > 1 {
+		probe := (low + high) / 2
+		met, measurement, err := t.check(probe, slo)
+		if err != nil {
+			return 0, 0, err
+		}
+		if met {
+			low, lowSLI = probe, measurement.SLI
+		} else {
+			high = probe
+		}
+	}
+
+	if low == 0 {
+		return 0, 0, errors.Errorf("SLO %d cannot be met even with load %d", slo, high)
+	}
+	log.Infof("Tuning: peak load %d with SLI %d (SLO %d)", low, lowSLI, slo)
+	return low, lowSLI, nil
+}
+
+// check measures load and returns whether it meets slo within tolerance.
+func (t LoadTuner) check(load int, slo int) (met bool, measurement LoadMeasurement, err error) {
+	measurement, err = t.measure(load)
+	if err != nil {
+		return false, LoadMeasurement{}, err
+	}
+	met = float64(measurement.SLI) <= float64(slo)*(1+t.config.Tolerance) &&
+		float64(measurement.QPS) >= float64(load)*(1-t.config.Tolerance)
+	log.Debugf("Tuning: load %d achieved %d QPS with SLI %d (SLO %d met: %t)", load, measurement.QPS, measurement.SLI, slo, met)
+	return met, measurement, nil
+}
+
+// measure runs ProbeRepetitions probes of load and returns medians of their measurements.
+func (t LoadTuner) measure(load int) (LoadMeasurement, error) {
+	qps := make([]int, t.config.ProbeRepetitions)
+	slis := make([]int, t.config.ProbeRepetitions)
+	for i := range qps {
+		measurement, err := t.probe(load)
+		if err != nil {
+			return LoadMeasurement{}, errors.Wrapf(err, "tuning probe of load %d failed", load)
+		}
+		qps[i], slis[i] = measurement.QPS, measurement.SLI
+	}
+	return LoadMeasurement{QPS: median(qps), SLI: median(slis)}, nil
+}
+
+func (t LoadTuner) probe(load int) (LoadMeasurement, error) {
+	handle, err := t.loadGenerator.Load(load, t.config.ProbeDuration)
+	if err != nil {
+		return LoadMeasurement{}, err
+	}
+
+	timeout := probeTimeoutFactor * t.config.ProbeDuration
+	terminated, err := handle.Wait(timeout)
+	if err != nil {
+		return LoadMeasurement{}, err
+	}
+	if !terminated {
+		if err := handle.Stop(); err != nil {
+			log.Errorf("Tuning: cannot stop %s: %s", handle, err)
+		}
+		return LoadMeasurement{}, errors.Errorf("load generator has not finished within %s", timeout)
+	}
+	exitCode, err := handle.ExitCode()
+	if err != nil {
+		return LoadMeasurement{}, err
+	}
+	if exitCode != 0 {
+		return LoadMeasurement{}, errors.Errorf("load generator exited with code %d", exitCode)
+	}
+
+	stdout, err := handle.StdoutFile()
+	if err != nil {
+		return LoadMeasurement{}, errors.Wrap(err, "cannot get load generator stdout file")
+	}
+	defer stdout.Close()
+
+	measurement, err := t.parser.ParseMeasurement(stdout)
+	if err != nil {
+		return LoadMeasurement{}, errors.Wrapf(err, "cannot parse output of %s", handle)
+	}
+	return measurement, nil
+}
+
+// median returns median of values (the lower one for even number of values).
+func median(values []int) int {
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	return sorted[(len(sorted)-1)/2]
+}
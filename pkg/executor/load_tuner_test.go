@@ -0,0 +1,153 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// parseQPSAndSLI parses output in form of "<qps> <sli>".
+var parseQPSAndSLI = LoadMeasurementParserFunc(func(stdout io.Reader) (measurement LoadMeasurement, err error) {
+	_, err = fmt.Fscan(stdout, &measurement.QPS, &measurement.SLI)
+	return measurement, err
+})
+
+// newFakeLoadGenerator returns load generator which output is given by measure.
+// Loads of all probes are appended to probes.
+func newFakeLoadGenerator(dir string, probes *[]int, measure func(load int) LoadMeasurement) *MockLoadGenerator {
+	loadGenerator := new(MockLoadGenerator)
+	loadGenerator.On("Load", mock.AnythingOfType("int"), time.Second).Return(func(load int, duration time.Duration) TaskHandle {
+		*probes = append(*probes, load)
+		measurement := measure(load)
+		output := path.Join(dir, fmt.Sprintf("probe_%d", len(*probes)))
+		So(ioutil.WriteFile(output, []byte(fmt.Sprintf("%d %d", measurement.QPS, measurement.SLI)), 0644), ShouldBeNil)
+		stdout, err := os.Open(output)
+		So(err, ShouldBeNil)
+
+		handle := new(MockTaskHandle)
+		handle.On("Wait", 3*time.Second).Return(true, nil)
+		handle.On("ExitCode").Return(0, nil)
+		handle.On("StdoutFile").Return(stdout, nil)
+		return handle
+	}, nil)
+	return loadGenerator
+}
+
+func TestLoadTuner(t *testing.T) {
+	Convey("When load generator is tuned with LoadTuner", t, func() {
+		dir, err := ioutil.TempDir("", "load_tuner")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		config := LoadTunerConfig{ProbeDuration: time.Second, ProbeRepetitions: 1, InitialLoad: 1000, Precision: 0.01}
+		var probes []int
+		// Latency rises with load and SLO of 500 is met up to 40000 QPS; load generator can generate at most 50000 QPS.
+		latency := func(load int) LoadMeasurement {
+			qps := load
+			if qps > 50000 {
+				qps = 50000
+			}
+			return LoadMeasurement{QPS: qps, SLI: 100 + load/100}
+		}
+
+		Convey("Peak load should be found with exponential and binary search", func() {
+			load, sli, err := NewLoadTuner(newFakeLoadGenerator(dir, &probes, latency), parseQPSAndSLI, config).Tune(500)
+			So(err, ShouldBeNil)
+			So(load, ShouldBeBetweenOrEqual, 39600, 40000)
+			So(sli, ShouldBeLessThanOrEqualTo, 500)
+			So(probes[:7], ShouldResemble, []int{1000, 2000, 4000, 8000, 16000, 32000, 64000})
+		})
+
+		Convey("Load not achieved by load generator should violate SLO", func() {
+			load, _, err := NewLoadTuner(newFakeLoadGenerator(dir, &probes, latency), parseQPSAndSLI, config).Tune(1000)
+			So(err, ShouldBeNil)
+			So(load, ShouldBeBetweenOrEqual, 49500, 50000)
+		})
+
+		Convey("Search should stop at maximal load", func() {
+			config.MaxLoad = 10000
+			load, _, err := NewLoadTuner(newFakeLoadGenerator(dir, &probes, latency), parseQPSAndSLI, config).Tune(500)
+			So(err, ShouldBeNil)
+			So(load, ShouldEqual, 10000)
+			So(probes, ShouldResemble, []int{1000, 2000, 4000, 8000, 10000})
+		})
+
+		Convey("Noisy probes should be tolerated", func() {
+			config.ProbeRepetitions = 3
+			config.Tolerance = 0.05
+			// Every third probe is an outlier; SLI up to 525 is tolerated.
+			noisy := func(load int) LoadMeasurement {
+				measurement := latency(load)
+				if len(probes)%3 == 0 {
+					measurement.SLI *= 10
+				}
+				return measurement
+			}
+			load, _, err := NewLoadTuner(newFakeLoadGenerator(dir, &probes, noisy), parseQPSAndSLI, config).Tune(500)
+			So(err, ShouldBeNil)
+			So(load, ShouldBeBetweenOrEqual, 39600, 42500)
+			So(len(probes)%3, ShouldEqual, 0)
+		})
+
+		Convey("Search without maximal load should stop when achieved QPS stops growing", func() {
+			// Load generator ignores requested load and always reports the same QPS.
+			constant := func(load int) LoadMeasurement {
+				return LoadMeasurement{QPS: 1000000, SLI: 100}
+			}
+			load, _, err := NewLoadTuner(newFakeLoadGenerator(dir, &probes, constant), parseQPSAndSLI, config).Tune(500)
+			So(err, ShouldBeNil)
+			So(load, ShouldBeBetweenOrEqual, 1000, 2000)
+			So(probes[:2], ShouldResemble, []int{1000, 2000})
+			So(probes, ShouldNotContain, 4000)
+		})
+
+		Convey("Search without maximal load should not double load until overflow", func() {
+			unlimited := func(load int) LoadMeasurement {
+				return LoadMeasurement{QPS: load, SLI: 100}
+			}
+			_, _, err := NewLoadTuner(newFakeLoadGenerator(dir, &probes, unlimited), parseQPSAndSLI, config).Tune(500)
+			So(err, ShouldNotBeNil)
+			So(probes[len(probes)-1], ShouldBeLessThanOrEqualTo, maxTunedLoad)
+		})
+
+		Convey("Probe which does not finish in time should be stopped and fail tuning", func() {
+			handle := new(MockTaskHandle)
+			handle.On("Wait", 3*time.Second).Return(false, nil)
+			handle.On("Stop").Return(nil)
+			loadGenerator := new(MockLoadGenerator)
+			loadGenerator.On("Load", 1000, time.Second).Return(handle, nil)
+
+			_, _, err := NewLoadTuner(loadGenerator, parseQPSAndSLI, config).Tune(500)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "has not finished within 3s")
+			handle.AssertExpectations(t)
+		})
+
+		Convey("Error should be returned when SLO cannot be met", func() {
+			_, _, err := NewLoadTuner(newFakeLoadGenerator(dir, &probes, latency), parseQPSAndSLI, config).Tune(50)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
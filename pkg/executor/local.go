@@ -15,10 +15,12 @@
 package executor
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sync"
 	"syscall"
 	"time"
 
@@ -32,6 +34,7 @@ import (
 // It runs command as current user.
 type Local struct {
 	commandDecorators isolation.Decorators
+	stopPolicy        StopPolicy
 }
 
 // NewLocal returns instance of local executors without any isolators.
@@ -44,6 +47,12 @@ func NewLocalIsolated(deco ...isolation.Decorator) Local {
 	return Local{commandDecorators: deco}
 }
 
+// NewLocalWithStopPolicy returns a Local instance with some isolators set, which stops tasks with given policy.
+// By default (empty policy) whole process group of the task is killed.
+func NewLocalWithStopPolicy(policy StopPolicy, deco ...isolation.Decorator) Local {
+	return Local{commandDecorators: deco, stopPolicy: policy}
+}
+
 // String returns user-friendly name of executor.
 func (l Local) String() string {
 	return "Local Executor"
@@ -52,6 +61,16 @@ func (l Local) String() string {
 // Execute runs the command given as input.
 // Returned Task is able to stop & monitor the provisioned process.
 func (l Local) Execute(command string) (TaskHandle, error) {
+	return l.ExecuteContext(context.Background(), command)
+}
+
+// ExecuteContext runs the command given as input and stops it when ctx is done.
+// Returned Task is able to stop & monitor the provisioned process.
+func (l Local) ExecuteContext(ctx context.Context, command string) (TaskHandle, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrapf(err, "command %q not started", command)
+	}
+
 	log.Debug("Local Executor: Starting '", l.commandDecorators.Decorate(command), "' locally ")
 
 	cmd := exec.Command("sh", "-c", l.commandDecorators.Decorate(command))
@@ -75,6 +94,9 @@ func (l Local) Execute(command string) (TaskHandle, error) {
 	cmd.Stdout = stdoutFile
 	cmd.Stderr = stderrFile
 
+	// OOM kills in isolations of the task are counted to find out whether the task has been OOM killed.
+	oomKillsBefore := oomKills(l.commandDecorators)
+
 	err = cmd.Start()
 	if err != nil {
 		removeDirectory(outputDirectory)
@@ -86,7 +108,14 @@ func (l Local) Execute(command string) (TaskHandle, error) {
 	// hasProcessExited channel is closed when launched process exits.
 	hasProcessExited := make(chan struct{})
 
+	stopPolicy := stopPolicyFromContext(ctx, l.stopPolicy)
+	if stopPolicy.IsEmpty() {
+		stopPolicy = KillPolicy
+	}
+
 	taskHandle := localTaskHandle{
+		stopPolicy:       stopPolicy,
+		startTime:        time.Now(),
 		cmdHandler:       cmd,
 		command:          command,
 		stdoutFilePath:   stdoutFile.Name(),
@@ -112,6 +141,8 @@ func (l Local) Execute(command string) (TaskHandle, error) {
 				log.Panicf("Waiting for local task failed\n%+v", err)
 			}
 		}
+		taskHandle.wallTime = time.Since(taskHandle.startTime)
+		taskHandle.oomKilled = oomKills(l.commandDecorators) > oomKillsBefore
 
 		err = syncAndClose(stdoutFile)
 		if err != nil {
@@ -133,9 +164,20 @@ func (l Local) Execute(command string) (TaskHandle, error) {
 		return nil, err
 	}
 	log.Debugf("Local Executor: pid %d started successfully", cmd.Process.Pid)
+	stopWhenDone(ctx, &taskHandle)
 	return &taskHandle, nil
 }
 
+// Push copies local file or directory to targetPath on local host.
+func (l Local) Push(ctx context.Context, localPath, targetPath string) error {
+	return copyTree(ctx, localFileSystem{}, localPath, localFileSystem{}, targetPath)
+}
+
+// Pull copies file or directory from targetPath on local host to localPath.
+func (l Local) Pull(ctx context.Context, targetPath, localPath string) error {
+	return copyTree(ctx, localFileSystem{}, targetPath, localFileSystem{}, localPath)
+}
+
 // localTaskHandle implements TaskHandle interface.
 type localTaskHandle struct {
 	cmdHandler     *exec.Cmd
@@ -148,6 +190,16 @@ type localTaskHandle struct {
 
 	// Command requested by user. This is how this TaskHandle presents.
 	command string
+
+	stopPolicy StopPolicy
+
+	startTime time.Time
+	// wallTime and oomKilled are set before hasProcessExited is closed.
+	wallTime  time.Duration
+	oomKilled bool
+
+	mutex   sync.Mutex
+	stopped bool
 }
 
 // isTerminated checks if channel processHasExited is closed. If it is closed, it means
@@ -170,30 +222,77 @@ func (taskHandle *localTaskHandle) getPid() int {
 
 // Stop terminates the local task.
 func (taskHandle *localTaskHandle) Stop() error {
-	if taskHandle.isTerminated() {
+	ctx, cancel := context.WithTimeout(context.Background(), taskHandle.stopPolicy.Timeout()+killWaitTimeout)
+	defer cancel()
+
+	return taskHandle.StopContext(ctx)
+}
+
+// StopContext terminates the local task with its stop policy and waits for its termination until ctx is done.
+// When policy signals the whole process group, it waits also for termination of all descendant processes
+// remaining in the group (even if the task itself has already terminated).
+func (taskHandle *localTaskHandle) StopContext(ctx context.Context) error {
+	if taskHandle.isTerminated() && !taskHandle.hasRemainingProcesses() {
 		return nil
 	}
 
-	// Sending SIGKILL signal to local task.
+	taskHandle.mutex.Lock()
+	taskHandle.stopped = taskHandle.stopped || !taskHandle.isTerminated()
+	taskHandle.mutex.Unlock()
+
 	// TODO: Add PID namespace to handle orphan tasks properly.
-	log.Debug("Sending ", syscall.SIGKILL, " to PID ", -taskHandle.getPid())
-	err := syscall.Kill(-taskHandle.getPid(), syscall.SIGKILL)
+	err := taskHandle.stopPolicy.escalate(ctx, taskHandle.signal, taskHandle.waitForProcesses)
 	if err != nil {
 		log.Errorf("Local Stop() of command %q has failed: %s", taskHandle.command, err.Error())
 		return errors.Wrapf(err, "Local Stop() of command %q has failed", taskHandle.command)
 	}
 
-	// Checking if kill was successful.
-	isTerminated, _ := taskHandle.Wait(killWaitTimeout)
-	if !isTerminated {
-		log.Errorf("Local Stop() of command %q has failed: timeout", taskHandle.command)
-		return errors.Errorf("Local Stop() of command %q has failed: timeout", taskHandle.command)
-	}
-
 	// No error, task terminated.
 	return nil
 }
 
+// signal sends signal to the task or its process group (depending on stop policy).
+// Processes which have already terminated are ignored.
+func (taskHandle *localTaskHandle) signal(signal syscall.Signal) error {
+	pid := taskHandle.getPid()
+	if taskHandle.stopPolicy.ProcessGroup {
+		pid = -pid
+	}
+
+	log.Debug("Sending ", signal, " to PID ", pid)
+	err := syscall.Kill(pid, signal)
+	if err == syscall.ESRCH {
+		return nil
+	}
+	return err
+}
+
+// hasRemainingProcesses returns true when stop policy signals process group of the task and there
+// are still running processes in the group.
+func (taskHandle *localTaskHandle) hasRemainingProcesses() bool {
+	if !taskHandle.stopPolicy.ProcessGroup {
+		return false
+	}
+	return len(processGroupMembers(taskHandle.getPid())) > 0
+}
+
+// waitForProcesses waits for termination of the task and remaining processes in its process group.
+func (taskHandle *localTaskHandle) waitForProcesses(ctx context.Context) (bool, error) {
+	terminated, err := taskHandle.WaitContext(ctx)
+	if !terminated {
+		return terminated, err
+	}
+
+	for taskHandle.hasRemainingProcesses() {
+		select {
+		case <-time.After(processGroupPollInterval):
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+	return true, nil
+}
+
 // Status returns a state of the task.
 func (taskHandle *localTaskHandle) Status() TaskState {
 	if !taskHandle.isTerminated() {
@@ -212,6 +311,27 @@ func (taskHandle *localTaskHandle) ExitCode() (int, error) {
 	return (taskHandle.cmdHandler.ProcessState.Sys().(syscall.WaitStatus)).ExitStatus(), nil
 }
 
+// TerminationStatus returns status of the terminated task.
+// Signal is reported when the shell running the task has been killed or the task has been killed
+// and shell reported it with exit code above 128.
+func (taskHandle *localTaskHandle) TerminationStatus() (TerminationStatus, error) {
+	if !taskHandle.isTerminated() {
+		return TerminationStatus{}, errors.Errorf("task %q is not terminated", taskHandle.command)
+	}
+
+	waitStatus := taskHandle.cmdHandler.ProcessState.Sys().(syscall.WaitStatus)
+	status := statusFromExitCode(waitStatus.ExitStatus())
+	if waitStatus.Signaled() {
+		status.Signal = waitStatus.Signal()
+	}
+	status.OOMKilled = taskHandle.oomKilled
+
+	taskHandle.mutex.Lock()
+	defer taskHandle.mutex.Unlock()
+	status.Stopped = taskHandle.stopped
+	return status, nil
+}
+
 // StdoutFile returns a file handle for file to the task's stdout file.
 func (taskHandle *localTaskHandle) StdoutFile() (*os.File, error) {
 	return openFile(taskHandle.stdoutFilePath)
@@ -222,6 +342,22 @@ func (taskHandle *localTaskHandle) StderrFile() (*os.File, error) {
 	return openFile(taskHandle.stderrFilePath)
 }
 
+// ResourceUsage returns resources consumed by the terminated task (and its waited-for children).
+func (taskHandle *localTaskHandle) ResourceUsage() (ResourceUsage, error) {
+	if !taskHandle.isTerminated() {
+		return ResourceUsage{}, errors.Errorf("task %q is not terminated", taskHandle.command)
+	}
+
+	rusage, ok := taskHandle.cmdHandler.ProcessState.SysUsage().(*syscall.Rusage)
+	if !ok || rusage == nil {
+		return ResourceUsage{}, errors.Errorf("resource usage of task %q is not available", taskHandle.command)
+	}
+
+	usage := rusageToResourceUsage(rusage)
+	usage.WallTime = taskHandle.wallTime
+	return usage, nil
+}
+
 // EraseOutput deletes the directory where stdout file resides.
 func (taskHandle *localTaskHandle) EraseOutput() error {
 	outputDir := filepath.Dir(taskHandle.stdoutFilePath)
@@ -247,6 +383,17 @@ func (taskHandle *localTaskHandle) Wait(timeout time.Duration) (bool, error) {
 	}
 }
 
+// WaitContext waits for the command to finish or ctx to be done.
+// It returns true if task is terminated.
+func (taskHandle *localTaskHandle) WaitContext(ctx context.Context) (bool, error) {
+	select {
+	case <-taskHandle.hasProcessExited:
+		return true, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
 func (taskHandle *localTaskHandle) String() string {
 	return fmt.Sprintf("Local %q", taskHandle.command)
 }
@@ -27,6 +27,7 @@ Openstack executor under the hood is using these are two components:
 package executor
 
 import (
+	"context"
 	"fmt"
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack"
@@ -40,12 +41,14 @@ import (
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/images"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
 	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/journal"
 	"github.com/intelsdi-x/swan/pkg/utils/uuid"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 )
 
@@ -148,6 +151,9 @@ type OpenstackConfig struct {
 	ID            string
 	Hypervisor    Hypervisor
 	HostAggregate HostAggregate
+
+	// StopPolicy is used to stop the task running on the instance before the instance is stopped.
+	StopPolicy StopPolicy
 }
 
 // Openstack defines OpenStack server configuration and client.
@@ -168,6 +174,16 @@ func (stack Openstack) String() string {
 
 // Execute runs provided command on OpenStack cluster.
 func (stack Openstack) Execute(command string) (TaskHandle, error) {
+	return stack.ExecuteContext(context.Background(), command)
+}
+
+// ExecuteContext runs provided command on OpenStack cluster and stops the instance when ctx is done.
+// If ctx is done while instance is being launched, the instance is deleted.
+func (stack Openstack) ExecuteContext(ctx context.Context, command string) (TaskHandle, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrapf(err, "%s instance for command %q not created", executorLogPrefix, command)
+	}
+
 	provider, err := openstack.AuthenticatedClient(stack.config.Auth)
 	if err != nil {
 		err = errors.Wrapf(err, "%s Couldn't get provider", executorLogPrefix)
@@ -247,6 +263,7 @@ func (stack Openstack) Execute(command string) (TaskHandle, error) {
 	}
 
 	log.Infof("%s Scheduled instance %s creation", executorLogPrefix, instance.ID)
+	journal.Created(openstackInstanceResource(instance.ID))
 
 	stack.config.ID = instance.ID
 
@@ -274,11 +291,23 @@ func (stack Openstack) Execute(command string) (TaskHandle, error) {
 		User:    stack.config.User,
 		KeyPath: stack.config.SSHKeyPath,
 		Port:    22,
+
+		// Freshly booted instances are not in known_hosts, so host keys are not verified.
+		InsecureIgnoreHostKey: true,
+
+		StopPolicy: stack.config.StopPolicy,
 	}
 
 	// Wait while to ensure that everything booted up
 	log.Infof("%s Waiting for %s instance to boot up", executorLogPrefix, instanceName)
-	time.Sleep(bootUpTimeOut.Value())
+	select {
+	case <-time.After(bootUpTimeOut.Value()):
+	case <-ctx.Done():
+		deleteInstance(stack.client, instance.ID)
+		err = errors.Wrapf(ctx.Err(), "%s Booting up %s instance has been interrupted", executorLogPrefix, instanceName)
+		log.Error(err.Error())
+		return nil, err
+	}
 
 	stack.config.Hypervisor.InstanceName, err = stack.obtainHypervisorInstanceName(instance.ID)
 	if err != nil {
@@ -300,7 +329,7 @@ func (stack Openstack) Execute(command string) (TaskHandle, error) {
 
 	log.Infof("%s Created remote executor", executorLogPrefix)
 
-	remoteHandler, err := remote.Execute(command)
+	remoteHandler, err := ExecuteContext(ctx, remote, command)
 	if err != nil {
 		log.Errorf("%s Couldn't execute %q on %s (%s)", executorLogPrefix, command, instanceName, floatingIP)
 		return nil, err
@@ -340,6 +369,7 @@ func (stack Openstack) Execute(command string) (TaskHandle, error) {
 		requestDelete:  make(chan struct{}),
 		stopped:        make(chan struct{}),
 		deleted:        make(chan struct{}),
+		remote:         remote,
 	}
 
 	taskWatcher := &openstackWatcher{
@@ -357,6 +387,7 @@ func (stack Openstack) Execute(command string) (TaskHandle, error) {
 	}
 
 	err = taskWatcher.watch()
+	stopWhenDone(ctx, taskHandle)
 
 	return taskHandle, nil
 }
@@ -553,6 +584,12 @@ type OpenstackTaskHandle struct {
 	requestDelete  chan struct{}
 	stopped        chan struct{}
 	deleted        chan struct{}
+
+	// remote is used to transfer files to and from the instance.
+	remote Executor
+
+	mutex         sync.Mutex
+	stoppedBySwan bool
 }
 
 // String returns user-friendly name of task handle.
@@ -565,6 +602,16 @@ func (th *OpenstackTaskHandle) Address() string {
 	return th.hostIP
 }
 
+// Push copies local file or directory to the instance over SFTP.
+func (th *OpenstackTaskHandle) Push(ctx context.Context, localPath, targetPath string) error {
+	return PushFiles(ctx, th.remote, localPath, targetPath)
+}
+
+// Pull copies file or directory from the instance over SFTP.
+func (th *OpenstackTaskHandle) Pull(ctx context.Context, targetPath, localPath string) error {
+	return PullFiles(ctx, th.remote, targetPath, localPath)
+}
+
 // EraseOutput removes directory where output files resides.
 func (th *OpenstackTaskHandle) EraseOutput() error {
 	outputDir := filepath.Dir(th.stdoutFilePath)
@@ -584,6 +631,20 @@ func (th *OpenstackTaskHandle) ExitCode() (int, error) {
 	return th.exitCode, nil
 }
 
+// TerminationStatus returns status of terminated task.
+func (th *OpenstackTaskHandle) TerminationStatus() (TerminationStatus, error) {
+	exitCode, err := th.ExitCode()
+	if err != nil {
+		return TerminationStatus{}, err
+	}
+
+	status := statusFromExitCode(exitCode)
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	status.Stopped = th.stoppedBySwan
+	return status, nil
+}
+
 // Status returns task status.
 func (th *OpenstackTaskHandle) Status() TaskState {
 	if th.isRunning() {
@@ -603,31 +664,51 @@ func (th *OpenstackTaskHandle) StdoutFile() (*os.File, error) {
 	return openFile(th.stdoutFilePath)
 }
 
-// Stop stops task.
-func (th *OpenstackTaskHandle) Stop() error {
+// StopContext stops task like Stop does, but gives up waiting for the instance when ctx is done.
+func (th *OpenstackTaskHandle) StopContext(ctx context.Context) error {
 	if !th.isRunning() {
 		return nil
 	}
 
 	log.Debugf("%s delete instance %q", taskHandleLogPrefix, th.instance)
+	th.mutex.Lock()
+	th.stoppedBySwan = true
+	th.mutex.Unlock()
 
-	th.requestStop <- struct{}{}
-
-	log.Debugf("%s waiting for instance %q to stop", taskHandleLogPrefix, th.instance)
-
-	<-th.stopped
-
-	log.Debugf("%s instance %q stop", taskHandleLogPrefix, th.instance)
+	select {
+	case th.requestStop <- struct{}{}:
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "%s instance %q has not been stopped", taskHandleLogPrefix, th.instance)
+	}
 
-	th.requestDelete <- struct{}{}
+	select {
+	case <-th.stopped:
+		log.Debugf("%s instance %q stop", taskHandleLogPrefix, th.instance)
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "%s instance %q has not been stopped", taskHandleLogPrefix, th.instance)
+	}
 
-	<-th.deleted
+	select {
+	case th.requestDelete <- struct{}{}:
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "%s instance %q has not been deleted", taskHandleLogPrefix, th.instance)
+	}
 
-	log.Debugf("%s instance %q deleted", taskHandleLogPrefix, th.instance)
+	select {
+	case <-th.deleted:
+		log.Debugf("%s instance %q deleted", taskHandleLogPrefix, th.instance)
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "%s instance %q has not been deleted", taskHandleLogPrefix, th.instance)
+	}
 
 	return nil
 }
 
+// Stop stops task.
+func (th *OpenstackTaskHandle) Stop() error {
+	return th.StopContext(context.Background())
+}
+
 // Wait blocks and waits for task to terminate.
 // For '0' it'll wait until task termination.
 func (th *OpenstackTaskHandle) Wait(timeout time.Duration) (bool, error) {
@@ -645,6 +726,20 @@ func (th *OpenstackTaskHandle) Wait(timeout time.Duration) (bool, error) {
 	}
 }
 
+// WaitContext blocks and waits for task to terminate or ctx to be done.
+func (th *OpenstackTaskHandle) WaitContext(ctx context.Context) (bool, error) {
+	if !th.isRunning() {
+		return true, nil
+	}
+
+	select {
+	case <-th.stopped:
+		return true, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
 func (th *OpenstackTaskHandle) isRunning() bool {
 	return th.running
 }
@@ -715,7 +810,7 @@ func (watcher *openstackWatcher) watch() error {
 
 				startstop.Stop(watcher.client, watcher.instance).ExtractErr()
 			case <-watcher.requestDelete:
-				servers.Delete(watcher.client, watcher.instance)
+				deleteInstance(watcher.client, watcher.instance)
 				watcher.deleted <- struct{}{}
 			}
 		}
@@ -723,3 +818,19 @@ func (watcher *openstackWatcher) watch() error {
 
 	return nil
 }
+
+// openstackInstanceResource describes instance in experiment journal.
+func openstackInstanceResource(instanceID string) journal.Resource {
+	return journal.Resource{Kind: journal.KindOpenstackInstance, ID: instanceID}
+}
+
+// deleteInstance deletes instance and records it in experiment journal.
+func deleteInstance(client *gophercloud.ServiceClient, instanceID string) error {
+	err := servers.Delete(client, instanceID).ExtractErr()
+	if err != nil {
+		log.Warnf("%s Couldn't delete instance %s: %s", executorLogPrefix, instanceID, err.Error())
+		return err
+	}
+	journal.Released(openstackInstanceResource(instanceID))
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// OutputStream selects stream of task's output.
+type OutputStream int
+
+const (
+	// Stdout is the task's standard output.
+	Stdout OutputStream = iota
+	// Stderr is the task's standard error.
+	Stderr
+)
+
+func (s OutputStream) String() string {
+	if s == Stderr {
+		return "stderr"
+	}
+	return "stdout"
+}
+
+// outputPollInterval is the interval of checking output files for new lines.
+var outputPollInterval = 100 * time.Millisecond
+
+// TaskOutput is implemented by task handles that are able to stream output of running task.
+type TaskOutput interface {
+	// Lines returns channel of lines (without trailing newline) written by the task to stream,
+	// starting from the beginning of the output.
+	// Channel is closed when task terminates and whole output has been read or when ctx is done.
+	Lines(ctx context.Context, stream OutputStream) (<-chan string, error)
+}
+
+// Lines streams lines of task's output.
+// Handles not implementing TaskOutput are supported by following their output files.
+func Lines(ctx context.Context, handle TaskInfo, stream OutputStream) (<-chan string, error) {
+	if output, ok := handle.(TaskOutput); ok {
+		return output.Lines(ctx, stream)
+	}
+	return followOutput(ctx, handle, stream)
+}
+
+// WaitForOutput blocks until line of task's output matches pattern and returns the line.
+// Parameter `timeout` is waiting timeout. For `0` it will wait until task termination.
+// Returns error when task terminates without matching line or timeout passes.
+func WaitForOutput(handle TaskInfo, stream OutputStream, pattern *regexp.Regexp, timeout time.Duration) (string, error) {
+	ctx := context.Background()
+	if timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return WaitForOutputContext(ctx, handle, stream, pattern)
+}
+
+// WaitForOutputContext blocks until line of task's output matches pattern or ctx is done and returns the line.
+// Returns error when task terminates without matching line or ctx is done.
+func WaitForOutputContext(ctx context.Context, handle TaskInfo, stream OutputStream, pattern *regexp.Regexp) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lines, err := Lines(ctx, handle, stream)
+	if err != nil {
+		return "", err
+	}
+
+	for line := range lines {
+		if pattern.MatchString(line) {
+			return line, nil
+		}
+	}
+
+	if ctx.Err() != nil {
+		return "", errors.Wrapf(ctx.Err(), "%s of %s has not matched %q", stream, handle, pattern)
+	}
+	return "", errors.Errorf("%s terminated and its %s has not matched %q", handle, stream, pattern)
+}
+
+// followOutput streams lines of task's output file until the task terminates.
+func followOutput(ctx context.Context, handle TaskInfo, stream OutputStream) (<-chan string, error) {
+	open := handle.StdoutFile
+	if stream == Stderr {
+		open = handle.StderrFile
+	}
+
+	file, err := open()
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open %s of %s", stream, handle)
+	}
+
+	return followFile(ctx, file, func() bool { return handle.Status() == TERMINATED }), nil
+}
+
+// followFile sends lines of file until terminated returns true and the whole file has been read.
+// File is closed when following finishes.
+func followFile(ctx context.Context, file *os.File, terminated func() bool) <-chan string {
+	lines := make(chan string)
+
+	go func() {
+		defer close(lines)
+		defer file.Close()
+
+		send := func(line string) bool {
+			select {
+			case lines <- strings.TrimSuffix(line, "\r"):
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		reader := bufio.NewReader(file)
+		partial := ""
+		for {
+			// Termination is checked before reading, so output written before termination is not lost.
+			finished := terminated()
+			for {
+				chunk, err := reader.ReadString('\n')
+				partial += chunk
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					log.Errorf("Cannot read output file %q: %s", file.Name(), err.Error())
+					return
+				}
+				if !send(strings.TrimSuffix(partial, "\n")) {
+					return
+				}
+				partial = ""
+			}
+
+			if finished {
+				if partial != "" {
+					send(partial)
+				}
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(outputPollInterval):
+			}
+		}
+	}()
+
+	return lines
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fileTaskInfo is a TaskInfo of a task writing to stdout file.
+type fileTaskInfo struct {
+	MockTaskHandle
+	mutex      sync.Mutex
+	filename   string
+	terminated bool
+}
+
+func (f *fileTaskInfo) StdoutFile() (*os.File, error) {
+	return os.Open(f.filename)
+}
+
+func (f *fileTaskInfo) Status() TaskState {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.terminated {
+		return TERMINATED
+	}
+	return RUNNING
+}
+
+func (f *fileTaskInfo) String() string {
+	return "task"
+}
+
+func (f *fileTaskInfo) terminate() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.terminated = true
+}
+
+func TestOutputStreaming(t *testing.T) {
+	outputPollInterval = time.Millisecond
+
+	Convey("When having running task writing to stdout file", t, func() {
+		directory, err := ioutil.TempDir("", "output_stream")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(directory)
+
+		task := &fileTaskInfo{filename: path.Join(directory, "stdout")}
+		stdout, err := os.Create(task.filename)
+		So(err, ShouldBeNil)
+		defer stdout.Close()
+
+		Convey("Lines should be streamed while task is running and until the end of output", func() {
+			lines, err := Lines(context.Background(), task, Stdout)
+			So(err, ShouldBeNil)
+
+			stdout.WriteString("first\nsec")
+			So(<-lines, ShouldEqual, "first")
+
+			stdout.WriteString("ond\r\nlast")
+			So(<-lines, ShouldEqual, "second")
+
+			task.terminate()
+			So(<-lines, ShouldEqual, "last")
+			_, ok := <-lines
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Streaming should finish when context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			lines, err := Lines(ctx, task, Stdout)
+			So(err, ShouldBeNil)
+
+			cancel()
+			_, ok := <-lines
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Waiting for output should return matching line", func() {
+			stdout.WriteString("starting\nlistening on port 11211\n")
+			line, err := WaitForOutput(task, Stdout, regexp.MustCompile(`port \d+`), waitTimeout)
+			So(err, ShouldBeNil)
+			So(line, ShouldEqual, "listening on port 11211")
+		})
+
+		Convey("Waiting for output should time out", func() {
+			stdout.WriteString("starting\n")
+			_, err := WaitForOutput(task, Stdout, regexp.MustCompile("ready"), 10*time.Millisecond)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "deadline exceeded")
+		})
+
+		Convey("Waiting for output should fail when task terminates", func() {
+			stdout.WriteString("failed\n")
+			task.terminate()
+			_, err := WaitForOutput(task, Stdout, regexp.MustCompile("ready"), waitTimeout)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "terminated")
+		})
+
+		Convey("Chained task handle should stream output of all tasks", func() {
+			second := &fileTaskInfo{filename: path.Join(directory, "second")}
+			So(ioutil.WriteFile(second.filename, []byte("from second\n"), 0644), ShouldBeNil)
+			second.terminate()
+			second.On("Wait", 0*time.Second).Return(true, nil)
+			task.On("Wait", 0*time.Second).After(10*time.Millisecond).Return(true, nil)
+			launcher := new(MockLauncher)
+			launcher.On("Launch").Return(second, nil)
+
+			stdout.WriteString("from first\n")
+			task.terminate()
+			chained := NewChainedTaskHandle(task, launcher)
+
+			lines, err := Lines(context.Background(), chained, Stdout)
+			So(err, ShouldBeNil)
+			received := []string{}
+			for line := range lines {
+				received = append(received, line)
+			}
+			So(received, ShouldResemble, []string{"from first", "from second"})
+		})
+	})
+}
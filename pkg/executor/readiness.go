@@ -0,0 +1,328 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultProbeInterval         = 100 * time.Millisecond
+	defaultProbeTimeout          = 1 * time.Second
+	defaultProbeFailureThreshold = 50
+)
+
+// ProbeCheck checks whether launched task is ready.
+type ProbeCheck interface {
+	fmt.Stringer
+	// Check returns nil when task is ready. It should give up when ctx is done.
+	Check(ctx context.Context, handle TaskHandle) error
+}
+
+// ReadinessProbe periodically runs Check until it passes.
+// Task is considered not ready when Check fails FailureThreshold times or task terminates.
+type ReadinessProbe struct {
+	Check ProbeCheck
+	// Interval between subsequent checks.
+	Interval time.Duration
+	// Timeout of a single check.
+	Timeout          time.Duration
+	FailureThreshold int
+}
+
+// NewReadinessProbe returns probe running check every 100ms for 5 seconds.
+func NewReadinessProbe(check ProbeCheck) ReadinessProbe {
+	return ReadinessProbe{
+		Check:            check,
+		Interval:         defaultProbeInterval,
+		Timeout:          defaultProbeTimeout,
+		FailureThreshold: defaultProbeFailureThreshold,
+	}
+}
+
+// NewTCPProbe returns probe passing when TCP connection to address (in form of `ip:port`) can be established.
+func NewTCPProbe(address string) ReadinessProbe {
+	return NewReadinessProbe(NewTCPCheck(address))
+}
+
+// NewHTTPProbe returns probe passing when HTTP GET of url returns success or redirection status.
+func NewHTTPProbe(url string) ReadinessProbe {
+	return NewReadinessProbe(NewHTTPCheck(url))
+}
+
+// NewExecProbe returns probe passing when command executed with executor exits with code 0.
+func NewExecProbe(executor Executor, command string) ReadinessProbe {
+	return NewReadinessProbe(NewExecCheck(executor, command))
+}
+
+// NewOutputProbe returns probe passing when task's output stream matches pattern.
+func NewOutputProbe(stream OutputStream, pattern *regexp.Regexp) ReadinessProbe {
+	return NewReadinessProbe(NewOutputCheck(stream, pattern))
+}
+
+func (probe ReadinessProbe) String() string {
+	return probe.Check.String()
+}
+
+// wait runs the check until it passes, fails FailureThreshold times, task terminates or ctx is done.
+func (probe ReadinessProbe) wait(ctx context.Context, handle TaskHandle) error {
+	for failures := 1; ; failures++ {
+		if handle.Status() == TERMINATED {
+			status, err := GetTerminationStatus(handle)
+			if err != nil {
+				return errors.Wrapf(err, "task terminated before %s passed", probe)
+			}
+			return errors.Errorf("task terminated (%s) before %s passed", status, probe)
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, probe.Timeout)
+		err := probe.Check.Check(checkCtx, handle)
+		cancel()
+		if err == nil {
+			log.Debugf("Readiness probe: %s of %s passed", probe, handle)
+			return nil
+		}
+		log.Debugf("Readiness probe: %s of %s failed (%d/%d): %s", probe, handle, failures, probe.FailureThreshold, err.Error())
+
+		if failures >= probe.FailureThreshold {
+			return errors.Wrapf(err, "%s failed %d times", probe, failures)
+		}
+
+		select {
+		case <-time.After(probe.Interval):
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "%s has not passed", probe)
+		}
+	}
+}
+
+// ReadinessInfo is implemented by task handles that know whether task is ready without probing it
+// (e.g. tasks simulated by Recording executor).
+type ReadinessInfo interface {
+	// Ready returns true when task is ready, so probes do not have to be run.
+	Ready() bool
+}
+
+// WaitUntilReady blocks until all probes pass one after another.
+// When any probe fails, the task is stopped and error including last lines of task's output is returned.
+// Probes are not run for tasks reporting readiness on their own (see ReadinessInfo).
+func WaitUntilReady(ctx context.Context, handle TaskHandle, probes ...ReadinessProbe) error {
+	if info, ok := handle.(ReadinessInfo); ok && info.Ready() {
+		return nil
+	}
+	for _, probe := range probes {
+		err := probe.wait(ctx, handle)
+		if err == nil {
+			continue
+		}
+
+		if stopErr := handle.Stop(); stopErr != nil {
+			log.Errorf("Cannot stop task %s which is not ready: %s", handle, stopErr.Error())
+		}
+		return errors.Errorf("task %s is not ready: %s%s", handle, err.Error(), outputTail(handle))
+	}
+	return nil
+}
+
+// outputTail returns last lines of task's stdout and stderr (when available).
+func outputTail(handle TaskInfo) (tail string) {
+	for _, stream := range []OutputStream{Stdout, Stderr} {
+		var file *os.File
+		var err error
+		if stream == Stdout {
+			file, err = handle.StdoutFile()
+		} else {
+			file, err = handle.StderrFile()
+		}
+		if err != nil || file == nil {
+			continue
+		}
+		lines, err := tailFile(file.Name(), logLinesCount)
+		file.Close()
+		if err != nil {
+			continue
+		}
+		tail += fmt.Sprintf("\nLast %d lines of %s: %s", logLinesCount, stream, lines)
+	}
+	return tail
+}
+
+// ReadinessLauncher is a decorator and Launcher implementation that returns launched task only when
+// it is ready according to probes. It can be combined with ServiceLauncher for long-running services.
+type ReadinessLauncher struct {
+	Launcher
+	probes []ReadinessProbe
+}
+
+// NewReadinessLauncher is constructor for ReadinessLauncher.
+func NewReadinessLauncher(launcher Launcher, probes ...ReadinessProbe) ReadinessLauncher {
+	return ReadinessLauncher{Launcher: launcher, probes: probes}
+}
+
+// Launch implements Launcher interface.
+func (rl ReadinessLauncher) Launch() (TaskHandle, error) {
+	return rl.LaunchContext(context.Background())
+}
+
+// LaunchContext implements ContextLauncher interface. Waiting for readiness is interrupted when ctx is done.
+func (rl ReadinessLauncher) LaunchContext(ctx context.Context) (TaskHandle, error) {
+	handle, err := LaunchContext(ctx, rl.Launcher)
+	if err != nil {
+		return nil, err
+	}
+
+	err = WaitUntilReady(ctx, handle, rl.probes...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot launch %s", rl.Launcher)
+	}
+	return handle, nil
+}
+
+type tcpCheck struct {
+	address string
+}
+
+// NewTCPCheck returns check passing when TCP connection to address can be established.
+func NewTCPCheck(address string) ProbeCheck {
+	return tcpCheck{address: address}
+}
+
+// Check implements ProbeCheck interface.
+func (c tcpCheck) Check(ctx context.Context, handle TaskHandle) error {
+	connection, err := (&net.Dialer{}).DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return err
+	}
+	return connection.Close()
+}
+
+func (c tcpCheck) String() string {
+	return fmt.Sprintf("TCP probe of %q", c.address)
+}
+
+type httpCheck struct {
+	url string
+}
+
+// NewHTTPCheck returns check passing when HTTP GET of url returns success or redirection status.
+func NewHTTPCheck(url string) ProbeCheck {
+	return httpCheck{url: url}
+}
+
+// Check implements ProbeCheck interface.
+func (c httpCheck) Check(ctx context.Context, handle TaskHandle) error {
+	request, err := http.NewRequest(http.MethodGet, c.url, nil)
+	if err != nil {
+		return errors.Wrapf(err, "invalid url %q", c.url)
+	}
+
+	response, err := http.DefaultClient.Do(request.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("unexpected status %q", response.Status)
+	}
+	return nil
+}
+
+func (c httpCheck) String() string {
+	return fmt.Sprintf("HTTP probe of %q", c.url)
+}
+
+type execCheck struct {
+	executor Executor
+	command  string
+}
+
+// NewExecCheck returns check passing when command executed with executor exits with code 0.
+func NewExecCheck(executor Executor, command string) ProbeCheck {
+	return execCheck{executor: executor, command: command}
+}
+
+// Check implements ProbeCheck interface.
+func (c execCheck) Check(ctx context.Context, handle TaskHandle) error {
+	probe, err := ExecuteContext(ctx, c.executor, c.command)
+	if err != nil {
+		return err
+	}
+	defer probe.EraseOutput()
+
+	if _, err := WaitContext(ctx, probe); err != nil {
+		return err
+	}
+	exitCode, err := probe.ExitCode()
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return errors.Errorf("command exited with code %d", exitCode)
+	}
+	return nil
+}
+
+func (c execCheck) String() string {
+	return fmt.Sprintf("exec probe %q", c.command)
+}
+
+type outputCheck struct {
+	stream  OutputStream
+	pattern *regexp.Regexp
+}
+
+// NewOutputCheck returns check passing when task's output stream matches pattern.
+func NewOutputCheck(stream OutputStream, pattern *regexp.Regexp) ProbeCheck {
+	return outputCheck{stream: stream, pattern: pattern}
+}
+
+// Check implements ProbeCheck interface.
+func (c outputCheck) Check(ctx context.Context, handle TaskHandle) error {
+	var file *os.File
+	var err error
+	if c.stream == Stdout {
+		file, err = handle.StdoutFile()
+	} else {
+		file, err = handle.StderrFile()
+	}
+	if err != nil {
+		return errors.Wrapf(err, "cannot open %s", c.stream)
+	}
+	defer file.Close()
+
+	output, err := ioutil.ReadAll(file)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read %s", c.stream)
+	}
+	if !c.pattern.Match(output) {
+		return errors.Errorf("%s does not match %q", c.stream, c.pattern)
+	}
+	return nil
+}
+
+func (c outputCheck) String() string {
+	return fmt.Sprintf("%s probe %q", c.stream, c.pattern)
+}
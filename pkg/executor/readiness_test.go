@@ -0,0 +1,159 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReadinessProbes(t *testing.T) {
+	Convey("When task writes its output to file", t, func() {
+		stdout, err := ioutil.TempFile("", "readiness")
+		So(err, ShouldBeNil)
+		defer os.Remove(stdout.Name())
+		stdout.WriteString("starting\nlistening on port 1234\n")
+		stdout.Close()
+
+		handle := &fileTaskInfo{filename: stdout.Name()}
+		handle.On("StderrFile").Return(nil, errors.New("no stderr"))
+		handle.On("Stop").Return(nil)
+
+		Convey("Output probe should pass when output matches pattern", func() {
+			probe := NewOutputProbe(Stdout, regexp.MustCompile(`listening on port \d+`))
+			So(WaitUntilReady(context.Background(), handle, probe), ShouldBeNil)
+			handle.AssertNotCalled(t, "Stop")
+		})
+
+		Convey("Task should be stopped when probe fails failure threshold times", func() {
+			probe := NewOutputProbe(Stdout, regexp.MustCompile(`ready`))
+			probe.Interval = time.Millisecond
+			probe.FailureThreshold = 3
+
+			err := WaitUntilReady(context.Background(), handle, probe)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "failed 3 times")
+			So(err.Error(), ShouldContainSubstring, "listening on port 1234")
+			handle.AssertCalled(t, "Stop")
+		})
+
+		Convey("Probe should fail when task terminates", func() {
+			handle.terminate()
+			handle.On("ExitCode").Return(1, nil)
+
+			err := WaitUntilReady(context.Background(), handle, NewOutputProbe(Stdout, regexp.MustCompile(`ready`)))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "task terminated (exit code 1)")
+		})
+
+		Convey("TCP probe should pass when connection can be established", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			So(err, ShouldBeNil)
+			address := listener.Addr().String()
+			So(WaitUntilReady(context.Background(), handle, NewTCPProbe(address)), ShouldBeNil)
+
+			listener.Close()
+			probe := NewTCPProbe(address)
+			probe.FailureThreshold = 1
+			So(WaitUntilReady(context.Background(), handle, probe), ShouldNotBeNil)
+		})
+
+		Convey("HTTP probe should pass only for successful responses", func() {
+			ready := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !ready {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer server.Close()
+
+			probe := NewHTTPProbe(server.URL)
+			probe.FailureThreshold = 1
+			So(WaitUntilReady(context.Background(), handle, probe), ShouldNotBeNil)
+
+			ready = true
+			So(WaitUntilReady(context.Background(), handle, probe), ShouldBeNil)
+		})
+
+		Convey("Exec probe should pass when command exits with code 0", func() {
+			executor := new(MockExecutor)
+			check := new(MockTaskHandle)
+			executor.On("Execute", "check").Return(check, nil)
+			check.On("Wait", 0*time.Second).Return(true, nil)
+			check.On("EraseOutput").Return(nil)
+			check.On("Stop").Return(nil)
+			check.On("ExitCode").Return(0, nil).Once()
+			check.On("ExitCode").Return(1, nil)
+
+			probe := NewExecProbe(executor, "check")
+			So(WaitUntilReady(context.Background(), handle, probe), ShouldBeNil)
+
+			probe.FailureThreshold = 1
+			err := WaitUntilReady(context.Background(), handle, probe)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "command exited with code 1")
+		})
+	})
+
+	Convey("Probes should be run only for tasks that do not report readiness", t, func() {
+		check := &countingCheck{}
+		handle := &readyTaskHandle{}
+		handle.On("String").Return("task")
+		handle.On("Status").Return(RUNNING)
+		probe := NewReadinessProbe(check)
+
+		handle.ready = true
+		So(WaitUntilReady(context.Background(), handle, probe), ShouldBeNil)
+		So(check.checks, ShouldEqual, 0)
+
+		handle.ready = false
+		So(WaitUntilReady(context.Background(), handle, probe), ShouldBeNil)
+		So(check.checks, ShouldEqual, 1)
+	})
+}
+
+// countingCheck always passes and counts how many times it has been run.
+type countingCheck struct {
+	checks int
+}
+
+func (c *countingCheck) Check(ctx context.Context, handle TaskHandle) error {
+	c.checks++
+	return nil
+}
+
+func (c *countingCheck) String() string {
+	return "counting check"
+}
+
+// readyTaskHandle reports readiness on its own.
+type readyTaskHandle struct {
+	MockTaskHandle
+	ready bool
+}
+
+func (h *readyTaskHandle) Ready() bool {
+	return h.ready
+}
@@ -0,0 +1,407 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	dryRunFormatFlag    = conf.NewStringFlag("dry_run_format", fmt.Sprintf("Format of execution plan printed when flag %q is set: 'script' or 'json'.", conf.DryRunFlag.Name), "script")
+	dryRunResponsesFlag = conf.NewStringFlag("dry_run_responses", "(optional) JSON file with results of commands simulated in dry run, e.g.\n"+
+		`[{"pattern": "mutilate .* -A ", "duration": "-1s"}, {"pattern": "mutilate .*--search", "exit_code": 0, "duration": "1s", "stdout": "..."}]`+"\n"+
+		"Negative duration means that task runs until it is stopped. By default tasks exit immediately with exit code 0.", "")
+
+	// dryRunPlan records commands of all executors created with NewDryRun.
+	dryRunPlan = NewPlan()
+	// dryRunResponses are loaded once from file provided by dryRunResponsesFlag.
+	dryRunResponses     []SimulatedResponse
+	dryRunResponsesErr  error
+	dryRunResponsesOnce sync.Once
+)
+
+// PlannedCommand is a command recorded by Recording executor.
+type PlannedCommand struct {
+	Time time.Time `json:"time"`
+	// Target is the host (or environment) where command would be executed.
+	Target string `json:"target"`
+	// Command is the command with all isolation decorations applied.
+	Command string `json:"command"`
+}
+
+// Plan is an ordered list of recorded commands. It is safe for concurrent use.
+type Plan struct {
+	mutex    sync.Mutex
+	commands []PlannedCommand
+}
+
+// NewPlan returns empty plan.
+func NewPlan() *Plan {
+	return &Plan{}
+}
+
+// Record appends command executed on target to the plan.
+func (p *Plan) Record(target, command string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.commands = append(p.commands, PlannedCommand{Time: time.Now(), Target: target, Command: command})
+}
+
+// Commands returns copy of recorded commands.
+func (p *Plan) Commands() []PlannedCommand {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]PlannedCommand(nil), p.commands...)
+}
+
+// WriteScript writes plan as a shell script. Every command is preceded by a comment with time
+// (relative to the first command) and target of the command.
+func (p *Plan) WriteScript(w io.Writer) error {
+	commands := p.Commands()
+	_, err := fmt.Fprintf(w, "#!/bin/sh\n# Execution plan (%d commands).\n", len(commands))
+	if err != nil {
+		return err
+	}
+	for _, command := range commands {
+		_, err = fmt.Fprintf(w, "\n# +%s on %s\n%s\n", command.Time.Sub(commands[0].Time), command.Target, command.Command)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes plan as JSON array of commands.
+func (p *Plan) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(p.Commands())
+}
+
+// SimulatedTask describes result of task simulated by Recording executor.
+type SimulatedTask struct {
+	ExitCode int
+	// Duration after which task terminates. Negative duration means that task runs until it is stopped.
+	Duration time.Duration
+	// Stdout is written to stdout file of the task when it starts.
+	Stdout string
+}
+
+// SimulatedResponse is a simulated task for commands matching Pattern.
+type SimulatedResponse struct {
+	Pattern *regexp.Regexp
+	Task    SimulatedTask
+}
+
+// LoadSimulatedResponses reads simulated responses from JSON file (see dry_run_responses flag).
+func LoadSimulatedResponses(path string) ([]SimulatedResponse, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read simulated responses from %q", path)
+	}
+
+	var entries []struct {
+		Pattern  string `json:"pattern"`
+		ExitCode int    `json:"exit_code"`
+		Duration string `json:"duration"`
+		Stdout   string `json:"stdout"`
+	}
+	err = json.Unmarshal(content, &entries)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse simulated responses from %q", path)
+	}
+
+	var responses []SimulatedResponse
+	for _, entry := range entries {
+		pattern, err := regexp.Compile(entry.Pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern %q in %q", entry.Pattern, path)
+		}
+		var duration time.Duration
+		if entry.Duration != "" {
+			duration, err = time.ParseDuration(entry.Duration)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid duration of %q in %q", entry.Pattern, path)
+			}
+		}
+		responses = append(responses, SimulatedResponse{
+			Pattern: pattern,
+			Task:    SimulatedTask{ExitCode: entry.ExitCode, Duration: duration, Stdout: entry.Stdout},
+		})
+	}
+	return responses, nil
+}
+
+// RecordingConfig is configuration for Recording executor.
+type RecordingConfig struct {
+	// Target is the name of host (or environment) recorded with commands.
+	Target string
+	// Plan records executed commands.
+	Plan *Plan
+	// Responses are matched with decorated commands in order; first matching response is used.
+	Responses []SimulatedResponse
+	// Default is used for commands not matching any of the responses.
+	Default    SimulatedTask
+	Decorators isolation.Decorators
+}
+
+// Recording executor does not execute commands. It records every decorated command in a plan
+// and returns simulated task handles.
+type Recording struct {
+	config RecordingConfig
+}
+
+// NewRecording returns Recording executor.
+func NewRecording(config RecordingConfig) Recording {
+	if config.Plan == nil {
+		config.Plan = NewPlan()
+	}
+	return Recording{config: config}
+}
+
+// NewDryRun returns Recording executor which records commands in plan shared by all dry run executors
+// (see WriteDryRunPlan) and simulates tasks with responses from dry_run_responses flag.
+func NewDryRun(target string, defaultTask SimulatedTask, decorators ...isolation.Decorator) (Executor, error) {
+	dryRunResponsesOnce.Do(func() {
+		if path := dryRunResponsesFlag.Value(); path != "" {
+			dryRunResponses, dryRunResponsesErr = LoadSimulatedResponses(path)
+		}
+	})
+	if dryRunResponsesErr != nil {
+		return nil, dryRunResponsesErr
+	}
+
+	return NewRecording(RecordingConfig{
+		Target:     target,
+		Plan:       dryRunPlan,
+		Responses:  dryRunResponses,
+		Default:    defaultTask,
+		Decorators: decorators,
+	}), nil
+}
+
+// WriteDryRunPlan writes commands recorded by dry run executors in format from dry_run_format flag.
+func WriteDryRunPlan(w io.Writer) error {
+	switch format := dryRunFormatFlag.Value(); format {
+	case "script":
+		return dryRunPlan.WriteScript(w)
+	case "json":
+		return dryRunPlan.WriteJSON(w)
+	default:
+		return errors.Errorf("unknown execution plan format %q", format)
+	}
+}
+
+// String returns user-friendly name of executor.
+func (r Recording) String() string {
+	return fmt.Sprintf("Recording executor for %s", r.config.Target)
+}
+
+// Execute records decorated command and returns simulated task handle.
+func (r Recording) Execute(command string) (TaskHandle, error) {
+	return r.ExecuteContext(context.Background(), command)
+}
+
+// ExecuteContext records decorated command and returns simulated task handle, which is stopped when ctx is done.
+func (r Recording) ExecuteContext(ctx context.Context, command string) (TaskHandle, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrapf(err, "command %q not started", command)
+	}
+
+	decorated := r.config.Decorators.Decorate(command)
+	r.config.Plan.Record(r.config.Target, decorated)
+	log.Debugf("Recording executor: %q on %s", decorated, r.config.Target)
+
+	task := r.config.Default
+	for _, response := range r.config.Responses {
+		if response.Pattern.MatchString(decorated) {
+			task = response.Task
+			break
+		}
+	}
+
+	handle, err := newSimulatedTaskHandle(command, r.config.Target, task)
+	if err != nil {
+		return nil, err
+	}
+	stopWhenDone(ctx, handle)
+	return handle, nil
+}
+
+// simulatedTaskHandle is a TaskHandle of task simulated by Recording executor.
+type simulatedTaskHandle struct {
+	command        string
+	target         string
+	stdoutFilePath string
+	stderrFilePath string
+
+	requestStop chan struct{}
+	terminated  chan struct{}
+
+	mutex  sync.Mutex
+	status TerminationStatus
+}
+
+func newSimulatedTaskHandle(command, target string, task SimulatedTask) (*simulatedTaskHandle, error) {
+	outputDirectory, err := ioutil.TempDir("", "swan-dry-run-")
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot create output directory for command %q", command)
+	}
+	stdoutFile, stderrFile, err := createExecutorOutputFiles(outputDirectory)
+	if err != nil {
+		removeDirectory(outputDirectory)
+		return nil, errors.Wrapf(err, "cannot create output files for command %q", command)
+	}
+	defer stderrFile.Close()
+	defer stdoutFile.Close()
+	if _, err := stdoutFile.WriteString(task.Stdout); err != nil {
+		removeDirectory(outputDirectory)
+		return nil, errors.Wrapf(err, "cannot write output of command %q", command)
+	}
+
+	handle := &simulatedTaskHandle{
+		command:        command,
+		target:         target,
+		stdoutFilePath: stdoutFile.Name(),
+		stderrFilePath: stderrFile.Name(),
+		requestStop:    make(chan struct{}),
+		terminated:     make(chan struct{}),
+		status:         TerminationStatus{ExitCode: task.ExitCode},
+	}
+
+	if task.Duration == 0 {
+		close(handle.terminated)
+		return handle, nil
+	}
+
+	go func() {
+		var timeout <-chan time.Time
+		if task.Duration > 0 {
+			timeout = time.After(task.Duration)
+		}
+		select {
+		case <-timeout:
+		case <-handle.requestStop:
+			handle.mutex.Lock()
+			handle.status = TerminationStatus{ExitCode: -1, Signal: syscall.SIGKILL, Stopped: true}
+			handle.mutex.Unlock()
+		}
+		close(handle.terminated)
+	}()
+	return handle, nil
+}
+
+func (th *simulatedTaskHandle) isTerminated() bool {
+	select {
+	case <-th.terminated:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop terminates simulated task.
+func (th *simulatedTaskHandle) Stop() error {
+	th.mutex.Lock()
+	if !th.isTerminated() && !th.status.Stopped {
+		th.status.Stopped = true
+		close(th.requestStop)
+	}
+	th.mutex.Unlock()
+
+	<-th.terminated
+	return nil
+}
+
+// Ready implements ReadinessInfo interface. Nothing listens for readiness probes of simulated task,
+// so it is always ready.
+func (th *simulatedTaskHandle) Ready() bool {
+	return true
+}
+
+// Status returns a state of the task.
+func (th *simulatedTaskHandle) Status() TaskState {
+	if th.isTerminated() {
+		return TERMINATED
+	}
+	return RUNNING
+}
+
+// ExitCode returns simulated exit code of terminated task.
+func (th *simulatedTaskHandle) ExitCode() (int, error) {
+	status, err := th.TerminationStatus()
+	return status.ExitCode, err
+}
+
+// TerminationStatus returns simulated status of terminated task.
+func (th *simulatedTaskHandle) TerminationStatus() (TerminationStatus, error) {
+	if !th.isTerminated() {
+		return TerminationStatus{}, errors.Errorf("simulated task %q is not terminated", th.command)
+	}
+
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	return th.status, nil
+}
+
+// Wait waits for simulated task to terminate.
+func (th *simulatedTaskHandle) Wait(timeout time.Duration) (bool, error) {
+	select {
+	case <-th.terminated:
+		return true, nil
+	case <-getTimeoutChan(timeout):
+		return false, nil
+	}
+}
+
+// EraseOutput removes output directory of the task.
+func (th *simulatedTaskHandle) EraseOutput() error {
+	return removeDirectory(filepath.Dir(th.stdoutFilePath))
+}
+
+// String returns user-friendly name of the task.
+func (th *simulatedTaskHandle) String() string {
+	return fmt.Sprintf("Simulated command %q on %s", th.command, th.target)
+}
+
+// Address returns target of the task.
+func (th *simulatedTaskHandle) Address() string {
+	return th.target
+}
+
+// StdoutFile returns file with simulated stdout.
+func (th *simulatedTaskHandle) StdoutFile() (*os.File, error) {
+	return openFile(th.stdoutFilePath)
+}
+
+// StderrFile returns empty stderr file.
+func (th *simulatedTaskHandle) StderrFile() (*os.File, error) {
+	return openFile(th.stderrFilePath)
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRecording(t *testing.T) {
+	Convey("Having recording executor with decorators and simulated responses", t, func() {
+		plan := NewPlan()
+		recording := NewRecording(RecordingConfig{
+			Target: "host",
+			Plan:   plan,
+			Responses: []SimulatedResponse{
+				{Pattern: regexp.MustCompile("^taskset -c 1 failing"), Task: SimulatedTask{ExitCode: 3, Stdout: "output\n"}},
+				{Pattern: regexp.MustCompile("service"), Task: SimulatedTask{Duration: -1}},
+				{Pattern: regexp.MustCompile("sleeping"), Task: SimulatedTask{Duration: 100 * time.Millisecond}},
+			},
+			Decorators: isolation.Decorators{isolation.Taskset{CPUList: isolation.NewIntSet(1)}},
+		})
+
+		Convey("Decorated commands should be recorded", func() {
+			handle, err := recording.Execute("first")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+			handle, err = recording.Execute("second")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+
+			commands := plan.Commands()
+			So(commands, ShouldHaveLength, 2)
+			So(commands[0].Target, ShouldEqual, "host")
+			So(commands[0].Command, ShouldEqual, "taskset -c 1 first")
+			So(commands[1].Command, ShouldEqual, "taskset -c 1 second")
+			So(commands[1].Time, ShouldHappenOnOrAfter, commands[0].Time)
+
+			Convey("Plan should be written as script", func() {
+				var script bytes.Buffer
+				So(plan.WriteScript(&script), ShouldBeNil)
+				So(script.String(), ShouldStartWith, "#!/bin/sh\n")
+				So(script.String(), ShouldContainSubstring, "on host\ntaskset -c 1 first\n")
+				So(strings.Index(script.String(), "first"), ShouldBeLessThan, strings.Index(script.String(), "second"))
+			})
+
+			Convey("Plan should be written as JSON", func() {
+				var output bytes.Buffer
+				So(plan.WriteJSON(&output), ShouldBeNil)
+				var decoded []PlannedCommand
+				So(json.Unmarshal(output.Bytes(), &decoded), ShouldBeNil)
+				So(decoded, ShouldHaveLength, 2)
+				So(decoded[1].Command, ShouldEqual, "taskset -c 1 second")
+			})
+		})
+
+		Convey("Tasks not matching any response should terminate immediately with default result", func() {
+			handle, err := recording.Execute("command")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+
+			So(handle.Status(), ShouldEqual, TERMINATED)
+			exitCode, err := handle.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, 0)
+		})
+
+		Convey("Task matching response should have simulated exit code and stdout", func() {
+			handle, err := recording.Execute("failing")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+
+			exitCode, err := handle.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, 3)
+
+			stdout, err := handle.StdoutFile()
+			So(err, ShouldBeNil)
+			defer stdout.Close()
+			content, err := ioutil.ReadAll(stdout)
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "output\n")
+		})
+
+		Convey("Task with duration should terminate after the duration", func() {
+			handle, err := recording.Execute("sleeping")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+
+			So(handle.Status(), ShouldEqual, RUNNING)
+			terminated, err := handle.Wait(5 * time.Second)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+			status, err := GetTerminationStatus(handle)
+			So(err, ShouldBeNil)
+			So(status.Stopped, ShouldBeFalse)
+		})
+
+		Convey("Task running until stopped should be stopped", func() {
+			handle, err := recording.Execute("service")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+
+			terminated, err := handle.Wait(10 * time.Millisecond)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeFalse)
+			_, err = handle.ExitCode()
+			So(err, ShouldNotBeNil)
+
+			So(handle.Stop(), ShouldBeNil)
+			So(handle.Status(), ShouldEqual, TERMINATED)
+			status, err := GetTerminationStatus(handle)
+			So(err, ShouldBeNil)
+			So(status.Stopped, ShouldBeTrue)
+			So(handle.Stop(), ShouldBeNil)
+		})
+
+		Convey("Task running until stopped should be stopped when context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			handle, err := recording.ExecuteContext(ctx, "service")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+
+			cancel()
+			terminated, err := handle.Wait(5 * time.Second)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+		})
+
+		Convey("Simulated task should be ready without checking probes", func() {
+			handle, err := recording.Execute("service")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+			defer handle.Stop()
+
+			So(WaitUntilReady(context.Background(), handle, NewTCPProbe("127.0.0.1:1")), ShouldBeNil)
+		})
+	})
+
+	Convey("Having file with simulated responses", t, func() {
+		directory, err := ioutil.TempDir("", "swan-responses")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(directory)
+		file := path.Join(directory, "responses.json")
+
+		Convey("Responses should be loaded", func() {
+			So(ioutil.WriteFile(file, []byte(`[{"pattern": "mutilate", "exit_code": 1, "duration": "2s", "stdout": "qps"}, {"pattern": "memcached", "duration": "-1s"}]`), 0644), ShouldBeNil)
+			responses, err := LoadSimulatedResponses(file)
+			So(err, ShouldBeNil)
+			So(responses, ShouldHaveLength, 2)
+			So(responses[0].Pattern.MatchString("mutilate -A"), ShouldBeTrue)
+			So(responses[0].Task, ShouldResemble, SimulatedTask{ExitCode: 1, Duration: 2 * time.Second, Stdout: "qps"})
+			So(responses[1].Task.Duration, ShouldBeLessThan, 0)
+		})
+
+		Convey("Invalid pattern should be reported", func() {
+			So(ioutil.WriteFile(file, []byte(`[{"pattern": "("}]`), 0644), ShouldBeNil)
+			_, err := LoadSimulatedResponses(file)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -15,47 +15,108 @@
 package executor
 
 import (
+	"context"
 	"fmt"
-	"io/ioutil"
+	"net"
 	"os"
 	"os/user"
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/intelsdi-x/swan/pkg/conf"
 	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/utils/uuid"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
 )
 
+// sshKeyPassphraseEnv is environment variable with passphrase of encrypted key.
+// Passphrase is not a flag, so it is not recorded in experiment metadata nor visible in process list.
+const sshKeyPassphraseEnv = conf.EnvironmentPrefix + "REMOTE_SSH_KEY_PASSPHRASE"
+
 var (
 	currentUser, _ = user.Current()
 
+	defaultKnownHostsPath = path.Join(currentUser.HomeDir, ".ssh/known_hosts")
+
 	sshUserFlag = conf.NewStringFlag("remote_ssh_user", "Login used for connecting to remote nodes.\n"+
 		"Default value is current user.", currentUser.Name)
 	sshUserKeyPathFlag = conf.NewStringFlag("remote_ssh_key_path", fmt.Sprintf("Key for user in from flag %q used for connecting to remote nodes.\n"+
 		"Default value is '$HOME/.ssh/id_rsa'", sshUserFlag.Name), path.Join(currentUser.HomeDir, ".ssh/id_rsa"))
 
 	sshPortFlag = conf.NewIntFlag("remote_ssh_port", "Port used for SSH connection to remote nodes. ", 22)
+
+	sshKeyPassphraseFileFlag = conf.NewStringFlag("remote_ssh_key_passphrase_file", fmt.Sprintf("File with passphrase of encrypted key from flag %q.\n"+
+		"Passphrase can be also given in %s environment variable.", sshUserKeyPathFlag.Name, sshKeyPassphraseEnv), "")
+	sshAgentFlag      = conf.NewBoolFlag("remote_ssh_agent", "Authenticate with keys from ssh-agent (SSH_AUTH_SOCK).", false)
+	sshKnownHostsFlag = conf.NewStringFlag("remote_ssh_known_hosts", "Path to known_hosts file used to verify host keys of remote nodes.\n"+
+		"Default value is '$HOME/.ssh/known_hosts'", defaultKnownHostsPath)
+	sshInsecureIgnoreHostKeyFlag = conf.NewBoolFlag("remote_ssh_insecure_ignore_host_key", "Connect to remote nodes without verifying their host keys (known_hosts file is not used).", false)
+	sshJumpHostFlag              = conf.NewStringFlag("remote_ssh_jump_host", "Jump host (in form of 'host:port') used for connecting to remote nodes.", "")
+	sshMaxSessionsFlag           = conf.NewIntFlag("remote_ssh_max_sessions", "Maximum number of tasks sharing single SSH connection to remote node (0 disables connection reuse).", 8)
+
+	remoteResourceAccountingFlag = conf.NewBoolFlag("remote_resource_accounting", "Account resources used by remote tasks (requires GNU time installed as /usr/bin/time on remote nodes).", false)
+	remoteFetchOutputFlag        = conf.NewBoolFlag("remote_fetch_output", "Copy output files of remote tasks over SFTP when tasks terminate (instead of relying on output streamed through pseudo-terminal).", true)
 )
 
 // RemoteConfig is configuration for Remote Executor.
 type RemoteConfig struct {
 	User    string
 	KeyPath string
+	// KeyPassphrase decrypts encrypted key.
+	KeyPassphrase string
+	// KeyPassphraseFile contains passphrase used when KeyPassphrase is empty.
+	KeyPassphraseFile string
+	// UseAgent authenticates with keys from ssh-agent.
+	UseAgent bool
 
 	Port int
+
+	// KnownHostsPath is a known_hosts file used to verify host keys.
+	KnownHostsPath string
+	// InsecureIgnoreHostKey disables host key verification (KnownHostsPath is not used then).
+	InsecureIgnoreHostKey bool
+	// JumpHost (in form of 'host:port') is used to connect to remote host when it is not empty.
+	JumpHost string
+	// MaxSessionsPerConnection is a limit of tasks sharing single SSH connection (0 disables connection reuse).
+	// It should be lower than MaxSessions of remote SSH server, because every task uses additional sessions to be stopped.
+	MaxSessionsPerConnection int
+
+	// ResourceAccounting wraps commands with GNU time, so resource usage of remote tasks is available.
+	ResourceAccounting bool
+
+	// FetchOutput makes remote tasks write copies of their stdout and stderr to files on remote host,
+	// which replace local output files when tasks terminate. Output streamed through pseudo-terminal
+	// has stderr merged into stdout and line endings converted, so it is used only while task runs.
+	FetchOutput bool
+
+	// StopPolicy describes signals sent to remote tasks when they are stopped.
+	// By default (empty policy) ssh session is closed, so session processes receive SIGHUP.
+	StopPolicy StopPolicy
 }
 
 // DefaultRemoteConfig returns default Remote Executor configuration from flags.
 func DefaultRemoteConfig() RemoteConfig {
 	return RemoteConfig{
-		User:    sshUserFlag.Value(),
-		KeyPath: sshUserKeyPathFlag.Value(),
-		Port:    sshPortFlag.Value(),
+		User:              sshUserFlag.Value(),
+		KeyPath:           sshUserKeyPathFlag.Value(),
+		KeyPassphrase:     os.Getenv(sshKeyPassphraseEnv),
+		KeyPassphraseFile: sshKeyPassphraseFileFlag.Value(),
+		UseAgent:          sshAgentFlag.Value(),
+		Port:              sshPortFlag.Value(),
+
+		KnownHostsPath:           sshKnownHostsFlag.Value(),
+		InsecureIgnoreHostKey:    sshInsecureIgnoreHostKeyFlag.Value(),
+		JumpHost:                 sshJumpHostFlag.Value(),
+		MaxSessionsPerConnection: sshMaxSessionsFlag.Value(),
+
+		ResourceAccounting: remoteResourceAccountingFlag.Value(),
+		FetchOutput:        remoteFetchOutputFlag.Value(),
 	}
 }
 
@@ -82,19 +143,11 @@ func NewRemote(address string, config RemoteConfig) (Executor, error) {
 
 // NewRemoteIsolated returns a remote executor instance.
 func NewRemoteIsolated(address string, config RemoteConfig, decorators isolation.Decorators) (Executor, error) {
-	authMethod, err := getAuthMethod(config.KeyPath)
+	clientConfig, err := newSSHClientConfig(config)
 	if err != nil {
 		return nil, err
 	}
 
-	clientConfig := &ssh.ClientConfig{
-		User: config.User,
-		Auth: []ssh.AuthMethod{
-			authMethod,
-		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-	}
-
 	return Remote{
 		targetHost:        address,
 		config:            config,
@@ -103,19 +156,30 @@ func NewRemoteIsolated(address string, config RemoteConfig, decorators isolation
 	}, nil
 }
 
-// getAuthMethod which uses given key.
-func getAuthMethod(keyPath string) (ssh.AuthMethod, error) {
-	buffer, err := ioutil.ReadFile(keyPath)
+// Push copies local file or directory to the remote host over SFTP.
+func (remote Remote) Push(ctx context.Context, localPath, targetPath string) error {
+	connection, release, err := remote.connect(ctx)
 	if err != nil {
-		return nil, errors.Wrapf(err, "reading key %q failed", keyPath)
+		return errors.Wrapf(err, "cannot connect to %q to copy %q", remote.targetHost, localPath)
 	}
+	defer release()
+
+	return transferFiles(ctx, connection, func(target fileSystem) error {
+		return copyTree(ctx, localFileSystem{}, localPath, target, targetPath)
+	})
+}
 
-	key, err := ssh.ParsePrivateKey(buffer)
+// Pull copies file or directory from the remote host over SFTP.
+func (remote Remote) Pull(ctx context.Context, targetPath, localPath string) error {
+	connection, release, err := remote.connect(ctx)
 	if err != nil {
-		return nil, errors.Wrapf(err, "parsing private key %q failed", keyPath)
+		return errors.Wrapf(err, "cannot connect to %q to copy %q", remote.targetHost, targetPath)
 	}
+	defer release()
 
-	return ssh.PublicKeys(key), nil
+	return transferFiles(ctx, connection, func(target fileSystem) error {
+		return copyTree(ctx, target, targetPath, localFileSystem{}, localPath)
+	})
 }
 
 // String returns User-friendly name of executor.
@@ -126,28 +190,39 @@ func (remote Remote) String() string {
 // Execute runs the command given as input.
 // Returned Task Handle is able to stop & monitor the provisioned process.
 func (remote Remote) Execute(command string) (TaskHandle, error) {
-	connectionCommand := fmt.Sprintf("%s:%d", remote.targetHost, remote.config.Port)
-	connection, err := ssh.Dial(
-		"tcp",
-		connectionCommand,
-		remote.clientConfig)
+	return remote.ExecuteContext(context.Background(), command)
+}
+
+// ExecuteContext runs the command given as input and stops it when ctx is done.
+// Returned Task Handle is able to stop & monitor the provisioned process.
+func (remote Remote) ExecuteContext(ctx context.Context, command string) (TaskHandle, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrapf(err, "command %q not started", command)
+	}
+
+	connection, releaseConnection, err := remote.connect(ctx)
 	if err != nil {
-		return nil, errors.Wrapf(err, "ssh.Dial to '%s@%s' for command %q failed",
-			remote.clientConfig.User, connectionCommand, command)
+		return nil, errors.Wrapf(err, "ssh.Dial to '%s@%s:%d' for command %q failed",
+			remote.clientConfig.User, remote.targetHost, remote.config.Port, command)
 	}
 
 	session, err := newSessionWithPty(connection)
 	if err != nil {
+		releaseConnection()
 		return nil, errors.Wrapf(err, "connection.sewSessionWithPty for command %q failed with error %v", command, err)
 	}
 
 	output, err := createOutputDirectory(command, "remote")
 	if err != nil {
+		session.Close()
+		releaseConnection()
 		return nil, errors.Wrapf(err, "createOutputDirectory for command %q failed", command)
 	}
 	stdoutFile, stderrFile, err := createExecutorOutputFiles(output)
 	if err != nil {
 		removeDirectory(output)
+		session.Close()
+		releaseConnection()
 		return nil, errors.Wrapf(err, "createExecutorOutputFiles for command %q failed", command)
 	}
 
@@ -164,9 +239,34 @@ func (remote Remote) Execute(command string) (TaskHandle, error) {
 
 	stringForSh = fmt.Sprintf("%s", stringForSh)
 
+	// Resource usage is written by GNU time to a file on the remote host and read when task terminates.
+	usageFile := ""
+	if remote.config.ResourceAccounting {
+		usageFile = path.Join("/tmp", "swan-usage-"+uuid.New())
+		stringForSh = timeDecorate(stringForSh, usageFile)
+	}
+
+	// Copies of output are written to files on the remote host and fetched when task terminates.
+	outputDirectory := ""
+	if remote.config.FetchOutput {
+		outputDirectory = path.Join("/tmp", "swan-output-"+uuid.New())
+		stringForSh = teeDecorate(stringForSh, outputDirectory)
+	}
+
+	// PID of remote shell (which is the leader of process group and session of the task)
+	// is written to a file on the remote host, so signals of stop policy can be sent to the task.
+	stopPolicy := stopPolicyFromContext(ctx, remote.config.StopPolicy)
+	pidFile := ""
+	if !stopPolicy.IsEmpty() {
+		pidFile = path.Join("/tmp", "swan-pid-"+uuid.New())
+		stringForSh = fmt.Sprintf("echo $$ > %s; %s", pidFile, stringForSh)
+	}
+
 	log.Debug("Starting '", stringForSh, "' remotely on '", remote.targetHost, "'")
 	err = session.Start(stringForSh)
 	if err != nil {
+		session.Close()
+		releaseConnection()
 		return nil, errors.Wrapf(err, "session.Start for command %q failed", command)
 	}
 
@@ -187,6 +287,8 @@ func (remote Remote) Execute(command string) (TaskHandle, error) {
 		stderrFilePath:   stderrFile.Name(),
 		host:             remote.targetHost,
 		exitCode:         errorExitCode,
+		stopPolicy:       stopPolicy,
+		pidFile:          pidFile,
 		hasProcessExited: hasProcessExited,
 	}
 
@@ -194,7 +296,7 @@ func (remote Remote) Execute(command string) (TaskHandle, error) {
 	go func() {
 		defer func() {
 			session.Close()
-			connection.Close()
+			releaseConnection()
 		}()
 		taskHandle.exitCode = successExitCode
 		// Wait for task completion.
@@ -207,10 +309,19 @@ func (remote Remote) Execute(command string) (TaskHandle, error) {
 				log.Panicf("Waiting for remote task failed %+v", err)
 			} else {
 				taskHandle.exitCode = exitError.Waitmsg.ExitStatus()
+				taskHandle.signal = signalNames[strings.TrimPrefix(exitError.Waitmsg.Signal(), "SIG")]
 			}
 		}
-		close(hasProcessExited)
 
+		if usageFile != "" {
+			taskHandle.usage, taskHandle.usageErr = readRemoteUsage(connection, usageFile)
+		}
+		if pidFile != "" {
+			removeRemoteFile(connection, pidFile)
+		}
+
+		// Output files are synced before termination is announced, so task output
+		// is complete as soon as Wait() returns.
 		err = syncAndClose(stdoutFile)
 		if err != nil {
 			log.Errorf("Cannot syncAndClose stdout file: %s", err.Error())
@@ -220,6 +331,15 @@ func (remote Remote) Execute(command string) (TaskHandle, error) {
 			log.Errorf("Cannot syncAndClose stderrFile file: %s", err.Error())
 		}
 
+		if outputDirectory != "" {
+			err = fetchRemoteOutput(connection, outputDirectory, stdoutFile.Name(), stderrFile.Name())
+			if err != nil {
+				log.Warnf("Cannot fetch output of remote task %q (output streamed through ssh session is kept): %s", command, err.Error())
+			}
+			removeRemoteFile(connection, outputDirectory)
+		}
+		close(hasProcessExited)
+
 		log.Debugf("Remote Executor: task %q exited with code %d", command, taskHandle.exitCode)
 	}()
 
@@ -229,6 +349,7 @@ func (remote Remote) Execute(command string) (TaskHandle, error) {
 	if err != nil {
 		return nil, err
 	}
+	stopWhenDone(ctx, &taskHandle)
 	return &taskHandle, nil
 }
 
@@ -247,6 +368,89 @@ type remoteTaskHandle struct {
 	// This channel is closed immediately when process exits.
 	// It is used to signal task termination.
 	hasProcessExited chan struct{}
+
+	// usage is read from remote host (before hasProcessExited is closed) when resource accounting is enabled.
+	usage    *ResourceUsage
+	usageErr error
+	// signal is set before hasProcessExited is closed when remote process has been killed by signal.
+	signal syscall.Signal
+
+	stopPolicy StopPolicy
+	// pidFile on remote host contains PID of the shell running the task (when stop policy is not empty).
+	pidFile string
+
+	mutex   sync.Mutex
+	stopped bool
+}
+
+// readRemoteUsage reads and removes file with resource usage written by GNU time on remote host.
+func readRemoteUsage(connection *ssh.Client, usageFile string) (*ResourceUsage, error) {
+	session, err := connection.NewSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create ssh session to read resource usage")
+	}
+	defer session.Close()
+
+	output, err := session.Output(fmt.Sprintf("cat %s && rm -f %s", usageFile, usageFile))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read resource usage from %q", usageFile)
+	}
+
+	usage, err := parseTimeOutput(string(output))
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// teeDecorate makes command write copies of its stdout and stderr to files in directory on remote host,
+// while output is still streamed through ssh session. Command runs in subshell, so shell waits for tee
+// even when command exits, and exit code of command is preserved.
+func teeDecorate(command, directory string) string {
+	stdout := path.Join(directory, "stdout")
+	stderr := path.Join(directory, "stderr")
+	return fmt.Sprintf("mkdir -p %[1]s && mkfifo %[2]s.fifo %[3]s.fifo && "+
+		"{ tee %[2]s < %[2]s.fifo & tee %[3]s < %[3]s.fifo >&2 & } && "+
+		"( %[4]s ) > %[2]s.fifo 2> %[3]s.fifo; rc=$?; wait; exit $rc",
+		directory, stdout, stderr, command)
+}
+
+// fetchRemoteOutput replaces local output files with copies of stdout and stderr written
+// by task decorated with teeDecorate. Local files are replaced atomically, so readers of local
+// output see either streamed or fetched output.
+func fetchRemoteOutput(connection *ssh.Client, directory, stdoutPath, stderrPath string) error {
+	return transferFiles(context.Background(), connection, func(remote fileSystem) error {
+		for remotePath, localPath := range map[string]string{
+			path.Join(directory, "stdout"): stdoutPath,
+			path.Join(directory, "stderr"): stderrPath,
+		} {
+			fetchedPath := localPath + ".fetched"
+			err := copyFile(remote, remotePath, localFileSystem{}, fetchedPath, 0644)
+			if err != nil {
+				os.Remove(fetchedPath)
+				return err
+			}
+			err = os.Rename(fetchedPath, localPath)
+			if err != nil {
+				return errors.Wrapf(err, "cannot replace %q", localPath)
+			}
+		}
+		return nil
+	})
+}
+
+// removeRemoteFile removes file or directory from remote host.
+func removeRemoteFile(connection *ssh.Client, file string) {
+	session, err := connection.NewSession()
+	if err != nil {
+		log.Warnf("Cannot create ssh session to remove %q: %s", file, err.Error())
+		return
+	}
+	defer session.Close()
+
+	if err := session.Run(fmt.Sprintf("rm -rf %s", file)); err != nil {
+		log.Warnf("Cannot remove %q: %s", file, err.Error())
+	}
 }
 
 // isTerminated checks if channel processHasExited is closed. If it is closed, it means
@@ -263,22 +467,70 @@ func (taskHandle *remoteTaskHandle) isTerminated() bool {
 
 // Stop terminates the remote task.
 func (taskHandle *remoteTaskHandle) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), taskHandle.stopPolicy.Timeout()+killWaitTimeout)
+	defer cancel()
+
+	return taskHandle.StopContext(ctx)
+}
+
+// StopContext terminates the remote task and waits for its termination until ctx is done.
+// Without stop policy ssh session is closed, otherwise signals of the policy are sent to the task.
+func (taskHandle *remoteTaskHandle) StopContext(ctx context.Context) error {
 	if taskHandle.isTerminated() {
 		return nil
 	}
 
+	taskHandle.mutex.Lock()
+	taskHandle.stopped = true
+	taskHandle.mutex.Unlock()
+
+	if !taskHandle.stopPolicy.IsEmpty() {
+		err := taskHandle.stopPolicy.escalate(ctx, taskHandle.sendSignal, taskHandle.WaitContext)
+		if err != nil {
+			return errors.Wrapf(err, "cannot stop remote task %q", taskHandle.command)
+		}
+		return nil
+	}
+
 	err := taskHandle.session.Close()
 	if err != nil {
 		return errors.Wrapf(err, "could not close ssh session")
 	}
-	isTerminated, _ := taskHandle.Wait(killWaitTimeout)
-	if !isTerminated {
-		return errors.New("cannot stop ssh session")
+	_, err = taskHandle.WaitContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot stop ssh session")
 	}
 	// No error, task terminated.
 	return nil
 }
 
+// sendSignal sends signal to the remote shell running the task or to its process group
+// (depending on stop policy) using kill command in separate ssh session.
+// Remaining processes of the group receive SIGHUP when session of the task ends.
+func (taskHandle *remoteTaskHandle) sendSignal(signal syscall.Signal) error {
+	session, err := taskHandle.connection.NewSession()
+	if err != nil {
+		if taskHandle.isTerminated() {
+			return nil
+		}
+		return errors.Wrapf(err, "cannot create ssh session to send %s", signal)
+	}
+	defer session.Close()
+
+	target := fmt.Sprintf("$(cat %s)", taskHandle.pidFile)
+	if taskHandle.stopPolicy.ProcessGroup {
+		target = "-- -" + target
+	}
+
+	// Processes may have already terminated, so failures of kill are ignored.
+	command := fmt.Sprintf("kill -%d %s 2>/dev/null || true", int(signal), target)
+	log.Debugf("Sending %s to remote task %q: %s", signal, taskHandle.command, command)
+	if err := session.Run(command); err != nil && !taskHandle.isTerminated() {
+		return errors.Wrapf(err, "cannot send %s", signal)
+	}
+	return nil
+}
+
 // Status returns a state of the task.
 func (taskHandle *remoteTaskHandle) Status() TaskState {
 	if !taskHandle.isTerminated() {
@@ -297,6 +549,23 @@ func (taskHandle *remoteTaskHandle) ExitCode() (int, error) {
 	return taskHandle.exitCode, nil
 }
 
+// TerminationStatus returns status of the terminated task.
+func (taskHandle *remoteTaskHandle) TerminationStatus() (TerminationStatus, error) {
+	if !taskHandle.isTerminated() {
+		return TerminationStatus{}, errors.Errorf("task %q is not terminated", taskHandle.command)
+	}
+
+	status := statusFromExitCode(taskHandle.exitCode)
+	if taskHandle.signal != 0 {
+		status.Signal = taskHandle.signal
+	}
+
+	taskHandle.mutex.Lock()
+	defer taskHandle.mutex.Unlock()
+	status.Stopped = taskHandle.stopped
+	return status, nil
+}
+
 // StdoutFile returns a file handle for file to the task's stdout file.
 func (taskHandle *remoteTaskHandle) StdoutFile() (*os.File, error) {
 	return openFile(taskHandle.stdoutFilePath)
@@ -307,6 +576,21 @@ func (taskHandle *remoteTaskHandle) StderrFile() (*os.File, error) {
 	return openFile(taskHandle.stderrFilePath)
 }
 
+// ResourceUsage returns resources consumed by the terminated task as reported by GNU time on remote host.
+// Resource accounting must be enabled in RemoteConfig.
+func (taskHandle *remoteTaskHandle) ResourceUsage() (ResourceUsage, error) {
+	if !taskHandle.isTerminated() {
+		return ResourceUsage{}, errors.Errorf("task %q is not terminated", taskHandle.command)
+	}
+	if taskHandle.usageErr != nil {
+		return ResourceUsage{}, taskHandle.usageErr
+	}
+	if taskHandle.usage == nil {
+		return ResourceUsage{}, errors.Errorf("resource accounting of remote task %q is disabled", taskHandle.command)
+	}
+	return *taskHandle.usage, nil
+}
+
 // EraseOutput deletes the directory where stdout file resides.
 func (taskHandle *remoteTaskHandle) EraseOutput() error {
 	outputDir := filepath.Dir(taskHandle.stdoutFilePath)
@@ -332,6 +616,17 @@ func (taskHandle *remoteTaskHandle) Wait(timeout time.Duration) (bool, error) {
 	}
 }
 
+// WaitContext waits for the command to finish or ctx to be done.
+// It returns true if task is terminated.
+func (taskHandle *remoteTaskHandle) WaitContext(ctx context.Context) (bool, error) {
+	select {
+	case <-taskHandle.hasProcessExited:
+		return true, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
 func (taskHandle *remoteTaskHandle) String() string {
 	return fmt.Sprintf("Remote command %q running on %s@%s", taskHandle.command, taskHandle.connection.User(), taskHandle.Address())
 }
@@ -340,6 +635,23 @@ func (taskHandle *remoteTaskHandle) Address() string {
 	return taskHandle.host
 }
 
+// dialContext connects to the SSH server like ssh.Dial does, but aborts connecting when ctx is done.
+func dialContext(ctx context.Context, network, address string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	clientConn, channels, requests, err := ssh.NewClientConn(conn, address, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return ssh.NewClient(clientConn, channels, requests), nil
+}
+
 // Killing the remote process related helper functions.
 func newSessionWithPty(connection *ssh.Client) (*ssh.Session, error) {
 	session, err := connection.NewSession()
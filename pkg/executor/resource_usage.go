@@ -0,0 +1,258 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/pkg/errors"
+)
+
+// ResourceUsage describes resources consumed by a terminated task.
+type ResourceUsage struct {
+	UserTime   time.Duration
+	SystemTime time.Duration
+	// MaxRSS is maximum resident set size in bytes.
+	MaxRSS                     int64
+	VoluntaryContextSwitches   int64
+	InvoluntaryContextSwitches int64
+	WallTime                   time.Duration
+}
+
+// Metadata returns resource usage as metadata entries with keys starting with prefix.
+func (u ResourceUsage) Metadata(prefix string) map[string]string {
+	return map[string]string{
+		prefix + "_user_time_seconds":            strconv.FormatFloat(u.UserTime.Seconds(), 'f', -1, 64),
+		prefix + "_system_time_seconds":          strconv.FormatFloat(u.SystemTime.Seconds(), 'f', -1, 64),
+		prefix + "_max_rss_bytes":                strconv.FormatInt(u.MaxRSS, 10),
+		prefix + "_voluntary_context_switches":   strconv.FormatInt(u.VoluntaryContextSwitches, 10),
+		prefix + "_involuntary_context_switches": strconv.FormatInt(u.InvoluntaryContextSwitches, 10),
+		prefix + "_wall_time_seconds":            strconv.FormatFloat(u.WallTime.Seconds(), 'f', -1, 64),
+	}
+}
+
+// ResourceAccounting is implemented by task handles that are able to report resources consumed by task.
+type ResourceAccounting interface {
+	// ResourceUsage returns resources consumed by terminated task.
+	// Returns error if task is not terminated or usage is not known.
+	ResourceUsage() (ResourceUsage, error)
+}
+
+// GetResourceUsage returns resources consumed by terminated task.
+// Returns error if handle does not implement ResourceAccounting.
+func GetResourceUsage(handle TaskInfo) (ResourceUsage, error) {
+	accounting, ok := handle.(ResourceAccounting)
+	if !ok {
+		return ResourceUsage{}, errors.Errorf("%s does not support resource accounting", handle)
+	}
+	return accounting.ResourceUsage()
+}
+
+func rusageToResourceUsage(rusage *syscall.Rusage) ResourceUsage {
+	return ResourceUsage{
+		UserTime:   time.Duration(rusage.Utime.Nano()),
+		SystemTime: time.Duration(rusage.Stime.Nano()),
+		// Linux reports max RSS in kilobytes.
+		MaxRSS:                     rusage.Maxrss * 1024,
+		VoluntaryContextSwitches:   rusage.Nvcsw,
+		InvoluntaryContextSwitches: rusage.Nivcsw,
+	}
+}
+
+// timeFormat is format of GNU time output parsed by parseTimeOutput:
+// wall clock, user and system time in seconds, max RSS in kilobytes, voluntary and involuntary context switches.
+const timeFormat = "%e %U %S %M %w %c"
+
+// timeDecorate wraps command with GNU time writing resource usage to outputFile.
+func timeDecorate(command, outputFile string) string {
+	return fmt.Sprintf("/usr/bin/time -f '%s' -o %s sh -c '%s'",
+		timeFormat, outputFile, strings.Replace(command, "'", `'\''`, -1))
+}
+
+// parseTimeOutput parses resource usage written by GNU time with timeFormat.
+// Only the last line is parsed, because GNU time prepends a line when command is terminated by a signal.
+func parseTimeOutput(output string) (ResourceUsage, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) != 6 {
+		return ResourceUsage{}, errors.Errorf("unexpected output of time %q", output)
+	}
+
+	seconds := make([]time.Duration, 3)
+	for i := range seconds {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return ResourceUsage{}, errors.Wrapf(err, "cannot parse time %q", fields[i])
+		}
+		seconds[i] = time.Duration(value * float64(time.Second))
+	}
+
+	counters := make([]int64, 3)
+	for i := range counters {
+		value, err := strconv.ParseInt(fields[i+3], 10, 64)
+		if err != nil {
+			return ResourceUsage{}, errors.Wrapf(err, "cannot parse counter %q", fields[i+3])
+		}
+		counters[i] = value
+	}
+
+	return ResourceUsage{
+		WallTime:                   seconds[0],
+		UserTime:                   seconds[1],
+		SystemTime:                 seconds[2],
+		MaxRSS:                     counters[0] * 1024,
+		VoluntaryContextSwitches:   counters[1],
+		InvoluntaryContextSwitches: counters[2],
+	}, nil
+}
+
+// userHZ is number of clock ticks per second used in cpuacct.stat.
+const userHZ = 100
+
+// findPodCgroup returns path (relative to hierarchy root) of cgroup of pod with given UID.
+// Both cgroupfs ("pod<uid>") and systemd ("kubepods-...-pod<uid with underscores>.slice") drivers are supported.
+func findPodCgroup(hierarchyRoot, podUID string) (string, error) {
+	root, err := filepath.EvalSymlinks(hierarchyRoot)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot resolve cgroup hierarchy %q", hierarchyRoot)
+	}
+
+	names := []string{"pod" + podUID, "pod" + strings.Replace(podUID, "-", "_", -1) + ".slice"}
+	found := ""
+	err = filepath.Walk(root, func(current string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		relative, _ := filepath.Rel(root, current)
+		if relative != "." && !strings.HasPrefix(relative, "kubepods") {
+			return filepath.SkipDir
+		}
+		for _, name := range names {
+			if strings.HasSuffix(info.Name(), name) {
+				found = relative
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot search for cgroup of pod %q", podUID)
+	}
+	if found == "" {
+		return "", errors.Errorf("cgroup of pod %q not found in %q", podUID, hierarchyRoot)
+	}
+	return found, nil
+}
+
+// podCgroupUsage returns resource usage of pod with given UID read from cgroup statistics.
+// Context switches are not accounted by cgroups.
+func podCgroupUsage(mountRoot, podUID string) (ResourceUsage, error) {
+	if isolation.DetectCgroupHierarchy(mountRoot) == isolation.CgroupV2 {
+		cgroupPath, err := findPodCgroup(mountRoot, podUID)
+		if err != nil {
+			return ResourceUsage{}, err
+		}
+		return unifiedCgroupUsage(path.Join(mountRoot, cgroupPath))
+	}
+
+	cgroupPath, err := findPodCgroup(path.Join(mountRoot, "memory"), podUID)
+	if err != nil {
+		return ResourceUsage{}, err
+	}
+
+	usage := ResourceUsage{}
+	stats, err := readStatFile(path.Join(mountRoot, "cpuacct", cgroupPath, "cpuacct.stat"))
+	if err != nil {
+		return ResourceUsage{}, err
+	}
+	usage.UserTime = time.Duration(stats["user"]) * time.Second / userHZ
+	usage.SystemTime = time.Duration(stats["system"]) * time.Second / userHZ
+
+	usage.MaxRSS, err = readIntFile(path.Join(mountRoot, "memory", cgroupPath, "memory.max_usage_in_bytes"))
+	if err != nil {
+		return ResourceUsage{}, err
+	}
+	return usage, nil
+}
+
+func unifiedCgroupUsage(cgroupPath string) (ResourceUsage, error) {
+	stats, err := readStatFile(path.Join(cgroupPath, "cpu.stat"))
+	if err != nil {
+		return ResourceUsage{}, err
+	}
+	usage := ResourceUsage{
+		UserTime:   time.Duration(stats["user_usec"]) * time.Microsecond,
+		SystemTime: time.Duration(stats["system_usec"]) * time.Microsecond,
+	}
+
+	// memory.peak is available since Linux 5.19; current usage is the best approximation otherwise.
+	usage.MaxRSS, err = readIntFile(path.Join(cgroupPath, "memory.peak"))
+	if os.IsNotExist(errors.Cause(err)) {
+		usage.MaxRSS, err = readIntFile(path.Join(cgroupPath, "memory.current"))
+	}
+	if err != nil {
+		return ResourceUsage{}, err
+	}
+	return usage, nil
+}
+
+// readStatFile reads file with "key value" lines.
+func readStatFile(filename string) (map[string]int64, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open %q", filename)
+	}
+	defer file.Close()
+
+	stats := map[string]int64{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		value, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse %q in %q", fields[1], filename)
+		}
+		stats[fields[0]] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "cannot read %q", filename)
+	}
+	return stats, nil
+}
+
+func readIntFile(filename string) (int64, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot read %q", filename)
+	}
+	value, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot parse content of %q", filename)
+	}
+	return value, nil
+}
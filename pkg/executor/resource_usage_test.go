@@ -0,0 +1,99 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func writeCgroupFile(root, name, content string) {
+	So(os.MkdirAll(path.Dir(path.Join(root, name)), 0755), ShouldBeNil)
+	So(ioutil.WriteFile(path.Join(root, name), []byte(content), 0644), ShouldBeNil)
+}
+
+func TestResourceUsage(t *testing.T) {
+	Convey("GNU time output should be parsed", t, func() {
+		usage, err := parseTimeOutput("Command terminated by signal 9\n1.50 0.75 0.25 2048 10 3\n")
+		So(err, ShouldBeNil)
+		So(usage, ShouldResemble, ResourceUsage{
+			WallTime:                   1500 * time.Millisecond,
+			UserTime:                   750 * time.Millisecond,
+			SystemTime:                 250 * time.Millisecond,
+			MaxRSS:                     2048 * 1024,
+			VoluntaryContextSwitches:   10,
+			InvoluntaryContextSwitches: 3,
+		})
+
+		_, err = parseTimeOutput("sh: 1: /usr/bin/time: not found")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Resource usage should be available as metadata", t, func() {
+		usage := ResourceUsage{UserTime: 1500 * time.Millisecond, MaxRSS: 4096}
+		records := usage.Metadata("phase memcached")
+		So(records, ShouldHaveLength, 6)
+		So(records["phase memcached_user_time_seconds"], ShouldEqual, "1.5")
+		So(records["phase memcached_max_rss_bytes"], ShouldEqual, "4096")
+	})
+
+	Convey("Handles without resource accounting should be reported", t, func() {
+		handle := new(MockTaskHandle)
+		handle.On("String").Return("task")
+		_, err := GetResourceUsage(handle)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("When reading resource usage of pod from cgroups", t, func() {
+		mountRoot, err := ioutil.TempDir("", "pod_cgroup")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(mountRoot)
+
+		Convey("Statistics in cgroup v1 hierarchy should be read", func() {
+			writeCgroupFile(mountRoot, "memory/kubepods/burstable/poduid-1/memory.max_usage_in_bytes", "1048576\n")
+			writeCgroupFile(mountRoot, "cpuacct/kubepods/burstable/poduid-1/cpuacct.stat", "user 250\nsystem 50\n")
+
+			usage, err := podCgroupUsage(mountRoot, "uid-1")
+			So(err, ShouldBeNil)
+			So(usage.UserTime, ShouldEqual, 2500*time.Millisecond)
+			So(usage.SystemTime, ShouldEqual, 500*time.Millisecond)
+			So(usage.MaxRSS, ShouldEqual, 1048576)
+		})
+
+		Convey("Statistics in unified hierarchy with systemd driver should be read", func() {
+			writeCgroupFile(mountRoot, "cgroup.controllers", "cpu memory")
+			pod := "kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-poduid_2.slice"
+			writeCgroupFile(mountRoot, pod+"/cpu.stat", "usage_usec 3000\nuser_usec 2000\nsystem_usec 1000\n")
+			writeCgroupFile(mountRoot, pod+"/memory.current", "4096\n")
+
+			usage, err := podCgroupUsage(mountRoot, "uid-2")
+			So(err, ShouldBeNil)
+			So(usage.UserTime, ShouldEqual, 2*time.Millisecond)
+			So(usage.SystemTime, ShouldEqual, time.Millisecond)
+			So(usage.MaxRSS, ShouldEqual, 4096)
+		})
+
+		Convey("Missing pod cgroup should be reported", func() {
+			writeCgroupFile(mountRoot, "memory/kubepods/memory.max_usage_in_bytes", "0")
+			_, err := podCgroupUsage(mountRoot, "uid-3")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -15,6 +15,7 @@
 package executor
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -51,11 +52,26 @@ func (sl ServiceLauncher) Launch() (TaskHandle, error) {
 	return NewServiceHandle(th), nil
 }
 
+// LaunchContext implements ContextLauncher interface.
+func (sl ServiceLauncher) LaunchContext(ctx context.Context) (TaskHandle, error) {
+	th, err := LaunchContext(ctx, sl.Launcher)
+	if err != nil {
+		return nil, err
+	}
+
+	handle := NewServiceHandle(th).(*serviceHandle)
+	handle.ctx = ctx
+	return handle, nil
+}
+
 type serviceHandle struct {
 	TaskHandle
 	taskHasBeenTerminatedByUser bool
 	err                         error
 	mutex                       *sync.Mutex
+	// ctx is context task is bound to (nil when task was launched without context).
+	// Task stopped because ctx is done has not terminated prematurely.
+	ctx context.Context
 }
 
 // NewServiceHandle wraps TaskHandle with serviceHandle.
@@ -85,6 +101,26 @@ func (s *serviceHandle) Wait(duration time.Duration) (bool, error) {
 	return s.TaskHandle.Wait(duration)
 }
 
+// StopContext implements ContextTaskControl interface.
+func (s *serviceHandle) StopContext(ctx context.Context) error {
+	err := s.checkErrorCondition()
+	if err != nil {
+		return err
+	}
+
+	return StopContext(ctx, s.TaskHandle)
+}
+
+// WaitContext implements ContextTaskControl interface.
+func (s *serviceHandle) WaitContext(ctx context.Context) (bool, error) {
+	err := s.checkErrorCondition()
+	if err != nil {
+		return true, err
+	}
+
+	return WaitContext(ctx, s.TaskHandle)
+}
+
 func (s *serviceHandle) checkErrorCondition() error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -95,6 +131,9 @@ func (s *serviceHandle) checkErrorCondition() error {
 	}
 
 	// When task has been Stopped by user, then no error is returned when task is terminated.
+	if s.ctx != nil && s.ctx.Err() != nil {
+		s.taskHasBeenTerminatedByUser = true
+	}
 	if !s.taskHasBeenTerminatedByUser {
 		s.taskHasBeenTerminatedByUser = true
 		if s.TaskHandle.Status() == TERMINATED {
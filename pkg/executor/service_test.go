@@ -15,6 +15,7 @@
 package executor
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"os"
@@ -187,6 +188,17 @@ func (sl successfulLauncher) String() string {
 	return "Underlying name"
 }
 
+// stoppedLauncher launches tasks which terminate immediately.
+type stoppedLauncher struct {
+	Launcher
+	output *os.File
+}
+
+// Launch implements Launcher interface.
+func (sl stoppedLauncher) Launch() (TaskHandle, error) {
+	return stoppedTaskHandle{output: sl.output}, nil
+}
+
 type failedLauncher struct {
 	Launcher
 }
@@ -213,6 +225,38 @@ func TestServiceLauncher(t *testing.T) {
 		So(err, ShouldEqual, errLaunchFailed)
 	})
 
+	Convey("Task stopped because its context is done should not be reported as terminated prematurely", t, func() {
+		output, err := ioutil.TempFile(os.TempDir(), "serviceTests")
+		So(err, ShouldBeNil)
+		Reset(func() {
+			os.Remove(output.Name())
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		l := ServiceLauncher{stoppedLauncher{output: output}}
+		th, err := l.LaunchContext(ctx)
+		So(err, ShouldBeNil)
+
+		cancel()
+		So(th.Stop(), ShouldBeNil)
+		So(StopContext(context.Background(), th), ShouldBeNil)
+	})
+
+	Convey("Task terminated before its context is done should be reported as terminated prematurely", t, func() {
+		output, err := ioutil.TempFile(os.TempDir(), "serviceTests")
+		So(err, ShouldBeNil)
+		Reset(func() {
+			os.Remove(output.Name())
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		l := ServiceLauncher{stoppedLauncher{output: output}}
+		th, err := l.LaunchContext(ctx)
+		So(err, ShouldBeNil)
+		So(th.Stop(), ShouldNotBeNil)
+	})
+
 	Convey("Launcher name should contain of embedded Launcher name so that it is transparent", t, func() {
 		l := ServiceLauncher{successfulLauncher{}}
 
@@ -1,7 +1,13 @@
 package executor
 
+import "github.com/intelsdi-x/swan/pkg/conf"
+
 // NewShell is a wrapper constructor for NewLocal or NewRemote executor depending on ip provided.
+// In dry run (see conf.DryRunFlag) commands are only recorded by Recording executor targeted at ip.
 func NewShell(ip string) (Executor, error) {
+	if conf.DryRunFlag.Value() {
+		return NewDryRun(ip, SimulatedTask{})
+	}
 	if ip == "127.0.0.1" || ip == "localhost" {
 		return NewLocal(), nil
 	}
@@ -0,0 +1,357 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshIdleConnectionTimeout is time after which pooled connection without sessions is closed.
+var sshIdleConnectionTimeout = 1 * time.Minute
+
+// newSSHClientConfig returns client configuration with authentication methods and host key verification
+// described by config.
+func newSSHClientConfig(config RemoteConfig) (*ssh.ClientConfig, error) {
+	authMethods, err := getAuthMethods(config)
+	if err != nil {
+		return nil, err
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if config.InsecureIgnoreHostKey {
+		log.Warnf("Remote executor: host keys of %s@remote hosts are not verified", config.User)
+	} else {
+		if config.KnownHostsPath == "" {
+			return nil, errors.New("known hosts file is required to verify host keys of remote hosts")
+		}
+		hostKeyCallback, err = knownhosts.New(config.KnownHostsPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read known hosts from %q (verification of host keys can be disabled with %q flag)",
+				config.KnownHostsPath, sshInsecureIgnoreHostKeyFlag.Name)
+		}
+	}
+
+	return &ssh.ClientConfig{
+		User:            config.User,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
+	}, nil
+}
+
+// getAuthMethods returns authentication with private key (possibly encrypted with passphrase) and ssh-agent.
+// Missing private key is not an error when ssh-agent is used.
+func getAuthMethods(config RemoteConfig) (methods []ssh.AuthMethod, err error) {
+	if config.KeyPath != "" {
+		passphrase := config.KeyPassphrase
+		if passphrase == "" && config.KeyPassphraseFile != "" {
+			buffer, err := ioutil.ReadFile(config.KeyPassphraseFile)
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading passphrase file %q failed", config.KeyPassphraseFile)
+			}
+			passphrase = strings.TrimRight(string(buffer), "\r\n")
+		}
+		authMethod, err := getAuthMethod(config.KeyPath, passphrase)
+		if err == nil {
+			methods = append(methods, authMethod)
+		} else if !config.UseAgent || !os.IsNotExist(errors.Cause(err)) {
+			return nil, err
+		} else {
+			log.Debugf("Remote executor: key %q not found, only ssh-agent is used", config.KeyPath)
+		}
+	}
+
+	if config.UseAgent {
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			return nil, errors.New("ssh-agent is not running (SSH_AUTH_SOCK is not set)")
+		}
+		// Connection to the agent is kept open for the lifetime of the executor.
+		agentConnection, err := net.Dial("unix", socket)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot connect to ssh-agent at %q", socket)
+		}
+		methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(agentConnection).Signers))
+	}
+
+	if len(methods) == 0 {
+		return nil, errors.New("neither private key nor ssh-agent is configured")
+	}
+	return methods, nil
+}
+
+// getAuthMethod which uses given key (decrypted with passphrase if it is not empty).
+func getAuthMethod(keyPath, passphrase string) (ssh.AuthMethod, error) {
+	buffer, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading key %q failed", keyPath)
+	}
+
+	var key ssh.Signer
+	if passphrase != "" {
+		key, err = ssh.ParsePrivateKeyWithPassphrase(buffer, []byte(passphrase))
+	} else {
+		key, err = ssh.ParsePrivateKey(buffer)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing private key %q failed", keyPath)
+	}
+
+	return ssh.PublicKeys(key), nil
+}
+
+// dialSSH connects to the SSH server at address directly or through jump host (when it is not empty).
+func dialSSH(ctx context.Context, address, jumpHost string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	if jumpHost == "" {
+		return dialContext(ctx, "tcp", address, config)
+	}
+
+	jump, err := dialContext(ctx, "tcp", jumpHost, config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot connect to jump host %q", jumpHost)
+	}
+
+	conn, err := jump.Dial("tcp", address)
+	if err != nil {
+		jump.Close()
+		return nil, errors.Wrapf(err, "cannot connect to %q through jump host %q", address, jumpHost)
+	}
+
+	clientConn, channels, requests, err := ssh.NewClientConn(conn, address, config)
+	if err != nil {
+		conn.Close()
+		jump.Close()
+		return nil, err
+	}
+
+	client := ssh.NewClient(clientConn, channels, requests)
+	// Connection to jump host is closed with connection it carries.
+	go func() {
+		client.Wait()
+		jump.Close()
+	}()
+	return client, nil
+}
+
+// sshConnectionPool shares SSH connections to the same host between tasks, so sessions of
+// concurrent tasks are multiplexed over single connection instead of paying a handshake per task.
+type sshConnectionPool struct {
+	mutex       sync.Mutex
+	connections map[string][]*pooledConnection
+}
+
+type pooledConnection struct {
+	*ssh.Client
+	key string
+	// sessions is number of tasks using the connection.
+	sessions  int
+	idleTimer *time.Timer
+	closed    bool
+}
+
+// sshPool is shared by all remote executors.
+var sshPool = &sshConnectionPool{connections: map[string][]*pooledConnection{}}
+
+// acquire returns connection identified by key with less than maxSessions sessions or dials new connection.
+func (pool *sshConnectionPool) acquire(key string, maxSessions int, dial func() (*ssh.Client, error)) (*pooledConnection, error) {
+	pool.mutex.Lock()
+	for _, connection := range pool.connections[key] {
+		if connection.sessions < maxSessions {
+			connection.sessions++
+			if connection.idleTimer != nil {
+				connection.idleTimer.Stop()
+				connection.idleTimer = nil
+			}
+			pool.mutex.Unlock()
+			return connection, nil
+		}
+	}
+	pool.mutex.Unlock()
+
+	client, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("SSH connection pool: new connection to %s", key)
+
+	connection := &pooledConnection{Client: client, key: key, sessions: 1}
+	pool.mutex.Lock()
+	pool.connections[key] = append(pool.connections[key], connection)
+	pool.mutex.Unlock()
+
+	// Broken connections are removed from the pool.
+	go func() {
+		client.Wait()
+		pool.remove(connection)
+	}()
+	return connection, nil
+}
+
+// release returns connection to the pool. Connection without sessions is closed after sshIdleConnectionTimeout.
+func (pool *sshConnectionPool) release(connection *pooledConnection) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	connection.sessions--
+	if connection.sessions > 0 || connection.closed {
+		return
+	}
+	connection.idleTimer = time.AfterFunc(sshIdleConnectionTimeout, func() {
+		pool.mutex.Lock()
+		idle := connection.sessions == 0 && !connection.closed
+		if idle {
+			pool.removeLocked(connection)
+		}
+		pool.mutex.Unlock()
+		if idle {
+			log.Debugf("SSH connection pool: closing idle connection to %s", connection.key)
+			connection.Close()
+		}
+	})
+}
+
+func (pool *sshConnectionPool) remove(connection *pooledConnection) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	pool.removeLocked(connection)
+}
+
+// removeLocked removes connection from the pool. Pool mutex must be held.
+func (pool *sshConnectionPool) removeLocked(connection *pooledConnection) {
+	if connection.closed {
+		return
+	}
+	connection.closed = true
+	connections := pool.connections[connection.key]
+	for i, pooled := range connections {
+		if pooled == connection {
+			pool.connections[connection.key] = append(connections[:i], connections[i+1:]...)
+			break
+		}
+	}
+	if len(pool.connections[connection.key]) == 0 {
+		delete(pool.connections, connection.key)
+	}
+}
+
+// connect returns connection to the remote host and function releasing it.
+// When connection reuse is enabled, connection is taken from the pool.
+func (remote Remote) connect(ctx context.Context) (*ssh.Client, func(), error) {
+	address := fmt.Sprintf("%s:%d", remote.targetHost, remote.config.Port)
+	dial := func() (*ssh.Client, error) {
+		return dialSSH(ctx, address, remote.config.JumpHost, remote.clientConfig)
+	}
+
+	if remote.config.MaxSessionsPerConnection <= 0 {
+		connection, err := dial()
+		if err != nil {
+			return nil, nil, err
+		}
+		return connection, func() { connection.Close() }, nil
+	}
+
+	key := fmt.Sprintf("%s@%s via %q", remote.config.User, address, remote.config.JumpHost)
+	connection, err := sshPool.acquire(key, remote.config.MaxSessionsPerConnection, dial)
+	if err != nil {
+		return nil, nil, err
+	}
+	return connection.Client, func() { sshPool.release(connection) }, nil
+}
+
+// sftpFileSystem is a fileSystem of remote host accessed over SFTP.
+type sftpFileSystem struct {
+	client *sftp.Client
+}
+
+func (fs sftpFileSystem) Stat(name string) (os.FileInfo, error)      { return fs.client.Stat(name) }
+func (fs sftpFileSystem) ReadDir(name string) ([]os.FileInfo, error) { return fs.client.ReadDir(name) }
+func (fs sftpFileSystem) Open(name string) (io.ReadCloser, error)    { return fs.client.Open(name) }
+
+func (fs sftpFileSystem) Create(name string, mode os.FileMode) (io.WriteCloser, error) {
+	file, err := fs.client.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return nil, err
+	}
+	err = fs.client.Chmod(name, mode)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// Mkdir creates directory with its parents (like os.MkdirAll does).
+func (fs sftpFileSystem) Mkdir(name string, mode os.FileMode) error {
+	info, err := fs.client.Stat(name)
+	if err == nil {
+		if !info.IsDir() {
+			return errors.Errorf("%q is not a directory", name)
+		}
+		return nil
+	}
+
+	if parent := path.Dir(name); parent != name {
+		err = fs.Mkdir(parent, 0755)
+		if err != nil {
+			return err
+		}
+	}
+	err = fs.client.Mkdir(name)
+	if err != nil {
+		return err
+	}
+	return fs.client.Chmod(name, mode)
+}
+
+// transferFiles runs copy with file system of remote host accessed over SFTP session on connection.
+// SFTP session is closed when ctx is done, what aborts the copy.
+func transferFiles(ctx context.Context, connection *ssh.Client, copy func(remote fileSystem) error) error {
+	client, err := sftp.NewClient(connection)
+	if err != nil {
+		return errors.Wrap(err, "cannot start SFTP session")
+	}
+	defer client.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			client.Close()
+		case <-done:
+		}
+	}()
+
+	err = copy(sftpFileSystem{client})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,517 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/sftp"
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testSSHServer is in-process SSH server executing commands locally.
+// It accepts single authorized key, serves SFTP and supports port forwarding, so it can be used as a jump host.
+type testSSHServer struct {
+	listener    net.Listener
+	config      *ssh.ServerConfig
+	hostKey     ssh.Signer
+	connections int32
+
+	mutex sync.Mutex
+	conns []net.Conn
+}
+
+func newTestSSHServer(authorized ssh.PublicKey) (*testSSHServer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	hostKey, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, ssh.ErrNoAuth
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	server := &testSSHServer{listener: listener, config: config, hostKey: hostKey}
+	go server.serve()
+	return server, nil
+}
+
+func (server *testSSHServer) address() string {
+	return server.listener.Addr().String()
+}
+
+func (server *testSSHServer) port() int {
+	return server.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (server *testSSHServer) knownHostsLine() string {
+	return knownhosts.Line([]string{knownhosts.Normalize(server.address())}, server.hostKey.PublicKey())
+}
+
+func (server *testSSHServer) serve() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		// Connections are counted before handshake, so clients observe the counter updated.
+		atomic.AddInt32(&server.connections, 1)
+		server.mutex.Lock()
+		server.conns = append(server.conns, conn)
+		server.mutex.Unlock()
+		go server.handleConnection(conn)
+	}
+}
+
+// close stops the server and closes all its connections.
+func (server *testSSHServer) close() {
+	server.listener.Close()
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	for _, conn := range server.conns {
+		conn.Close()
+	}
+}
+
+func (server *testSSHServer) handleConnection(conn net.Conn) {
+	_, channels, requests, err := ssh.NewServerConn(conn, server.config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		switch newChannel.ChannelType() {
+		case "session":
+			go handleTestSession(newChannel)
+		case "direct-tcpip":
+			go handleTestForwarding(newChannel)
+		default:
+			newChannel.Reject(ssh.UnknownChannelType, newChannel.ChannelType())
+		}
+	}
+}
+
+func handleTestSession(newChannel ssh.NewChannel) {
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		return
+	}
+	for request := range requests {
+		switch request.Type {
+		case "pty-req":
+			request.Reply(true, nil)
+		case "exec":
+			var payload struct{ Command string }
+			if err := ssh.Unmarshal(request.Payload, &payload); err != nil {
+				request.Reply(false, nil)
+				continue
+			}
+			request.Reply(true, nil)
+			go runTestCommand(channel, payload.Command)
+		case "subsystem":
+			var payload struct{ Name string }
+			if err := ssh.Unmarshal(request.Payload, &payload); err != nil || payload.Name != "sftp" {
+				request.Reply(false, nil)
+				continue
+			}
+			request.Reply(true, nil)
+			go serveTestSFTP(channel)
+		default:
+			request.Reply(false, nil)
+		}
+	}
+}
+
+func runTestCommand(channel ssh.Channel, command string) {
+	defer channel.Close()
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = channel
+	cmd.Stderr = channel.Stderr()
+
+	status := 0
+	if err := cmd.Run(); err != nil {
+		status = 127
+		if exitError, ok := err.(*exec.ExitError); ok {
+			status = exitError.Sys().(syscall.WaitStatus).ExitStatus()
+		}
+	}
+	channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(status)}))
+}
+
+func serveTestSFTP(channel ssh.Channel) {
+	defer channel.Close()
+	server, err := sftp.NewServer(channel)
+	if err != nil {
+		return
+	}
+	server.Serve()
+}
+
+func handleTestForwarding(newChannel ssh.NewChannel) {
+	var payload struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+	if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+	if err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		target.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	go func() {
+		io.Copy(channel, target)
+		channel.Close()
+	}()
+	go func() {
+		io.Copy(target, channel)
+		target.Close()
+	}()
+}
+
+// executeRemotely runs command with remote executor and returns its standard output.
+func executeRemotely(remote Executor, command string) (string, error) {
+	handle, err := remote.Execute(command)
+	if err != nil {
+		return "", err
+	}
+	defer handle.EraseOutput()
+
+	handle.Wait(0)
+	stdout, err := handle.StdoutFile()
+	if err != nil {
+		return "", err
+	}
+	output, err := ioutil.ReadAll(stdout)
+	return string(output), err
+}
+
+func TestRemoteSSH(t *testing.T) {
+	Convey("Having SSH server and user key", t, func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		So(err, ShouldBeNil)
+		signer, err := ssh.NewSignerFromKey(key)
+		So(err, ShouldBeNil)
+
+		server, err := newTestSSHServer(signer.PublicKey())
+		So(err, ShouldBeNil)
+		defer server.close()
+
+		// Connections pooled in other tests must not be reused.
+		sshPool = &sshConnectionPool{connections: map[string][]*pooledConnection{}}
+
+		directory, err := ioutil.TempDir("", "swan-ssh")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(directory)
+
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		So(err, ShouldBeNil)
+		keyPath := path.Join(directory, "id_ecdsa")
+		So(ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600), ShouldBeNil)
+
+		knownHostsPath := path.Join(directory, "known_hosts")
+		So(ioutil.WriteFile(knownHostsPath, []byte(server.knownHostsLine()+"\n"), 0600), ShouldBeNil)
+
+		config := RemoteConfig{
+			User:           "swan",
+			KeyPath:        keyPath,
+			Port:           server.port(),
+			KnownHostsPath: knownHostsPath,
+		}
+
+		Convey("Remote executor should run command on known host", func() {
+			remote, err := NewRemote("127.0.0.1", config)
+			So(err, ShouldBeNil)
+
+			output, err := executeRemotely(remote, "echo hello")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "hello\n")
+		})
+
+		Convey("Remote executor should refuse to connect to host with unknown key", func() {
+			So(ioutil.WriteFile(knownHostsPath, []byte{}, 0600), ShouldBeNil)
+			remote, err := NewRemote("127.0.0.1", config)
+			So(err, ShouldBeNil)
+
+			_, err = remote.Execute("echo hello")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "key is unknown")
+		})
+
+		Convey("Remote executor should fail when known hosts file is missing", func() {
+			config.KnownHostsPath = path.Join(directory, "missing")
+			_, err := NewRemote("127.0.0.1", config)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Remote executor should fail when known hosts file is not given", func() {
+			config.KnownHostsPath = ""
+			_, err := NewRemote("127.0.0.1", config)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Remote executor should connect to unknown host when host key verification is disabled", func() {
+			config.KnownHostsPath = path.Join(directory, "missing")
+			config.InsecureIgnoreHostKey = true
+			remote, err := NewRemote("127.0.0.1", config)
+			So(err, ShouldBeNil)
+
+			output, err := executeRemotely(remote, "echo hello")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "hello\n")
+		})
+
+		Convey("Remote executor should use encrypted key", func() {
+			block, err := x509.EncryptPEMBlock(rand.Reader, "EC PRIVATE KEY", keyDER, []byte("secret"), x509.PEMCipherAES256)
+			So(err, ShouldBeNil)
+			So(ioutil.WriteFile(keyPath, pem.EncodeToMemory(block), 0600), ShouldBeNil)
+
+			_, err = NewRemote("127.0.0.1", config)
+			So(err, ShouldNotBeNil)
+
+			config.KeyPassphrase = "secret"
+			remote, err := NewRemote("127.0.0.1", config)
+			So(err, ShouldBeNil)
+
+			output, err := executeRemotely(remote, "echo encrypted")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "encrypted\n")
+		})
+
+		Convey("Remote executor should read passphrase of encrypted key from file", func() {
+			block, err := x509.EncryptPEMBlock(rand.Reader, "EC PRIVATE KEY", keyDER, []byte("secret"), x509.PEMCipherAES256)
+			So(err, ShouldBeNil)
+			So(ioutil.WriteFile(keyPath, pem.EncodeToMemory(block), 0600), ShouldBeNil)
+
+			config.KeyPassphraseFile = path.Join(directory, "passphrase")
+			_, err = NewRemote("127.0.0.1", config)
+			So(err, ShouldNotBeNil)
+
+			So(ioutil.WriteFile(config.KeyPassphraseFile, []byte("secret\n"), 0600), ShouldBeNil)
+			remote, err := NewRemote("127.0.0.1", config)
+			So(err, ShouldBeNil)
+
+			output, err := executeRemotely(remote, "echo encrypted")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "encrypted\n")
+		})
+
+		Convey("Remote executor should authenticate with ssh-agent", func() {
+			keyring := agent.NewKeyring()
+			So(keyring.Add(agent.AddedKey{PrivateKey: key}), ShouldBeNil)
+
+			socket := path.Join(directory, "agent.sock")
+			listener, err := net.Listen("unix", socket)
+			So(err, ShouldBeNil)
+			defer listener.Close()
+			go func() {
+				for {
+					conn, err := listener.Accept()
+					if err != nil {
+						return
+					}
+					go agent.ServeAgent(keyring, conn)
+				}
+			}()
+
+			previousSocket := os.Getenv("SSH_AUTH_SOCK")
+			os.Setenv("SSH_AUTH_SOCK", socket)
+			defer os.Setenv("SSH_AUTH_SOCK", previousSocket)
+
+			config.KeyPath = path.Join(directory, "missing")
+			config.UseAgent = true
+			remote, err := NewRemote("127.0.0.1", config)
+			So(err, ShouldBeNil)
+
+			output, err := executeRemotely(remote, "echo agent")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "agent\n")
+		})
+
+		Convey("Remote executor should reuse connection for subsequent tasks", func() {
+			config.MaxSessionsPerConnection = 2
+			remote, err := NewRemote("127.0.0.1", config)
+			So(err, ShouldBeNil)
+
+			// Tasks run until barrier file is created.
+			barrier := path.Join(directory, "barrier")
+			command := fmt.Sprintf("while [ ! -f %s ]; do sleep 0.01; done", barrier)
+			release := func() { ioutil.WriteFile(barrier, []byte{}, 0600) }
+			defer release()
+
+			first, err := remote.Execute(command)
+			So(err, ShouldBeNil)
+			defer first.EraseOutput()
+			second, err := remote.Execute(command)
+			So(err, ShouldBeNil)
+			defer second.EraseOutput()
+			So(atomic.LoadInt32(&server.connections), ShouldEqual, 1)
+
+			Convey("But should dial new connection when sessions limit is reached", func() {
+				third, err := remote.Execute(command)
+				So(err, ShouldBeNil)
+				defer third.EraseOutput()
+				So(atomic.LoadInt32(&server.connections), ShouldEqual, 2)
+
+				release()
+				third.Wait(0)
+			})
+
+			release()
+			first.Wait(0)
+			second.Wait(0)
+
+			output, err := executeRemotely(remote, "echo reused")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "reused\n")
+			So(atomic.LoadInt32(&server.connections), ShouldBeLessThanOrEqualTo, 2)
+		})
+
+		Convey("Remote executor should dial new connection for every task when reuse is disabled", func() {
+			remote, err := NewRemote("127.0.0.1", config)
+			So(err, ShouldBeNil)
+
+			for i := 0; i < 2; i++ {
+				_, err := executeRemotely(remote, "true")
+				So(err, ShouldBeNil)
+			}
+			So(atomic.LoadInt32(&server.connections), ShouldEqual, 2)
+		})
+
+		Convey("Remote executor should transfer files over SFTP", func() {
+			remote, err := NewRemote("127.0.0.1", config)
+			So(err, ShouldBeNil)
+
+			source := path.Join(directory, "source")
+			So(os.MkdirAll(path.Join(source, "nested"), 0755), ShouldBeNil)
+			So(ioutil.WriteFile(path.Join(source, "nested", "file"), []byte("content"), 0640), ShouldBeNil)
+
+			target := path.Join(directory, "remote", "target")
+			So(PushFiles(context.Background(), remote, source, target), ShouldBeNil)
+			content, err := ioutil.ReadFile(path.Join(target, "nested", "file"))
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "content")
+			info, err := os.Stat(path.Join(target, "nested", "file"))
+			So(err, ShouldBeNil)
+			So(info.Mode().Perm(), ShouldEqual, os.FileMode(0640))
+
+			pulled := path.Join(directory, "pulled")
+			So(PullFiles(context.Background(), remote, path.Join(target, "nested", "file"), pulled), ShouldBeNil)
+			content, err = ioutil.ReadFile(pulled)
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "content")
+
+			err = PullFiles(context.Background(), remote, path.Join(directory, "missing"), pulled)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Remote executor should fetch output of terminated task", func() {
+			config.FetchOutput = true
+			remote, err := NewRemote("127.0.0.1", config)
+			So(err, ShouldBeNil)
+
+			remoteOutputs, err := filepath.Glob("/tmp/swan-output-*")
+			So(err, ShouldBeNil)
+
+			handle, err := remote.Execute("echo out; echo err >&2; sleep 0.5; exit 3")
+			So(err, ShouldBeNil)
+			defer handle.EraseOutput()
+			handle.Wait(0)
+
+			exitCode, err := handle.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, 3)
+
+			stdout, err := handle.StdoutFile()
+			So(err, ShouldBeNil)
+			output, err := ioutil.ReadAll(stdout)
+			So(err, ShouldBeNil)
+			So(string(output), ShouldEqual, "out\n")
+
+			stderr, err := handle.StderrFile()
+			So(err, ShouldBeNil)
+			output, err = ioutil.ReadAll(stderr)
+			So(err, ShouldBeNil)
+			So(string(output), ShouldEqual, "err\n")
+
+			// Copies of output are removed from remote host.
+			remainingOutputs, err := filepath.Glob("/tmp/swan-output-*")
+			So(err, ShouldBeNil)
+			So(remainingOutputs, ShouldHaveLength, len(remoteOutputs))
+		})
+
+		Convey("Remote executor should connect through jump host", func() {
+			config.JumpHost = server.address()
+			remote, err := NewRemote("127.0.0.1", config)
+			So(err, ShouldBeNil)
+
+			output, err := executeRemotely(remote, "echo jumped")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "jumped\n")
+			// One connection to the jump host and one forwarded through it.
+			So(atomic.LoadInt32(&server.connections), ShouldEqual, 2)
+		})
+	})
+}
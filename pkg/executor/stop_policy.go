@@ -0,0 +1,222 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// StopStep is a single step of stop escalation: Signal is sent to the task
+// and the task is given Grace time to terminate before next step is taken.
+type StopStep struct {
+	Signal syscall.Signal
+	Grace  time.Duration
+}
+
+// StopPolicy describes how executors stop tasks: signals from Steps are sent in order
+// until the task terminates (e.g. SIGINT -> wait -> SIGTERM -> wait -> SIGKILL).
+// Grace of the last step is ignored - after the last signal executor waits until stop context is done.
+// Empty policy means that executor stops tasks in its own default way.
+type StopPolicy struct {
+	Steps []StopStep
+	// ProcessGroup sends signals to the whole process group (or session) of the task
+	// instead of the task process only, so no descendant processes survive.
+	ProcessGroup bool
+}
+
+// KillPolicy kills the whole process group of the task immediately.
+var KillPolicy = StopPolicy{
+	Steps:        []StopStep{{Signal: syscall.SIGKILL}},
+	ProcessGroup: true,
+}
+
+// NewStopPolicy returns policy signalling the whole process group of the task with given steps.
+func NewStopPolicy(steps ...StopStep) StopPolicy {
+	return StopPolicy{Steps: steps, ProcessGroup: true}
+}
+
+// ParseStopPolicy parses policy in format "SIGINT:5s,SIGTERM:10s,SIGKILL" (signals can be given
+// by name with or without "SIG" prefix or by number). Returned policy signals the whole process group.
+func ParseStopPolicy(policy string) (StopPolicy, error) {
+	var steps []StopStep
+	for _, step := range strings.Split(policy, ",") {
+		step = strings.TrimSpace(step)
+		if step == "" {
+			continue
+		}
+
+		parts := strings.SplitN(step, ":", 2)
+		signal, err := parseSignal(parts[0])
+		if err != nil {
+			return StopPolicy{}, errors.Wrapf(err, "invalid stop policy %q", policy)
+		}
+
+		var grace time.Duration
+		if len(parts) == 2 {
+			grace, err = time.ParseDuration(parts[1])
+			if err != nil {
+				return StopPolicy{}, errors.Wrapf(err, "invalid grace period of %q in stop policy %q", step, policy)
+			}
+		}
+		steps = append(steps, StopStep{Signal: signal, Grace: grace})
+	}
+
+	if len(steps) == 0 {
+		return StopPolicy{}, errors.Errorf("stop policy %q has no steps", policy)
+	}
+	return NewStopPolicy(steps...), nil
+}
+
+// parseSignal parses signal name (e.g. "SIGTERM" or "TERM") or number.
+func parseSignal(name string) (syscall.Signal, error) {
+	if number, err := strconv.Atoi(name); err == nil && number > 0 {
+		return syscall.Signal(number), nil
+	}
+	if signal, ok := signalNames[strings.TrimPrefix(strings.ToUpper(name), "SIG")]; ok {
+		return signal, nil
+	}
+	return 0, errors.Errorf("unknown signal %q", name)
+}
+
+// IsEmpty returns true when policy has no steps and executor default way of stopping tasks should be used.
+func (policy StopPolicy) IsEmpty() bool {
+	return len(policy.Steps) == 0
+}
+
+// Timeout returns sum of grace periods of all but the last step, i.e. how long it takes
+// to send the last signal.
+func (policy StopPolicy) Timeout() (timeout time.Duration) {
+	for i := 0; i < len(policy.Steps)-1; i++ {
+		timeout += policy.Steps[i].Grace
+	}
+	return timeout
+}
+
+func (policy StopPolicy) String() string {
+	steps := make([]string, 0, len(policy.Steps))
+	for i, step := range policy.Steps {
+		if i == len(policy.Steps)-1 {
+			steps = append(steps, step.Signal.String())
+			continue
+		}
+		steps = append(steps, fmt.Sprintf("%s:%s", step.Signal, step.Grace))
+	}
+	return strings.Join(steps, ",")
+}
+
+// escalate applies policy: it sends signals using signal function and waits for
+// termination using wait function until the task terminates or ctx is done.
+func (policy StopPolicy) escalate(ctx context.Context, signal func(syscall.Signal) error, wait func(context.Context) (bool, error)) error {
+	for i, step := range policy.Steps {
+		log.Debugf("Stop policy: sending %s (step %d of %d)", step.Signal, i+1, len(policy.Steps))
+		if err := signal(step.Signal); err != nil {
+			return errors.Wrapf(err, "cannot send %s", step.Signal)
+		}
+
+		stepCtx, cancel := ctx, context.CancelFunc(func() {})
+		if i < len(policy.Steps)-1 {
+			stepCtx, cancel = context.WithTimeout(ctx, step.Grace)
+		}
+		terminated, _ := wait(stepCtx)
+		cancel()
+
+		if terminated {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return errors.Wrapf(err, "task has not terminated after %s", step.Signal)
+		}
+	}
+	return errors.New("stop policy has no steps")
+}
+
+// script returns shell script applying policy to processes in PID namespace of the script (e.g. container).
+// With ProcessGroup signals are sent to all processes in the namespace, otherwise to its init process only.
+// Note that init process ignores signals without installed handlers sent from its namespace.
+func (policy StopPolicy) script() string {
+	commands := []string{}
+	for i, step := range policy.Steps {
+		if policy.ProcessGroup {
+			commands = append(commands, fmt.Sprintf("kill -%d -- -1 2>/dev/null", int(step.Signal)))
+		}
+		commands = append(commands, fmt.Sprintf("kill -%d 1 2>/dev/null", int(step.Signal)))
+		if i < len(policy.Steps)-1 {
+			commands = append(commands, fmt.Sprintf("sleep %g", step.Grace.Seconds()))
+		}
+	}
+	// Failures of kill (e.g. processes which have already terminated) are not failures of the hook.
+	commands = append(commands, "true")
+	return strings.Join(commands, "; ")
+}
+
+type stopPolicyKey struct{}
+
+// ContextWithStopPolicy returns context carrying stop policy. Tasks executed with ExecuteContext
+// and such context are stopped with given policy instead of the one configured in executor.
+func ContextWithStopPolicy(ctx context.Context, policy StopPolicy) context.Context {
+	return context.WithValue(ctx, stopPolicyKey{}, policy)
+}
+
+// stopPolicyFromContext returns policy carried by ctx or given executor policy.
+func stopPolicyFromContext(ctx context.Context, executorPolicy StopPolicy) StopPolicy {
+	if policy, ok := ctx.Value(stopPolicyKey{}).(StopPolicy); ok {
+		return policy
+	}
+	return executorPolicy
+}
+
+// processGroupPollInterval is the interval of checking whether processes in process group have terminated.
+var processGroupPollInterval = 10 * time.Millisecond
+
+// processGroupMembers returns PIDs of running (i.e. not zombie) processes which belong to given process group.
+// Zombies are ignored, because orphans may not be reaped when there is no proper init process.
+func processGroupMembers(pgid int) (members []int) {
+	entries, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		log.Warnf("Cannot list processes: %s", err.Error())
+		return nil
+	}
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := ioutil.ReadFile(path.Join("/proc", entry.Name(), "stat"))
+		if err != nil {
+			// Process has already terminated.
+			continue
+		}
+		// Format is "pid (comm) state ppid pgrp ..." and comm may contain spaces and parentheses.
+		fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+		if len(fields) < 3 || fields[0] == "Z" {
+			continue
+		}
+		if group, err := strconv.Atoi(fields[2]); err == nil && group == pgid {
+			members = append(members, pid)
+		}
+	}
+	return members
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStopPolicy(t *testing.T) {
+	Convey("Stop policy should be parsed", t, func() {
+		policy, err := ParseStopPolicy("SIGINT:5s, TERM:10s,9")
+		So(err, ShouldBeNil)
+		So(policy.ProcessGroup, ShouldBeTrue)
+		So(policy.Steps, ShouldResemble, []StopStep{
+			{Signal: syscall.SIGINT, Grace: 5 * time.Second},
+			{Signal: syscall.SIGTERM, Grace: 10 * time.Second},
+			{Signal: syscall.SIGKILL},
+		})
+		So(policy.Timeout(), ShouldEqual, 15*time.Second)
+		So(policy.String(), ShouldEqual, "interrupt:5s,terminated:10s,killed")
+
+		for _, invalid := range []string{"", "SIGFOO", "SIGINT:5", "SIGINT:5s,"} {
+			_, err := ParseStopPolicy(invalid)
+			if invalid == "SIGINT:5s," {
+				So(err, ShouldBeNil)
+				continue
+			}
+			So(err, ShouldNotBeNil)
+		}
+	})
+
+	Convey("Stop policy should escalate until task terminates", t, func() {
+		policy := NewStopPolicy(
+			StopStep{Signal: syscall.SIGINT, Grace: 10 * time.Millisecond},
+			StopStep{Signal: syscall.SIGTERM, Grace: 10 * time.Millisecond},
+			StopStep{Signal: syscall.SIGKILL})
+
+		var sent []syscall.Signal
+		signal := func(signal syscall.Signal) error {
+			sent = append(sent, signal)
+			return nil
+		}
+
+		Convey("Next step should not be taken when task terminates", func() {
+			wait := func(ctx context.Context) (bool, error) {
+				if len(sent) < 2 {
+					<-ctx.Done()
+					return false, ctx.Err()
+				}
+				return true, nil
+			}
+			So(policy.escalate(context.Background(), signal, wait), ShouldBeNil)
+			So(sent, ShouldResemble, []syscall.Signal{syscall.SIGINT, syscall.SIGTERM})
+		})
+
+		Convey("Error should be returned when task does not terminate before context is done", func() {
+			wait := func(ctx context.Context) (bool, error) {
+				<-ctx.Done()
+				return false, ctx.Err()
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			So(policy.escalate(ctx, signal, wait), ShouldNotBeNil)
+			So(sent, ShouldResemble, []syscall.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL})
+		})
+	})
+
+	Convey("Stop policy should be translated to script signalling processes in container", t, func() {
+		script := NewStopPolicy(StopStep{Signal: syscall.SIGINT, Grace: 500 * time.Millisecond}, StopStep{Signal: syscall.SIGKILL}).script()
+		So(script, ShouldEqual, "kill -2 -- -1 2>/dev/null; kill -2 1 2>/dev/null; sleep 0.5; kill -9 -- -1 2>/dev/null; kill -9 1 2>/dev/null; true")
+
+		script = StopPolicy{Steps: []StopStep{{Signal: syscall.SIGTERM}}}.script()
+		So(strings.Contains(script, "-- -1"), ShouldBeFalse)
+
+		So(podGracePeriodSeconds(StopPolicy{}), ShouldEqual, 5)
+		So(podGracePeriodSeconds(NewStopPolicy(StopStep{Signal: syscall.SIGINT, Grace: 2500 * time.Millisecond}, StopStep{Signal: syscall.SIGKILL})), ShouldEqual, 4)
+	})
+
+	Convey("Stop policy from context should take precedence over executor policy", t, func() {
+		So(stopPolicyFromContext(context.Background(), KillPolicy), ShouldResemble, KillPolicy)
+		policy := NewStopPolicy(StopStep{Signal: syscall.SIGTERM})
+		So(stopPolicyFromContext(ContextWithStopPolicy(context.Background(), policy), KillPolicy), ShouldResemble, policy)
+	})
+}
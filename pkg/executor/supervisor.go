@@ -0,0 +1,399 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	supervisorRestartPolicyFlag  = conf.NewStringFlag("supervisor_restart_policy", "Restart policy of supervised tasks: 'never', 'on-failure' or 'always'.", RestartNever.String())
+	supervisorMaxRestartsFlag    = conf.NewIntFlag("supervisor_max_restarts", "Maximum number of restarts of supervised task (negative value means no limit).", 3)
+	supervisorInitialBackoffFlag = conf.NewDurationFlag("supervisor_initial_backoff", "Delay before the first restart of supervised task. It is doubled with every restart.", 1*time.Second)
+	supervisorMaxBackoffFlag     = conf.NewDurationFlag("supervisor_max_backoff", "Maximum delay before restart of supervised task.", 1*time.Minute)
+)
+
+// RestartPolicy describes when supervised task is restarted.
+type RestartPolicy int
+
+const (
+	// RestartNever never restarts the task.
+	RestartNever RestartPolicy = iota
+	// RestartOnFailure restarts the task when it fails (see TerminationStatus.Failed).
+	RestartOnFailure
+	// RestartAlways restarts the task whenever it terminates on its own.
+	RestartAlways
+)
+
+var restartPolicyNames = map[RestartPolicy]string{
+	RestartNever:     "never",
+	RestartOnFailure: "on-failure",
+	RestartAlways:    "always",
+}
+
+func (policy RestartPolicy) String() string {
+	return restartPolicyNames[policy]
+}
+
+// ParseRestartPolicy parses restart policy name ("never", "on-failure" or "always").
+func ParseRestartPolicy(name string) (RestartPolicy, error) {
+	for policy, policyName := range restartPolicyNames {
+		if policyName == name {
+			return policy, nil
+		}
+	}
+	return RestartNever, errors.Errorf("unknown restart policy %q", name)
+}
+
+// shouldRestart returns true when task terminated with given status should be restarted.
+func (policy RestartPolicy) shouldRestart(status TerminationStatus) bool {
+	switch policy {
+	case RestartAlways:
+		return !status.Stopped
+	case RestartOnFailure:
+		return status.Failed()
+	}
+	return false
+}
+
+// SupervisorConfig describes how SupervisingLauncher restarts tasks.
+type SupervisorConfig struct {
+	Policy RestartPolicy
+	// MaxRestarts limits number of restarts (negative value means no limit).
+	MaxRestarts int
+	// InitialBackoff is a delay before the first restart. It is doubled with every restart up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// OnRestart is called after every restart (e.g. to record it in experiment metadata).
+	OnRestart func(Restart)
+}
+
+// DefaultSupervisorConfig returns supervisor configuration from flags.
+func DefaultSupervisorConfig() (SupervisorConfig, error) {
+	policy, err := ParseRestartPolicy(supervisorRestartPolicyFlag.Value())
+	if err != nil {
+		return SupervisorConfig{}, errors.Wrapf(err, "invalid value of flag %q", supervisorRestartPolicyFlag.Name)
+	}
+
+	return SupervisorConfig{
+		Policy:         policy,
+		MaxRestarts:    supervisorMaxRestartsFlag.Value(),
+		InitialBackoff: supervisorInitialBackoffFlag.Value(),
+		MaxBackoff:     supervisorMaxBackoffFlag.Value(),
+	}, nil
+}
+
+// backoff returns delay before given (1-based) restart.
+func (config SupervisorConfig) backoff(restart int) time.Duration {
+	backoff := config.InitialBackoff
+	for i := 1; i < restart; i++ {
+		backoff *= 2
+		if config.MaxBackoff > 0 && backoff >= config.MaxBackoff {
+			break
+		}
+	}
+	if config.MaxBackoff > 0 && backoff > config.MaxBackoff {
+		return config.MaxBackoff
+	}
+	return backoff
+}
+
+// Incarnation describes a single run of supervised task.
+type Incarnation struct {
+	Started  time.Time
+	Finished time.Time
+	// Status is valid when incarnation has finished.
+	Status TerminationStatus
+}
+
+// Restart describes restart of supervised task.
+type Restart struct {
+	// Task is name of the launcher of the task.
+	Task string
+	// Count is number of the restart (starting from 1).
+	Count int
+	// Reason is termination status of previous incarnation.
+	Reason  TerminationStatus
+	Backoff time.Duration
+}
+
+func (r Restart) String() string {
+	return fmt.Sprintf("restart %d of %s after %s (backoff %s)", r.Count, r.Task, r.Reason, r.Backoff)
+}
+
+// SupervisingLauncher is a decorator and Launcher implementation that restarts launched tasks
+// according to restart policy. It can be combined with ServiceLauncher to report an error when
+// supervised service terminates and is not restarted anymore.
+type SupervisingLauncher struct {
+	Launcher
+	config SupervisorConfig
+}
+
+// NewSupervisingLauncher is constructor for SupervisingLauncher.
+func NewSupervisingLauncher(launcher Launcher, config SupervisorConfig) SupervisingLauncher {
+	return SupervisingLauncher{Launcher: launcher, config: config}
+}
+
+// Launch implements Launcher interface.
+func (sl SupervisingLauncher) Launch() (TaskHandle, error) {
+	return sl.LaunchContext(context.Background())
+}
+
+// LaunchContext implements ContextLauncher interface.
+// Every incarnation of the task is launched with ctx and no restarts are made when ctx is done.
+func (sl SupervisingLauncher) LaunchContext(ctx context.Context) (TaskHandle, error) {
+	handle, err := LaunchContext(ctx, sl.Launcher)
+	if err != nil {
+		return nil, err
+	}
+
+	supervised := &SupervisedTaskHandle{
+		launcher:     sl.Launcher,
+		config:       sl.config,
+		current:      handle,
+		incarnations: []Incarnation{{Started: time.Now()}},
+		handles:      []TaskHandle{handle},
+		finished:     make(chan struct{}),
+		stop:         make(chan struct{}),
+	}
+	go supervised.supervise(ctx)
+
+	return supervised, nil
+}
+
+// SupervisedTaskHandle is TaskHandle of task launched by SupervisingLauncher.
+// It is terminated when supervised task terminates and is not restarted anymore.
+// TaskInfo methods refer to the current (or last) incarnation of the task.
+type SupervisedTaskHandle struct {
+	launcher Launcher
+	config   SupervisorConfig
+
+	// Guarded by mutex.
+	mutex        sync.Mutex
+	current      TaskHandle
+	incarnations []Incarnation
+	handles      []TaskHandle
+
+	encounteredError error
+	finished         chan struct{}
+	stop             chan struct{}
+	stopOnce         sync.Once
+}
+
+func (sh *SupervisedTaskHandle) supervise(ctx context.Context) {
+	defer close(sh.finished)
+
+	for restarts := 0; ; {
+		handle := sh.currentHandle()
+		select {
+		case <-getWaitChannel(handle):
+		case <-sh.stop:
+			sh.encounteredError = handle.Stop()
+			sh.incarnationFinished(handle)
+			return
+		}
+
+		status := sh.incarnationFinished(handle)
+		if ctx.Err() != nil || !sh.config.Policy.shouldRestart(status) {
+			return
+		}
+		if sh.config.MaxRestarts >= 0 && restarts >= sh.config.MaxRestarts {
+			log.Warnf("Supervisor: %s terminated (%s) and has been restarted %d times already: giving up", sh.launcher, status, restarts)
+			return
+		}
+
+		restarts++
+		restart := Restart{Task: sh.launcher.String(), Count: restarts, Reason: status, Backoff: sh.config.backoff(restarts)}
+		log.Warnf("Supervisor: %s terminated (%s): restarting in %s (%d/%d)", sh.launcher, status, restart.Backoff, restarts, sh.config.MaxRestarts)
+		select {
+		case <-time.After(restart.Backoff):
+		case <-sh.stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+
+		handle, err := LaunchContext(ctx, sh.launcher)
+		if err != nil {
+			sh.encounteredError = errors.Wrapf(err, "cannot restart %s", sh.launcher)
+			log.Error(sh.encounteredError.Error())
+			return
+		}
+		sh.restarted(handle)
+
+		if sh.config.OnRestart != nil {
+			sh.config.OnRestart(restart)
+		}
+	}
+}
+
+func (sh *SupervisedTaskHandle) currentHandle() TaskHandle {
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+	return sh.current
+}
+
+// incarnationFinished records termination of current incarnation and returns its status.
+func (sh *SupervisedTaskHandle) incarnationFinished(handle TaskHandle) TerminationStatus {
+	status, err := GetTerminationStatus(handle)
+	if err != nil {
+		log.Errorf("Supervisor: cannot get termination status of %s: %s", handle, err.Error())
+	}
+
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+	last := &sh.incarnations[len(sh.incarnations)-1]
+	last.Finished = time.Now()
+	last.Status = status
+	return status
+}
+
+func (sh *SupervisedTaskHandle) restarted(handle TaskHandle) {
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+	sh.current = handle
+	sh.handles = append(sh.handles, handle)
+	sh.incarnations = append(sh.incarnations, Incarnation{Started: time.Now()})
+}
+
+// Restarts returns number of restarts of the task so far.
+func (sh *SupervisedTaskHandle) Restarts() int {
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+	return len(sh.incarnations) - 1
+}
+
+// Incarnations returns all runs of the task so far.
+func (sh *SupervisedTaskHandle) Incarnations() []Incarnation {
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+	incarnations := make([]Incarnation, len(sh.incarnations))
+	copy(incarnations, sh.incarnations)
+	return incarnations
+}
+
+func (sh *SupervisedTaskHandle) isTerminated() bool {
+	select {
+	case <-sh.finished:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop stops current incarnation of the task and supervision.
+func (sh *SupervisedTaskHandle) Stop() error {
+	sh.stopOnce.Do(func() { close(sh.stop) })
+
+	_, err := sh.Wait(0)
+	return err
+}
+
+// StopContext stops current incarnation of the task and supervision, and waits until it is done or ctx is done.
+func (sh *SupervisedTaskHandle) StopContext(ctx context.Context) error {
+	sh.stopOnce.Do(func() { close(sh.stop) })
+
+	_, err := sh.WaitContext(ctx)
+	return err
+}
+
+// Wait waits until the task terminates and is not restarted anymore.
+func (sh *SupervisedTaskHandle) Wait(timeout time.Duration) (bool, error) {
+	select {
+	case <-sh.finished:
+		return true, sh.encounteredError
+	case <-getTimeoutChan(timeout):
+		return false, nil
+	}
+}
+
+// WaitContext waits until the task terminates and is not restarted anymore or ctx is done.
+func (sh *SupervisedTaskHandle) WaitContext(ctx context.Context) (bool, error) {
+	select {
+	case <-sh.finished:
+		return true, sh.encounteredError
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// Status returns RUNNING until the task terminates and is not restarted anymore.
+func (sh *SupervisedTaskHandle) Status() TaskState {
+	if sh.isTerminated() {
+		return TERMINATED
+	}
+	return RUNNING
+}
+
+// ExitCode returns exit code of the last incarnation.
+func (sh *SupervisedTaskHandle) ExitCode() (int, error) {
+	if !sh.isTerminated() {
+		return -1, errors.Errorf("supervised task %s is not terminated", sh.launcher)
+	}
+	return sh.currentHandle().ExitCode()
+}
+
+// TerminationStatus returns termination status of the last incarnation.
+func (sh *SupervisedTaskHandle) TerminationStatus() (TerminationStatus, error) {
+	if !sh.isTerminated() {
+		return TerminationStatus{}, errors.Errorf("supervised task %s is not terminated", sh.launcher)
+	}
+	return GetTerminationStatus(sh.currentHandle())
+}
+
+// Address returns address of current incarnation.
+func (sh *SupervisedTaskHandle) Address() string {
+	return sh.currentHandle().Address()
+}
+
+// StdoutFile returns stdout file of current incarnation.
+func (sh *SupervisedTaskHandle) StdoutFile() (*os.File, error) {
+	return sh.currentHandle().StdoutFile()
+}
+
+// StderrFile returns stderr file of current incarnation.
+func (sh *SupervisedTaskHandle) StderrFile() (*os.File, error) {
+	return sh.currentHandle().StderrFile()
+}
+
+// Lines streams lines of output of current incarnation.
+func (sh *SupervisedTaskHandle) Lines(ctx context.Context, stream OutputStream) (<-chan string, error) {
+	return Lines(ctx, sh.currentHandle(), stream)
+}
+
+// EraseOutput deletes output of all incarnations.
+func (sh *SupervisedTaskHandle) EraseOutput() error {
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+
+	var errCollection errcollection.ErrorCollection
+	for _, handle := range sh.handles {
+		errCollection.Add(handle.EraseOutput())
+	}
+	return errCollection.GetErrIfAny()
+}
+
+func (sh *SupervisedTaskHandle) String() string {
+	return fmt.Sprintf("Supervised %s", sh.currentHandle())
+}
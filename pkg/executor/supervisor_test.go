@@ -0,0 +1,115 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSupervisingLauncher(t *testing.T) {
+	Convey("When having supervised task", t, func() {
+		launcher := new(MockLauncher)
+		launcher.On("String").Return("memcached")
+		config := SupervisorConfig{Policy: RestartOnFailure, MaxRestarts: 2, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+		var restarts []Restart
+		config.OnRestart = func(restart Restart) { restarts = append(restarts, restart) }
+
+		Convey("Failing task should be restarted until max restarts is reached", func() {
+			failing := new(MockTaskHandle)
+			failing.On("Wait", 0*time.Second).Return(true, nil)
+			failing.On("ExitCode").Return(1, nil)
+			launcher.On("Launch").Return(failing, nil)
+
+			handle, err := NewSupervisingLauncher(launcher, config).Launch()
+			So(err, ShouldBeNil)
+			terminated, err := handle.Wait(0)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+			So(handle.Status(), ShouldEqual, TERMINATED)
+
+			supervised := handle.(*SupervisedTaskHandle)
+			So(supervised.Restarts(), ShouldEqual, 2)
+			incarnations := supervised.Incarnations()
+			So(incarnations, ShouldHaveLength, 3)
+			for _, incarnation := range incarnations {
+				So(incarnation.Status.ExitCode, ShouldEqual, 1)
+				So(incarnation.Finished, ShouldHappenOnOrAfter, incarnation.Started)
+			}
+
+			So(restarts, ShouldHaveLength, 2)
+			So(restarts[1].Count, ShouldEqual, 2)
+			So(restarts[1].Task, ShouldEqual, "memcached")
+			So(restarts[1].Backoff, ShouldEqual, 2*time.Millisecond)
+			So(launcher.AssertNumberOfCalls(t, "Launch", 3), ShouldBeTrue)
+		})
+
+		Convey("Successful task should not be restarted on failure", func() {
+			succeeding := new(MockTaskHandle)
+			succeeding.On("Wait", 0*time.Second).Return(true, nil)
+			succeeding.On("ExitCode").Return(0, nil)
+			launcher.On("Launch").Return(succeeding, nil)
+
+			handle, err := NewSupervisingLauncher(launcher, config).Launch()
+			So(err, ShouldBeNil)
+			handle.Wait(0)
+			So(handle.(*SupervisedTaskHandle).Restarts(), ShouldEqual, 0)
+			So(restarts, ShouldBeEmpty)
+
+			exitCode, err := handle.ExitCode()
+			So(err, ShouldBeNil)
+			So(exitCode, ShouldEqual, 0)
+		})
+
+		Convey("Stopped task should not be restarted", func() {
+			running := new(MockTaskHandle)
+			running.On("Wait", 0*time.Second).After(time.Hour).Return(true, nil)
+			running.On("Stop").Return(nil)
+			running.On("ExitCode").Return(137, nil)
+			launcher.On("Launch").Return(running, nil)
+
+			config.Policy = RestartAlways
+			handle, err := NewSupervisingLauncher(launcher, config).Launch()
+			So(err, ShouldBeNil)
+			So(handle.Status(), ShouldEqual, RUNNING)
+
+			So(handle.Stop(), ShouldBeNil)
+			So(handle.Status(), ShouldEqual, TERMINATED)
+			So(handle.(*SupervisedTaskHandle).Restarts(), ShouldEqual, 0)
+			So(running.AssertCalled(t, "Stop"), ShouldBeTrue)
+		})
+	})
+
+	Convey("Backoff should grow exponentially up to maximum", t, func() {
+		config := SupervisorConfig{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+		So(config.backoff(1), ShouldEqual, time.Second)
+		So(config.backoff(3), ShouldEqual, 4*time.Second)
+		So(config.backoff(4), ShouldEqual, 5*time.Second)
+		So(config.backoff(100), ShouldEqual, 5*time.Second)
+	})
+
+	Convey("Restart policy should be parsed", t, func() {
+		for _, policy := range []RestartPolicy{RestartNever, RestartOnFailure, RestartAlways} {
+			parsed, err := ParseRestartPolicy(policy.String())
+			So(err, ShouldBeNil)
+			So(parsed, ShouldEqual, policy)
+		}
+		_, err := ParseRestartPolicy("sometimes")
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -15,6 +15,7 @@
 package executor
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
@@ -68,6 +69,69 @@ type TaskControl interface {
 	EraseOutput() error
 }
 
+// ContextTaskControl is implemented by task handles that are able to bound waiting and stopping with a context.
+type ContextTaskControl interface {
+	// WaitContext blocks and waits for task to terminate or ctx to be done.
+	// Returns `terminated` true when task terminates.
+	// Returns `terminated` false and ctx.Err() when ctx is done before task terminates.
+	WaitContext(ctx context.Context) (terminated bool, err error)
+	// StopContext stops a task and waits until it terminates.
+	// Returns ctx.Err() when ctx is done before task terminates.
+	StopContext(ctx context.Context) error
+}
+
+// WaitContext waits for task to terminate or ctx to be done.
+// Handles not implementing ContextTaskControl are supported.
+func WaitContext(ctx context.Context, handle TaskControl) (bool, error) {
+	if contextHandle, ok := handle.(ContextTaskControl); ok {
+		return contextHandle.WaitContext(ctx)
+	}
+
+	select {
+	case err := <-getWaitChannel(handle):
+		return true, err
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// WaitTimeoutContext waits for task to terminate like Wait(timeout) does (0 means no limit),
+// but returns ctx.Err() as soon as ctx is done.
+func WaitTimeoutContext(ctx context.Context, handle TaskControl, timeout time.Duration) (bool, error) {
+	waitCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	terminated, err := WaitContext(waitCtx, handle)
+	if !terminated && ctx.Err() == nil && waitCtx.Err() == context.DeadlineExceeded {
+		return false, nil
+	}
+	return terminated, err
+}
+
+// StopContext stops a task and waits until it terminates or ctx is done.
+// Handles not implementing ContextTaskControl are supported.
+func StopContext(ctx context.Context, handle TaskControl) error {
+	if contextHandle, ok := handle.(ContextTaskControl); ok {
+		return contextHandle.StopContext(ctx)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- handle.Stop()
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // StopAndEraseOutput run Stop and EraseOutput on TaskHandle and add errors to errorCollection
 func StopAndEraseOutput(handle TaskHandle) (errorCollection errcollection.ErrorCollection) {
 	if handle != nil {
@@ -0,0 +1,193 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestContextHelpers(t *testing.T) {
+	Convey("When using context helpers with handles unaware of context", t, func() {
+		handle := new(MockTaskHandle)
+
+		Convey("WaitContext should return result of Wait when task terminates", func() {
+			handle.On("Wait", 0*time.Second).Return(true, nil)
+
+			terminated, err := WaitContext(context.Background(), handle)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeTrue)
+		})
+
+		Convey("WaitContext should return context error when context is done first", func() {
+			handle.On("Wait", 0*time.Second).After(waitTimeout).Return(true, nil)
+			ctx, cancel := context.WithTimeout(context.Background(), taskExecutionTime)
+			defer cancel()
+
+			terminated, err := WaitContext(ctx, handle)
+			So(err, ShouldResemble, context.DeadlineExceeded)
+			So(terminated, ShouldBeFalse)
+		})
+
+		Convey("WaitTimeoutContext should report task which has not terminated within timeout", func() {
+			handle.On("Wait", 0*time.Second).After(waitTimeout).Return(true, nil)
+
+			terminated, err := WaitTimeoutContext(context.Background(), handle, taskExecutionTime)
+			So(err, ShouldBeNil)
+			So(terminated, ShouldBeFalse)
+		})
+
+		Convey("WaitTimeoutContext should return context error when context is done before timeout", func() {
+			handle.On("Wait", 0*time.Second).After(waitTimeout).Return(true, nil)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			terminated, err := WaitTimeoutContext(ctx, handle, waitTimeout)
+			So(err, ShouldResemble, context.Canceled)
+			So(terminated, ShouldBeFalse)
+		})
+
+		Convey("StopContext should return result of Stop", func() {
+			handle.On("Stop").Return(errStopFailed)
+
+			err := StopContext(context.Background(), handle)
+			So(err, ShouldEqual, errStopFailed)
+		})
+
+		Convey("StopContext should return context error when Stop blocks too long", func() {
+			handle.On("Stop").After(waitTimeout).Return(nil)
+			ctx, cancel := context.WithTimeout(context.Background(), taskExecutionTime)
+			defer cancel()
+
+			err := StopContext(ctx, handle)
+			So(err, ShouldResemble, context.DeadlineExceeded)
+		})
+	})
+
+	Convey("When executing command with executor unaware of context", t, func() {
+		mExecutor := new(MockExecutor)
+		handle := new(MockTaskHandle)
+
+		Convey("Task should not be executed when context is already done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			th, err := ExecuteContext(ctx, mExecutor, "command")
+			So(err, ShouldResemble, context.Canceled)
+			So(th, ShouldBeNil)
+			So(mExecutor.AssertNotCalled(t, "Execute", "command"), ShouldBeTrue)
+		})
+
+		Convey("Executor error should be returned", func() {
+			mExecutor.On("Execute", "command").Return(nil, errors.New("execute failed"))
+
+			th, err := ExecuteContext(context.Background(), mExecutor, "command")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "execute failed")
+			So(th, ShouldBeNil)
+		})
+
+		Convey("Task should be stopped when context is canceled", func() {
+			stopped := make(chan struct{})
+			mExecutor.On("Execute", "command").Return(handle, nil)
+			handle.On("Wait", 0*time.Second).After(waitTimeout).Return(true, nil)
+			handle.On("String").Return("command")
+			handle.On("Stop").Return(nil).Run(func(_ mock.Arguments) { close(stopped) })
+			ctx, cancel := context.WithCancel(context.Background())
+
+			th, err := ExecuteContext(ctx, mExecutor, "command")
+			So(err, ShouldBeNil)
+			So(th, ShouldEqual, handle)
+
+			cancel()
+			select {
+			case <-stopped:
+			case <-time.After(waitTimeout):
+				t.Fatal("task has not been stopped after context was canceled")
+			}
+		})
+	})
+
+	Convey("When generating load with context", t, func() {
+		loadGenerator := new(MockLoadGenerator)
+		handle := new(MockTaskHandle)
+
+		Convey("Load should not be generated when context is already done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			th, err := LoadContext(ctx, loadGenerator, 100, time.Second)
+			So(err, ShouldResemble, context.Canceled)
+			So(th, ShouldBeNil)
+			So(loadGenerator.AssertNotCalled(t, "Load", 100, time.Second), ShouldBeTrue)
+		})
+
+		Convey("Load generator should be stopped when context is canceled", func() {
+			stopped := make(chan struct{})
+			loadGenerator.On("Load", 100, time.Second).Return(handle, nil)
+			handle.On("Wait", 0*time.Second).After(waitTimeout).Return(true, nil)
+			handle.On("String").Return("load generator")
+			handle.On("Stop").Return(nil).Run(func(_ mock.Arguments) { close(stopped) })
+			ctx, cancel := context.WithCancel(context.Background())
+
+			th, err := LoadContext(ctx, loadGenerator, 100, time.Second)
+			So(err, ShouldBeNil)
+			So(th, ShouldEqual, handle)
+
+			cancel()
+			select {
+			case <-stopped:
+			case <-time.After(waitTimeout):
+				t.Fatal("load generator has not been stopped after context was canceled")
+			}
+		})
+	})
+
+	Convey("When launching workload with launcher unaware of context", t, func() {
+		launcher := new(MockLauncher)
+		handle := new(MockTaskHandle)
+
+		Convey("Workload should not be launched when context is already done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			th, err := LaunchContext(ctx, launcher)
+			So(err, ShouldResemble, context.Canceled)
+			So(th, ShouldBeNil)
+			So(launcher.AssertNotCalled(t, "Launch"), ShouldBeTrue)
+		})
+
+		Convey("Workload should not be stopped when it terminates before context is canceled", func() {
+			launcher.On("Launch").Return(handle, nil)
+			handle.On("Wait", 0*time.Second).Return(true, nil)
+			ctx, cancel := context.WithCancel(context.Background())
+
+			th, err := LaunchContext(ctx, launcher)
+			So(err, ShouldBeNil)
+			So(th, ShouldEqual, handle)
+
+			// Give watching goroutine a chance to notice termination.
+			time.Sleep(taskExecutionTime)
+			cancel()
+			time.Sleep(taskExecutionTime)
+			So(handle.AssertNotCalled(t, "Stop"), ShouldBeTrue)
+		})
+	})
+}
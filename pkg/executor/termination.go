@@ -0,0 +1,145 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	log "github.com/sirupsen/logrus"
+)
+
+// TerminationStatus describes how and why a task terminated.
+type TerminationStatus struct {
+	ExitCode int
+	// Signal that terminated the task (0 when task exited on its own).
+	Signal syscall.Signal
+	// OOMKilled is true when task was killed by OOM killer.
+	OOMKilled bool
+	// Stopped is true when task has been stopped by swan (Stop, StopContext or done context).
+	Stopped bool
+	// LaunchTimedOut is true when task has been stopped because it was not launched in time
+	// (see LaunchTimedOutError).
+	LaunchTimedOut bool
+}
+
+// String returns user-friendly description of termination.
+func (s TerminationStatus) String() string {
+	var reasons []string
+	switch {
+	case s.Signal != 0:
+		reasons = append(reasons, fmt.Sprintf("killed by signal %d (%s)", s.Signal, s.Signal))
+	default:
+		reasons = append(reasons, fmt.Sprintf("exit code %d", s.ExitCode))
+	}
+	if s.OOMKilled {
+		reasons = append(reasons, "OOM killed")
+	}
+	if s.LaunchTimedOut {
+		reasons = append(reasons, "launch timed out")
+	} else if s.Stopped {
+		reasons = append(reasons, "stopped")
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// Failed returns true when task has not been stopped by swan and did not exit successfully.
+func (s TerminationStatus) Failed() bool {
+	if s.LaunchTimedOut || s.OOMKilled {
+		return true
+	}
+	if s.Stopped {
+		return false
+	}
+	return s.ExitCode != 0 || s.Signal != 0
+}
+
+// Retryable returns true when task failed for a reason unrelated to the task itself
+// (OOM kill, launch timeout or signal sent by someone else), so running it again might succeed.
+// Tasks failing with non-zero exit code are expected to fail again.
+func (s TerminationStatus) Retryable() bool {
+	return s.Failed() && (s.OOMKilled || s.LaunchTimedOut || s.Signal != 0)
+}
+
+// TerminationInfo is implemented by task handles that are able to tell why task terminated.
+type TerminationInfo interface {
+	// TerminationStatus returns status of terminated task.
+	// Returns error if task is not terminated.
+	TerminationStatus() (TerminationStatus, error)
+}
+
+// GetTerminationStatus returns status of terminated task.
+// For handles not implementing TerminationInfo status is derived from exit code.
+func GetTerminationStatus(handle TaskInfo) (TerminationStatus, error) {
+	if info, ok := handle.(TerminationInfo); ok {
+		return info.TerminationStatus()
+	}
+
+	exitCode, err := handle.ExitCode()
+	if err != nil {
+		return TerminationStatus{}, err
+	}
+	return statusFromExitCode(exitCode), nil
+}
+
+// statusFromExitCode follows shell convention: exit codes above 128 mean that process was killed by signal (code - 128).
+func statusFromExitCode(exitCode int) TerminationStatus {
+	status := TerminationStatus{ExitCode: exitCode}
+	if exitCode > 128 && exitCode < 128+65 {
+		status.Signal = syscall.Signal(exitCode - 128)
+	}
+	return status
+}
+
+// signalNames maps signal names used by SSH (RFC 4254, without "SIG" prefix) to signals.
+var signalNames = map[string]syscall.Signal{
+	"ABRT": syscall.SIGABRT,
+	"ALRM": syscall.SIGALRM,
+	"FPE":  syscall.SIGFPE,
+	"HUP":  syscall.SIGHUP,
+	"ILL":  syscall.SIGILL,
+	"INT":  syscall.SIGINT,
+	"KILL": syscall.SIGKILL,
+	"PIPE": syscall.SIGPIPE,
+	"QUIT": syscall.SIGQUIT,
+	"SEGV": syscall.SIGSEGV,
+	"TERM": syscall.SIGTERM,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
+
+// oomCounter is implemented by isolations that count OOM kills of their tasks (e.g. isolation.MemorySize).
+type oomCounter interface {
+	OOMKills() (int, error)
+}
+
+// oomKills returns number of OOM kills counted by decorators implementing oomCounter.
+func oomKills(decorators isolation.Decorators) (count int) {
+	for _, decorator := range decorators {
+		counter, ok := decorator.(oomCounter)
+		if !ok {
+			continue
+		}
+		kills, err := counter.OOMKills()
+		if err != nil {
+			log.Debugf("Cannot read OOM kills: %s", err.Error())
+			continue
+		}
+		count += kills
+	}
+	return count
+}
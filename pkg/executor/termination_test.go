@@ -0,0 +1,67 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTerminationStatus(t *testing.T) {
+	Convey("Exit codes above 128 should be reported as signals", t, func() {
+		So(statusFromExitCode(0), ShouldResemble, TerminationStatus{})
+		So(statusFromExitCode(1), ShouldResemble, TerminationStatus{ExitCode: 1})
+		So(statusFromExitCode(137), ShouldResemble, TerminationStatus{ExitCode: 137, Signal: syscall.SIGKILL})
+	})
+
+	Convey("Termination reasons should be classified", t, func() {
+		success := TerminationStatus{}
+		So(success.Failed(), ShouldBeFalse)
+		So(success.String(), ShouldEqual, "exit code 0")
+
+		failure := TerminationStatus{ExitCode: 2}
+		So(failure.Failed(), ShouldBeTrue)
+		So(failure.Retryable(), ShouldBeFalse)
+
+		stopped := TerminationStatus{ExitCode: -1, Signal: syscall.SIGKILL, Stopped: true}
+		So(stopped.Failed(), ShouldBeFalse)
+		So(stopped.String(), ShouldEqual, "killed by signal 9 (killed), stopped")
+
+		killed := TerminationStatus{ExitCode: 137, Signal: syscall.SIGKILL, OOMKilled: true}
+		So(killed.Failed(), ShouldBeTrue)
+		So(killed.Retryable(), ShouldBeTrue)
+		So(killed.String(), ShouldEqual, "killed by signal 9 (killed), OOM killed")
+
+		timedOut := TerminationStatus{ExitCode: -1, Stopped: true, LaunchTimedOut: true}
+		So(timedOut.Failed(), ShouldBeTrue)
+		So(timedOut.Retryable(), ShouldBeTrue)
+		So(timedOut.String(), ShouldEqual, "exit code -1, launch timed out")
+	})
+
+	Convey("Status of handles without TerminationInfo should be derived from exit code", t, func() {
+		handle := new(MockTaskHandle)
+		handle.On("ExitCode").Return(143, nil).Once()
+		status, err := GetTerminationStatus(handle)
+		So(err, ShouldBeNil)
+		So(status.Signal, ShouldEqual, syscall.SIGTERM)
+
+		handle.On("ExitCode").Return(0, errors.New("task is still running"))
+		_, err = GetTerminationStatus(handle)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package experiment
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/metadata"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// CheckpointMetadataKind is kind of metadata progress of experiment is recorded with, so that it can be resumed.
+	// Keys are names of completed phases and values are times of their completion.
+	CheckpointMetadataKind = "checkpoint"
+
+	// peakLoadCheckpointKey is the key peak load found in tuning phase is recorded with.
+	peakLoadCheckpointKey = "peak_load"
+	// sliCheckpointSuffix is appended to name of completed phase to form the key SLI measured in the phase is recorded with.
+	sliCheckpointSuffix = " sli"
+)
+
+// Checkpoints keeps track of completed phases of the experiment, so that they are not run again
+// when interrupted experiment is resumed (see -resume flag).
+type Checkpoints struct {
+	metaData  metadata.Metadata
+	completed map[string]string
+}
+
+// NewCheckpoints returns checkpoints recorded in metadata. Checkpoints of interrupted experiment are loaded when resume is true.
+func NewCheckpoints(metaData metadata.Metadata, resume bool) *Checkpoints {
+	checkpoints := &Checkpoints{metaData: metaData, completed: map[string]string{}}
+	if !resume {
+		return checkpoints
+	}
+
+	completed, err := metaData.GetByKind(CheckpointMetadataKind)
+	if err != nil {
+		// Experiment might have been interrupted before any phase completed.
+		logrus.Warnf("Cannot retrieve checkpoints of resumed experiment, all phases will be run: %s", err.Error())
+		return checkpoints
+	}
+	for key, value := range completed {
+		checkpoints.completed[key] = value
+	}
+	logrus.Infof("Resuming experiment with %d checkpoints", len(completed))
+	return checkpoints
+}
+
+// Completed returns true when phase has already been completed.
+func (c *Checkpoints) Completed(phase string) bool {
+	_, completed := c.completed[phase]
+	return completed && phase != peakLoadCheckpointKey
+}
+
+// Complete records completion of phase.
+func (c *Checkpoints) Complete(phase string) error {
+	return c.record(phase, time.Now().Format(time.RFC3339))
+}
+
+// PeakLoad returns recorded peak load. Second value is false when peak load has not been recorded.
+func (c *Checkpoints) PeakLoad() (int, bool) {
+	value, ok := c.completed[peakLoadCheckpointKey]
+	if !ok {
+		return 0, false
+	}
+	peakLoad, err := strconv.Atoi(value)
+	if err != nil {
+		logrus.Warnf("Recorded peak load %q is invalid: %s", value, err.Error())
+		return 0, false
+	}
+	return peakLoad, true
+}
+
+// RecordPeakLoad records peak load, so that tuning is not run again when experiment is resumed.
+func (c *Checkpoints) RecordPeakLoad(peakLoad int) error {
+	return c.record(peakLoadCheckpointKey, strconv.Itoa(peakLoad))
+}
+
+// SLI returns SLI measured in completed phase. Second value is false when SLI has not been recorded.
+func (c *Checkpoints) SLI(phase string) (int, bool) {
+	value, ok := c.completed[phase+sliCheckpointSuffix]
+	if !ok {
+		return 0, false
+	}
+	sli, err := strconv.Atoi(value)
+	if err != nil {
+		logrus.Warnf("Recorded SLI of phase %q is invalid: %s", phase, err.Error())
+		return 0, false
+	}
+	return sli, true
+}
+
+// RecordSLI records SLI measured in phase, so that it can be used again when experiment is resumed
+// and the phase is skipped (e.g. by adaptive load schedule).
+func (c *Checkpoints) RecordSLI(phase string, sli int) error {
+	return c.record(phase+sliCheckpointSuffix, strconv.Itoa(sli))
+}
+
+func (c *Checkpoints) record(key, value string) error {
+	err := c.metaData.Record(key, value, CheckpointMetadataKind)
+	if err != nil {
+		return errors.Wrapf(err, "cannot record checkpoint %q", key)
+	}
+	c.completed[key] = value
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package experiment
+
+import (
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/metadata"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCheckpoints(t *testing.T) {
+	Convey("When experiment records checkpoints", t, func() {
+		metaData := metadata.NewMemory("experiment")
+		checkpoints := NewCheckpoints(metaData, false)
+		So(checkpoints.Completed("phase 1"), ShouldBeFalse)
+		_, recorded := checkpoints.PeakLoad()
+		So(recorded, ShouldBeFalse)
+
+		So(checkpoints.RecordPeakLoad(1000), ShouldBeNil)
+		So(checkpoints.Complete("phase 1"), ShouldBeNil)
+		So(checkpoints.Completed("phase 1"), ShouldBeTrue)
+
+		Convey("Resumed experiment should skip completed phases and reuse peak load", func() {
+			resumed := NewCheckpoints(metaData, true)
+			So(resumed.Completed("phase 1"), ShouldBeTrue)
+			So(resumed.Completed("phase 2"), ShouldBeFalse)
+			load, recorded := resumed.PeakLoad()
+			So(recorded, ShouldBeTrue)
+			So(load, ShouldEqual, 1000)
+		})
+
+		Convey("Resumed experiment should reuse SLI measured in completed phases", func() {
+			So(checkpoints.RecordSLI("phase 1", 500), ShouldBeNil)
+			resumed := NewCheckpoints(metaData, true)
+			sli, recorded := resumed.SLI("phase 1")
+			So(recorded, ShouldBeTrue)
+			So(sli, ShouldEqual, 500)
+			_, recorded = resumed.SLI("phase 2")
+			So(recorded, ShouldBeFalse)
+		})
+
+		Convey("New experiment should not use checkpoints", func() {
+			restarted := NewCheckpoints(metaData, false)
+			So(restarted.Completed("phase 1"), ShouldBeFalse)
+		})
+	})
+
+	Convey("Experiment interrupted before any checkpoint was recorded should be resumed from the beginning", t, func() {
+		checkpoints := NewCheckpoints(metadata.NewMemory("experiment"), true)
+		So(checkpoints.Completed("phase 1"), ShouldBeFalse)
+		_, recorded := checkpoints.PeakLoad()
+		So(recorded, ShouldBeFalse)
+	})
+}
@@ -20,15 +20,26 @@ import (
 	"os"
 
 	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/metadata"
 	"github.com/intelsdi-x/swan/pkg/utils/errutil"
+	"github.com/intelsdi-x/swan/pkg/utils/uuid"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// SpecMetadataKind is kind of metadata specification of experiment is stored with.
+	SpecMetadataKind = "spec"
+	// SpecMetadataKey is the key specification of experiment is stored with.
+	SpecMetadataKey = "spec"
+)
+
 var (
 	// Flags are defined using directly go native "flag" package to not be registered as experiment configuration.
 	loadConfig             = flag.String("config", "", "Load configuration from file")
 	dumpConfig             = flag.Bool("config-dump", false, "Dump configuration as environment script.")
 	dumpConfigExperimentID = flag.String("config-dump-experiment-id", "", "Dump configuration based on experiment ID.")
+	dumpSpecExperimentID   = flag.String("spec-dump-experiment-id", "", "Dump specification of experiment with given ID, so that it can be rerun exactly.")
+	resumeExperimentID     = flag.String("resume", "", "Resume interrupted experiment with given ID: completed phases are skipped and new results are stored under the same ID.")
 )
 
 // Configure handles configuration parsing, generation and restoration based on config-* flags.
@@ -66,5 +77,27 @@ func Configure() bool {
 		}
 		os.Exit(0)
 	}
+
+	if *dumpSpecExperimentID != "" {
+		metaData, err := metadata.NewDefault(*dumpSpecExperimentID)
+		errutil.Check(err)
+		spec, err := metaData.GetByKind(SpecMetadataKind)
+		errutil.CheckWithContext(err, "Cannot retrieve experiment specification")
+		fmt.Print(spec[SpecMetadataKey])
+		os.Exit(0)
+	}
 	return level == logrus.ErrorLevel
 }
+
+// Resumed returns true when interrupted experiment is resumed (see -resume flag).
+func Resumed() bool {
+	return *resumeExperimentID != ""
+}
+
+// ExperimentID returns ID of resumed experiment or new ID when experiment is not resumed.
+func ExperimentID() string {
+	if Resumed() {
+		return *resumeExperimentID
+	}
+	return uuid.New()
+}
@@ -33,6 +33,8 @@ const (
 	LoadPointQPSKey = "swan_loadpoint_qps"
 	// AggressorNameKey defines the key for Snap tag.
 	AggressorNameKey = "swan_aggressor_name"
+	// AttemptKey defines the key for Snap tag (number of times repetition has been retried).
+	AttemptKey = "swan_attempt"
 
 	// See /usr/include/sysexits.h for reference regarding constants below
 
@@ -42,6 +44,8 @@ const (
 	ExSoftware = 70
 	// ExIOErr represents input/output error exit code
 	ExIOErr = 74
+	// ExTempFail represents exit code of experiment interrupted by a signal
+	ExTempFail = 75
 )
 
 // CreateExperimentDir creates directory structure for the experiment.
@@ -57,7 +61,7 @@ func CreateExperimentDir(uuid, appName string) (experimentDirectory string, logF
 	}
 
 	masterLogFilename := path.Join(experimentDirectory, "master.log")
-	logFile, err = os.OpenFile(masterLogFilename, os.O_WRONLY|os.O_CREATE, 0755)
+	logFile, err = os.OpenFile(masterLogFilename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0755)
 	if err != nil {
 		return "", &os.File{}, errors.Wrapf(err, "could not open log file %q", masterLogFilename)
 	}
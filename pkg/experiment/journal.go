@@ -0,0 +1,57 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package experiment
+
+import (
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/journal"
+	"github.com/sirupsen/logrus"
+)
+
+// JournalDirectoryFlag is the directory where experiments keep journals of resources they create.
+var JournalDirectoryFlag = conf.NewStringFlag("journal_directory", "Directory where experiments keep journals of created resources (cgroups, pods, Snap tasks etc.) used by swan-cleanup.", journal.DefaultDirectory)
+
+// OpenJournal starts recording resources created by experiment in its journal.
+// Returned function stops recording and removes the journal if all recorded resources have been released.
+func OpenJournal(experimentID string) (closeJournal func(), err error) {
+	directory := JournalDirectoryFlag.Value()
+	j, err := journal.Open(directory, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	journal.SetCurrent(j)
+
+	return func() {
+		journal.SetCurrent(nil)
+		if err := j.Close(); err != nil {
+			logrus.Warnf("Cannot close journal of experiment %q: %s", experimentID, err.Error())
+			return
+		}
+
+		outstanding, err := journal.Outstanding(journal.Path(directory, experimentID))
+		if err != nil {
+			logrus.Warnf("Cannot read journal of experiment %q: %s", experimentID, err.Error())
+			return
+		}
+		if len(outstanding) > 0 {
+			logrus.Warnf("Experiment %q has not released %d resources: run swan-cleanup to reclaim them", experimentID, len(outstanding))
+			return
+		}
+
+		if err := journal.Remove(directory, experimentID); err != nil {
+			logrus.Warnf("Cannot remove journal of experiment %q: %s", experimentID, err.Error())
+		}
+	}, nil
+}
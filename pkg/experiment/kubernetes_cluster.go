@@ -30,7 +30,7 @@ var (
 // ShouldLaunchKubernetesCluster checks RunOnKubernetesFlag and RunOnExistingKubernetesFlag
 // and returns information if Kubernetes cluster should be launched.
 func ShouldLaunchKubernetesCluster() bool {
-	return RunOnKubernetesFlag.Value() == true && RunOnExistingKubernetesFlag.Value() == false
+	return RunOnKubernetesFlag.Value() == true && RunOnExistingKubernetesFlag.Value() == false && conf.DryRunFlag.Value() == false
 }
 
 //LaunchKubernetesCluster starts new Kubernetes cluster using configuration provided with flags.
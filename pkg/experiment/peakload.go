@@ -15,10 +15,57 @@
 package experiment
 
 import (
+	"github.com/intelsdi-x/swan/pkg/conf"
 	"github.com/intelsdi-x/swan/pkg/executor"
 	"github.com/pkg/errors"
 )
 
+var (
+	// GenericTuningFlag makes tuning phase search for peak load with executor.LoadTuner instead of load generator's built-in tuning.
+	GenericTuningFlag = conf.NewBoolFlag("experiment_generic_tuning", "Search for peak load with short load probes instead of load generator's built-in tuning (load generator needs to report achieved QPS and latency, e.g. mutilate or YCSB).", false)
+	// TuningProbeDurationFlag is duration of single probe of generic tuning.
+	TuningProbeDurationFlag = conf.NewDurationFlag("experiment_tuning_probe_duration", "Duration of single load probe in generic tuning.", executor.DefaultLoadTunerConfig().ProbeDuration)
+	// TuningProbeRepetitionsFlag is number of probes of every load in generic tuning.
+	TuningProbeRepetitionsFlag = conf.NewIntFlag("experiment_tuning_probe_repetitions", "Number of probes of every load in generic tuning (medians of achieved QPS and latency are used).", executor.DefaultLoadTunerConfig().ProbeRepetitions)
+	// TuningInitialLoadFlag is the first load probed by generic tuning.
+	TuningInitialLoadFlag = conf.NewIntFlag("experiment_tuning_initial_load", "Initial load in generic tuning; it is doubled until SLO is violated and then peak load is found with binary search.", executor.DefaultLoadTunerConfig().InitialLoad)
+	// TuningMaxLoadFlag limits load probed by generic tuning.
+	TuningMaxLoadFlag = conf.NewIntFlag("experiment_tuning_max_load", "Maximal load probed in generic tuning (0 means no limit; the search then stops when achieved QPS stops growing).", executor.DefaultLoadTunerConfig().MaxLoad)
+	// TuningToleranceFlag is noise tolerated by generic tuning.
+	TuningToleranceFlag = conf.NewIntFlag("experiment_tuning_tolerance", "Percent by which latency can exceed SLO and achieved QPS can be lower than requested in generic tuning.", int(executor.DefaultLoadTunerConfig().Tolerance*100))
+)
+
+// DefaultLoadTunerConfig returns configuration of generic tuning from flags.
+func DefaultLoadTunerConfig() executor.LoadTunerConfig {
+	config := executor.DefaultLoadTunerConfig()
+	config.ProbeDuration = TuningProbeDurationFlag.Value()
+	config.ProbeRepetitions = TuningProbeRepetitionsFlag.Value()
+	config.InitialLoad = TuningInitialLoadFlag.Value()
+	config.MaxLoad = TuningMaxLoadFlag.Value()
+	config.Tolerance = float64(TuningToleranceFlag.Value()) / 100
+	return config
+}
+
+// tune returns peak load found by load generator's built-in tuning or, when GenericTuningFlag is set, by executor.LoadTuner.
+// Tuning of load generators implementing executor.ConfigurableTuner is configured with flags.
+func tune(loadGenerator executor.LoadGenerator, slo int) (int, error) {
+	if !GenericTuningFlag.Value() {
+		if tuner, ok := loadGenerator.(executor.ConfigurableTuner); ok {
+			peakLoad, _, err := tuner.TuneWithConfig(slo, DefaultLoadTunerConfig())
+			return peakLoad, err
+		}
+		peakLoad, _, err := loadGenerator.Tune(slo)
+		return peakLoad, err
+	}
+
+	parser, ok := loadGenerator.(executor.LoadMeasurementParser)
+	if !ok {
+		return 0, errors.Errorf("load generator %T does not report measurements required by generic tuning", loadGenerator)
+	}
+	peakLoad, _, err := executor.NewLoadTuner(loadGenerator, parser, DefaultLoadTunerConfig()).Tune(slo)
+	return peakLoad, err
+}
+
 // GetPeakLoad runs tuning in order to determine the peak load.
 func GetPeakLoad(hpLauncher executor.Launcher, loadGenerator executor.LoadGenerator, slo int) (peakLoad int, err error) {
 	prTask, err := hpLauncher.Launch()
@@ -32,13 +79,14 @@ func GetPeakLoad(hpLauncher executor.Launcher, loadGenerator executor.LoadGenera
 			err = errStop
 		}
 	}()
+	defer Shutdown().TrackTaskHandle(prTask)()
 
 	err = loadGenerator.Populate()
 	if err != nil {
 		return 0, errors.Wrap(err, "tunning: cannot populate high-priority task with data")
 	}
 
-	peakLoad, _, err = loadGenerator.Tune(slo)
+	peakLoad, err = tune(loadGenerator, slo)
 	if err != nil {
 		return 0, errors.Wrap(err, "tuning failed")
 	}
@@ -0,0 +1,44 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package experiment
+
+import (
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type configurableTuner struct {
+	executor.MockLoadGenerator
+	config executor.LoadTunerConfig
+}
+
+func (t *configurableTuner) TuneWithConfig(slo int, config executor.LoadTunerConfig) (int, int, error) {
+	t.config = config
+	return 1000, slo, nil
+}
+
+func TestTune(t *testing.T) {
+	Convey("Tuning of configurable tuner should be configured with flags", t, func() {
+		tuner := &configurableTuner{}
+
+		peakLoad, err := tune(tuner, 500)
+		So(err, ShouldBeNil)
+		So(peakLoad, ShouldEqual, 1000)
+		So(tuner.config, ShouldResemble, DefaultLoadTunerConfig())
+		So(tuner.AssertNotCalled(t, "Tune", 500), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package experiment
+
+import (
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/metadata"
+	"github.com/sirupsen/logrus"
+)
+
+// ResourceUsageMetadataKind is kind of metadata resource usage of tasks is recorded with.
+const ResourceUsageMetadataKind = "resource_usage"
+
+// RecordResourceUsage records resources consumed by terminated tasks (by name) in phase as metadata.
+// Keys have form "<phase> <name>_<measure>" (e.g. "baseline memcached_user_time_seconds").
+// Tasks not supporting resource accounting are skipped.
+func RecordResourceUsage(metaData metadata.Metadata, phase string, tasks map[string]executor.TaskInfo) error {
+	records := map[string]string{}
+	for name, task := range tasks {
+		if task == nil {
+			continue
+		}
+		usage, err := executor.GetResourceUsage(task)
+		if err != nil {
+			logrus.Debugf("Resource usage of %s in phase %q is not available: %s", name, phase, err.Error())
+			continue
+		}
+		for key, value := range usage.Metadata(phase + " " + name) {
+			records[key] = value
+		}
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+	return metaData.RecordMap(records, ResourceUsageMetadataKind)
+}
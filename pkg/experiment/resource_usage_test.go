@@ -0,0 +1,50 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package experiment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type accountedTaskHandle struct {
+	executor.MockTaskHandle
+	usage executor.ResourceUsage
+}
+
+func (h *accountedTaskHandle) ResourceUsage() (executor.ResourceUsage, error) {
+	return h.usage, nil
+}
+
+func TestRecordResourceUsage(t *testing.T) {
+	Convey("Resource usage of tasks should be recorded with phase and task name", t, func() {
+		metadata := &recordingMetadata{}
+		accounted := &accountedTaskHandle{usage: executor.ResourceUsage{WallTime: 2 * time.Second}}
+		unaccounted := new(executor.MockTaskHandle)
+		unaccounted.On("String").Return("task")
+
+		err := RecordResourceUsage(metadata, "phase", map[string]executor.TaskInfo{
+			"memcached": accounted,
+			"stress":    unaccounted,
+		})
+		So(err, ShouldBeNil)
+		So(metadata.records, ShouldHaveLength, 1)
+		So(metadata.records[0]["phase memcached_wall_time_seconds"], ShouldEqual, "2")
+		So(metadata.records[0], ShouldHaveLength, 6)
+	})
+}
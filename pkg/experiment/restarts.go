@@ -0,0 +1,39 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package experiment
+
+import (
+	"fmt"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/metadata"
+	"github.com/sirupsen/logrus"
+)
+
+// RestartMetadataKind is kind of metadata restarts of supervised tasks are recorded with.
+const RestartMetadataKind = "restart"
+
+// RestartRecorder returns function recording restarts of supervised tasks in phase as metadata
+// (to be used as executor.SupervisorConfig.OnRestart).
+// Keys have form "<phase> <task> restart_<count>" and values describe the reason of restart.
+func RestartRecorder(metaData metadata.Metadata, phase string) func(executor.Restart) {
+	return func(restart executor.Restart) {
+		key := fmt.Sprintf("%s %s restart_%d", phase, restart.Task, restart.Count)
+		err := metaData.Record(key, restart.String(), RestartMetadataKind)
+		if err != nil {
+			logrus.Errorf("Cannot record %s: %s", restart, err.Error())
+		}
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/intelsdi-x/swan/pkg/experiment"
 	"github.com/intelsdi-x/swan/pkg/isolation"
 	"github.com/intelsdi-x/swan/pkg/kubernetes"
+	"github.com/sirupsen/logrus"
 )
 
 var (
@@ -54,8 +55,12 @@ type ExecutorFactory interface {
 	BuildBestEffortExecutor(decorator ...isolation.Decorator) (executor.Executor, error)
 }
 
-// NewExecutorFactory returns Local or Kubernetes executor factory, depending on flags.
+// NewExecutorFactory returns Local, Kubernetes or dry run executor factory, depending on flags.
 func NewExecutorFactory() ExecutorFactory {
+	if conf.DryRunFlag.Value() {
+		return NewDryRunExecutorFactory()
+	}
+
 	if experiment.RunOnKubernetesFlag.Value() {
 		return NewKubernetesExecutorFactory()
 	}
@@ -82,6 +87,42 @@ func (factory LocalExecutorFactory) BuildBestEffortExecutor(decorators ...isolat
 	return executor.NewLocalIsolated(decorators...), nil
 }
 
+// DryRunExecutorFactory produces Recording executors, which only record commands of HP and BE workloads.
+type DryRunExecutorFactory struct {
+	target string
+}
+
+// NewDryRunExecutorFactory returns Dry Run Executor Factory instance.
+func NewDryRunExecutorFactory() ExecutorFactory {
+	target := "local"
+	if experiment.RunOnKubernetesFlag.Value() {
+		target = "kubernetes"
+	}
+	return &DryRunExecutorFactory{target: target}
+}
+
+// BuildHighPriorityExecutor returns recording executor simulating tasks which run until they are stopped.
+func (factory DryRunExecutorFactory) BuildHighPriorityExecutor(decorators ...isolation.Decorator) (executor.Executor, error) {
+	return executor.NewDryRun(factory.target, executor.SimulatedTask{Duration: -1}, decorators...)
+}
+
+// BuildBestEffortExecutor returns recording executor simulating tasks which run until they are stopped.
+func (factory DryRunExecutorFactory) BuildBestEffortExecutor(decorators ...isolation.Decorator) (executor.Executor, error) {
+	return executor.NewDryRun(factory.target, executor.SimulatedTask{Duration: -1}, decorators...)
+}
+
+// PrintDryRunPlan prints execution plan of commands recorded in dry run (see DryRunExecutorFactory).
+// It does nothing when dry run is not requested, so experiments call it on every exit path.
+func PrintDryRunPlan() {
+	if !conf.DryRunFlag.Value() {
+		return
+	}
+	err := executor.WriteDryRunPlan(os.Stdout)
+	if err != nil {
+		logrus.Errorf("Cannot print execution plan: %s", err.Error())
+	}
+}
+
 // KubernetesExecutorFactory produces Kubernetes Executors.
 type KubernetesExecutorFactory struct {
 }
@@ -30,10 +30,14 @@ var (
 	SLOFlag = conf.NewIntFlag("experiment_slo", "Given SLO for the HP workload in experiment. [us]", 500)
 	// LoadPointsCountFlag represents number of load points per each aggressor
 	LoadPointsCountFlag = conf.NewIntFlag("experiment_load_points", "Number of load points to test", 10)
+	// LoadScheduleFlag chooses how load points are spread
+	LoadScheduleFlag = conf.NewStringFlag("experiment_load_schedule", "Schedule of load points: 'linear' (evenly spread up to peak load) or 'adaptive' (refined where measured latency changes fastest or crosses SLO; linear when SLI cannot be measured)", LinearLoadSchedule)
 	// LoadDurationFlag allows us to set repetition duration from command line argument or environmental variable
 	LoadDurationFlag = conf.NewDurationFlag("experiment_load_duration", "Load duration on HP task.", 15*time.Second)
 	// RepetitionsFlag indicates number of repetitions per each load point
 	RepetitionsFlag = conf.NewIntFlag("experiment_repetitions", "Number of repetitions for each measurement", 1)
+	// RepetitionRetriesFlag indicates how many times repetition failed for a transient reason is retried
+	RepetitionRetriesFlag = conf.NewIntFlag("experiment_repetition_retries", "Number of times repetition is retried when it fails for a transient reason (OOM kill, launch timeout or task killed by signal)", 0)
 	// StopOnErrorFlag forces experiment to terminate on error
 	StopOnErrorFlag = conf.NewBoolFlag("experiment_stop_on_error", "Stop experiment in a case of error", false)
 	// PeakLoadFlag represents special case when peak load is provided instead of calculated from Tuning phase.
@@ -23,7 +23,18 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	// NUMAPlacementLocal places LLC-interfering BE workloads on threads sharing LLC with HP workload.
+	NUMAPlacementLocal = "local"
+	// NUMAPlacementCrossNUMA places LLC-interfering BE workloads on threads of NUMA node other than
+	// HP workload's, but binds their memory to HP workload's NUMA node, so they interfere on remote memory.
+	NUMAPlacementCrossNUMA = "cross-numa"
+)
+
 var (
+	// BeNUMAPlacementFlag allows to choose placement of LLC-interfering best effort workloads.
+	BeNUMAPlacementFlag = conf.NewStringFlag("experiment_be_numa_placement", "Placement of LLC-interfering BE workloads: 'local' (sharing LLC with HP workload) or 'cross-numa' (running on CPUs of other NUMA node with memory bound to NUMA node of HP workload to measure remote memory interference).", NUMAPlacementLocal)
+
 	// For CPU count based isolation policy flags.
 	hpCPUCountFlag = conf.NewIntFlag("experiment_hp_workload_cpu_count", "Number of CPUs assigned to high priority task. CPUs will be assigned automatically to workloads.", 1)
 	beCPUCountFlag = conf.NewIntFlag("experiment_be_workload_cpu_count", "Number of CPUs assigned to best effort task. CPUs will be assigned automatically to workloads.", 1)
@@ -50,9 +61,66 @@ func GetWorkloadsIsolations() (hpIsolation, beL1Isolation, beLLCIsolation isolat
 	beL1Isolation = isolation.Taskset{CPUList: beL1Threads}
 	beLLCIsolation = isolation.Taskset{CPUList: beLLCThreads}
 
+	switch BeNUMAPlacementFlag.Value() {
+	case NUMAPlacementLocal:
+	case NUMAPlacementCrossNUMA:
+		allThreads, err := topo.Discover()
+		errutil.CheckWithContext(err, "cannot discover CPU topology")
+		var hpNodes, beRemoteThreads isolation.IntSet
+		if isManualPolicy() {
+			// Threads chosen by user are respected.
+			beRemoteThreads = beLLCThreads
+			hpNodes, err = numaNodes(allThreads, hpThreads)
+		} else {
+			hpNodes, beRemoteThreads, err = crossNUMAPlacement(allThreads, hpThreads, beCPUCountFlag.Value())
+		}
+		errutil.Check(err)
+
+		log.Infof("Using cross-NUMA placement: BE-LLC workloads run on CPU threads %s with memory bound to NUMA nodes %s of HP workload", beRemoteThreads.AsRangeString(), hpNodes.AsRangeString())
+		memoryPolicy := isolation.Numactl{Policy: isolation.NUMAPolicyBind, Nodes: hpNodes}
+		hpIsolation = isolation.Decorators{hpIsolation, memoryPolicy}
+		beLLCIsolation = isolation.Decorators{isolation.Taskset{CPUList: beRemoteThreads}, memoryPolicy}
+	default:
+		errutil.Check(errors.Errorf("unknown BE NUMA placement %q (flag %q)", BeNUMAPlacementFlag.Value(), BeNUMAPlacementFlag.Name))
+	}
+
 	return hpIsolation, beL1Isolation, beLLCIsolation
 }
 
+// numaNodes returns NUMA nodes of threads.
+func numaNodes(allThreads topo.ThreadSet, threads isolation.IntSet) (isolation.IntSet, error) {
+	nodes := allThreads.Filter(func(t topo.Thread) bool { return threads.Contains(t.ID()) }).AvailableNUMANodes()
+	if nodes.Empty() {
+		return nil, errors.Errorf("cannot find NUMA nodes of threads %s - NUMA topology is unknown", threads.AsRangeString())
+	}
+	return nodes, nil
+}
+
+// crossNUMAPlacement returns NUMA nodes of HP threads and beCPUCount threads (one per core, sharing LLC)
+// from NUMA node other than HP threads' ones.
+func crossNUMAPlacement(allThreads topo.ThreadSet, hpThreads isolation.IntSet, beCPUCount int) (hpNodes, beThreads isolation.IntSet, err error) {
+	hpNodes, err = numaNodes(allThreads, hpThreads)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	remote := allThreads.Filter(func(t topo.Thread) bool { return t.NUMANode() >= 0 && !hpNodes.Contains(t.NUMANode()) })
+	if len(remote) == 0 {
+		return nil, nil, errors.Errorf("there is no NUMA node other than %s of HP threads - cross-NUMA placement requires at least 2 NUMA nodes", hpNodes.AsRangeString())
+	}
+
+	// Keep BE threads within single remote NUMA node.
+	remoteNode, err := remote.FromNUMANodes(remote.AvailableNUMANodes().AsSlice()[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	beThreads, err = remoteNode.SharedCacheThreads().AvailableThreads().Take(beCPUCount)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "cannot allocate threads for BE task on remote NUMA node (%d required)", beCPUCount)
+	}
+	return hpNodes, beThreads, nil
+}
+
 // GetWorkloadCPUThreads returns set of Thread IDs for High Priority and Best Effort workloads from flags.
 func GetWorkloadCPUThreads() (hpThreads, beL1Threads, beLLCThreads isolation.IntSet) {
 	if isManualPolicy() {
@@ -91,28 +159,51 @@ func isManualPolicy() bool {
 }
 
 func newDefaultTopology(hpCPUCount, beCPUCount int) (defaultTopology, error) {
+	allThreads, err := topo.Discover()
+	if err != nil {
+		return defaultTopology{}, errors.Wrap(err, "cannot discover CPU topology")
+	}
+	return newTopology(allThreads, hpCPUCount, beCPUCount)
+}
+
+// newTopology places HP and BE workloads on threads sharing last-level cache.
+// BE threads are chosen outside of L2 cache domains of HP threads when possible,
+// so LLC-interfering workloads do not interfere on L2 cache (shared by several
+// cores on some platforms) as well.
+func newTopology(allThreads topo.ThreadSet, hpCPUCount, beCPUCount int) (defaultTopology, error) {
 	var topology defaultTopology
 	var err error
 
-	threadSet := topo.SharedCacheThreads()
+	threadSet := allThreads.SharedCacheThreads()
 	topology.HpThreadIDs, err = threadSet.AvailableThreads().Take(hpCPUCount)
 	if err != nil {
 		return topology, errors.Wrapf(err, "there is not enough cpus to run HP task (%d required)", hpCPUCount)
 	}
 
 	// Allocate sibling threads of HP workload to create L1 cache contention
-	threadSetOfHpThreads, err := topo.NewThreadSetFromIntSet(topology.HpThreadIDs)
+	threadSetOfHpThreads, err := allThreads.FromThreads(topology.HpThreadIDs.AsSlice()...)
 	if err != nil {
 		return topology, errors.Wrapf(err, "cannot allocate threads for HP task (threads IDs=%v)", topology.HpThreadIDs)
 	}
-	topology.SiblingThreadsToHpThreads = topo.GetSiblingThreadsOfThreadSet(threadSetOfHpThreads)
-
-	// Allocate BE threads from the remaining threads on the same socket as the
-	// HP workload.
-	remaining := threadSet.AvailableThreads().Difference(topology.HpThreadIDs)
-	topology.SharingLLCButNotL1Threads, err = remaining.Take(beCPUCount)
+	hpCores, err := allThreads.FromCores(threadSetOfHpThreads.AvailableCores().AsSlice()...)
+	if err != nil {
+		return topology, errors.Wrapf(err, "cannot find cores of HP task (threads IDs=%v)", topology.HpThreadIDs)
+	}
+	topology.SiblingThreadsToHpThreads = hpCores.Difference(threadSetOfHpThreads)
+
+	// Allocate BE threads from the remaining threads sharing last-level cache
+	// with the HP workload.
+	remaining := threadSet.Filter(func(t topo.Thread) bool { return !topology.HpThreadIDs.Contains(t.ID()) })
+	hpL2Caches := threadSetOfHpThreads.AvailableCaches(2)
+	outsideHpL2 := remaining.Filter(func(t topo.Thread) bool { return !hpL2Caches.Contains(t.CacheID(2)) })
+	if len(outsideHpL2) >= beCPUCount {
+		remaining = outsideHpL2
+	} else if !hpL2Caches.Empty() {
+		log.Warnf("Not enough threads outside of L2 cache of HP task (%d required, %d left) - BE task will share L2 cache with HP task", beCPUCount, len(outsideHpL2))
+	}
+	topology.SharingLLCButNotL1Threads, err = remaining.AvailableThreads().Take(beCPUCount)
 	if err != nil {
-		return topology, errors.Wrapf(err, "cannot allocate remaining threads for BE task (%d required, %d left) - minimum 2 CPUs are required to run experiment", beCPUCount, len(remaining.AsSlice()))
+		return topology, errors.Wrapf(err, "cannot allocate remaining threads for BE task (%d required, %d left) - minimum 2 CPUs are required to run experiment", beCPUCount, len(remaining))
 	}
 
 	return topology, nil
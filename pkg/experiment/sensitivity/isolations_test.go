@@ -0,0 +1,88 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sensitivity
+
+import (
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/isolation/topo"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newThread returns thread without HyperThreads with L2 cache shared by pairs of cores
+// and L3 cache shared by 8 cores.
+func newThread(id int) topo.Thread {
+	return topo.NewThreadWithTopology(id, id, 0, id/8, []topo.Cache{
+		{Level: 1, Type: topo.CacheTypeData, ID: id},
+		{Level: 2, Type: topo.CacheTypeUnified, ID: id / 2},
+		{Level: 3, Type: topo.CacheTypeUnified, ID: id / 8},
+	})
+}
+
+func TestNewTopology(t *testing.T) {
+	Convey("When placing workloads automatically on platform with shared L2 caches and several L3 slices per socket", t, func() {
+		threads := topo.ThreadSet{}
+		for id := 0; id < 16; id++ {
+			threads = append(threads, newThread(id))
+		}
+
+		topology, err := newTopology(threads, 1, 2)
+		So(err, ShouldBeNil)
+
+		Convey("BE threads should share L3 cache slice, but not L2 cache with HP threads", func() {
+			So(topology.HpThreadIDs.Equals(isolation.NewIntSet(0)), ShouldBeTrue)
+			So(topology.SharingLLCButNotL1Threads.Equals(isolation.NewIntSet(2, 3)), ShouldBeTrue)
+			So(topology.SiblingThreadsToHpThreads, ShouldBeEmpty)
+		})
+
+		Convey("BE threads should share L2 cache when there is not enough threads outside of it", func() {
+			topology, err := newTopology(threads, 1, 7)
+			So(err, ShouldBeNil)
+			So(topology.SharingLLCButNotL1Threads.Equals(isolation.NewIntSet(1, 2, 3, 4, 5, 6, 7)), ShouldBeTrue)
+		})
+
+		Convey("It should fail when there is not enough threads in L3 cache slice", func() {
+			_, err := newTopology(threads, 1, 8)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestCrossNUMAPlacement(t *testing.T) {
+	Convey("When placing BE workloads across NUMA nodes", t, func() {
+		threads := topo.ThreadSet{}
+		for id := 0; id < 16; id++ {
+			threads = append(threads, newThread(id))
+		}
+
+		Convey("BE threads should be on other NUMA node than HP threads", func() {
+			hpNodes, beThreads, err := crossNUMAPlacement(threads, isolation.NewIntSet(0), 2)
+			So(err, ShouldBeNil)
+			So(hpNodes.Equals(isolation.NewIntSet(0)), ShouldBeTrue)
+			So(beThreads.Equals(isolation.NewIntSet(8, 9)), ShouldBeTrue)
+		})
+
+		Convey("It should fail when there is single NUMA node", func() {
+			_, _, err := crossNUMAPlacement(threads[:8], isolation.NewIntSet(0), 2)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("It should fail when NUMA topology is unknown", func() {
+			_, _, err := crossNUMAPlacement(topo.ThreadSet{topo.NewThread(0, 0, 0), topo.NewThread(1, 1, 0)}, isolation.NewIntSet(0), 1)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
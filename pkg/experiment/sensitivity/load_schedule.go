@@ -0,0 +1,214 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sensitivity
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// LinearLoadSchedule spreads load points evenly up to peak load.
+	LinearLoadSchedule = "linear"
+	// AdaptiveLoadSchedule refines load points where latency changes fastest or crosses SLO.
+	AdaptiveLoadSchedule = "adaptive"
+
+	// minAdaptiveInterval is the smallest distance (fraction of peak load) between load points chosen by adaptive schedule.
+	minAdaptiveInterval = 0.01
+)
+
+// LoadSchedule chooses load points measured in colocation with an aggressor.
+type LoadSchedule interface {
+	// Count returns number of load points in the schedule.
+	Count() int
+	// Next returns fraction of peak load generated in the next load point.
+	// Second value is false when all load points have been measured.
+	Next() (fraction float64, ok bool)
+	// Observe passes SLI measured at load point returned by the last call to Next.
+	Observe(sli int)
+}
+
+// LoadScheduleFactory returns new schedule for every aggressor.
+type LoadScheduleFactory func() LoadSchedule
+
+// DefaultLoadSchedule returns schedule of load points chosen with experiment_load_schedule and experiment_load_points flags.
+func DefaultLoadSchedule(slo int) (LoadScheduleFactory, error) {
+	return NewLoadScheduleFactory(LoadScheduleFlag.Value(), LoadPointsCountFlag.Value(), slo)
+}
+
+// NewLoadScheduleFactory returns factory of schedules with count load points of given kind (LinearLoadSchedule or AdaptiveLoadSchedule).
+func NewLoadScheduleFactory(kind string, count int, slo int) (LoadScheduleFactory, error) {
+	switch kind {
+	case LinearLoadSchedule:
+		return func() LoadSchedule { return NewFixedLoadSchedule(EvenLoadPoints(count)) }, nil
+	case AdaptiveLoadSchedule:
+		return func() LoadSchedule { return NewAdaptiveLoadSchedule(count, slo) }, nil
+	default:
+		return nil, errors.Errorf("unknown load schedule %q (should be %q or %q)", kind, LinearLoadSchedule, AdaptiveLoadSchedule)
+	}
+}
+
+// EvenLoadPoints returns count load points evenly spread up to peak load (e.g. 0.25, 0.5, 0.75 and 1 for 4 load points).
+func EvenLoadPoints(count int) []float64 {
+	loadPoints := make([]float64, count)
+	for i := range loadPoints {
+		loadPoints[i] = float64(i+1) / float64(count)
+	}
+	return loadPoints
+}
+
+type fixedLoadSchedule struct {
+	fractions []float64
+	next      int
+}
+
+// NewFixedLoadSchedule returns schedule of given load points (fractions of peak load) which ignores measured SLI.
+func NewFixedLoadSchedule(fractions []float64) LoadSchedule {
+	return &fixedLoadSchedule{fractions: fractions}
+}
+
+func (s *fixedLoadSchedule) Count() int {
+	return len(s.fractions)
+}
+
+func (s *fixedLoadSchedule) Next() (float64, bool) {
+	if s.next >= len(s.fractions) {
+		return 0, false
+	}
+	s.next++
+	return s.fractions[s.next-1], true
+}
+
+func (s *fixedLoadSchedule) Observe(sli int) {}
+
+type adaptiveLoadSchedule struct {
+	count int
+	slo   int
+	// coarse load points are measured first to find the shape of latency curve.
+	coarse []float64
+	// chosen load points in order they were returned.
+	chosen []float64
+	// measured SLI of chosen load points (by fraction).
+	measured map[float64]int
+}
+
+// NewAdaptiveLoadSchedule returns schedule of count load points which first measures half of them evenly spread
+// up to peak load and then refines intervals where measured SLI changes fastest, preferring intervals where it crosses SLO.
+// When SLI of any load point is not observed, remaining load points are chosen like in linear schedule.
+func NewAdaptiveLoadSchedule(count int, slo int) LoadSchedule {
+	coarse := (count + 1) / 2
+	if coarse < 2 {
+		coarse = count
+	}
+	return &adaptiveLoadSchedule{
+		count:    count,
+		slo:      slo,
+		coarse:   EvenLoadPoints(coarse),
+		measured: map[float64]int{},
+	}
+}
+
+func (s *adaptiveLoadSchedule) Count() int {
+	return s.count
+}
+
+func (s *adaptiveLoadSchedule) Next() (float64, bool) {
+	if len(s.chosen) >= s.count {
+		return 0, false
+	}
+
+	var fraction float64
+	refined := false
+	if len(s.chosen) < len(s.coarse) {
+		fraction = s.coarse[len(s.chosen)]
+	} else if len(s.measured) == len(s.chosen) {
+		fraction, refined = s.refine()
+	}
+	if len(s.chosen) >= len(s.coarse) && !refined {
+		fraction = s.linear()
+	}
+
+	s.chosen = append(s.chosen, fraction)
+	return fraction, true
+}
+
+func (s *adaptiveLoadSchedule) Observe(sli int) {
+	if len(s.chosen) > 0 {
+		s.measured[s.chosen[len(s.chosen)-1]] = sli
+	}
+}
+
+// refine returns middle of interval between measured load points where SLI changes fastest
+// (relatively to SLO and width of the interval). Intervals where SLI crosses SLO are refined first.
+// Interval from zero load to the first load point is refined only when SLI crosses SLO in it,
+// because SLI at zero load is not known (it is assumed to meet SLO).
+func (s *adaptiveLoadSchedule) refine() (float64, bool) {
+	fractions := []float64{0}
+	for fraction := range s.measured {
+		fractions = append(fractions, fraction)
+	}
+	sort.Float64s(fractions)
+
+	measured := map[float64]int{0: 0}
+	for fraction, sli := range s.measured {
+		measured[fraction] = sli
+	}
+	if len(fractions) > 1 {
+		measured[0] = minInt(measured[fractions[1]], s.slo)
+	}
+
+	slo := math.Max(float64(s.slo), 1)
+	best, bestCrosses, bestGradient := 0.0, false, -1.0
+	for i := 1; i < len(fractions); i++ {
+		low, high := fractions[i-1], fractions[i]
+		if high-low < 2*minAdaptiveInterval {
+			continue
+		}
+		lowSLI, highSLI := measured[low], measured[high]
+		gradient := math.Abs(float64(highSLI-lowSLI)) / slo / (high - low)
+		crosses := (lowSLI <= s.slo) != (highSLI <= s.slo)
+		if (crosses && !bestCrosses) || (crosses == bestCrosses && gradient > bestGradient) {
+			best, bestCrosses, bestGradient = (low+high)/2, crosses, gradient
+		}
+	}
+	return best, bestGradient >= 0
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// linear returns first load point of linear schedule which has not been chosen yet.
+func (s *adaptiveLoadSchedule) linear() float64 {
+	linear := EvenLoadPoints(s.count)
+	for _, fraction := range linear {
+		chosen := false
+		for _, c := range s.chosen {
+			if math.Abs(c-fraction) < minAdaptiveInterval/2 {
+				chosen = true
+				break
+			}
+		}
+		if !chosen {
+			return fraction
+		}
+	}
+	return linear[len(linear)-1]
+}
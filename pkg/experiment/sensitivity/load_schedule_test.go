@@ -0,0 +1,112 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sensitivity
+
+import (
+	"sort"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// loadPoints returns all load points of schedule which does not get SLI feedback.
+func loadPoints(schedule LoadSchedule) (fractions []float64) {
+	for {
+		fraction, ok := schedule.Next()
+		if !ok {
+			return fractions
+		}
+		fractions = append(fractions, fraction)
+	}
+}
+
+func TestLoadSchedule(t *testing.T) {
+	Convey("Linear schedule should spread load points evenly up to peak load", t, func() {
+		factory, err := NewLoadScheduleFactory(LinearLoadSchedule, 4, 500)
+		So(err, ShouldBeNil)
+		schedule := factory()
+		So(schedule.Count(), ShouldEqual, 4)
+		So(loadPoints(schedule), ShouldResemble, []float64{0.25, 0.5, 0.75, 1})
+
+		_, err = NewLoadScheduleFactory("exponential", 4, 500)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("When adaptive schedule is used", t, func() {
+		schedule := NewAdaptiveLoadSchedule(10, 500)
+		So(schedule.Count(), ShouldEqual, 10)
+
+		Convey("It should fall back to linear schedule when SLI is not available", func() {
+			fractions := loadPoints(schedule)
+			sort.Float64s(fractions)
+			So(fractions, ShouldHaveLength, 10)
+			for i, fraction := range fractions {
+				So(fraction, ShouldAlmostEqual, EvenLoadPoints(10)[i])
+			}
+		})
+
+		Convey("It should refine load points where latency rises and crosses SLO", func() {
+			// Latency is flat up to 60% of peak load and then it rises steeply crossing SLO at 68%.
+			latency := func(fraction float64) int {
+				if fraction <= 0.6 {
+					return 100
+				}
+				return 100 + int((fraction-0.6)*5000)
+			}
+
+			var fractions []float64
+			for {
+				fraction, ok := schedule.Next()
+				if !ok {
+					break
+				}
+				fractions = append(fractions, fraction)
+				schedule.Observe(latency(fraction))
+			}
+
+			So(fractions, ShouldHaveLength, 10)
+			So(fractions[:5], ShouldResemble, EvenLoadPoints(5))
+			for _, fraction := range fractions[5:] {
+				So(fraction, ShouldBeGreaterThan, 0.6)
+			}
+			So(fractions[5], ShouldAlmostEqual, 0.7)
+		})
+
+		Convey("It should prefer narrow steep interval to wide flat one with larger change of SLI", func() {
+			schedule := &adaptiveLoadSchedule{count: 10, slo: 1000, measured: map[float64]int{0.2: 100, 0.3: 200, 1: 500}}
+			fraction, ok := schedule.refine()
+			So(ok, ShouldBeTrue)
+			So(fraction, ShouldAlmostEqual, 0.25)
+		})
+
+		Convey("It should refine interval from zero load when SLO is violated at the first load point", func() {
+			schedule := &adaptiveLoadSchedule{count: 10, slo: 500, measured: map[float64]int{0.5: 600, 1: 5000}}
+			fraction, ok := schedule.refine()
+			So(ok, ShouldBeTrue)
+			So(fraction, ShouldAlmostEqual, 0.25)
+		})
+
+		Convey("It should fall back to linear schedule when SLI of any load point is missing", func() {
+			for i := 0; i < 4; i++ {
+				schedule.Next()
+				schedule.Observe(100)
+			}
+			schedule.Next()
+			fraction, ok := schedule.Next()
+			So(ok, ShouldBeTrue)
+			So(fraction, ShouldAlmostEqual, 0.1)
+		})
+	})
+}
@@ -0,0 +1,546 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sensitivity
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/experiment"
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/metadata"
+	"github.com/intelsdi-x/swan/pkg/snap"
+	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// LoadPointMetadataKind is kind of metadata load points chosen for every aggressor are recorded with.
+	// Keys have form "<aggressor> load_point_<number>" and values are loads (QPS).
+	LoadPointMetadataKind = "load_point"
+
+	// LoadGeneratorTaskName is name of load generator task in recorded resource usage.
+	LoadGeneratorTaskName = "load_generator"
+
+	// defaultSessionFlushTime is time given to Snap sessions to publish collected metrics before they are stopped.
+	defaultSessionFlushTime = 5 * time.Second
+)
+
+// LauncherFactory builds launchers of High Priority and Best Effort workloads (see WorkloadFactory).
+// Best Effort launcher might be nil when no aggressor should be run (baseline).
+type LauncherFactory interface {
+	BuildDefaultHighPriorityLauncher(workloadName string, tags snap.Tags) (executor.Launcher, error)
+	BuildDefaultBestEffortLauncher(workloadName string, tags snap.Tags) (executor.Launcher, error)
+}
+
+// Phase describes single repetition of a load point measured in colocation with an aggressor.
+type Phase struct {
+	Name       string
+	Aggressor  string
+	LoadPoint  int
+	QPS        int
+	Repetition int
+	// Attempt is number of times repetition failed for a transient reason has been retried.
+	Attempt int
+	// Tags are applied to metrics collected in the phase.
+	Tags snap.Tags
+}
+
+// RecordName returns name metadata of the phase (e.g. resource usage) is recorded under.
+// Retried attempts are recorded separately, so that records of failed attempt are not mixed with the retry's.
+func (p Phase) RecordName() string {
+	if p.Attempt == 0 {
+		return p.Name
+	}
+	return fmt.Sprintf("%s; attempt %d", p.Name, p.Attempt)
+}
+
+// Repetition is a phase with tasks running in it. BestEffort is nil in baseline.
+type Repetition struct {
+	Phase
+	HighPriority  executor.TaskHandle
+	BestEffort    executor.TaskHandle
+	LoadGenerator executor.TaskHandle
+}
+
+// SessionLauncherFactory returns launcher of Snap session publishing results of repetition
+// (e.g. parsing load generator output). It is called when load generation is finished.
+type SessionLauncherFactory func(repetition Repetition) (executor.Launcher, error)
+
+// RunnerHooks are optional callbacks called by Runner.
+type RunnerHooks struct {
+	// BeforePhase is called before any task of the phase is launched. Returned error fails the repetition.
+	BeforePhase func(phase Phase) error
+	// AfterPhase is called when load generation is finished and results are being published,
+	// before tasks of the repetition are stopped. Returned error fails the repetition.
+	AfterPhase func(repetition Repetition) error
+	// OnError is called when repetition has failed and will not be retried.
+	OnError func(phase Phase, err error)
+	// MeasureSLI returns SLI (e.g. 99th percentile latency in microseconds) achieved in successful repetition.
+	// It provides live feedback for adaptive load schedule.
+	MeasureSLI func(repetition Repetition) (int, error)
+}
+
+// RunnerConfig configures sensitivity experiment Runner.
+type RunnerConfig struct {
+	ExperimentID string
+	AppName      string
+	// HighPriorityWorkload is name of HP workload built by LauncherFactory (e.g. Memcached).
+	HighPriorityWorkload string
+	Aggressors           []string
+	// PeakLoad is found in tuning phase when it is equal to RunTuningPhase.
+	PeakLoad int
+	SLO      int
+	// LoadSchedule chooses load points measured with every aggressor.
+	LoadSchedule LoadScheduleFactory
+	Repetitions  int
+	LoadDuration time.Duration
+	// LoadGeneratorWaitTimeout is time to wait for load generator to stop on its own (0 means no limit).
+	LoadGeneratorWaitTimeout time.Duration
+	StopOnError              bool
+	// RepetitionRetries limits number of times repetition failed for a transient reason is retried.
+	RepetitionRetries int
+	SessionFlushTime  time.Duration
+	// Resume skips phases completed before experiment was interrupted and reuses recorded peak load.
+	Resume bool
+	// Supervisor restarts HP workload crashed during repetition (unless Policy is RestartNever).
+	Supervisor executor.SupervisorConfig
+	// Isolations (e.g. cgroups or resctrl groups workloads are decorated with) are created before
+	// tasks of every repetition are launched and cleaned when they are stopped.
+	Isolations []isolation.Isolation
+	Metadata   metadata.Metadata
+	// Shutdown tracks tasks and isolations of repetitions (process-wide coordinator when nil).
+	Shutdown *experiment.ShutdownCoordinator
+}
+
+// DefaultRunnerConfig returns configuration of experiment profiling HP workload read from flags.
+func DefaultRunnerConfig(experimentID, appName, highPriorityWorkload string, metaData metadata.Metadata) (RunnerConfig, error) {
+	supervisor, err := executor.DefaultSupervisorConfig()
+	if err != nil {
+		return RunnerConfig{}, errors.Wrapf(err, "cannot configure %s supervisor", highPriorityWorkload)
+	}
+	schedule, err := DefaultLoadSchedule(SLOFlag.Value())
+	if err != nil {
+		return RunnerConfig{}, errors.Wrapf(err, "invalid value of flag %q", LoadScheduleFlag.Name)
+	}
+
+	return RunnerConfig{
+		ExperimentID:             experimentID,
+		AppName:                  appName,
+		HighPriorityWorkload:     highPriorityWorkload,
+		Aggressors:               AggressorsFlag.Value(),
+		PeakLoad:                 PeakLoadFlag.Value(),
+		SLO:                      SLOFlag.Value(),
+		LoadSchedule:             schedule,
+		Repetitions:              RepetitionsFlag.Value(),
+		LoadDuration:             LoadDurationFlag.Value(),
+		LoadGeneratorWaitTimeout: LoadGeneratorWaitTimeoutFlag.Value(),
+		StopOnError:              StopOnErrorFlag.Value(),
+		RepetitionRetries:        RepetitionRetriesFlag.Value(),
+		SessionFlushTime:         defaultSessionFlushTime,
+		Resume:                   experiment.Resumed(),
+		Supervisor:               supervisor,
+		Metadata:                 metaData,
+		Shutdown:                 experiment.Shutdown(),
+	}, nil
+}
+
+// LoadGeneratorSLI returns function measuring SLI of repetition by parsing load generator output (e.g. with mutilate.ParseSLI).
+func LoadGeneratorSLI(parse func(io.Reader) (int, error)) func(Repetition) (int, error) {
+	return func(repetition Repetition) (int, error) {
+		output, err := repetition.LoadGenerator.StdoutFile()
+		if err != nil {
+			return 0, errors.Wrap(err, "cannot get load generator stdout file")
+		}
+		defer output.Close()
+		return parse(output)
+	}
+}
+
+// Runner drives sensitivity experiment: it runs HP workload under load generated at every load point
+// in colocation with every aggressor and publishes results with Snap sessions.
+type Runner struct {
+	config        RunnerConfig
+	factory       LauncherFactory
+	loadGenerator executor.LoadGenerator
+	sessions      []SessionLauncherFactory
+	hooks         RunnerHooks
+}
+
+// NewRunner is a constructor for Runner.
+func NewRunner(config RunnerConfig, factory LauncherFactory, loadGenerator executor.LoadGenerator, sessions []SessionLauncherFactory, hooks RunnerHooks) *Runner {
+	if config.Shutdown == nil {
+		config.Shutdown = experiment.Shutdown()
+	}
+	return &Runner{
+		config:        config,
+		factory:       factory,
+		loadGenerator: loadGenerator,
+		sessions:      sessions,
+		hooks:         hooks,
+	}
+}
+
+// Run finds peak load (unless it is configured), records experiment metadata and runs all repetitions
+// of every load point for every aggressor. Repetitions failed for a transient reason are retried.
+// Completed repetitions are checkpointed, so that they are skipped when experiment is resumed.
+// Errors of failed repetitions are collected and returned when experiment ends
+// or, when StopOnError is set, as soon as first repetition fails.
+func (r *Runner) Run() error {
+	checkpoints := experiment.NewCheckpoints(r.config.Metadata, r.config.Resume)
+
+	load, err := r.peakLoad(checkpoints)
+	if err != nil {
+		return err
+	}
+	err = checkpoints.RecordPeakLoad(load)
+	if err != nil {
+		return err
+	}
+
+	records := map[string]string{
+		"command_arguments": strings.Join(os.Args, ","),
+		"experiment_name":   r.config.AppName,
+		"peak_load":         strconv.Itoa(load),
+		"load_points":       strconv.Itoa(r.config.LoadSchedule().Count()),
+		"repetitions":       strconv.Itoa(r.config.Repetitions),
+		"load_duration":     r.config.LoadDuration.String(),
+	}
+	err = r.config.Metadata.RecordMap(records, metadata.TypeEmpty)
+	if err != nil {
+		return errors.Wrap(err, "cannot save metadata")
+	}
+
+	// Load points chosen before experiment was interrupted are reused, so that completed phases match them.
+	recordedLoadPoints := map[string]string{}
+	if r.config.Resume {
+		recordedLoadPoints, err = r.config.Metadata.GetByKind(LoadPointMetadataKind)
+		if err != nil {
+			return errors.Wrap(err, "cannot get recorded load points")
+		}
+	}
+
+	errColl := &errcollection.ErrorCollection{}
+	for _, aggressor := range r.config.Aggressors {
+		schedule := r.config.LoadSchedule()
+		for loadPoint := 0; ; loadPoint++ {
+			fraction, ok := schedule.Next()
+			if !ok {
+				break
+			}
+			// Calculate number of QPS in phase.
+			phaseQPS := int(float64(load)*fraction + 0.5)
+			loadPointKey := fmt.Sprintf("%s load_point_%d", aggressor, loadPoint)
+			if recorded, ok := recordedLoadPoints[loadPointKey]; ok {
+				if qps, err := strconv.Atoi(recorded); err == nil {
+					phaseQPS = qps
+				}
+			} else if err := r.config.Metadata.Record(loadPointKey, strconv.Itoa(phaseQPS), LoadPointMetadataKind); err != nil {
+				logrus.Errorf("Cannot record load point %d of aggressor %s: %s", loadPoint, aggressor, err.Error())
+			}
+
+			// SLI measured in repetitions of load point is passed to schedule.
+			var slis []int
+			retries := 0
+			for repetition := 0; repetition < r.config.Repetitions; repetition++ {
+				phase := r.newPhase(aggressor, loadPoint, phaseQPS, repetition, retries)
+				if checkpoints.Completed(phase.Name) {
+					logrus.Infof("Skipping completed phase: %s", phase.Name)
+					// SLI measured before experiment was interrupted is still passed to schedule.
+					if sli, recorded := checkpoints.SLI(phase.Name); recorded {
+						slis = append(slis, sli)
+					}
+					continue
+				}
+				// Tasks (by name) which resource usage is recorded when repetition finishes.
+				tasks := map[string]executor.TaskInfo{}
+
+				sli, measured, err := r.runRepetition(phase, tasks)
+				if err != nil && retries < r.config.RepetitionRetries && experiment.RetryableFailure(err, tasks) {
+					retries++
+					logrus.Warnf("Repetition failed (%s): %q; retrying (%d/%d)", phase.Name, err.Error(), retries, r.config.RepetitionRetries)
+					repetition--
+					continue
+				}
+				retries = 0
+				if err == nil {
+					if measured {
+						slis = append(slis, sli)
+						if err := checkpoints.RecordSLI(phase.Name, sli); err != nil {
+							logrus.Errorf("SLI of phase %s will not be known when experiment is resumed: %s", phase.Name, err.Error())
+						}
+					}
+					if err := checkpoints.Complete(phase.Name); err != nil {
+						logrus.Errorf("Phase %s will be run again when experiment is resumed: %s", phase.Name, err.Error())
+					}
+					continue
+				}
+
+				logrus.Errorf("Experiment failed (%s): %q", phase.Name, err.Error())
+				if r.hooks.OnError != nil {
+					r.hooks.OnError(phase, err)
+				}
+				err = errors.Wrapf(err, "%s failed", phase.Name)
+				if r.config.StopOnError {
+					return err
+				}
+				errColl.Add(err)
+			}
+
+			if len(slis) > 0 {
+				schedule.Observe(mean(slis))
+			}
+		}
+	}
+	return errColl.GetErrIfAny()
+}
+
+func mean(values []int) int {
+	sum := 0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / len(values)
+}
+
+// peakLoad returns peak load recorded before experiment was interrupted, configured peak load or runs tuning phase to find it.
+func (r *Runner) peakLoad(checkpoints *experiment.Checkpoints) (int, error) {
+	if load, recorded := checkpoints.PeakLoad(); recorded {
+		if r.config.PeakLoad != RunTuningPhase && r.config.PeakLoad != load {
+			logrus.Warnf("Using peak load %d recorded by resumed experiment instead of configured %d", load, r.config.PeakLoad)
+		} else {
+			logrus.Infof("Skipping tuning phase, using peakload %d recorded by resumed experiment", load)
+		}
+		return load, nil
+	}
+	if r.config.PeakLoad != RunTuningPhase {
+		logrus.Infof("Skipping tuning phase, using peakload %d", r.config.PeakLoad)
+		return r.config.PeakLoad, nil
+	}
+
+	logrus.Info("Tuning phase...")
+	tuningTags := snap.Tags{
+		experiment.ExperimentKey: r.config.ExperimentID,
+		experiment.PhaseKey:      "tuning",
+	}
+	hpLauncher, err := r.factory.BuildDefaultHighPriorityLauncher(r.config.HighPriorityWorkload, tuningTags)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot prepare %s", r.config.HighPriorityWorkload)
+	}
+	load, err := experiment.GetPeakLoad(hpLauncher, r.loadGenerator, r.config.SLO)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot retrieve peak load during tuning")
+	}
+	logrus.Infof("Ran tuning and achieved load of %d", load)
+	return load, nil
+}
+
+func (r *Runner) newPhase(aggressor string, loadPoint, qps, repetition, attempt int) Phase {
+	name := fmt.Sprintf("Aggressor %s; load point %d; repetition %d", aggressor, loadPoint, repetition)
+	return Phase{
+		Name:       name,
+		Aggressor:  aggressor,
+		LoadPoint:  loadPoint,
+		QPS:        qps,
+		Repetition: repetition,
+		Attempt:    attempt,
+		Tags: snap.Tags{
+			experiment.ExperimentKey:    r.config.ExperimentID,
+			experiment.PhaseKey:         name,
+			experiment.RepetitionKey:    repetition,
+			experiment.LoadPointQPSKey:  qps,
+			experiment.AggressorNameKey: aggressor,
+			experiment.AttemptKey:       attempt,
+		},
+	}
+}
+
+// runRepetition runs single repetition of the phase and stops all its tasks. Tasks run in repetition are added to tasks.
+// SLI of repetition is returned when it has been measured.
+func (r *Runner) runRepetition(phase Phase, tasks map[string]executor.TaskInfo) (sli int, measured bool, err error) {
+	// We need to collect all the TaskHandles created in order to cleanup after repetition finishes.
+	var processes []executor.TaskHandle
+	var untrackProcesses []func()
+	// Isolations created for repetition are cleaned in reverse order when its tasks are stopped.
+	var isolations []isolation.Isolation
+	var untrackIsolations []func()
+	defer func() {
+		// Collecting all the errors that might have been encountered during cleanup.
+		errColl := &errcollection.ErrorCollection{}
+		for _, handle := range processes {
+			errColl.Add(handle.Stop())
+		}
+		for _, untrack := range untrackProcesses {
+			untrack()
+		}
+		for i := len(isolations) - 1; i >= 0; i-- {
+			errColl.Add(isolations[i].Clean())
+			untrackIsolations[i]()
+		}
+		errColl.Add(experiment.RecordResourceUsage(r.config.Metadata, phase.RecordName(), tasks))
+		// Error of repetition is returned as is when cleanup succeeded, so that its cause can be examined.
+		if cleanupErr := errColl.GetErrIfAny(); cleanupErr != nil {
+			errColl = &errcollection.ErrorCollection{}
+			errColl.Add(err)
+			errColl.Add(cleanupErr)
+			err = errColl.GetErrIfAny()
+		}
+	}()
+	// Tasks of repetition are stopped as soon as experiment is interrupted.
+	ctx := r.config.Shutdown.Context()
+	launch := func(name string, launcher executor.Launcher) (executor.TaskHandle, error) {
+		handle, err := executor.LaunchContext(ctx, launcher)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot launch %s in phase %q", name, phase.Name)
+		}
+		processes = append(processes, handle)
+		untrackProcesses = append(untrackProcesses, r.config.Shutdown.TrackTaskHandle(handle))
+		tasks[name] = handle
+		return handle, nil
+	}
+
+	logrus.Infof("Starting phase: %s", phase.Name)
+	if r.hooks.BeforePhase != nil {
+		err = r.hooks.BeforePhase(phase)
+		if err != nil {
+			return 0, false, errors.Wrapf(err, "cannot prepare phase %q", phase.Name)
+		}
+	}
+
+	err = experiment.CreateRepetitionDir(r.config.AppName, r.config.ExperimentID, phase.Name, phase.Repetition)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "cannot create repetition log directory in phase %q", phase.Name)
+	}
+
+	for _, i := range r.config.Isolations {
+		err = i.Create()
+		if err != nil {
+			return 0, false, errors.Wrapf(err, "cannot create isolation in phase %q", phase.Name)
+		}
+		isolations = append(isolations, i)
+		untrackIsolations = append(untrackIsolations, r.config.Shutdown.TrackIsolation(i))
+	}
+
+	hpLauncher, err := r.factory.BuildDefaultHighPriorityLauncher(r.config.HighPriorityWorkload, phase.Tags)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "cannot prepare %s", r.config.HighPriorityWorkload)
+	}
+	if r.config.Supervisor.Policy != executor.RestartNever {
+		supervisorConfig := r.config.Supervisor
+		supervisorConfig.OnRestart = experiment.RestartRecorder(r.config.Metadata, phase.RecordName())
+		hpLauncher = executor.NewSupervisingLauncher(hpLauncher, supervisorConfig)
+	}
+	repetition := Repetition{Phase: phase}
+	repetition.HighPriority, err = launch(r.config.HighPriorityWorkload, hpLauncher)
+	if err != nil {
+		return 0, false, err
+	}
+
+	err = r.loadGenerator.Populate()
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "cannot populate %s in phase %q", r.config.HighPriorityWorkload, phase.Name)
+	}
+
+	beLauncher, err := r.factory.BuildDefaultBestEffortLauncher(phase.Aggressor, phase.Tags)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "cannot prepare best effort workload %q", phase.Aggressor)
+	}
+	// Launch BE tasks when we are not in baseline.
+	if beLauncher != nil {
+		repetition.BestEffort, err = launch(phase.Aggressor, beLauncher)
+		if err != nil {
+			return 0, false, err
+		}
+	}
+
+	logrus.Debugf("Launching Load Generator with load point %d", phase.LoadPoint)
+	repetition.LoadGenerator, err = executor.LoadContext(ctx, r.loadGenerator, phase.QPS, r.config.LoadDuration)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "unable to start load generation in phase %q", phase.Name)
+	}
+	defer r.config.Shutdown.TrackTaskHandle(repetition.LoadGenerator)()
+	tasks[LoadGeneratorTaskName] = repetition.LoadGenerator
+
+	terminated, err := executor.WaitTimeoutContext(ctx, repetition.LoadGenerator, r.config.LoadGeneratorWaitTimeout)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "load generator failed in phase %q", phase.Name)
+	}
+	if !terminated {
+		logrus.Warn("Load generator failed to stop on its own. Attempting to stop...")
+		err = repetition.LoadGenerator.Stop()
+		if err != nil {
+			return 0, false, errors.Wrapf(err, "stopping load generator errored in phase %q", phase.Name)
+		}
+	}
+
+	if repetition.BestEffort != nil {
+		err = repetition.BestEffort.Stop()
+		if err != nil {
+			return 0, false, errors.Wrapf(err, "best effort task has failed in phase %q", phase.Name)
+		}
+	}
+
+	// Nothing is executed in dry run, so there are no results to publish.
+	if !conf.DryRunFlag.Value() {
+		for _, newSession := range r.sessions {
+			sessionLauncher, err := newSession(repetition)
+			if err != nil {
+				return 0, false, errors.Wrapf(err, "cannot create Snap session in phase %q", phase.Name)
+			}
+			snapHandle, err := executor.LaunchContext(ctx, sessionLauncher)
+			if err != nil {
+				return 0, false, errors.Wrapf(err, "cannot launch %s in phase %q", sessionLauncher, phase.Name)
+			}
+			defer r.config.Shutdown.TrackTaskHandle(snapHandle)()
+			defer func() {
+				// It is ugly but there is no other way to make sure that data is written to Cassandra as of now.
+				time.Sleep(r.config.SessionFlushTime)
+				snapHandle.Stop()
+			}()
+		}
+	}
+
+	if r.hooks.AfterPhase != nil {
+		err = r.hooks.AfterPhase(repetition)
+		if err != nil {
+			return 0, false, errors.Wrapf(err, "phase %q failed", phase.Name)
+		}
+	}
+
+	exitCode, err := repetition.LoadGenerator.ExitCode()
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "cannot get exit code of load generator in phase %q", phase.Name)
+	}
+	if exitCode != 0 {
+		return 0, false, errors.Errorf("executing Load Generator returned with exit code %d in phase %q", exitCode, phase.Name)
+	}
+
+	if r.hooks.MeasureSLI != nil {
+		sli, err := r.hooks.MeasureSLI(repetition)
+		if err != nil {
+			logrus.Warnf("Cannot measure SLI in phase %q: %s", phase.Name, err.Error())
+			return 0, false, nil
+		}
+		return sli, true, nil
+	}
+	return 0, false, nil
+}
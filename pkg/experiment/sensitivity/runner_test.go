@@ -0,0 +1,392 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sensitivity
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/experiment"
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/metadata"
+	"github.com/intelsdi-x/swan/pkg/snap"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/mock"
+)
+
+// fakeLauncherFactory returns given launchers and does not run BE workload for NoneAggressorID.
+type fakeLauncherFactory struct {
+	hpLauncher executor.Launcher
+	beLauncher executor.Launcher
+}
+
+func (f fakeLauncherFactory) BuildDefaultHighPriorityLauncher(workloadName string, tags snap.Tags) (executor.Launcher, error) {
+	return f.hpLauncher, nil
+}
+
+func (f fakeLauncherFactory) BuildDefaultBestEffortLauncher(workloadName string, tags snap.Tags) (executor.Launcher, error) {
+	if workloadName == NoneAggressorID {
+		return nil, nil
+	}
+	return f.beLauncher, nil
+}
+
+// countingIsolation counts its creations and cleanups.
+type countingIsolation struct {
+	created, cleaned int
+}
+
+func (i *countingIsolation) Decorate(command string) string {
+	return command
+}
+
+func (i *countingIsolation) Create() error {
+	i.created++
+	return nil
+}
+
+func (i *countingIsolation) Clean() error {
+	i.cleaned++
+	return nil
+}
+
+func (i *countingIsolation) Isolate(PID int) error {
+	return nil
+}
+
+// recordingLoadSchedule remembers SLI observed by schedule.
+type recordingLoadSchedule struct {
+	LoadSchedule
+	observed []int
+}
+
+func (s *recordingLoadSchedule) Observe(sli int) {
+	s.observed = append(s.observed, sli)
+	s.LoadSchedule.Observe(sli)
+}
+
+func newMockHandle(name string) *executor.MockTaskHandle {
+	handle := new(executor.MockTaskHandle)
+	handle.On("String").Return(name)
+	handle.On("Stop").Return(nil)
+	// Tasks bound to context of the experiment are waited for in background.
+	handle.On("Wait", time.Duration(0)).Return(true, nil)
+	return handle
+}
+
+func TestRunner(t *testing.T) {
+	Convey("When running sensitivity experiment with runner", t, func() {
+		workingDirectory, err := os.Getwd()
+		So(err, ShouldBeNil)
+		appName, err := ioutil.TempDir("", "runner_test")
+		So(err, ShouldBeNil)
+		appName = path.Base(appName)
+		defer func() {
+			os.Chdir(workingDirectory)
+			os.RemoveAll(path.Join(os.TempDir(), appName))
+		}()
+
+		hpHandle := newMockHandle("hp")
+		hpHandle.On("ExitCode").Return(0, nil)
+		hpLauncher := new(executor.MockLauncher)
+		hpLauncher.On("Launch").Return(hpHandle, nil)
+
+		beHandle := newMockHandle("be")
+		beLauncher := new(executor.MockLauncher)
+		beLauncher.On("Launch").Return(beHandle, nil)
+
+		loadGeneratorHandle := newMockHandle("load generator")
+		loadGeneratorHandle.On("Wait", time.Duration(0)).Return(true, nil)
+		loadGenerator := new(executor.MockLoadGenerator)
+		loadGenerator.On("Populate").Return(nil)
+		loadGenerator.On("Load", mock.AnythingOfType("int"), time.Second).Return(loadGeneratorHandle, nil)
+
+		sessionHandle := newMockHandle("session")
+		sessionLauncher := new(executor.MockLauncher)
+		sessionLauncher.On("Launch").Return(sessionHandle, nil)
+		var sessions []Repetition
+		newSession := func(repetition Repetition) (executor.Launcher, error) {
+			sessions = append(sessions, repetition)
+			return sessionLauncher, nil
+		}
+
+		var phases []Phase
+		var failedPhases []Phase
+		hooks := RunnerHooks{
+			BeforePhase: func(phase Phase) error {
+				phases = append(phases, phase)
+				return nil
+			},
+			OnError: func(phase Phase, err error) {
+				failedPhases = append(failedPhases, phase)
+			},
+		}
+
+		metaData := metadata.NewMemory("experiment")
+		config := RunnerConfig{
+			ExperimentID:         "experiment",
+			AppName:              appName,
+			HighPriorityWorkload: Memcached,
+			Aggressors:           []string{NoneAggressorID, "aggressor"},
+			PeakLoad:             100,
+			LoadSchedule:         func() LoadSchedule { return NewFixedLoadSchedule(EvenLoadPoints(2)) },
+			Repetitions:          1,
+			LoadDuration:         time.Second,
+			Metadata:             metaData,
+			Shutdown:             experiment.NewShutdownCoordinator(),
+		}
+		newRunner := func() *Runner {
+			return NewRunner(config, fakeLauncherFactory{hpLauncher, beLauncher}, loadGenerator, []SessionLauncherFactory{newSession}, hooks)
+		}
+
+		Convey("When load generator succeeds", func() {
+			loadGeneratorHandle.On("ExitCode").Return(0, nil)
+
+			err := newRunner().Run()
+			So(err, ShouldBeNil)
+
+			Convey("Every load point should be run with every aggressor", func() {
+				So(phases, ShouldHaveLength, 4)
+				So(phases[0].Name, ShouldEqual, "Aggressor None; load point 0; repetition 0")
+				So(phases[3].Aggressor, ShouldEqual, "aggressor")
+				So([]int{phases[0].QPS, phases[1].QPS, phases[2].QPS, phases[3].QPS}, ShouldResemble, []int{50, 100, 50, 100})
+				So(phases[1].Tags[experiment.LoadPointQPSKey], ShouldEqual, 100)
+				So(phases[1].Tags[experiment.AggressorNameKey], ShouldEqual, NoneAggressorID)
+				So(failedPhases, ShouldBeEmpty)
+			})
+
+			Convey("Snap sessions should be launched with tasks of every repetition", func() {
+				So(sessions, ShouldHaveLength, 4)
+				So(sessions[0].HighPriority, ShouldEqual, hpHandle)
+				So(sessions[0].BestEffort, ShouldBeNil)
+				So(sessions[2].BestEffort, ShouldEqual, beHandle)
+				So(sessions[2].LoadGenerator, ShouldEqual, loadGeneratorHandle)
+				sessionHandle.AssertNumberOfCalls(t, "Stop", 4)
+			})
+
+			Convey("Tasks should be stopped and peak load should be recorded", func() {
+				hpHandle.AssertNumberOfCalls(t, "Stop", 4)
+				loadGenerator.AssertNumberOfCalls(t, "Populate", 4)
+				records, err := metaData.GetByKind(metadata.TypeEmpty)
+				So(err, ShouldBeNil)
+				So(records["peak_load"], ShouldEqual, "100")
+			})
+		})
+
+		Convey("Isolations should be created and cleaned in every repetition", func() {
+			loadGeneratorHandle.On("ExitCode").Return(0, nil)
+			counting := &countingIsolation{}
+			config.Isolations = []isolation.Isolation{counting}
+
+			err := newRunner().Run()
+			So(err, ShouldBeNil)
+			So(counting.created, ShouldEqual, 4)
+			So(counting.cleaned, ShouldEqual, 4)
+		})
+
+		Convey("Tasks should not be launched when experiment has been interrupted", func() {
+			So(config.Shutdown.Shutdown(syscall.SIGINT), ShouldBeNil)
+			config.StopOnError = true
+
+			err := newRunner().Run()
+			So(err, ShouldNotBeNil)
+			So(errors.Cause(err), ShouldEqual, context.Canceled)
+			hpLauncher.AssertNotCalled(t, "Launch")
+		})
+
+		Convey("Process-wide shutdown coordinator should be used when none is configured", func() {
+			loadGeneratorHandle.On("ExitCode").Return(0, nil)
+			config.Shutdown = nil
+
+			So(newRunner().Run(), ShouldBeNil)
+			So(phases, ShouldHaveLength, 4)
+		})
+
+		Convey("When load generator fails", func() {
+			loadGeneratorHandle.On("ExitCode").Return(1, nil)
+
+			Convey("Errors of all repetitions should be collected", func() {
+				err := newRunner().Run()
+				So(err, ShouldNotBeNil)
+				So(phases, ShouldHaveLength, 4)
+				So(failedPhases, ShouldHaveLength, 4)
+			})
+
+			Convey("Experiment should stop on first error when requested", func() {
+				config.StopOnError = true
+				err := newRunner().Run()
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "exit code 1")
+				So(phases, ShouldHaveLength, 1)
+				hpHandle.AssertNumberOfCalls(t, "Stop", 1)
+			})
+		})
+
+		Convey("When load generator is killed once", func() {
+			loadGeneratorHandle.On("ExitCode").Return(137, nil).Twice()
+			loadGeneratorHandle.On("ExitCode").Return(0, nil)
+			config.Aggressors = []string{NoneAggressorID}
+			config.LoadSchedule = func() LoadSchedule { return NewFixedLoadSchedule(EvenLoadPoints(1)) }
+
+			Convey("Repetition should be retried when retries are allowed", func() {
+				config.RepetitionRetries = 1
+				err := newRunner().Run()
+				So(err, ShouldBeNil)
+				So(phases, ShouldHaveLength, 2)
+				So(phases[1].Repetition, ShouldEqual, 0)
+			})
+
+			Convey("Retried attempt should be tagged and recorded separately", func() {
+				config.RepetitionRetries = 1
+				So(newRunner().Run(), ShouldBeNil)
+				So(phases, ShouldHaveLength, 2)
+				So(phases[0].Tags[experiment.AttemptKey], ShouldEqual, 0)
+				So(phases[1].Tags[experiment.AttemptKey], ShouldEqual, 1)
+				So(phases[0].RecordName(), ShouldEqual, "Aggressor None; load point 0; repetition 0")
+				So(phases[1].RecordName(), ShouldEqual, "Aggressor None; load point 0; repetition 0; attempt 1")
+			})
+
+			Convey("Repetition should fail when retries are not allowed", func() {
+				err := newRunner().Run()
+				So(err, ShouldNotBeNil)
+				So(phases, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When aggressor is not launched in time once", func() {
+			loadGeneratorHandle.On("ExitCode").Return(0, nil)
+			beLauncher = new(executor.MockLauncher)
+			beLauncher.On("Launch").Return(nil, &executor.LaunchTimedOutError{}).Once()
+			beLauncher.On("Launch").Return(beHandle, nil)
+			config.Aggressors = []string{"aggressor"}
+			config.LoadSchedule = func() LoadSchedule { return NewFixedLoadSchedule(EvenLoadPoints(1)) }
+
+			Convey("Repetition should be retried when retries are allowed", func() {
+				config.RepetitionRetries = 1
+				So(newRunner().Run(), ShouldBeNil)
+				So(phases, ShouldHaveLength, 2)
+				So(phases[1].Attempt, ShouldEqual, 1)
+				So(failedPhases, ShouldBeEmpty)
+			})
+
+			Convey("Repetition should fail when retries are not allowed", func() {
+				So(newRunner().Run(), ShouldNotBeNil)
+				So(failedPhases, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When interrupted experiment is resumed", func() {
+			loadGeneratorHandle.On("ExitCode").Return(0, nil)
+			checkpoints := experiment.NewCheckpoints(metaData, false)
+			So(checkpoints.RecordPeakLoad(200), ShouldBeNil)
+			So(checkpoints.Complete("Aggressor None; load point 0; repetition 0"), ShouldBeNil)
+			So(checkpoints.Complete("Aggressor None; load point 1; repetition 0"), ShouldBeNil)
+			So(metaData.Record("aggressor load_point_1", "150", LoadPointMetadataKind), ShouldBeNil)
+			config.PeakLoad = RunTuningPhase
+			config.Resume = true
+
+			err := newRunner().Run()
+			So(err, ShouldBeNil)
+
+			Convey("Completed phases should be skipped and recorded peak load should be used", func() {
+				So(phases, ShouldHaveLength, 2)
+				So(phases[0].Name, ShouldEqual, "Aggressor aggressor; load point 0; repetition 0")
+				So(phases[0].QPS, ShouldEqual, 100)
+				loadGenerator.AssertNotCalled(t, "Tune", mock.Anything)
+			})
+
+			Convey("Recorded load points should be reused", func() {
+				So(phases[1].QPS, ShouldEqual, 150)
+			})
+
+			Convey("All phases should be checkpointed", func() {
+				records, err := metaData.GetByKind(experiment.CheckpointMetadataKind)
+				So(err, ShouldBeNil)
+				So(records, ShouldContainKey, "Aggressor aggressor; load point 1; repetition 0")
+				So(records, ShouldHaveLength, 5)
+			})
+		})
+
+		Convey("When SLI of repetitions is measured", func() {
+			loadGeneratorHandle.On("ExitCode").Return(0, nil)
+			schedule := &recordingLoadSchedule{LoadSchedule: NewFixedLoadSchedule([]float64{0.5, 1})}
+			config.LoadSchedule = func() LoadSchedule { return schedule }
+			config.Aggressors = []string{NoneAggressorID}
+			config.Repetitions = 2
+			slis := []int{100, 200, 300, 500}
+			hooks.MeasureSLI = func(repetition Repetition) (int, error) {
+				sli := slis[0]
+				slis = slis[1:]
+				return sli, nil
+			}
+
+			err := newRunner().Run()
+			So(err, ShouldBeNil)
+
+			Convey("Mean SLI of load point should be passed to load schedule", func() {
+				So(schedule.observed, ShouldResemble, []int{150, 400})
+			})
+
+			Convey("SLI of skipped phases should be passed to load schedule when experiment is resumed", func() {
+				resumedSchedule := &recordingLoadSchedule{LoadSchedule: NewFixedLoadSchedule([]float64{0.5, 1})}
+				config.LoadSchedule = func() LoadSchedule { return resumedSchedule }
+				config.Resume = true
+
+				So(newRunner().Run(), ShouldBeNil)
+				So(phases, ShouldHaveLength, 4)
+				So(resumedSchedule.observed, ShouldResemble, []int{150, 400})
+			})
+
+			Convey("Chosen load points should be recorded", func() {
+				records, err := metaData.GetByKind(LoadPointMetadataKind)
+				So(err, ShouldBeNil)
+				So(records, ShouldResemble, map[string]string{"None load_point_0": "50", "None load_point_1": "100"})
+			})
+		})
+
+		Convey("When hook fails, repetition should fail without launching tasks", func() {
+			hooks.BeforePhase = func(phase Phase) error {
+				return errors.New("hook error")
+			}
+			config.StopOnError = true
+			err := newRunner().Run()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "hook error")
+			hpLauncher.AssertNotCalled(t, "Launch")
+		})
+	})
+}
+
+func TestWorkloadFactoryIsolations(t *testing.T) {
+	Convey("Isolations of workloads built by factory should be collected once", t, func() {
+		hp, be := &countingIsolation{}, &countingIsolation{}
+		factory := NewWorkloadFactoryWithIsolation(NewLocalExecutorFactory(),
+			isolation.Decorators{isolation.Taskset{CPUList: isolation.NewIntSet(0)}, hp},
+			be,
+			isolation.Decorators{be})
+
+		isolations := factory.Isolations()
+		So(isolations, ShouldHaveLength, 2)
+		So(isolations[0], ShouldPointTo, hp)
+		So(isolations[1], ShouldPointTo, be)
+	})
+}
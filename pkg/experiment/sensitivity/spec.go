@@ -0,0 +1,375 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sensitivity
+
+import (
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/intelsdi-x/swan/pkg/conf"
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/experiment"
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/metadata"
+	"github.com/intelsdi-x/swan/pkg/snap"
+	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Isolations of aggressors which can be chosen in specification.
+	l1Isolation   = "l1"
+	l3Isolation   = "l3"
+	noneIsolation = "none"
+)
+
+var (
+	// SpecFlag is path to specification of the experiment which overrides experiment flags.
+	SpecFlag = conf.NewStringFlag("experiment_spec", "Path to experiment specification (YAML or JSON file). Workloads, aggressors, load points, repetitions, durations and SLO are taken from the specification instead of flags.", "")
+
+	highPriorityWorkloads = []string{Memcached, Specjbb}
+	bestEffortWorkloads   = []string{NoneAggressorID, caffeWorkload, caffeWorkloadWithIsolation, l1d, l1i, llc, membw, streambw, stressngL1, strssngL3, stressngMemcpy, stressngStream}
+)
+
+// Spec is declarative specification of sensitivity experiment.
+// Specification is stored verbatim in metadata, so that experiment can be rerun exactly
+// (see -spec-dump-experiment-id flag).
+type Spec struct {
+	HighPriority  WorkloadSpec    `json:"high_priority"`
+	LoadGenerator WorkloadSpec    `json:"load_generator"`
+	Aggressors    []AggressorSpec `json:"aggressors"`
+	LoadPoints    LoadPointsSpec  `json:"load_points"`
+	// PeakLoad is found in tuning phase when it is not set.
+	PeakLoad     int    `json:"peak_load,omitempty"`
+	SLO          int    `json:"slo"`
+	Repetitions  int    `json:"repetitions"`
+	LoadDuration string `json:"load_duration"`
+	// LoadGeneratorWaitTimeout is time to wait for load generator to stop on its own (no limit when it is not set).
+	LoadGeneratorWaitTimeout string `json:"load_generator_wait_timeout,omitempty"`
+
+	source                   []byte
+	loadDuration             time.Duration
+	loadGeneratorWaitTimeout time.Duration
+}
+
+// WorkloadSpec specifies workload (e.g. "memcached" or "mutilate") and its configuration.
+type WorkloadSpec struct {
+	Name string `json:"name"`
+	// Config overrides values of flags configuring the workload (e.g. "memcached_threads": "4").
+	Config map[string]string `json:"config,omitempty"`
+}
+
+// AggressorSpec specifies Best Effort workload run in colocation with HP workload.
+type AggressorSpec struct {
+	Workload string `json:"workload"`
+	// Name identifies aggressor in results. Workload name is used when it is not set.
+	Name string `json:"name,omitempty"`
+	// Processes is number of aggressor processes run in parallel. Default number is run when it is not set.
+	Processes int `json:"processes,omitempty"`
+	// Isolation of aggressor: "l1" (sharing L1 cache with HP workload), "l3" (sharing only L3 cache) or "none".
+	// Default isolation of the workload is used when it is not set.
+	Isolation string `json:"isolation,omitempty"`
+}
+
+// LoadPointsSpec specifies load points either as explicit list, as a range or as number of adaptively chosen load points.
+type LoadPointsSpec struct {
+	// Percents of peak load generated in subsequent load points.
+	Percents []float64 `json:"percents,omitempty"`
+	// Range of load points evenly spread between percents of peak load.
+	Range *LoadRangeSpec `json:"range,omitempty"`
+	// Adaptive is number of load points chosen by adaptive schedule (see NewAdaptiveLoadSchedule).
+	Adaptive int `json:"adaptive,omitempty"`
+}
+
+// LoadRangeSpec specifies Count load points evenly spread from From to To percent of peak load.
+// By default load points are spread up to peak load, like with experiment_load_points flag.
+type LoadRangeSpec struct {
+	Count int     `json:"count"`
+	From  float64 `json:"from,omitempty"`
+	To    float64 `json:"to,omitempty"`
+}
+
+// LoadSpec reads specification from YAML or JSON file and validates it.
+func LoadSpec(path string) (*Spec, error) {
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read experiment specification %q", path)
+	}
+	spec, err := ParseSpec(source)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid experiment specification %q", path)
+	}
+	return spec, nil
+}
+
+// SpecFromFlags returns specification given with experiment_spec flag or nil when flag is not set.
+func SpecFromFlags() (*Spec, error) {
+	if SpecFlag.Value() == "" {
+		return nil, nil
+	}
+	return LoadSpec(SpecFlag.Value())
+}
+
+// ParseSpec parses YAML or JSON specification and validates it.
+func ParseSpec(source []byte) (*Spec, error) {
+	spec := &Spec{}
+	err := yaml.Unmarshal(source, spec)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse experiment specification")
+	}
+	spec.source = source
+
+	err = spec.validate()
+	if err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// validate checks all fields of specification, so that experiment does not fail after it has been started.
+func (spec *Spec) validate() (err error) {
+	errColl := &errcollection.ErrorCollection{}
+
+	if !contains(highPriorityWorkloads, spec.HighPriority.Name) {
+		errColl.Add(errors.Errorf("unknown high priority workload %q (should be one of %v)", spec.HighPriority.Name, highPriorityWorkloads))
+	}
+	if spec.LoadGenerator.Name == "" {
+		errColl.Add(errors.New("load generator is not specified"))
+	}
+	for _, workload := range []WorkloadSpec{spec.HighPriority, spec.LoadGenerator} {
+		for name := range workload.Config {
+			if !conf.IsRegistered(name) {
+				errColl.Add(errors.Errorf("unknown configuration %q of %s", name, workload.Name))
+			}
+		}
+	}
+
+	if len(spec.Aggressors) == 0 {
+		errColl.Add(errors.Errorf("no aggressors are specified (use %q to run baseline)", NoneAggressorID))
+	}
+	names := map[string]bool{}
+	for _, aggressor := range spec.Aggressors {
+		if !contains(bestEffortWorkloads, aggressor.Workload) {
+			errColl.Add(errors.Errorf("unknown aggressor workload %q (should be one of %v)", aggressor.Workload, bestEffortWorkloads))
+		}
+		if names[aggressor.name()] {
+			errColl.Add(errors.Errorf("aggressor %q is specified more than once (aggressors with the same workload need unique names)", aggressor.name()))
+		}
+		names[aggressor.name()] = true
+		if aggressor.Processes < 0 {
+			errColl.Add(errors.Errorf("number of processes of aggressor %q cannot be negative", aggressor.name()))
+		}
+		if !contains([]string{"", l1Isolation, l3Isolation, noneIsolation}, aggressor.Isolation) {
+			errColl.Add(errors.Errorf("unknown isolation %q of aggressor %q (should be %q, %q or %q)", aggressor.Isolation, aggressor.name(), l1Isolation, l3Isolation, noneIsolation))
+		}
+	}
+
+	errColl.Add(spec.LoadPoints.validate())
+	if spec.PeakLoad < 0 {
+		errColl.Add(errors.New("peak load cannot be negative"))
+	}
+	if spec.SLO <= 0 {
+		errColl.Add(errors.New("SLO should be positive"))
+	}
+	if spec.Repetitions <= 0 {
+		errColl.Add(errors.New("number of repetitions should be positive"))
+	}
+	spec.loadDuration, err = time.ParseDuration(spec.LoadDuration)
+	if err != nil || spec.loadDuration <= 0 {
+		errColl.Add(errors.Errorf("invalid load duration %q", spec.LoadDuration))
+	}
+	if spec.LoadGeneratorWaitTimeout != "" {
+		spec.loadGeneratorWaitTimeout, err = time.ParseDuration(spec.LoadGeneratorWaitTimeout)
+		if err != nil || spec.loadGeneratorWaitTimeout < 0 {
+			errColl.Add(errors.Errorf("invalid load generator wait timeout %q", spec.LoadGeneratorWaitTimeout))
+		}
+	}
+
+	return errColl.GetErrIfAny()
+}
+
+func (spec LoadPointsSpec) validate() error {
+	specified := 0
+	for _, given := range []bool{len(spec.Percents) > 0, spec.Range != nil, spec.Adaptive != 0} {
+		if given {
+			specified++
+		}
+	}
+	if specified != 1 {
+		return errors.New("load points should be specified either as list of percents, as range or as number of adaptive load points")
+	}
+	if spec.Adaptive < 0 {
+		return errors.New("number of adaptive load points should be positive")
+	}
+	for _, percent := range spec.Percents {
+		if percent <= 0 {
+			return errors.Errorf("load point %v%% is not positive", percent)
+		}
+	}
+	if spec.Range != nil {
+		if spec.Range.Count <= 0 {
+			return errors.New("number of load points in range should be positive")
+		}
+		if spec.Range.From < 0 || spec.Range.To < 0 || (spec.Range.To != 0 && spec.Range.From > spec.Range.To) {
+			return errors.Errorf("invalid range of load points from %v%% to %v%%", spec.Range.From, spec.Range.To)
+		}
+	}
+	return nil
+}
+
+// schedule returns factory of schedules of specified load points.
+func (spec LoadPointsSpec) schedule(slo int) LoadScheduleFactory {
+	if spec.Adaptive > 0 {
+		return func() LoadSchedule { return NewAdaptiveLoadSchedule(spec.Adaptive, slo) }
+	}
+	fractions := spec.fractions()
+	return func() LoadSchedule { return NewFixedLoadSchedule(fractions) }
+}
+
+// fractions returns explicit load points or load points in range as fractions of peak load.
+func (spec LoadPointsSpec) fractions() []float64 {
+	if spec.Range == nil {
+		fractions := make([]float64, len(spec.Percents))
+		for i, percent := range spec.Percents {
+			fractions[i] = percent / 100
+		}
+		return fractions
+	}
+
+	count := spec.Range.Count
+	if spec.Range.From == 0 && spec.Range.To == 0 {
+		return EvenLoadPoints(count)
+	}
+	from, to := spec.Range.From/100, spec.Range.To/100
+	if to == 0 {
+		to = 1
+	}
+	if count == 1 {
+		return []float64{to}
+	}
+	fractions := make([]float64, count)
+	for i := range fractions {
+		fractions[i] = from + (to-from)*float64(i)/float64(count-1)
+	}
+	return fractions
+}
+
+func (aggressor AggressorSpec) name() string {
+	if aggressor.Name != "" {
+		return aggressor.Name
+	}
+	return aggressor.Workload
+}
+
+// CheckWorkloads returns error when specification is not supported by experiment running given HP workload and load generator.
+func (spec *Spec) CheckWorkloads(highPriorityWorkload, loadGenerator string) error {
+	if spec.HighPriority.Name != highPriorityWorkload || spec.LoadGenerator.Name != loadGenerator {
+		return errors.Errorf("experiment runs %s with %s load generator, but %s with %s is specified",
+			highPriorityWorkload, loadGenerator, spec.HighPriority.Name, spec.LoadGenerator.Name)
+	}
+	return nil
+}
+
+// Apply overrides flags configuring HP workload and load generator with values from specification.
+// It has to be called before workloads are prepared.
+func (spec *Spec) Apply() error {
+	errColl := &errcollection.ErrorCollection{}
+	for _, workload := range []WorkloadSpec{spec.HighPriority, spec.LoadGenerator} {
+		// Flags are set in deterministic order.
+		names := make([]string, 0, len(workload.Config))
+		for name := range workload.Config {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			errColl.Add(conf.SetFlag(name, workload.Config[name]))
+		}
+	}
+	return errColl.GetErrIfAny()
+}
+
+// Configure overrides runner configuration with specification.
+func (spec *Spec) Configure(config *RunnerConfig) {
+	config.HighPriorityWorkload = spec.HighPriority.Name
+	config.Aggressors = make([]string, len(spec.Aggressors))
+	for i, aggressor := range spec.Aggressors {
+		config.Aggressors[i] = aggressor.name()
+	}
+	config.LoadSchedule = spec.LoadPoints.schedule(spec.SLO)
+	config.PeakLoad = spec.PeakLoad
+	config.SLO = spec.SLO
+	config.Repetitions = spec.Repetitions
+	config.LoadDuration = spec.loadDuration
+	config.LoadGeneratorWaitTimeout = spec.loadGeneratorWaitTimeout
+}
+
+// Record stores specification verbatim in metadata.
+func (spec *Spec) Record(metaData metadata.Metadata) error {
+	err := metaData.Record(experiment.SpecMetadataKey, string(spec.source), experiment.SpecMetadataKind)
+	if err != nil {
+		return errors.Wrap(err, "cannot save experiment specification")
+	}
+	return nil
+}
+
+// LauncherFactory returns factory building aggressors with number of processes and isolation given in specification.
+func (spec *Spec) LauncherFactory(factory *WorkloadFactory) LauncherFactory {
+	return specLauncherFactory{spec: spec, WorkloadFactory: factory}
+}
+
+type specLauncherFactory struct {
+	*WorkloadFactory
+	spec *Spec
+}
+
+// BuildDefaultBestEffortLauncher builds aggressor by its name in specification.
+func (f specLauncherFactory) BuildDefaultBestEffortLauncher(name string, tags snap.Tags) (executor.Launcher, error) {
+	for _, aggressor := range f.spec.Aggressors {
+		if aggressor.name() != name {
+			continue
+		}
+
+		decorators := f.getDefaultBestEffortIsolation(aggressor.Workload)
+		switch aggressor.Isolation {
+		case l1Isolation:
+			decorators = isolation.Decorators{f.l1Isolation}
+		case l3Isolation:
+			decorators = isolation.Decorators{f.l3Isolation}
+		case noneIsolation:
+			decorators = isolation.Decorators{}
+		}
+
+		additionalDecorators := f.getBestEffortAdditionalDecorators(aggressor.Workload)
+		if aggressor.Processes > 0 {
+			additionalDecorators = isolation.Decorators{}
+			if aggressor.Processes != 1 {
+				additionalDecorators = executor.NewParallel(aggressor.Processes)
+			}
+		}
+		return f.createBestEffortWorkload(aggressor.Workload, decorators, additionalDecorators, tags)
+	}
+	return nil, errors.Errorf("aggressor %q is not specified", name)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sensitivity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/experiment"
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/metadata"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testSpec = `
+high_priority:
+  name: memcached
+  config:
+    memcached_threads: "8"
+load_generator:
+  name: mutilate
+aggressors:
+  - workload: None
+  - workload: stress-ng-cache-l3
+    isolation: l1
+  - workload: stress-ng-cache-l3
+    name: stress-ng-cache-l3-parallel
+    processes: 4
+load_points:
+  percents: [10, 50, 95]
+slo: 500
+repetitions: 3
+load_duration: 10s
+`
+
+type prefixDecorator string
+
+func (d prefixDecorator) Decorate(command string) string {
+	return string(d) + " " + command
+}
+
+// recordingExecutorFactory remembers command decorated by decorators of the last executor it has built.
+type recordingExecutorFactory struct {
+	decorated string
+}
+
+func (f *recordingExecutorFactory) BuildHighPriorityExecutor(decorators ...isolation.Decorator) (executor.Executor, error) {
+	return f.BuildBestEffortExecutor(decorators...)
+}
+
+func (f *recordingExecutorFactory) BuildBestEffortExecutor(decorators ...isolation.Decorator) (executor.Executor, error) {
+	f.decorated = isolation.Decorators(decorators).Decorate("aggressor")
+	return executor.NewLocal(), nil
+}
+
+func TestSpec(t *testing.T) {
+	Convey("When experiment specification is parsed", t, func() {
+		spec, err := ParseSpec([]byte(testSpec))
+		So(err, ShouldBeNil)
+
+		Convey("Runner should be configured with specification", func() {
+			config := RunnerConfig{PeakLoad: 100}
+			spec.Configure(&config)
+			So(config.HighPriorityWorkload, ShouldEqual, Memcached)
+			So(config.Aggressors, ShouldResemble, []string{NoneAggressorID, "stress-ng-cache-l3", "stress-ng-cache-l3-parallel"})
+			So(loadPoints(config.LoadSchedule()), ShouldResemble, []float64{0.1, 0.5, 0.95})
+			So(config.PeakLoad, ShouldEqual, RunTuningPhase)
+			So(config.Repetitions, ShouldEqual, 3)
+			So(config.LoadDuration, ShouldEqual, 10*time.Second)
+		})
+
+		Convey("Specification should be stored verbatim in metadata", func() {
+			metaData := metadata.NewMemory("experiment")
+			So(spec.Record(metaData), ShouldBeNil)
+			recorded, err := metaData.GetByKind(experiment.SpecMetadataKind)
+			So(err, ShouldBeNil)
+			So(recorded[experiment.SpecMetadataKey], ShouldEqual, testSpec)
+		})
+
+		Convey("Experiment running different workloads should reject specification", func() {
+			So(spec.CheckWorkloads(Memcached, "mutilate"), ShouldBeNil)
+			So(spec.CheckWorkloads(Specjbb, "specjbb"), ShouldNotBeNil)
+		})
+
+		Convey("Aggressors should be built with isolation and number of processes from specification", func() {
+			executorFactory := &recordingExecutorFactory{}
+			factory := NewWorkloadFactoryWithIsolation(executorFactory, prefixDecorator("hp"), prefixDecorator("l1"), prefixDecorator("l3"))
+			launcherFactory := spec.LauncherFactory(&factory)
+
+			launcher, err := launcherFactory.BuildDefaultBestEffortLauncher(NoneAggressorID, nil)
+			So(err, ShouldBeNil)
+			So(launcher, ShouldBeNil)
+
+			launcher, err = launcherFactory.BuildDefaultBestEffortLauncher("stress-ng-cache-l3", nil)
+			So(err, ShouldBeNil)
+			So(launcher, ShouldNotBeNil)
+			So(executorFactory.decorated, ShouldEqual, "l1 aggressor")
+
+			launcher, err = launcherFactory.BuildDefaultBestEffortLauncher("stress-ng-cache-l3-parallel", nil)
+			So(err, ShouldBeNil)
+			So(launcher, ShouldNotBeNil)
+			So(executorFactory.decorated, ShouldContainSubstring, "seq 4")
+			So(executorFactory.decorated, ShouldContainSubstring, `"l3 aggressor"`)
+
+			_, err = launcherFactory.BuildDefaultBestEffortLauncher("l1d", nil)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Specification can be given in JSON", t, func() {
+		spec, err := ParseSpec([]byte(`{"high_priority": {"name": "specjbb"}, "load_generator": {"name": "specjbb"},
+			"aggressors": [{"workload": "None"}], "load_points": {"range": {"count": 4}}, "peak_load": 1000,
+			"slo": 100, "repetitions": 1, "load_duration": "1m", "load_generator_wait_timeout": "5m"}`))
+		So(err, ShouldBeNil)
+
+		config := RunnerConfig{}
+		spec.Configure(&config)
+		So(loadPoints(config.LoadSchedule()), ShouldResemble, []float64{0.25, 0.5, 0.75, 1})
+		So(config.PeakLoad, ShouldEqual, 1000)
+		So(config.LoadGeneratorWaitTimeout, ShouldEqual, 5*time.Minute)
+	})
+
+	Convey("Load points can be specified as range", t, func() {
+		spec := LoadPointsSpec{Range: &LoadRangeSpec{Count: 3, From: 10, To: 90}}
+		So(spec.validate(), ShouldBeNil)
+		So(spec.fractions(), ShouldResemble, []float64{0.1, 0.5, 0.9})
+	})
+
+	Convey("Invalid specification should be rejected upfront", t, func() {
+		_, err := ParseSpec([]byte(`
+high_priority:
+  name: redis
+  config:
+    redis_threads: "8"
+aggressors:
+  - workload: stress-ng-cache-l3
+    isolation: l2
+  - workload: stress-ng-cache-l3
+load_points:
+  percents: [10]
+  range:
+    count: 10
+slo: 500
+repetitions: 0
+load_duration: ten seconds
+`))
+		So(err, ShouldNotBeNil)
+		for _, problem := range []string{
+			`unknown high priority workload "redis"`,
+			"load generator is not specified",
+			`unknown configuration "redis_threads"`,
+			`unknown isolation "l2"`,
+			`aggressor "stress-ng-cache-l3" is specified more than once`,
+			"either as list of percents, as range or as number of adaptive load points",
+			"number of repetitions should be positive",
+			`invalid load duration "ten seconds"`,
+		} {
+			So(err.Error(), ShouldContainSubstring, problem)
+		}
+
+		_, err = ParseSpec([]byte("high_priority: [memcached"))
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -22,6 +22,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/intelsdi-x/swan/pkg/conf"
 	"github.com/intelsdi-x/swan/pkg/executor"
 	"github.com/intelsdi-x/swan/pkg/utils/errutil"
 	"github.com/intelsdi-x/swan/pkg/utils/sysctl"
@@ -96,7 +97,11 @@ func OS() {
 
 // ExecutorsNOFILELimit validates if environment provided by executors can run
 // distributed application that requires large number of open file descriptors.
+// Validation is skipped in dry run, because commands are not executed then.
 func ExecutorsNOFILELimit(executors []executor.Executor) {
+	if conf.DryRunFlag.Value() {
+		return
+	}
 	for _, executor := range executors {
 		checkNOFILE(
 			executor,
@@ -15,6 +15,8 @@
 package sensitivity
 
 import (
+	"reflect"
+
 	"github.com/intelsdi-x/swan/pkg/conf"
 	"github.com/intelsdi-x/swan/pkg/executor"
 	"github.com/intelsdi-x/swan/pkg/isolation"
@@ -142,6 +144,35 @@ func NewWorkloadFactoryWithIsolation(
 	}
 }
 
+// Isolations returns isolations (e.g. cgroups) which HP and BE workloads built by the factory are decorated with.
+// They should be created before workloads are launched and cleaned when they are stopped (see RunnerConfig.Isolations).
+func (factory *WorkloadFactory) Isolations() []isolation.Isolation {
+	var isolations []isolation.Isolation
+	seen := map[isolation.Isolation]bool{}
+	var collect func(decorator isolation.Decorator)
+	collect = func(decorator isolation.Decorator) {
+		switch d := decorator.(type) {
+		case isolation.Decorators:
+			for _, decorator := range d {
+				collect(decorator)
+			}
+		case isolation.Isolation:
+			// Isolation shared by several workloads is created only once.
+			if reflect.TypeOf(d).Comparable() {
+				if seen[d] {
+					return
+				}
+				seen[d] = true
+			}
+			isolations = append(isolations, d)
+		}
+	}
+	for _, decorator := range []isolation.Decorator{factory.hpIsolation, factory.l1Isolation, factory.l3Isolation} {
+		collect(decorator)
+	}
+	return isolations
+}
+
 // BuildDefaultHighPriorityLauncher builds High Priority workload launcher with predefined isolation.
 func (factory *WorkloadFactory) BuildDefaultHighPriorityLauncher(
 	workloadName string, tags snap.Tags) (launcher executor.Launcher, err error) {
@@ -160,7 +191,7 @@ func (factory *WorkloadFactory) BuildHighPriorityLauncherWithIsolation(
 func (factory *WorkloadFactory) BuildDefaultBestEffortLauncher(
 	workloadName string,
 	tags snap.Tags) (launcher executor.Launcher, err error) {
-	return factory.createBestEffortWorkload(workloadName, factory.getDefaultBestEffortIsolation(workloadName), tags)
+	return factory.createBestEffortWorkload(workloadName, factory.getDefaultBestEffortIsolation(workloadName), factory.getBestEffortAdditionalDecorators(workloadName), tags)
 }
 
 // BuildBestEffortLauncherWithIsolation builds Best Effort launcher with provided isolation.
@@ -168,7 +199,7 @@ func (factory *WorkloadFactory) BuildBestEffortLauncherWithIsolation(
 	workloadName string,
 	isolation isolation.Decorator,
 	tags snap.Tags) (launcher executor.Launcher, err error) {
-	return factory.createBestEffortWorkload(workloadName, isolation, tags)
+	return factory.createBestEffortWorkload(workloadName, isolation, factory.getBestEffortAdditionalDecorators(workloadName), tags)
 }
 
 func (factory *WorkloadFactory) createHighPriorityWorkload(
@@ -194,6 +225,7 @@ func (factory *WorkloadFactory) createHighPriorityWorkload(
 func (factory *WorkloadFactory) createBestEffortWorkload(
 	name string,
 	isolation isolation.Decorator,
+	additionalDecorators isolation.Decorator,
 	tags snap.Tags) (executor.Launcher, error) {
 
 	if name == NoneAggressorID {
@@ -201,7 +233,6 @@ func (factory *WorkloadFactory) createBestEffortWorkload(
 	}
 
 	var workload executor.Launcher
-	additionalDecorators := factory.getBestEffortAdditionalDecorators(name)
 	exec, err := factory.executorFactory.BuildBestEffortExecutor(isolation, additionalDecorators)
 	if err != nil {
 		return nil, err
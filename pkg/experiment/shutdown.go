@@ -0,0 +1,212 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/metadata"
+	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// InterruptedKey defines the metadata key set when experiment was interrupted by a signal.
+	InterruptedKey = "interrupted"
+	// InterruptSignalKey defines the metadata key holding name of the signal that interrupted experiment.
+	InterruptSignalKey = "interrupt_signal"
+	// InterruptTimeKey defines the metadata key holding time of the interruption.
+	InterruptTimeKey = "interrupt_time"
+)
+
+// shutdownCoordinator is the process-wide coordinator returned by Shutdown().
+var shutdownCoordinator = NewShutdownCoordinator()
+
+// Shutdown returns process-wide ShutdownCoordinator.
+func Shutdown() *ShutdownCoordinator {
+	return shutdownCoordinator
+}
+
+type trackedResource struct {
+	name    string
+	cleanup func() error
+}
+
+// ShutdownCoordinator tracks resources (tasks, isolations, clusters) created by the experiment
+// and releases them in reverse order of creation when the experiment receives SIGINT or SIGTERM.
+// Resources that are released by experiment itself should be untracked.
+type ShutdownCoordinator struct {
+	mutex     sync.Mutex
+	resources []*trackedResource
+	metadata  metadata.Metadata
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	signals  chan os.Signal
+	stopped  chan struct{}
+	stopOnce sync.Once
+
+	// exit is called after cleanup has finished; os.Exit unless replaced in tests.
+	exit func(code int)
+}
+
+// NewShutdownCoordinator is constructor for ShutdownCoordinator.
+func NewShutdownCoordinator() *ShutdownCoordinator {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ShutdownCoordinator{
+		ctx:     ctx,
+		cancel:  cancel,
+		signals: make(chan os.Signal, 2),
+		stopped: make(chan struct{}),
+		exit:    os.Exit,
+	}
+}
+
+// Start makes ShutdownCoordinator handle SIGINT and SIGTERM.
+// When one of them is received, all tracked resources are released, interruption is recorded
+// in metadata and the process exits with ExTempFail exit code.
+// Second signal received during cleanup terminates the process immediately.
+func (s *ShutdownCoordinator) Start() {
+	signal.Notify(s.signals, syscall.SIGINT, syscall.SIGTERM)
+	go s.watch()
+}
+
+// Stop makes ShutdownCoordinator stop handling signals. It does not release tracked resources.
+func (s *ShutdownCoordinator) Stop() {
+	s.stopOnce.Do(func() {
+		signal.Stop(s.signals)
+		close(s.stopped)
+	})
+}
+
+// Context returns context that is canceled as soon as the experiment is interrupted.
+func (s *ShutdownCoordinator) Context() context.Context {
+	return s.ctx
+}
+
+// SetMetadata sets metadata where interruption of the experiment is recorded.
+func (s *ShutdownCoordinator) SetMetadata(metadata metadata.Metadata) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.metadata = metadata
+}
+
+// Track registers cleanup function of resource.
+// Returned function untracks the resource and should be called when resource has been released.
+// Resources tracked after the experiment has been interrupted are released immediately.
+func (s *ShutdownCoordinator) Track(name string, cleanup func() error) (untrack func()) {
+	resource := &trackedResource{name: name, cleanup: cleanup}
+
+	s.mutex.Lock()
+	if s.Interrupted() {
+		s.mutex.Unlock()
+		logrus.Debugf("Shutdown: releasing %s created after interruption", name)
+		if err := cleanup(); err != nil {
+			logrus.Errorf("Shutdown: cannot release %s: %s", name, err.Error())
+		}
+		return func() {}
+	}
+	s.resources = append(s.resources, resource)
+	s.mutex.Unlock()
+
+	return func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		for i, tracked := range s.resources {
+			if tracked == resource {
+				s.resources = append(s.resources[:i], s.resources[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// TrackTaskHandle registers task (e.g. workload, Snap session or Kubernetes cluster) to be stopped on shutdown.
+func (s *ShutdownCoordinator) TrackTaskHandle(handle executor.TaskHandle) (untrack func()) {
+	return s.Track(fmt.Sprintf("task %s", handle), handle.Stop)
+}
+
+// TrackIsolation registers isolation to be cleaned on shutdown.
+func (s *ShutdownCoordinator) TrackIsolation(i isolation.Isolation) (untrack func()) {
+	return s.Track(fmt.Sprintf("isolation %q", i.Decorate("")), i.Clean)
+}
+
+// Interrupted returns true when the experiment has been interrupted.
+func (s *ShutdownCoordinator) Interrupted() bool {
+	return s.ctx.Err() != nil
+}
+
+// Shutdown releases all tracked resources in reverse order and records the interruption in metadata.
+func (s *ShutdownCoordinator) Shutdown(reason os.Signal) error {
+	s.mutex.Lock()
+	s.cancel()
+	resources := s.resources
+	s.resources = nil
+	metaData := s.metadata
+	s.mutex.Unlock()
+
+	var errCollection errcollection.ErrorCollection
+	for i := len(resources) - 1; i >= 0; i-- {
+		logrus.Debugf("Shutdown: releasing %s", resources[i].name)
+		if err := resources[i].cleanup(); err != nil {
+			logrus.Errorf("Shutdown: cannot release %s: %s", resources[i].name, err.Error())
+			errCollection.Add(err)
+		}
+	}
+
+	if metaData != nil {
+		err := metaData.RecordMap(map[string]string{
+			InterruptedKey:     "true",
+			InterruptSignalKey: reason.String(),
+			InterruptTimeKey:   time.Now().Format(time.RFC822Z),
+		}, metadata.TypeEmpty)
+		errCollection.Add(err)
+	}
+
+	return errCollection.GetErrIfAny()
+}
+
+func (s *ShutdownCoordinator) watch() {
+	var sig os.Signal
+	select {
+	case sig = <-s.signals:
+	case <-s.stopped:
+		return
+	}
+
+	logrus.Warnf("Received %s: stopping experiment (send the signal again to exit immediately)", sig)
+	go func() {
+		sig := <-s.signals
+		logrus.Errorf("Received %s during cleanup: exiting without releasing remaining resources", sig)
+		s.exit(ExTempFail)
+	}()
+
+	err := s.Shutdown(sig)
+	if err != nil {
+		logrus.Errorf("Experiment has been interrupted and not all resources were released: %s", err.Error())
+	} else {
+		logrus.Warn("Experiment has been interrupted and all resources were released")
+	}
+	s.exit(ExTempFail)
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package experiment
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type recordingMetadata struct {
+	records []map[string]string
+}
+
+func (m *recordingMetadata) Record(key string, value string, kind string) error {
+	return m.RecordMap(map[string]string{key: value}, kind)
+}
+
+func (m *recordingMetadata) RecordMap(metadata map[string]string, kind string) error {
+	m.records = append(m.records, metadata)
+	return nil
+}
+
+func (m *recordingMetadata) GetByKind(kind string) (map[string]string, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *recordingMetadata) Clear() error {
+	return nil
+}
+
+func TestShutdownCoordinator(t *testing.T) {
+	Convey("When having ShutdownCoordinator with tracked resources", t, func() {
+		shutdown := NewShutdownCoordinator()
+		metadata := &recordingMetadata{}
+		shutdown.SetMetadata(metadata)
+
+		var released []string
+		track := func(name string, err error) func() {
+			return shutdown.Track(name, func() error {
+				released = append(released, name)
+				return err
+			})
+		}
+
+		track("first", nil)
+		untrack := track("second", nil)
+		track("third", nil)
+
+		Convey("Shutdown should release them in reverse order", func() {
+			err := shutdown.Shutdown(syscall.SIGINT)
+			So(err, ShouldBeNil)
+			So(released, ShouldResemble, []string{"third", "second", "first"})
+			So(shutdown.Interrupted(), ShouldBeTrue)
+			So(shutdown.Context().Err(), ShouldNotBeNil)
+		})
+
+		Convey("Untracked resources should not be released", func() {
+			untrack()
+			err := shutdown.Shutdown(syscall.SIGINT)
+			So(err, ShouldBeNil)
+			So(released, ShouldResemble, []string{"third", "first"})
+		})
+
+		Convey("Interruption should be recorded in metadata", func() {
+			err := shutdown.Shutdown(syscall.SIGTERM)
+			So(err, ShouldBeNil)
+			So(metadata.records, ShouldHaveLength, 1)
+			So(metadata.records[0][InterruptedKey], ShouldEqual, "true")
+			So(metadata.records[0][InterruptSignalKey], ShouldEqual, syscall.SIGTERM.String())
+		})
+
+		Convey("Errors should be returned, but all resources should be released", func() {
+			track("failing", errors.New("cleanup failed"))
+			err := shutdown.Shutdown(syscall.SIGINT)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "cleanup failed")
+			So(released, ShouldResemble, []string{"failing", "third", "second", "first"})
+		})
+
+		Convey("Resources tracked after interruption should be released immediately", func() {
+			shutdown.Shutdown(syscall.SIGINT)
+			released = nil
+
+			track("late", nil)
+			So(released, ShouldResemble, []string{"late"})
+		})
+
+		Convey("Task handles should be stopped", func() {
+			handle := new(executor.MockTaskHandle)
+			handle.On("String").Return("task")
+			handle.On("Stop").Return(nil)
+			shutdown.TrackTaskHandle(handle)
+
+			err := shutdown.Shutdown(syscall.SIGINT)
+			So(err, ShouldBeNil)
+			So(handle.AssertCalled(t, "Stop"), ShouldBeTrue)
+		})
+
+		Convey("Signal should trigger shutdown and exit with ExTempFail", func() {
+			exitCode := make(chan int, 1)
+			shutdown.exit = func(code int) { exitCode <- code }
+			go shutdown.watch()
+
+			shutdown.signals <- syscall.SIGTERM
+			select {
+			case code := <-exitCode:
+				So(code, ShouldEqual, ExTempFail)
+			case <-time.After(5 * time.Second):
+				t.Fatal("experiment has not exited after signal")
+			}
+			So(released, ShouldResemble, []string{"third", "second", "first"})
+		})
+	})
+}
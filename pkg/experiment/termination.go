@@ -0,0 +1,47 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package experiment
+
+import (
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// RetryableFailure returns true when repetition failed with err because a task has not been launched in time
+// (see executor.LaunchTimedOutError) or any of terminated tasks (by name) failed for a transient reason
+// (see executor.TerminationStatus.Retryable), so running the repetition again might succeed.
+// Tasks which status is not known are ignored.
+func RetryableFailure(err error, tasks map[string]executor.TaskInfo) bool {
+	if _, timedOut := errors.Cause(err).(*executor.LaunchTimedOutError); timedOut {
+		logrus.Warnf("Task has not been launched in time: %s", err.Error())
+		return true
+	}
+	retryable := false
+	for name, task := range tasks {
+		if task == nil {
+			continue
+		}
+		status, err := executor.GetTerminationStatus(task)
+		if err != nil {
+			continue
+		}
+		if status.Retryable() {
+			logrus.Warnf("Task %s has failed for transient reason: %s", name, status)
+			retryable = true
+		}
+	}
+	return retryable
+}
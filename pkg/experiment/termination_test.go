@@ -0,0 +1,51 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package experiment
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type terminatedTaskHandle struct {
+	executor.MockTaskHandle
+	status executor.TerminationStatus
+}
+
+func (h *terminatedTaskHandle) TerminationStatus() (executor.TerminationStatus, error) {
+	return h.status, nil
+}
+
+func TestRetryableFailure(t *testing.T) {
+	Convey("Repetition should be retried only when a task failed for transient reason", t, func() {
+		stopped := &terminatedTaskHandle{status: executor.TerminationStatus{Signal: syscall.SIGKILL, Stopped: true}}
+		failed := &terminatedTaskHandle{status: executor.TerminationStatus{ExitCode: 1}}
+		oomKilled := &terminatedTaskHandle{status: executor.TerminationStatus{Signal: syscall.SIGKILL, OOMKilled: true}}
+
+		failure := errors.New("repetition failed")
+		So(RetryableFailure(failure, map[string]executor.TaskInfo{"hp": stopped, "lg": failed}), ShouldBeFalse)
+		So(RetryableFailure(failure, map[string]executor.TaskInfo{"hp": stopped, "be": oomKilled, "lg": nil}), ShouldBeTrue)
+
+		Convey("or when a task has not been launched in time", func() {
+			timedOut := errors.Wrap(&executor.LaunchTimedOutError{}, "cannot launch hp")
+			So(RetryableFailure(timedOut, map[string]executor.TaskInfo{"lg": failed}), ShouldBeTrue)
+			So(RetryableFailure(timedOut, map[string]executor.TaskInfo{}), ShouldBeTrue)
+		})
+	})
+}
@@ -19,14 +19,17 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	pth "path"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/intelsdi-x/swan/pkg/conf"
 	"github.com/intelsdi-x/swan/pkg/executor"
 	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/journal"
 	"github.com/pkg/errors"
 )
 
@@ -40,17 +43,40 @@ const (
 	// MemoryController is the canonical name of the cgroups memory controller.
 	MemoryController = "memory"
 
+	// FreezerController is the canonical name of the cgroups freezer controller.
+	// Unified hierarchy has no freezer controller: every cgroup can be frozen there.
+	FreezerController = "freezer"
+
 	// DefaultCommandTimeout is the default amount of time to wait for
 	// dispatched commands to finish executing.
 	DefaultCommandTimeout = 10 * time.Second
 )
 
+var (
+	filesystemBackendFlag = conf.NewBoolFlag("cgroup_filesystem_backend", "Manipulate cgroups through cgroupfs instead of libcgroup tools (used automatically when cgcreate is not installed).", false)
+
+	// lookPath finds libcgroup tools (for mocking purposes).
+	lookPath = exec.LookPath
+)
+
+// useFilesystemBackend returns true when cgroups in v1 hierarchy should be manipulated through cgroupfs
+// (see NewFilesystemCgroup): when it is requested with flag or libcgroup tools are not installed.
+func useFilesystemBackend() bool {
+	if filesystemBackendFlag.Value() {
+		return true
+	}
+	_, err := lookPath("cgcreate")
+	return err != nil
+}
+
 // Cgroup represents a Linux control group.
 // See https://www.kernel.org/doc/Documentation/cgroup-v1/cgroups.txt
 //
-// Usage of this interface requires the libcgroup tools to be installed
-// on the system. This library interacts with cgroups by shelling out to
+// Usage of this interface on cgroup v1 requires the libcgroup tools to be
+// installed on the system. This library interacts with cgroups by shelling out to
 // utility programs like `cgcreate`, `cgexec`, `cgget` and friends.
+// Cgroups created with NewFilesystemCgroup and cgroups in unified (v2) hierarchy
+// are manipulated through cgroupfs directly (see NewUnifiedCgroup).
 type Cgroup interface {
 	isolation.Isolation
 	Metadata
@@ -82,10 +108,14 @@ type Metadata interface {
 	// Spec returns an identifier compatible with libcgroup-tools.
 	// Returns a string like 'cpu,cpuset:/my/cool/group'.
 	Spec() string
+
+	// Hierarchy returns version of the hierarchy this cgroup belongs to.
+	Hierarchy() isolation.CgroupHierarchy
 }
 
 // Filesystem represents Linux control group's backing virtual file system.
-// These methods shell out to external libcgroup-tools programs.
+// These methods shell out to external libcgroup-tools programs unless
+// the cgroup is manipulated through cgroupfs directly.
 type Filesystem interface {
 	// AbsPath returns the absolute path to this cgroup within the
 	// VFS mount for the specified controller.
@@ -151,7 +181,16 @@ func (b ByPathLength) Less(i, j int) bool {
 
 // NewCgroup returns a new Cgroup with the supplied controllers and path.
 // Returns an error if no controllers are specified or the path is empty.
+// Cgroup in unified hierarchy is returned when platform uses cgroup v2.
+// Cgroup manipulated through cgroupfs is returned when cgroup_filesystem_backend
+// flag is set or libcgroup tools are not installed.
 func NewCgroup(controllers []string, path string) (Cgroup, error) {
+	if isolation.DetectCgroupHierarchy(isolation.DefaultCgroupMountRoot) == isolation.CgroupV2 {
+		return NewUnifiedCgroup(controllers, path, isolation.DefaultCgroupMountRoot)
+	}
+	if useFilesystemBackend() {
+		return NewFilesystemCgroup(controllers, path, isolation.DefaultCgroupMountRoot)
+	}
 	return NewCgroupWithExecutor(controllers,
 		path,
 		executor.NewLocal(),
@@ -198,6 +237,10 @@ func (cg *cgroup) IsRoot() bool {
 	return cg.Path() == "/"
 }
 
+func (cg *cgroup) Hierarchy() isolation.CgroupHierarchy {
+	return isolation.CgroupV1
+}
+
 func (cg *cgroup) AbsPath(controller string) string {
 	p, err := SubsysPath(controller, cg.executor, cg.cmdTimeout)
 	if err != nil {
@@ -221,15 +264,22 @@ func (cg *cgroup) Exists() (bool, error) {
 
 func (cg *cgroup) Create() error {
 	_, err := cg.cmdOutput("cgcreate", "-g", cg.Spec())
+	if err == nil {
+		journal.Created(journal.Resource{Kind: journal.KindCgroup, ID: cg.Spec()})
+	}
 	return err
 }
 
 func (cg *cgroup) Destroy(recursive bool) error {
+	var err error
 	if recursive {
-		_, err := cg.cmdOutput("cgdelete", "--recursive", "-g", cg.Spec())
-		return err
+		_, err = cg.cmdOutput("cgdelete", "--recursive", "-g", cg.Spec())
+	} else {
+		_, err = cg.cmdOutput("cgdelete", "-g", cg.Spec())
+	}
+	if err == nil {
+		journal.Released(journal.Resource{Kind: journal.KindCgroup, ID: cg.Spec()})
 	}
-	_, err := cg.cmdOutput("cgdelete", "-g", cg.Spec())
 	return err
 }
 
@@ -240,11 +290,16 @@ func (cg *cgroup) Parent() Cgroup {
 	parentPath, _ := pth.Split(cg.path)
 	// Discarding errors here because controllers and path are both
 	// guaranteed to be non-empty.
-	p, _ := NewCgroup(cg.controllers, parentPath)
+	p, _ := NewCgroupWithExecutor(cg.controllers, parentPath, cg.executor, cg.cmdTimeout)
 	return p
 }
 
 func (cg *cgroup) Ancestors() []Cgroup {
+	return ancestors(cg)
+}
+
+// ancestors returns all ancestors of cgroup in depth order beginning with the root.
+func ancestors(cg Cgroup) []Cgroup {
 	result := []Cgroup{}
 	current := cg.Parent()
 	for {
@@ -307,6 +362,11 @@ func (cg *cgroup) Set(name string, value string) error {
 }
 
 func (cg *cgroup) SetAndCheck(name string, value string) error {
+	return setAndCheck(cg, name, value)
+}
+
+// setAndCheck overwrites the value of an attribute and checks that it has been set.
+func setAndCheck(cg Cgroup, name string, value string) error {
 	err := cg.Set(name, value)
 	if err != nil {
 		return err
@@ -15,6 +15,7 @@
 package cgroup
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/intelsdi-x/swan/pkg/isolation"
@@ -57,6 +58,29 @@ func TestNewCgroup(t *testing.T) {
 	})
 }
 
+func TestUseFilesystemBackend(t *testing.T) {
+	Convey("When libcgroup tools are not installed", t, func() {
+		defer func(previous func(string) (string, error)) { lookPath = previous }(lookPath)
+		lookPath = func(file string) (string, error) {
+			return "", errors.New("not found")
+		}
+
+		Convey("Cgroups should be manipulated through cgroupfs", func() {
+			So(useFilesystemBackend(), ShouldBeTrue)
+		})
+	})
+	Convey("When libcgroup tools are installed", t, func() {
+		defer func(previous func(string) (string, error)) { lookPath = previous }(lookPath)
+		lookPath = func(file string) (string, error) {
+			return "/usr/bin/" + file, nil
+		}
+
+		Convey("Cgroups should be manipulated through cgroupfs only when it is requested", func() {
+			So(useFilesystemBackend(), ShouldEqual, filesystemBackendFlag.Value())
+		})
+	})
+}
+
 // Controllers() []string
 func TestCgroupControllers(t *testing.T) {
 	Convey("After constructing a cgroup", t, func() {
@@ -58,6 +58,13 @@ const (
 	// CPUSetMemExclusive is the name of the exclusive memory node attribute
 	// for a cpuset.
 	CPUSetMemExclusive = "cpuset.mem_exclusive"
+
+	// CPUSetPartition is the name of the partition type attribute for a cpuset
+	// in unified hierarchy. Cpus of a "root" partition are used exclusively by it.
+	CPUSetPartition = "cpuset.cpus.partition"
+
+	// CPUSetPartitionRoot is the partition type of cpuset with exclusive cpus.
+	CPUSetPartitionRoot = "root"
 )
 
 // CPUSet describes a cgroup cpuset with core ids and numa (memory) nodes.
@@ -70,8 +77,16 @@ type cpuset struct {
 }
 
 // NewCPUSet creates a new CPUSet with the default (local) executor
-// and default timeout.
+// and default timeout. CPUSet in unified hierarchy is returned when
+// platform uses cgroup v2. CPUSet manipulated through cgroupfs is returned
+// when libcgroup tools should not be used (see NewCgroup).
 func NewCPUSet(path string, cpus, mems isolation.IntSet, cpuExclusive, memExclusive bool) (CPUSet, error) {
+	if isolation.DetectCgroupHierarchy(isolation.DefaultCgroupMountRoot) == isolation.CgroupV2 {
+		return NewUnifiedCPUSet(path, cpus, mems, cpuExclusive, memExclusive, isolation.DefaultCgroupMountRoot)
+	}
+	if useFilesystemBackend() {
+		return NewFilesystemCPUSet(path, cpus, mems, cpuExclusive, memExclusive, isolation.DefaultCgroupMountRoot)
+	}
 	return NewCPUSetWithExecutor(path, cpus, mems, cpuExclusive, memExclusive, executor.NewLocal(), DefaultCommandTimeout)
 }
 
@@ -89,6 +104,34 @@ func NewCPUSetWithExecutor(path string,
 	if err != nil {
 		return nil, err
 	}
+	return newCPUSet(cg, cpus, mems, cpuExclusive, memExclusive)
+}
+
+// NewUnifiedCPUSet creates a new CPUSet in unified (v2) hierarchy mounted in mountRoot.
+// Memory nodes cannot be allocated exclusively in unified hierarchy, so memExclusive
+// results in an error on Create.
+func NewUnifiedCPUSet(path string, cpus, mems isolation.IntSet, cpuExclusive, memExclusive bool, mountRoot string) (CPUSet, error) {
+	cg, err := NewUnifiedCgroup([]string{CPUSetController}, path, mountRoot)
+	if err != nil {
+		return nil, err
+	}
+	return newCPUSet(cg, cpus, mems, cpuExclusive, memExclusive)
+}
+
+// NewFilesystemCPUSet creates a new CPUSet that is manipulated through cgroupfs mounted in mountRoot
+// instead of libcgroup tools (see NewFilesystemCgroup). Version of the hierarchy is detected.
+func NewFilesystemCPUSet(path string, cpus, mems isolation.IntSet, cpuExclusive, memExclusive bool, mountRoot string) (CPUSet, error) {
+	if isolation.DetectCgroupHierarchy(mountRoot) == isolation.CgroupV2 {
+		return NewUnifiedCPUSet(path, cpus, mems, cpuExclusive, memExclusive, mountRoot)
+	}
+	cg, err := NewFilesystemCgroup([]string{CPUSetController}, path, mountRoot)
+	if err != nil {
+		return nil, err
+	}
+	return newCPUSet(cg, cpus, mems, cpuExclusive, memExclusive)
+}
+
+func newCPUSet(cg Cgroup, cpus, mems isolation.IntSet, cpuExclusive, memExclusive bool) (CPUSet, error) {
 	if len(cpus) == 0 {
 		return nil, errors.Errorf("Empty set of cpus provided")
 	}
@@ -160,6 +203,9 @@ func (cs *cpuset) Create() error {
 	// the root of the hierarchy. If this is not done first, setting the
 	// attribute will fail! These values default to "0" (off) for all
 	// non-root cgroups.
+	//
+	// In unified hierarchy exclusivity is expressed by partition type: cpus
+	// are exclusive to a "root" partition, which parent is also a "root" partition.
 
 	for _, a := range cs.cgroup.Ancestors() {
 		err = cs.setupCgroup(a)
@@ -185,6 +231,10 @@ func (cs *cpuset) Isolate(PID int) error {
 }
 
 func (cs *cpuset) setupCgroup(c Cgroup) error {
+	if c.Hierarchy() == isolation.CgroupV2 {
+		return cs.setupUnifiedCgroup(c)
+	}
+
 	// Set cpus without overwriting any currently set ranges.
 	current, err := c.Get(CPUSetCpus)
 	if err != nil {
@@ -227,3 +277,46 @@ func (cs *cpuset) setupCgroup(c Cgroup) error {
 
 	return nil
 }
+
+func (cs *cpuset) setupUnifiedCgroup(c Cgroup) error {
+	// Root of unified hierarchy owns all cpus and memory nodes and it is always a "root" partition.
+	if c.IsRoot() {
+		return nil
+	}
+
+	if cs.memExclusive {
+		return errors.Errorf("Exclusive memory nodes are not supported in unified hierarchy (cgroup %q)", c.Spec())
+	}
+
+	// Empty cpuset.cpus and cpuset.mems mean that parent's resources are used.
+	current, err := c.Get(CPUSetCpus)
+	if err != nil {
+		return err
+	}
+	if current == "" {
+		err = c.Set(CPUSetCpus, cs.cpus.AsRangeString())
+		if err != nil {
+			return err
+		}
+	}
+
+	current, err = c.Get(CPUSetMems)
+	if err != nil {
+		return err
+	}
+	if current == "" {
+		err = c.Set(CPUSetMems, cs.mems.AsRangeString())
+		if err != nil {
+			return err
+		}
+	}
+
+	if cs.cpuExclusive {
+		err = c.SetAndCheck(CPUSetPartition, CPUSetPartitionRoot)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgroup
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	pth "path"
+	"strconv"
+	"strings"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/journal"
+	"github.com/pkg/errors"
+)
+
+// NewFilesystemCgroup returns a new Cgroup with the supplied controllers and path
+// in v1 hierarchies mounted in mountRoot (e.g. /sys/fs/cgroup/cpuset, /sys/fs/cgroup/cpu,cpuacct).
+//
+// Contrary to NewCgroup, the cgroup is manipulated by reading and writing cgroupfs
+// files directly, so libcgroup tools are not required and commands are not dispatched
+// through an executor.
+// Returns an error if no controllers are specified or the path is empty.
+func NewFilesystemCgroup(controllers []string, path string, mountRoot string) (Cgroup, error) {
+	if len(controllers) == 0 {
+		return nil, errors.Errorf("No controllers specified for cgroup")
+	}
+	if path == "" {
+		return nil, errors.Errorf("Empty path specified for cgroup")
+	}
+	if mountRoot == "" {
+		return nil, errors.Errorf("Empty mount root specified for cgroup")
+	}
+	canonicalPath := pth.Join("/", path)
+	return &fsCgroup{controllers, canonicalPath, mountRoot}, nil
+}
+
+// The fsCgroup struct implements the Cgroup interface through cgroupfs.
+type fsCgroup struct {
+	controllers []string
+	path        string
+	mountRoot   string
+}
+
+func (cg *fsCgroup) Controllers() []string {
+	return cg.controllers
+}
+
+func (cg *fsCgroup) Path() string {
+	return cg.path
+}
+
+func (cg *fsCgroup) IsRoot() bool {
+	return cg.Path() == "/"
+}
+
+func (cg *fsCgroup) Hierarchy() isolation.CgroupHierarchy {
+	return isolation.CgroupV1
+}
+
+func (cg *fsCgroup) Parent() Cgroup {
+	if cg.path == "/" {
+		return nil
+	}
+	parentPath, _ := pth.Split(cg.path)
+	// Discarding errors here because controllers, path and mount root are
+	// guaranteed to be non-empty.
+	p, _ := NewFilesystemCgroup(cg.controllers, parentPath, cg.mountRoot)
+	return p
+}
+
+func (cg *fsCgroup) Ancestors() []Cgroup {
+	return ancestors(cg)
+}
+
+func (cg *fsCgroup) Spec() string {
+	return strings.Join(cg.controllers, ",") + ":" + cg.path
+}
+
+func (cg *fsCgroup) AbsPath(controller string) string {
+	for _, c := range cg.controllers {
+		if c == controller {
+			mount, err := controllerMount(cg.mountRoot, controller)
+			if err != nil {
+				return ""
+			}
+			return pth.Join(mount, cg.path)
+		}
+	}
+	return ""
+}
+
+// directories returns cgroup directories for all controllers.
+func (cg *fsCgroup) directories() ([]string, error) {
+	directories := []string{}
+	for _, controller := range cg.controllers {
+		mount, err := controllerMount(cg.mountRoot, controller)
+		if err != nil {
+			return nil, err
+		}
+		directories = append(directories, pth.Join(mount, cg.path))
+	}
+	return directories, nil
+}
+
+func (cg *fsCgroup) Exists() (bool, error) {
+	directories, err := cg.directories()
+	if err != nil {
+		return false, err
+	}
+	for _, directory := range directories {
+		_, err := os.Stat(directory)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return false, nil
+			}
+			return false, errors.Wrapf(err, "cannot check if cgroup %q exists", cg.Spec())
+		}
+	}
+	return true, nil
+}
+
+func (cg *fsCgroup) Create() error {
+	directories, err := cg.directories()
+	if err != nil {
+		return err
+	}
+	for _, directory := range directories {
+		err = os.MkdirAll(directory, 0755)
+		if err != nil {
+			return errors.Wrapf(err, "cannot create cgroup %q", cg.Spec())
+		}
+	}
+	journal.Created(journal.Resource{Kind: journal.KindCgroup, ID: cg.Spec()})
+	return nil
+}
+
+func (cg *fsCgroup) Destroy(recursive bool) error {
+	directories, err := cg.directories()
+	if err != nil {
+		return err
+	}
+
+	for _, directory := range directories {
+		remove := []string{directory}
+		if recursive {
+			children, err := nestedDirectories(directory)
+			if err != nil {
+				return err
+			}
+			// Children have to be removed before their parents.
+			remove = append(children, remove...)
+		}
+
+		for _, d := range remove {
+			err := os.Remove(d)
+			if err != nil && !os.IsNotExist(err) {
+				return errors.Wrapf(err, "cannot destroy cgroup %q", cg.Spec())
+			}
+		}
+	}
+	journal.Released(journal.Resource{Kind: journal.KindCgroup, ID: cg.Spec()})
+	return nil
+}
+
+func (cg *fsCgroup) Tasks(controller string) (isolation.IntSet, error) {
+	d := cg.AbsPath(controller)
+	if d == "" {
+		return nil, errors.Errorf("Failed to read absolute path for controller %q", controller)
+	}
+
+	tf, err := os.Open(pth.Join(d, "tasks"))
+	if err != nil {
+		return nil, err
+	}
+	defer tf.Close()
+
+	pids := isolation.NewIntSet()
+	s := bufio.NewScanner(tf)
+	for s.Scan() {
+		t, err := strconv.Atoi(s.Text())
+		if err != nil {
+			return nil, err
+		}
+		pids.Add(t)
+	}
+	if s.Err() != nil {
+		return nil, s.Err()
+	}
+
+	return pids, nil
+}
+
+// attributeFile returns path of file for attribute (e.g. "cpuset.cpus").
+// Attribute belongs to the controller named by its prefix.
+func (cg *fsCgroup) attributeFile(name string) (string, error) {
+	controller := strings.SplitN(name, ".", 2)[0]
+	d := cg.AbsPath(controller)
+	if d == "" {
+		return "", errors.Errorf("Controller %q of attribute %q is not a member of cgroup %q", controller, name, cg.Spec())
+	}
+	return pth.Join(d, name), nil
+}
+
+func (cg *fsCgroup) Get(name string) (string, error) {
+	filename, err := cg.attributeFile(name)
+	if err != nil {
+		return "", err
+	}
+	bytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read attribute %q of cgroup %q", name, cg.Spec())
+	}
+	return strings.TrimSpace(string(bytes)), nil
+}
+
+func (cg *fsCgroup) Set(name string, value string) error {
+	filename, err := cg.attributeFile(name)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(filename, []byte(value), 0644)
+	if err != nil {
+		return errors.Wrapf(err, "cannot set attribute %q of cgroup %q to %q", name, cg.Spec(), value)
+	}
+	return nil
+}
+
+func (cg *fsCgroup) SetAndCheck(name string, value string) error {
+	return setAndCheck(cg, name, value)
+}
+
+func (cg *fsCgroup) Clean() error {
+	return cg.Destroy(true)
+}
+
+func (cg *fsCgroup) procsFiles() []string {
+	procsFiles := []string{}
+	for _, controller := range cg.controllers {
+		procsFiles = append(procsFiles, pth.Join(cg.AbsPath(controller), isolation.CgroupProcs))
+	}
+	return procsFiles
+}
+
+func (cg *fsCgroup) Decorate(command string) string {
+	return isolation.CgroupProcsDecorate(command, cg.procsFiles()...)
+}
+
+func (cg *fsCgroup) Isolate(PID int) error {
+	for _, procsFile := range cg.procsFiles() {
+		err := isolation.WriteCgroupProcs(procsFile, PID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// controllerMount returns directory where v1 hierarchy of controller is mounted in mountRoot.
+// Co-mounted controllers share a directory named after all of them (e.g. "cpu,cpuacct").
+func controllerMount(mountRoot, controller string) (string, error) {
+	mount := pth.Join(mountRoot, controller)
+	if _, err := os.Stat(mount); err == nil {
+		return mount, nil
+	}
+
+	files, err := ioutil.ReadDir(mountRoot)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot list cgroup mounts in %q", mountRoot)
+	}
+	for _, file := range files {
+		for _, name := range strings.Split(file.Name(), ",") {
+			if name == controller {
+				return pth.Join(mountRoot, file.Name()), nil
+			}
+		}
+	}
+	return "", errors.Errorf("Subsystem '%s' is not mounted in %q", controller, mountRoot)
+}
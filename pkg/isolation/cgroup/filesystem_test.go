@@ -0,0 +1,127 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgroup
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/isolation/isolationtest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFilesystemCgroup(t *testing.T) {
+	Convey("When having cgroup manipulated through cgroupfs", t, func() {
+		root := isolationtest.NewFakeCgroupfsV1()
+		defer os.RemoveAll(root)
+
+		cg, err := NewFilesystemCgroup([]string{"cpuset", "cpuacct"}, "swan/foo", root)
+		So(err, ShouldBeNil)
+		So(cg.Hierarchy(), ShouldEqual, isolation.CgroupV1)
+
+		Convey("Each controller should have its own directory", func() {
+			So(cg.AbsPath("cpuset"), ShouldEqual, path.Join(root, "cpuset", "swan", "foo"))
+			So(cg.AbsPath("cpuacct"), ShouldEqual, path.Join(root, "cpu,cpuacct", "swan", "foo"))
+			So(cg.AbsPath("memory"), ShouldBeEmpty)
+		})
+
+		Convey("It should be created in all hierarchies", func() {
+			exists, err := cg.Exists()
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeFalse)
+
+			So(cg.Create(), ShouldBeNil)
+			exists, err = cg.Exists()
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeTrue)
+
+			Convey("Attributes should be written to file of their controller", func() {
+				So(cg.Set("cpuacct.usage", "0"), ShouldBeNil)
+				So(isolationtest.ReadFile(path.Join(root, "cpu,cpuacct", "swan", "foo", "cpuacct.usage")), ShouldEqual, "0")
+				value, err := cg.Get("cpuacct.usage")
+				So(err, ShouldBeNil)
+				So(value, ShouldEqual, "0")
+			})
+
+			Convey("Attributes of other controllers should not be accessible", func() {
+				So(cg.Set("memory.limit_in_bytes", "0"), ShouldNotBeNil)
+				_, err := cg.Get("memory.limit_in_bytes")
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("Process should be moved to all hierarchies", func() {
+				So(cg.Isolate(1234), ShouldBeNil)
+				So(isolationtest.ReadFile(path.Join(root, "cpuset", "swan", "foo", isolation.CgroupProcs)), ShouldEqual, "1234")
+				So(isolationtest.ReadFile(path.Join(root, "cpu,cpuacct", "swan", "foo", isolation.CgroupProcs)), ShouldEqual, "1234")
+			})
+
+			Convey("It should be destroyed in all hierarchies with its children", func() {
+				child, err := NewFilesystemCgroup([]string{"cpuset", "cpuacct"}, "swan/foo/bar", root)
+				So(err, ShouldBeNil)
+				So(child.Create(), ShouldBeNil)
+
+				So(cg.Destroy(true), ShouldBeNil)
+				exists, err := cg.Exists()
+				So(err, ShouldBeNil)
+				So(exists, ShouldBeFalse)
+			})
+		})
+
+		Convey("Command should be decorated without cgexec", func() {
+			decorated := cg.Decorate("sleep 1")
+			So(decorated, ShouldNotContainSubstring, "cgexec")
+			So(decorated, ShouldContainSubstring, path.Join(root, "cpuset", "swan", "foo", isolation.CgroupProcs))
+			So(decorated, ShouldContainSubstring, path.Join(root, "cpu,cpuacct", "swan", "foo", isolation.CgroupProcs))
+		})
+	})
+}
+
+func TestFilesystemCPUSet(t *testing.T) {
+	Convey("When creating exclusive CPUSet through cgroupfs", t, func() {
+		root := isolationtest.NewFakeCgroupfsV1("swan", "swan/hp")
+		defer os.RemoveAll(root)
+
+		cpuSet, err := NewFilesystemCPUSet("swan/hp", isolation.NewIntSet(0, 1), isolation.NewIntSet(0), true, false, root)
+		So(err, ShouldBeNil)
+		So(cpuSet.Cgroup().Hierarchy(), ShouldEqual, isolation.CgroupV1)
+		So(cpuSet.Create(), ShouldBeNil)
+
+		Convey("Root cpus should not be overwritten", func() {
+			So(isolationtest.ReadFile(path.Join(root, "cpuset", CPUSetCpus)), ShouldEqual, "0-3")
+		})
+
+		Convey("Cpus and exclusivity should be set for the cgroup and its ancestors", func() {
+			for _, cgroup := range []string{"swan", "swan/hp"} {
+				So(isolationtest.ReadFile(path.Join(root, "cpuset", cgroup, CPUSetCpus)), ShouldEqual, "0,1")
+				So(isolationtest.ReadFile(path.Join(root, "cpuset", cgroup, CPUSetMems)), ShouldEqual, "0")
+				So(isolationtest.ReadFile(path.Join(root, "cpuset", cgroup, CPUSetCPUExclusive)), ShouldEqual, "1")
+			}
+		})
+	})
+
+	Convey("When creating CPUSet through cgroupfs of unified hierarchy", t, func() {
+		root := isolationtest.NewFakeCgroupfs("swan")
+		defer os.RemoveAll(root)
+
+		cpuSet, err := NewFilesystemCPUSet("swan", isolation.NewIntSet(0), isolation.NewIntSet(0), false, false, root)
+		So(err, ShouldBeNil)
+
+		Convey("Unified hierarchy should be used", func() {
+			So(cpuSet.Cgroup().Hierarchy(), ShouldEqual, isolation.CgroupV2)
+		})
+	})
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgroup
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	pth "path"
+	"strconv"
+	"strings"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/journal"
+	"github.com/pkg/errors"
+)
+
+// NewUnifiedCgroup returns a new Cgroup with the supplied controllers and path
+// in unified (v2) hierarchy mounted in mountRoot.
+// See https://www.kernel.org/doc/Documentation/cgroup-v2.txt
+//
+// All controllers share single directory in unified hierarchy, so the cgroup is
+// manipulated by reading and writing files in that directory.
+// Returns an error if no controllers are specified or the path is empty.
+func NewUnifiedCgroup(controllers []string, path string, mountRoot string) (Cgroup, error) {
+	if len(controllers) == 0 {
+		return nil, errors.Errorf("No controllers specified for cgroup")
+	}
+	if path == "" {
+		return nil, errors.Errorf("Empty path specified for cgroup")
+	}
+	if mountRoot == "" {
+		return nil, errors.Errorf("Empty mount root specified for cgroup")
+	}
+	canonicalPath := pth.Join("/", path)
+	return &unifiedCgroup{controllers, canonicalPath, mountRoot}, nil
+}
+
+// The unifiedCgroup struct implements the Cgroup interface for cgroup v2.
+type unifiedCgroup struct {
+	controllers []string
+	path        string
+	mountRoot   string
+}
+
+func (cg *unifiedCgroup) Controllers() []string {
+	return cg.controllers
+}
+
+func (cg *unifiedCgroup) Path() string {
+	return cg.path
+}
+
+func (cg *unifiedCgroup) IsRoot() bool {
+	return cg.Path() == "/"
+}
+
+func (cg *unifiedCgroup) Hierarchy() isolation.CgroupHierarchy {
+	return isolation.CgroupV2
+}
+
+func (cg *unifiedCgroup) Parent() Cgroup {
+	if cg.path == "/" {
+		return nil
+	}
+	parentPath, _ := pth.Split(cg.path)
+	// Discarding errors here because controllers, path and mount root are
+	// guaranteed to be non-empty.
+	p, _ := NewUnifiedCgroup(cg.controllers, parentPath, cg.mountRoot)
+	return p
+}
+
+func (cg *unifiedCgroup) Ancestors() []Cgroup {
+	return ancestors(cg)
+}
+
+func (cg *unifiedCgroup) Spec() string {
+	return strings.Join(cg.controllers, ",") + ":" + cg.path
+}
+
+func (cg *unifiedCgroup) directory() string {
+	return pth.Join(cg.mountRoot, cg.path)
+}
+
+func (cg *unifiedCgroup) AbsPath(controller string) string {
+	for _, c := range cg.controllers {
+		if c == controller {
+			return cg.directory()
+		}
+	}
+	return ""
+}
+
+func (cg *unifiedCgroup) Exists() (bool, error) {
+	info, err := os.Stat(cg.directory())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "cannot check if cgroup %q exists", cg.Spec())
+	}
+	return info.IsDir(), nil
+}
+
+func (cg *unifiedCgroup) Create() error {
+	controllers := []string{}
+	for _, controller := range cg.controllers {
+		// Freezing is built into every cgroup in unified hierarchy, so it is not enabled for children.
+		if controller != FreezerController {
+			controllers = append(controllers, controller)
+		}
+	}
+	err := isolation.CreateUnifiedCgroup(cg.mountRoot, cg.path, controllers)
+	if err == nil {
+		journal.Created(journal.Resource{Kind: journal.KindCgroup, ID: cg.Spec()})
+	}
+	return err
+}
+
+func (cg *unifiedCgroup) Destroy(recursive bool) error {
+	directories := []string{cg.directory()}
+	if recursive {
+		children, err := nestedDirectories(cg.directory())
+		if err != nil {
+			return err
+		}
+		// Children have to be removed before their parents.
+		directories = append(children, directories...)
+	}
+
+	for _, directory := range directories {
+		err := os.Remove(directory)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "cannot destroy cgroup %q", cg.Spec())
+		}
+	}
+	journal.Released(journal.Resource{Kind: journal.KindCgroup, ID: cg.Spec()})
+	return nil
+}
+
+func (cg *unifiedCgroup) Tasks(controller string) (isolation.IntSet, error) {
+	d := cg.AbsPath(controller)
+	if d == "" {
+		return nil, errors.Errorf("Failed to read absolute path for controller %q", controller)
+	}
+
+	// There is no tasks file in unified hierarchy; processes are listed in cgroup.procs.
+	tf, err := os.Open(pth.Join(d, isolation.CgroupProcs))
+	if err != nil {
+		return nil, err
+	}
+	defer tf.Close()
+
+	pids := isolation.NewIntSet()
+	s := bufio.NewScanner(tf)
+	for s.Scan() {
+		t, err := strconv.Atoi(s.Text())
+		if err != nil {
+			return nil, err
+		}
+		pids.Add(t)
+	}
+	if s.Err() != nil {
+		return nil, s.Err()
+	}
+
+	return pids, nil
+}
+
+func (cg *unifiedCgroup) Get(name string) (string, error) {
+	filename := pth.Join(cg.directory(), name)
+	bytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read attribute %q of cgroup %q", name, cg.Spec())
+	}
+	return strings.TrimSpace(string(bytes)), nil
+}
+
+func (cg *unifiedCgroup) Set(name string, value string) error {
+	filename := pth.Join(cg.directory(), name)
+	err := ioutil.WriteFile(filename, []byte(value), 0644)
+	if err != nil {
+		return errors.Wrapf(err, "cannot set attribute %q of cgroup %q to %q", name, cg.Spec(), value)
+	}
+	return nil
+}
+
+func (cg *unifiedCgroup) SetAndCheck(name string, value string) error {
+	return setAndCheck(cg, name, value)
+}
+
+func (cg *unifiedCgroup) Clean() error {
+	return cg.Destroy(true)
+}
+
+func (cg *unifiedCgroup) Decorate(command string) string {
+	return isolation.CgroupProcsDecorate(command, pth.Join(cg.directory(), isolation.CgroupProcs))
+}
+
+func (cg *unifiedCgroup) Isolate(PID int) error {
+	return isolation.WriteCgroupProcs(pth.Join(cg.directory(), isolation.CgroupProcs), PID)
+}
+
+// nestedDirectories returns directories nested in root (root excluded); children precede their parents.
+func nestedDirectories(root string) ([]string, error) {
+	files, err := ioutil.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "cannot list %q", root)
+	}
+
+	directories := []string{}
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+		directory := pth.Join(root, file.Name())
+		children, err := nestedDirectories(directory)
+		if err != nil {
+			return nil, err
+		}
+		directories = append(directories, children...)
+		directories = append(directories, directory)
+	}
+	return directories, nil
+}
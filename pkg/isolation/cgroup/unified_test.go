@@ -0,0 +1,148 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgroup
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/intelsdi-x/swan/pkg/isolation/isolationtest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUnifiedCgroup(t *testing.T) {
+	Convey("When having cgroup in unified hierarchy", t, func() {
+		root := isolationtest.NewFakeCgroupfs()
+		defer os.RemoveAll(root)
+
+		cg, err := NewUnifiedCgroup([]string{"cpu", "memory"}, "swan/foo", root)
+		So(err, ShouldBeNil)
+		So(cg.Hierarchy(), ShouldEqual, isolation.CgroupV2)
+
+		Convey("It should not exist before creation", func() {
+			exists, err := cg.Exists()
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeFalse)
+		})
+
+		Convey("Its parents should be in the same hierarchy", func() {
+			So(cg.Parent().Hierarchy(), ShouldEqual, isolation.CgroupV2)
+			So(cg.Parent().AbsPath("cpu"), ShouldEqual, path.Join(root, "swan"))
+		})
+
+		Convey("All controllers should share single directory", func() {
+			So(cg.AbsPath("cpu"), ShouldEqual, path.Join(root, "swan", "foo"))
+			So(cg.AbsPath("memory"), ShouldEqual, path.Join(root, "swan", "foo"))
+			So(cg.AbsPath("cpuset"), ShouldBeEmpty)
+		})
+
+		Convey("After creation", func() {
+			So(cg.Create(), ShouldBeNil)
+
+			Convey("It should exist with controllers enabled", func() {
+				exists, err := cg.Exists()
+				So(err, ShouldBeNil)
+				So(exists, ShouldBeTrue)
+				So(isolationtest.ReadFile(path.Join(root, isolation.CgroupSubtreeControl)), ShouldEqual, "+cpu +memory")
+				So(isolationtest.ReadFile(path.Join(root, "swan", isolation.CgroupSubtreeControl)), ShouldEqual, "+cpu +memory")
+			})
+
+			Convey("Attributes should be written to and read from files", func() {
+				So(cg.Set("cpu.weight", "100"), ShouldBeNil)
+				value, err := cg.Get("cpu.weight")
+				So(err, ShouldBeNil)
+				So(value, ShouldEqual, "100")
+				So(cg.SetAndCheck("memory.max", "max"), ShouldBeNil)
+			})
+
+			Convey("Tasks should be read from cgroup.procs", func() {
+				So(cg.Isolate(1), ShouldBeNil)
+				tasks, err := cg.Tasks("cpu")
+				So(err, ShouldBeNil)
+				So(tasks, ShouldResemble, isolation.NewIntSet(1))
+			})
+
+			Convey("It should be destroyed with its children", func() {
+				child, err := NewUnifiedCgroup([]string{"cpu", "memory"}, "swan/foo/bar/baz", root)
+				So(err, ShouldBeNil)
+				So(child.Create(), ShouldBeNil)
+				// Remove files that would not exist in cgroupfs.
+				So(os.Remove(path.Join(root, "swan", "foo", isolation.CgroupSubtreeControl)), ShouldBeNil)
+				So(os.Remove(path.Join(root, "swan", "foo", "bar", isolation.CgroupSubtreeControl)), ShouldBeNil)
+
+				So(cg.Destroy(true), ShouldBeNil)
+				exists, err := cg.Exists()
+				So(err, ShouldBeNil)
+				So(exists, ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("When creating freezer cgroup in unified hierarchy", t, func() {
+		root := isolationtest.NewFakeCgroupfs()
+		defer os.RemoveAll(root)
+
+		cg, err := NewUnifiedCgroup([]string{FreezerController}, "swan/be", root)
+		So(err, ShouldBeNil)
+		So(cg.Create(), ShouldBeNil)
+
+		Convey("Freezer should not be enabled as a controller", func() {
+			_, err := os.Stat(path.Join(root, isolation.CgroupSubtreeControl))
+			So(os.IsNotExist(err), ShouldBeTrue)
+			So(cg.AbsPath(FreezerController), ShouldEqual, path.Join(root, "swan", "be"))
+		})
+	})
+}
+
+func TestUnifiedCPUSet(t *testing.T) {
+	Convey("When creating exclusive CPUSet in unified hierarchy", t, func() {
+		root := isolationtest.NewFakeCgroupfs("swan", "swan/hp")
+		defer os.RemoveAll(root)
+
+		cpuSet, err := NewUnifiedCPUSet("swan/hp", isolation.NewIntSet(0, 1, 2), isolation.NewIntSet(0), true, false, root)
+		So(err, ShouldBeNil)
+		So(cpuSet.Create(), ShouldBeNil)
+
+		Convey("Cpus and memory nodes should be set for the cgroup and its ancestors", func() {
+			for _, cgroup := range []string{"swan", "swan/hp"} {
+				So(isolationtest.ReadFile(path.Join(root, cgroup, CPUSetCpus)), ShouldEqual, "0,1,2")
+				So(isolationtest.ReadFile(path.Join(root, cgroup, CPUSetMems)), ShouldEqual, "0")
+			}
+		})
+
+		Convey("Cgroup and its ancestors should be root partitions", func() {
+			So(isolationtest.ReadFile(path.Join(root, "swan", CPUSetPartition)), ShouldEqual, CPUSetPartitionRoot)
+			So(isolationtest.ReadFile(path.Join(root, "swan/hp", CPUSetPartition)), ShouldEqual, CPUSetPartitionRoot)
+		})
+
+		Convey("Command should be decorated without cgexec", func() {
+			So(cpuSet.Decorate("sleep 1"), ShouldContainSubstring, path.Join(root, "swan/hp", isolation.CgroupProcs))
+		})
+	})
+
+	Convey("When creating CPUSet with exclusive memory nodes in unified hierarchy", t, func() {
+		root := isolationtest.NewFakeCgroupfs("swan")
+		defer os.RemoveAll(root)
+
+		cpuSet, err := NewUnifiedCPUSet("swan", isolation.NewIntSet(0), isolation.NewIntSet(0), false, true, root)
+		So(err, ShouldBeNil)
+
+		Convey("Creation should fail", func() {
+			So(cpuSet.Create(), ShouldNotBeNil)
+		})
+	})
+}
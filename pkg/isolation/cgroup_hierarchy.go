@@ -0,0 +1,161 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isolation
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CgroupHierarchy is the version of cgroup hierarchy mounted on the platform.
+type CgroupHierarchy int
+
+const (
+	// CgroupV1 means that every controller is mounted in its own hierarchy
+	// (see https://www.kernel.org/doc/Documentation/cgroup-v1/cgroups.txt).
+	CgroupV1 CgroupHierarchy = 1
+
+	// CgroupV2 means that all controllers are available in single, unified hierarchy
+	// (see https://www.kernel.org/doc/Documentation/cgroup-v2.txt).
+	CgroupV2 CgroupHierarchy = 2
+
+	// DefaultCgroupMountRoot is the directory where cgroup hierarchies are mounted.
+	DefaultCgroupMountRoot = "/sys/fs/cgroup"
+
+	// CgroupProcs is the name of file that contains PIDs of processes in a cgroup.
+	CgroupProcs = "cgroup.procs"
+
+	// CgroupControllers is the name of file that lists controllers available in unified hierarchy.
+	CgroupControllers = "cgroup.controllers"
+
+	// CgroupSubtreeControl is the name of file that enables controllers for children of unified hierarchy cgroup.
+	CgroupSubtreeControl = "cgroup.subtree_control"
+)
+
+// DetectCgroupHierarchy returns the version of cgroup hierarchy mounted in mountRoot.
+// Unified hierarchy exposes cgroup.controllers file in its root. Hybrid setups
+// (unified hierarchy mounted next to v1 controllers) are treated as v1.
+func DetectCgroupHierarchy(mountRoot string) CgroupHierarchy {
+	_, err := os.Stat(path.Join(mountRoot, CgroupControllers))
+	if err == nil {
+		return CgroupV2
+	}
+	return CgroupV1
+}
+
+// CreateUnifiedCgroup creates cgroup with given path (and its missing ancestors) in unified hierarchy
+// mounted in mountRoot. Controllers have to be enabled in parent's cgroup.subtree_control to be available
+// in a child, so they are enabled on the way down from the root of the hierarchy.
+// Existing cgroups are left intact.
+func CreateUnifiedCgroup(mountRoot, cgroupPath string, controllers []string) error {
+	enable := []string{}
+	for _, controller := range controllers {
+		enable = append(enable, "+"+controller)
+	}
+	value := strings.Join(enable, " ")
+
+	directory := mountRoot
+	for _, element := range strings.Split(strings.Trim(path.Clean("/"+cgroupPath), "/"), "/") {
+		if element == "" {
+			break
+		}
+		if len(enable) > 0 {
+			filename := path.Join(directory, CgroupSubtreeControl)
+			err := ioutil.WriteFile(filename, []byte(value), 0644)
+			if err != nil {
+				return errors.Wrapf(err, "cannot enable controllers %q in %q", value, filename)
+			}
+		}
+
+		directory = path.Join(directory, element)
+		err := os.Mkdir(directory, 0755)
+		if err != nil && !os.IsExist(err) {
+			return errors.Wrapf(err, "cannot create cgroup %q", directory)
+		}
+	}
+	return nil
+}
+
+// CgroupProcsDecorate returns command that moves itself to the cgroups with given cgroup.procs files
+// before it is executed. It is an equivalent of cgexec that does not need libcgroup tools.
+func CgroupProcsDecorate(command string, procsFiles ...string) string {
+	return writePIDDecorate(command, procsFiles...)
+}
+
+// writePIDDecorate returns command that writes its PID to files before it is executed.
+func writePIDDecorate(command string, files ...string) string {
+	script := []string{}
+	for _, file := range files {
+		script = append(script, fmt.Sprintf("echo $$ > %s", file))
+	}
+	script = append(script, "exec "+command)
+	return "sh -c " + shellQuote(strings.Join(script, " && "))
+}
+
+// WriteCgroupProcs moves process with given PID to cgroup with given cgroup.procs file.
+func WriteCgroupProcs(procsFile string, PID int) error {
+	err := ioutil.WriteFile(procsFile, []byte(strconv.Itoa(PID)), 0644)
+	if err != nil {
+		return errors.Wrapf(err, "could not write PID %d to file %q", PID, procsFile)
+	}
+	return nil
+}
+
+// CPUSharesToWeight converts cgroup v1 cpu.shares [2-262144] to cgroup v2 cpu.weight [1-10000].
+// Conversion is linear and the same as used by container runtimes (e.g. runc).
+func CPUSharesToWeight(shares int) int {
+	if shares < 2 {
+		shares = 2
+	}
+	if shares > 262144 {
+		shares = 262144
+	}
+	return 1 + ((shares-2)*9999)/262142
+}
+
+// createUnifiedCgroup creates cgroup with given name and controller in unified hierarchy and sets its attribute.
+func createUnifiedCgroup(mountRoot, name, controller, attribute, value string) error {
+	err := CreateUnifiedCgroup(mountRoot, name, []string{controller})
+	if err != nil {
+		return err
+	}
+
+	filename := path.Join(mountRoot, name, attribute)
+	err = ioutil.WriteFile(filename, []byte(value), 0644)
+	if err != nil {
+		return errors.Wrapf(err, "could not write %q to file %q", value, filename)
+	}
+	return nil
+}
+
+// removeUnifiedCgroup removes cgroup with given name from unified hierarchy.
+func removeUnifiedCgroup(mountRoot, name string) error {
+	directory := path.Join(mountRoot, name)
+	err := os.Remove(directory)
+	if err != nil {
+		return errors.Wrapf(err, "cannot remove cgroup %q", directory)
+	}
+	return nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isolation
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/isolation/isolationtest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDetectCgroupHierarchy(t *testing.T) {
+	Convey("When detecting cgroup hierarchy", t, func() {
+		Convey("Unified hierarchy should be detected as cgroup v2", func() {
+			root := isolationtest.NewFakeCgroupfs()
+			defer os.RemoveAll(root)
+			So(DetectCgroupHierarchy(root), ShouldEqual, CgroupV2)
+		})
+
+		Convey("Controller hierarchies should be detected as cgroup v1", func() {
+			root := isolationtest.NewFakeCgroupfsV1()
+			defer os.RemoveAll(root)
+			So(DetectCgroupHierarchy(root), ShouldEqual, CgroupV1)
+		})
+	})
+}
+
+func TestCreateUnifiedCgroup(t *testing.T) {
+	Convey("When creating nested cgroup in unified hierarchy", t, func() {
+		root := isolationtest.NewFakeCgroupfs()
+		defer os.RemoveAll(root)
+
+		err := CreateUnifiedCgroup(root, "/swan/hp", []string{"cpuset", "cpu"})
+		So(err, ShouldBeNil)
+
+		Convey("Cgroup and its ancestors should be created", func() {
+			info, err := os.Stat(path.Join(root, "swan", "hp"))
+			So(err, ShouldBeNil)
+			So(info.IsDir(), ShouldBeTrue)
+		})
+
+		Convey("Controllers should be enabled for ancestors' children", func() {
+			So(isolationtest.ReadFile(path.Join(root, CgroupSubtreeControl)), ShouldEqual, "+cpuset +cpu")
+			So(isolationtest.ReadFile(path.Join(root, "swan", CgroupSubtreeControl)), ShouldEqual, "+cpuset +cpu")
+		})
+
+		Convey("Creating it again should not fail", func() {
+			So(CreateUnifiedCgroup(root, "/swan/hp", []string{"cpuset", "cpu"}), ShouldBeNil)
+		})
+	})
+}
+
+func TestUnifiedCPUSharesAndMemorySize(t *testing.T) {
+	Convey("When using CPU shares and memory size isolations with cgroup v2", t, func() {
+		root := isolationtest.NewFakeCgroupfs()
+		defer os.RemoveAll(root)
+
+		cpu := NewCPUSharesWithMountRoot("swan-cpu", 262144, root).(*CPUShares)
+		memory := NewMemorySizeWithMountRoot("swan-memory", 1024, root).(*MemorySize)
+
+		Convey("Shares should be converted to cpu.weight", func() {
+			So(cpu.Create(), ShouldBeNil)
+			So(isolationtest.ReadFile(path.Join(root, "swan-cpu", "cpu.weight")), ShouldEqual, "10000")
+			So(isolationtest.ReadFile(path.Join(root, CgroupSubtreeControl)), ShouldEqual, "+cpu")
+
+			Convey("Changed shares should be written to cpu.weight", func() {
+				So(cpu.SetShares(1024), ShouldBeNil)
+				So(isolationtest.ReadFile(path.Join(root, "swan-cpu", "cpu.weight")), ShouldEqual, "39")
+			})
+		})
+
+		Convey("Size should be written to memory.max", func() {
+			So(memory.Create(), ShouldBeNil)
+			So(isolationtest.ReadFile(path.Join(root, "swan-memory", "memory.max")), ShouldEqual, "1024")
+			So(isolationtest.ReadFile(path.Join(root, CgroupSubtreeControl)), ShouldEqual, "+memory")
+
+			Convey("Changed size should be written to memory.max", func() {
+				So(memory.SetSize(2048), ShouldBeNil)
+				So(isolationtest.ReadFile(path.Join(root, "swan-memory", "memory.max")), ShouldEqual, "2048")
+			})
+		})
+
+		Convey("Cgroups should be removed on clean", func() {
+			So(cpu.Create(), ShouldBeNil)
+			// Remove file that kernel removes together with cgroup.
+			So(os.Remove(path.Join(root, "swan-cpu", "cpu.weight")), ShouldBeNil)
+			So(cpu.Clean(), ShouldBeNil)
+			_, err := os.Stat(path.Join(root, "swan-cpu"))
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+
+		Convey("OOM kills should be read from memory.events", func() {
+			So(memory.Create(), ShouldBeNil)
+			events := "low 0\nhigh 0\nmax 3\noom 2\noom_kill 1\n"
+			So(ioutil.WriteFile(path.Join(root, "swan-memory", "memory.events"), []byte(events), 0644), ShouldBeNil)
+			kills, err := memory.OOMKills()
+			So(err, ShouldBeNil)
+			So(kills, ShouldEqual, 1)
+		})
+
+		Convey("Isolated process should be written to cgroup.procs", func() {
+			So(cpu.Create(), ShouldBeNil)
+			So(cpu.Isolate(1234), ShouldBeNil)
+			So(isolationtest.ReadFile(path.Join(root, "swan-cpu", CgroupProcs)), ShouldEqual, "1234")
+		})
+
+		Convey("Command should move itself to cgroup.procs instead of using cgexec", func() {
+			So(cpu.Decorate("sleep 1"), ShouldEqual, "sh -c 'echo $$ > "+path.Join(root, "swan-cpu", CgroupProcs)+" && exec sleep 1'")
+		})
+	})
+}
+
+func TestCPUSharesToWeight(t *testing.T) {
+	Convey("When converting cpu.shares to cpu.weight", t, func() {
+		So(CPUSharesToWeight(2), ShouldEqual, 1)
+		So(CPUSharesToWeight(1024), ShouldEqual, 39)
+		So(CPUSharesToWeight(262144), ShouldEqual, 10000)
+		So(CPUSharesToWeight(0), ShouldEqual, 1)
+	})
+}
+
+func TestCgroupProcsDecorate(t *testing.T) {
+	Convey("When decorating command with quotes", t, func() {
+		decorated := CgroupProcsDecorate("echo 'foo'", "/a/cgroup.procs", "/b/cgroup.procs")
+		So(decorated, ShouldEqual, `sh -c 'echo $$ > /a/cgroup.procs && echo $$ > /b/cgroup.procs && exec echo '\''foo'\'''`)
+	})
+}
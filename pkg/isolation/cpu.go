@@ -24,23 +24,41 @@ import (
 )
 
 // CPUShares defines data needed for CPU controller.
+// On cgroup v2 shares are converted to cpu.weight (see CPUSharesToWeight).
 type CPUShares struct {
-	name   string
-	shares int
+	name      string
+	shares    int
+	mountRoot string
 }
 
 // NewCPUShares instance creation.
 func NewCPUShares(name string, shares int) Isolation {
-	return &CPUShares{name: name, shares: shares}
+	return NewCPUSharesWithMountRoot(name, shares, DefaultCgroupMountRoot)
+}
+
+// NewCPUSharesWithMountRoot creates CPUShares in cgroup hierarchy mounted in mountRoot.
+func NewCPUSharesWithMountRoot(name string, shares int, mountRoot string) Isolation {
+	return &CPUShares{name: name, shares: shares, mountRoot: mountRoot}
+}
+
+func (cpu *CPUShares) unified() bool {
+	return DetectCgroupHierarchy(cpu.mountRoot) == CgroupV2
 }
 
 // Decorate implements Decorator interface
 func (cpu *CPUShares) Decorate(command string) string {
+	if cpu.unified() {
+		return CgroupProcsDecorate(command, path.Join(cpu.mountRoot, cpu.name, CgroupProcs))
+	}
 	return "cgexec -g cpu:" + cpu.name + " " + command
 }
 
 // Clean removes the specified cgroup
 func (cpu *CPUShares) Clean() error {
+	if cpu.unified() {
+		return removeUnifiedCgroup(cpu.mountRoot, cpu.name)
+	}
+
 	cmd := exec.Command("sh", "-c", "cgdelete -g cpu"+":"+cpu.name)
 	err := cmd.Run()
 	if err != nil {
@@ -52,6 +70,11 @@ func (cpu *CPUShares) Clean() error {
 
 // Create specified cgroup.
 func (cpu *CPUShares) Create() error {
+	if cpu.unified() {
+		weight := strconv.Itoa(CPUSharesToWeight(cpu.shares))
+		return createUnifiedCgroup(cpu.mountRoot, cpu.name, "cpu", "cpu.weight", weight)
+	}
+
 	// 1 Create cpu cgroup
 	cmd := exec.Command("cgcreate", "-g", "cpu:"+cpu.name)
 	err := cmd.Run()
@@ -60,21 +83,42 @@ func (cpu *CPUShares) Create() error {
 	}
 
 	// 2 Set cpu cgroup shares
-	cmd = exec.Command("cgset", "-r", "cpu.shares="+strconv.Itoa(cpu.shares), cpu.name)
-	err = cmd.Run()
+	return cpu.SetShares(cpu.shares)
+}
+
+// SetShares changes shares of created cgroup.
+func (cpu *CPUShares) SetShares(shares int) error {
+	if cpu.unified() {
+		filename := path.Join(cpu.mountRoot, cpu.name, "cpu.weight")
+		weight := strconv.Itoa(CPUSharesToWeight(shares))
+		err := ioutil.WriteFile(filename, []byte(weight), 0644)
+		if err != nil {
+			return errors.Wrapf(err, "could not write %q to file %q", weight, filename)
+		}
+		cpu.shares = shares
+		return nil
+	}
+
+	cmd := exec.Command("cgset", "-r", "cpu.shares="+strconv.Itoa(shares), cpu.name)
+	err := cmd.Run()
 	if err != nil {
 		return errors.Wrapf(err, "running command %q failed", cmd.Path)
 	}
+	cpu.shares = shares
 
 	return nil
 }
 
 // Isolate associates specified pid to the cgroup.
 func (cpu *CPUShares) Isolate(PID int) error {
+	if cpu.unified() {
+		return WriteCgroupProcs(path.Join(cpu.mountRoot, cpu.name, CgroupProcs), PID)
+	}
+
 	// Associate task with the specified cgroup.
 	strPID := strconv.Itoa(PID)
 	d := []byte(strPID)
-	filePath := path.Join("/sys/fs/cgroup/cpu", cpu.name, "tasks")
+	filePath := path.Join(cpu.mountRoot, "cpu", cpu.name, "tasks")
 	err := ioutil.WriteFile(filePath, d, 0644)
 
 	if err != nil {
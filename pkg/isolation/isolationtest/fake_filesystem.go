@@ -0,0 +1,97 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package isolationtest provides fake cgroupfs and resctrl filesystems for isolation tests.
+// Helpers assert with goconvey, so they have to be called inside Convey blocks.
+package isolationtest
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+// Names of files created by kernel that are needed by fake filesystems.
+const (
+	cgroupControllers  = "cgroup.controllers"
+	cgroupProcs        = "cgroup.procs"
+	cpusetCpus         = "cpuset.cpus"
+	cpusetMems         = "cpuset.mems"
+	cpusetPartition    = "cpuset.cpus.partition"
+	cpusetCPUExclusive = "cpuset.cpu_exclusive"
+	cpusetMemExclusive = "cpuset.mem_exclusive"
+	resctrlSchemata    = "schemata"
+)
+
+// NewFakeCgroupfs creates directory tree that looks like root of unified (v2) hierarchy
+// with cgroups that already exist (kernel creates their interface files).
+// Returned root has to be removed by the caller.
+func NewFakeCgroupfs(cgroups ...string) string {
+	root := newFakeFilesystem("cgroupfs")
+	WriteFile(path.Join(root, cgroupControllers), "cpuset cpu io memory pids")
+	for _, cgroup := range cgroups {
+		directory := path.Join(root, cgroup)
+		for _, attribute := range []string{cpusetCpus, cpusetMems, cpusetPartition, cgroupProcs} {
+			WriteFile(path.Join(directory, attribute), "")
+		}
+	}
+	return root
+}
+
+// NewFakeCgroupfsV1 creates directory tree that looks like v1 hierarchies mounted in
+// the root (cpuset and co-mounted cpu,cpuacct) with cpuset cgroups that already exist.
+// Returned root has to be removed by the caller.
+func NewFakeCgroupfsV1(cgroups ...string) string {
+	root := newFakeFilesystem("cgroupfs")
+	convey.So(os.Mkdir(path.Join(root, "cpu,cpuacct"), 0755), convey.ShouldBeNil)
+	for _, cgroup := range append([]string{"/"}, cgroups...) {
+		directory := path.Join(root, "cpuset", cgroup)
+		for _, attribute := range []string{cpusetCpus, cpusetMems, cpusetCPUExclusive, cpusetMemExclusive, cgroupProcs} {
+			WriteFile(path.Join(directory, attribute), "")
+		}
+	}
+	// Root cgroup owns all cpus and memory nodes.
+	WriteFile(path.Join(root, "cpuset", cpusetCpus), "0-3")
+	WriteFile(path.Join(root, "cpuset", cpusetMems), "0")
+	return root
+}
+
+// NewFakeResctrl creates directory tree that looks like resctrl filesystem with given default schemata.
+// Returned root has to be removed by the caller.
+func NewFakeResctrl(schemata string) string {
+	root := newFakeFilesystem("resctrl")
+	WriteFile(path.Join(root, resctrlSchemata), schemata)
+	return root
+}
+
+func newFakeFilesystem(prefix string) string {
+	root, err := ioutil.TempDir("", prefix)
+	convey.So(err, convey.ShouldBeNil)
+	return root
+}
+
+// WriteFile writes content to filename creating missing directories.
+func WriteFile(filename, content string) {
+	convey.So(os.MkdirAll(path.Dir(filename), 0755), convey.ShouldBeNil)
+	convey.So(ioutil.WriteFile(filename, []byte(content), 0644), convey.ShouldBeNil)
+}
+
+// ReadFile returns content of filename.
+func ReadFile(filename string) string {
+	bytes, err := ioutil.ReadFile(filename)
+	convey.So(err, convey.ShouldBeNil)
+	return string(bytes)
+}
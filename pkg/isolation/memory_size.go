@@ -19,31 +19,72 @@ import (
 	"os/exec"
 	"path"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 )
 
 // MemorySize defines input data
+// On cgroup v2 size is written to memory.max.
 type MemorySize struct {
-	name string
-	size int
+	name      string
+	size      int
+	mountRoot string
 }
 
 // NewMemorySize creates an instance of input data.
 func NewMemorySize(name string, size int) Isolation {
+	return NewMemorySizeWithMountRoot(name, size, DefaultCgroupMountRoot)
+}
+
+// NewMemorySizeWithMountRoot creates MemorySize in cgroup hierarchy mounted in mountRoot.
+func NewMemorySizeWithMountRoot(name string, size int, mountRoot string) Isolation {
 	return &MemorySize{
-		name: name,
-		size: size,
+		name:      name,
+		size:      size,
+		mountRoot: mountRoot,
 	}
 }
 
+func (memorySize *MemorySize) unified() bool {
+	return DetectCgroupHierarchy(memorySize.mountRoot) == CgroupV2
+}
+
 // Decorate implements Decorator interface.
 func (memorySize *MemorySize) Decorate(command string) string {
+	if memorySize.unified() {
+		return CgroupProcsDecorate(command, path.Join(memorySize.mountRoot, memorySize.name, CgroupProcs))
+	}
 	return "cgexec -g memory:" + memorySize.name + " " + command
 }
 
+// OOMKills returns number of tasks killed by OOM killer in the cgroup.
+// It is read from memory.events on cgroup v2 and from memory.oom_control on cgroup v1 (Linux 4.13 or newer).
+func (memorySize *MemorySize) OOMKills() (int, error) {
+	filename := path.Join(memorySize.mountRoot, "memory", memorySize.name, "memory.oom_control")
+	if memorySize.unified() {
+		filename = path.Join(memorySize.mountRoot, memorySize.name, "memory.events")
+	}
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot read %q", filename)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			return strconv.Atoi(fields[1])
+		}
+	}
+	return 0, errors.Errorf("no oom_kill counter in %q", filename)
+}
+
 // Clean removes specified cgroup.
 func (memorySize *MemorySize) Clean() error {
+	if memorySize.unified() {
+		return removeUnifiedCgroup(memorySize.mountRoot, memorySize.name)
+	}
+
 	cmd := exec.Command("cgdelete", "-g", "memory:"+memorySize.name)
 	err := cmd.Run()
 	if err != nil {
@@ -55,6 +96,10 @@ func (memorySize *MemorySize) Clean() error {
 
 // Create specified cgroup.
 func (memorySize *MemorySize) Create() error {
+	if memorySize.unified() {
+		return createUnifiedCgroup(memorySize.mountRoot, memorySize.name, "memory", "memory.max", strconv.Itoa(memorySize.size))
+	}
+
 	// 1.a Create memory size cgroup.
 	cmd := exec.Command("cgcreate", "-g", "memory:"+memorySize.name)
 	err := cmd.Run()
@@ -63,22 +108,43 @@ func (memorySize *MemorySize) Create() error {
 	}
 
 	// 1.b Set cgroup memory size.
-	cmd = exec.Command("cgset", "-r", "memory.limit_in_bytes="+strconv.Itoa(memorySize.size), memorySize.name)
-	err = cmd.Run()
+	return memorySize.SetSize(memorySize.size)
+}
+
+// SetSize changes memory limit of created cgroup.
+func (memorySize *MemorySize) SetSize(size int) error {
+	if memorySize.unified() {
+		filename := path.Join(memorySize.mountRoot, memorySize.name, "memory.max")
+		value := strconv.Itoa(size)
+		err := ioutil.WriteFile(filename, []byte(value), 0644)
+		if err != nil {
+			return errors.Wrapf(err, "could not write %q to file %q", value, filename)
+		}
+		memorySize.size = size
+		return nil
+	}
+
+	cmd := exec.Command("cgset", "-r", "memory.limit_in_bytes="+strconv.Itoa(size), memorySize.name)
+	err := cmd.Run()
 	if err != nil {
 		return errors.Wrapf(err, "running command %q failed", cmd.Path)
 	}
+	memorySize.size = size
 
 	return nil
 }
 
 // Isolate create specified cgroup and associates specified process id
 func (memorySize *MemorySize) Isolate(PID int) error {
+	if memorySize.unified() {
+		return WriteCgroupProcs(path.Join(memorySize.mountRoot, memorySize.name, CgroupProcs), PID)
+	}
+
 	// Set PID to cgroups.
 	strPID := strconv.Itoa(PID)
 	d := []byte(strPID)
 
-	filePath := path.Join("/sys/fs/cgroup/memory", memorySize.name, "tasks")
+	filePath := path.Join(memorySize.mountRoot, "memory", memorySize.name, "tasks")
 	err := ioutil.WriteFile(filePath, d, 0644)
 
 	if err != nil {
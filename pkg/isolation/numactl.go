@@ -0,0 +1,52 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isolation
+
+import (
+	"fmt"
+)
+
+// NUMA memory policies supported by Numactl.
+const (
+	// NUMAPolicyBind allocates memory only from given nodes.
+	NUMAPolicyBind = "bind"
+	// NUMAPolicyPreferred allocates memory from given node and falls back to other nodes when it is full.
+	NUMAPolicyPreferred = "preferred"
+	// NUMAPolicyInterleave allocates memory round-robin from given nodes.
+	NUMAPolicyInterleave = "interleave"
+)
+
+// Numactl is wrapper for numactl linux tool to run process with NUMA memory policy.
+// Preferred policy accepts single node, so only the lowest one from Nodes is used.
+type Numactl struct {
+	Policy string
+	Nodes  IntSet
+}
+
+// Decorate command with numactl prefix. Command is not decorated when Nodes are empty.
+func (n Numactl) Decorate(command string) string {
+	if n.Nodes.Empty() {
+		return command
+	}
+
+	switch n.Policy {
+	case NUMAPolicyPreferred:
+		return fmt.Sprintf("numactl --preferred=%d %s", n.Nodes.AsSlice()[0], command)
+	case NUMAPolicyInterleave:
+		return fmt.Sprintf("numactl --interleave=%s %s", n.Nodes.AsRangeString(), command)
+	default:
+		return fmt.Sprintf("numactl --membind=%s %s", n.Nodes.AsRangeString(), command)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isolation
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNumactlDecorator(t *testing.T) {
+	Convey("When I want to use numactl decorator", t, func() {
+		Convey("With bind policy", func() {
+			decorator := Numactl{Policy: NUMAPolicyBind, Nodes: NewIntSet(0, 1)}
+			So(decorator.Decorate("test"), ShouldEqual, "numactl --membind=0,1 test")
+		})
+
+		Convey("With preferred policy", func() {
+			decorator := Numactl{Policy: NUMAPolicyPreferred, Nodes: NewIntSet(3, 1)}
+			So(decorator.Decorate("test"), ShouldEqual, "numactl --preferred=1 test")
+		})
+
+		Convey("With interleave policy", func() {
+			decorator := Numactl{Policy: NUMAPolicyInterleave, Nodes: NewIntSet(0, 1, 2, 3)}
+			So(decorator.Decorate("test"), ShouldEqual, "numactl --interleave=0,1,2,3 test")
+		})
+
+		Convey("Combined with taskset", func() {
+			decorators := Decorators{Taskset{NewIntSet(4)}, Numactl{Policy: NUMAPolicyBind, Nodes: NewIntSet(0)}}
+			So(decorators.Decorate("test"), ShouldEqual, "numactl --membind=0 taskset -c 4 test")
+		})
+	})
+}
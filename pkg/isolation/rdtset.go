@@ -20,22 +20,33 @@ import (
 
 	"bytes"
 
+	"github.com/intelsdi-x/swan/pkg/journal"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
-// Rdtset is an instance of Decorator that used rdtset command for isolation. It allows to set CPU affinity and allocate cache available to those CPUs.
+// Rdtset is an instance of Decorator that used rdtset command for isolation. It allows to set CPU affinity and allocate cache
+// (and optionally memory bandwidth) available to those CPUs.
 // See documentation at: experiments/memcached-cat/README.md
 type Rdtset struct {
 	CPURange string
 	Mask     int
+	// MBAPercent is the percentage of memory bandwidth available to CPUs; zero means that bandwidth is not restricted.
+	MBAPercent int
 }
 
 // Decorate implements Decorator interface
 func (r Rdtset) Decorate(command string) (decorated string) {
-	decorated = fmt.Sprintf("rdtset -v -c %s -t 'l3=%#x;cpu=%s' %s", r.CPURange, r.Mask, r.CPURange, command)
+	var mba string
+	if r.MBAPercent != 0 {
+		mba = fmt.Sprintf("mba=%d;", r.MBAPercent)
+	}
+	decorated = fmt.Sprintf("rdtset -v -c %s -t '%sl3=%#x;cpu=%s' %s", r.CPURange, mba, r.Mask, r.CPURange, command)
 	logrus.Debugf("Command decorated with rdtset: %s", decorated)
 
+	// Class of service set by rdtset outlives the command, so it needs to be reclaimed if experiment crashes.
+	journal.Created(journal.Resource{Kind: journal.KindRDT, ID: fmt.Sprintf("%s:%#x", r.CPURange, r.Mask)})
+
 	return
 }
 
@@ -48,6 +59,7 @@ func CleanRDTAssingments() (string, error) {
 	if err != nil {
 		return output, errors.Wrapf(err, "pqos -R failed. Output: %q", output)
 	}
+	journal.Released(journal.Resource{Kind: journal.KindRDT})
 
 	return output, nil
 }
@@ -28,6 +28,13 @@ func TestRdtsetDecorator(t *testing.T) {
 
 			So(command, ShouldEqual, "rdtset -v -c 0-3 -t 'l3=0x7ff;cpu=0-3' ls -l")
 		})
+
+		Convey("It should restrict memory bandwidth when percentage is given", func() {
+			decorator := &Rdtset{Mask: 3, CPURange: "4-7", MBAPercent: 50}
+			command := decorator.Decorate("ls -l")
+
+			So(command, ShouldEqual, "rdtset -v -c 4-7 -t 'mba=50;l3=0x3;cpu=4-7' ls -l")
+		})
 	})
 
 }
@@ -0,0 +1,235 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isolation
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/intelsdi-x/swan/pkg/journal"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultResctrlMountRoot is the directory where resctrl filesystem is mounted.
+	DefaultResctrlMountRoot = "/sys/fs/resctrl"
+
+	// ResctrlSchemata is the name of file with allocations of resctrl group.
+	ResctrlSchemata = "schemata"
+
+	// ResctrlTasks is the name of file with PIDs of tasks assigned to resctrl group.
+	ResctrlTasks = "tasks"
+
+	resctrlL3 = "L3"
+	resctrlMB = "MB"
+)
+
+// Resctrl is an Isolation that allocates L3 cache ways and memory bandwidth
+// with Intel RDT through resctrl filesystem (https://www.kernel.org/doc/Documentation/x86/intel_rdt_ui.txt).
+// Allocation is applied to all cache domains (sockets) of the platform.
+type Resctrl struct {
+	name      string
+	l3Mask    int
+	mbPercent int
+	mountRoot string
+}
+
+// NewResctrl creates resctrl group with given name that can use L3 cache ways from l3Mask
+// and mbPercent of memory bandwidth. Zero mask or percentage leaves the resource unrestricted.
+func NewResctrl(name string, l3Mask, mbPercent int) Isolation {
+	return NewResctrlWithMountRoot(name, l3Mask, mbPercent, DefaultResctrlMountRoot)
+}
+
+// NewResctrlWithMountRoot creates resctrl group in resctrl filesystem mounted in mountRoot.
+func NewResctrlWithMountRoot(name string, l3Mask, mbPercent int, mountRoot string) Isolation {
+	return &Resctrl{name: name, l3Mask: l3Mask, mbPercent: mbPercent, mountRoot: mountRoot}
+}
+
+func (r *Resctrl) directory() string {
+	return path.Join(r.mountRoot, r.name)
+}
+
+func (r *Resctrl) resource() journal.Resource {
+	return journal.Resource{Kind: journal.KindResctrlGroup, ID: r.name, Location: r.mountRoot}
+}
+
+// Decorate implements Decorator interface.
+func (r *Resctrl) Decorate(command string) string {
+	return writePIDDecorate(command, path.Join(r.directory(), ResctrlTasks))
+}
+
+// Create creates resctrl group and writes its schemata.
+// Allocation is validated before the group is created, so invalid allocation leaves nothing behind.
+func (r *Resctrl) Create() error {
+	schemata, err := r.schemata(r.l3Mask, r.mbPercent)
+	if err != nil {
+		return err
+	}
+
+	err = os.Mkdir(r.directory(), 0755)
+	if err != nil && !os.IsExist(err) {
+		return errors.Wrapf(err, "cannot create resctrl group %q", r.directory())
+	}
+	journal.Created(r.resource())
+
+	err = r.writeSchemata(schemata)
+	if err != nil {
+		if removeErr := os.Remove(r.directory()); removeErr == nil {
+			journal.Released(r.resource())
+		}
+		return err
+	}
+	return nil
+}
+
+// SetL3Mask changes L3 cache ways available to created resctrl group.
+func (r *Resctrl) SetL3Mask(l3Mask int) error {
+	err := r.setSchemata(l3Mask, r.mbPercent)
+	if err != nil {
+		return err
+	}
+	r.l3Mask = l3Mask
+	return nil
+}
+
+// SetMBPercent changes percentage of memory bandwidth available to created resctrl group.
+func (r *Resctrl) SetMBPercent(mbPercent int) error {
+	err := r.setSchemata(r.l3Mask, mbPercent)
+	if err != nil {
+		return err
+	}
+	r.mbPercent = mbPercent
+	return nil
+}
+
+func (r *Resctrl) setSchemata(l3Mask, mbPercent int) error {
+	schemata, err := r.schemata(l3Mask, mbPercent)
+	if err != nil {
+		return err
+	}
+	return r.writeSchemata(schemata)
+}
+
+// schemata returns content of schemata file allocating l3Mask and mbPercent in all domains
+// (empty when nothing is restricted).
+func (r *Resctrl) schemata(l3Mask, mbPercent int) (string, error) {
+	if mbPercent < 0 || mbPercent > 100 {
+		return "", errors.Errorf("invalid memory bandwidth percentage %d for resctrl group %q", mbPercent, r.name)
+	}
+
+	domains, err := resctrlDomains(path.Join(r.mountRoot, ResctrlSchemata))
+	if err != nil {
+		return "", err
+	}
+
+	schemata := []string{}
+	if l3Mask != 0 {
+		line, err := schemataLine(resctrlL3, domains, fmt.Sprintf("%x", l3Mask))
+		if err != nil {
+			return "", err
+		}
+		schemata = append(schemata, line)
+	}
+	if mbPercent != 0 {
+		line, err := schemataLine(resctrlMB, domains, strconv.Itoa(mbPercent))
+		if err != nil {
+			return "", err
+		}
+		schemata = append(schemata, line)
+	}
+	if len(schemata) == 0 {
+		return "", nil
+	}
+	return strings.Join(schemata, "\n") + "\n", nil
+}
+
+func (r *Resctrl) writeSchemata(schemata string) error {
+	if schemata == "" {
+		return nil
+	}
+
+	filename := path.Join(r.directory(), ResctrlSchemata)
+	err := ioutil.WriteFile(filename, []byte(schemata), 0644)
+	if err != nil {
+		return errors.Wrapf(err, "could not write %q to file %q", schemata, filename)
+	}
+	return nil
+}
+
+// Isolate assigns task with given PID to resctrl group.
+func (r *Resctrl) Isolate(PID int) error {
+	filename := path.Join(r.directory(), ResctrlTasks)
+	err := ioutil.WriteFile(filename, []byte(strconv.Itoa(PID)), 0644)
+	if err != nil {
+		return errors.Wrapf(err, "could not write PID %d to file %q", PID, filename)
+	}
+	return nil
+}
+
+// Clean removes resctrl group. Its tasks are moved back to default group by kernel.
+func (r *Resctrl) Clean() error {
+	err := os.Remove(r.directory())
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "cannot remove resctrl group %q", r.directory())
+	}
+	journal.Released(r.resource())
+	return nil
+}
+
+// resctrlDomains returns IDs of domains of every resource listed in schemata file
+// (e.g. "L3:0=fffff;1=fffff" is L3 resource with domains 0 and 1).
+func resctrlDomains(filename string) (map[string][]string, error) {
+	bytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read resctrl schemata %q", filename)
+	}
+
+	domains := map[string][]string{}
+	for _, line := range strings.Split(string(bytes), "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		resource := strings.TrimSpace(fields[0])
+		for _, allocation := range strings.Split(fields[1], ";") {
+			domain := strings.SplitN(allocation, "=", 2)
+			if len(domain) != 2 {
+				return nil, errors.Errorf("malformed allocation %q in resctrl schemata %q", allocation, filename)
+			}
+			domains[resource] = append(domains[resource], strings.TrimSpace(domain[0]))
+		}
+	}
+	return domains, nil
+}
+
+// schemataLine returns allocation of value in all domains of resource.
+func schemataLine(resource string, domains map[string][]string, value string) (string, error) {
+	ids, ok := domains[resource]
+	if !ok {
+		return "", errors.Errorf("%s allocation is not supported by the platform", resource)
+	}
+	sort.Strings(ids)
+
+	allocations := []string{}
+	for _, id := range ids {
+		allocations = append(allocations, id+"="+value)
+	}
+	return resource + ":" + strings.Join(allocations, ";"), nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isolation
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/isolation/isolationtest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResctrl(t *testing.T) {
+	Convey("When using resctrl on two socket platform with CAT and MBA", t, func() {
+		root := isolationtest.NewFakeResctrl("    L3:0=7ff;1=7ff\n    MB:0=100;1=100\n")
+		defer os.RemoveAll(root)
+
+		Convey("Cache mask and memory bandwidth should be written to schemata of all domains", func() {
+			resctrl := NewResctrlWithMountRoot("swan-be", 0x3, 50, root)
+			So(resctrl.Create(), ShouldBeNil)
+			So(isolationtest.ReadFile(path.Join(root, "swan-be", ResctrlSchemata)), ShouldEqual, "L3:0=3;1=3\nMB:0=50;1=50\n")
+
+			Convey("Tasks should be assigned through tasks file", func() {
+				So(resctrl.Isolate(1234), ShouldBeNil)
+				So(isolationtest.ReadFile(path.Join(root, "swan-be", ResctrlTasks)), ShouldEqual, "1234")
+				So(resctrl.Decorate("sleep 1"), ShouldEqual, "sh -c 'echo $$ > "+path.Join(root, "swan-be", ResctrlTasks)+" && exec sleep 1'")
+			})
+
+			Convey("Group should be removed on Clean", func() {
+				// Files which would be removed along with the group by kernel.
+				So(os.Remove(path.Join(root, "swan-be", ResctrlSchemata)), ShouldBeNil)
+				So(resctrl.Clean(), ShouldBeNil)
+				_, err := os.Stat(path.Join(root, "swan-be"))
+				So(os.IsNotExist(err), ShouldBeTrue)
+			})
+		})
+
+		Convey("Only cache should be restricted when memory bandwidth is not given", func() {
+			resctrl := NewResctrlWithMountRoot("swan-hp", 0x7fc, 0, root)
+			So(resctrl.Create(), ShouldBeNil)
+			So(isolationtest.ReadFile(path.Join(root, "swan-hp", ResctrlSchemata)), ShouldEqual, "L3:0=7fc;1=7fc\n")
+		})
+
+		Convey("Invalid memory bandwidth percentage should be rejected before group is created", func() {
+			resctrl := NewResctrlWithMountRoot("swan-be", 0, 150, root)
+			So(resctrl.Create(), ShouldNotBeNil)
+			_, err := os.Stat(path.Join(root, "swan-be"))
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+	})
+
+	Convey("When using resctrl on platform without MBA", t, func() {
+		root := isolationtest.NewFakeResctrl("L3:0=fffff\n")
+		defer os.RemoveAll(root)
+
+		Convey("Memory bandwidth allocation should fail without leaving resctrl group", func() {
+			resctrl := NewResctrlWithMountRoot("swan-be", 0x3, 50, root)
+			So(resctrl.Create(), ShouldNotBeNil)
+			_, err := os.Stat(path.Join(root, "swan-be"))
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+	})
+}
@@ -18,22 +18,36 @@ import (
 	"github.com/intelsdi-x/swan/pkg/utils/errutil"
 )
 
-// SharedCacheThreads returns threads from one socket that share a last-level
+// SharedCacheThreads returns threads that share a last-level
 // cache. To avoid placing workloads on both hyperthreads for any physical
 // core, only one thread from each is included in the result.
 func SharedCacheThreads() ThreadSet {
 	allThreads, err := Discover()
 	errutil.Check(err)
 
-	// Retain only threads for one socket.
-	socket, err := allThreads.Sockets(1)
-	errutil.Check(err)
+	return allThreads.SharedCacheThreads()
+}
+
+// SharedCacheThreads returns threads from this set that share the first last-level
+// cache, one thread per physical core. Socket is treated as the last-level cache
+// domain when caches are unknown. There might be several last-level caches per
+// socket (e.g. with sub-NUMA clustering).
+func (s ThreadSet) SharedCacheThreads() ThreadSet {
+	var domain ThreadSet
+	if level := s.LastLevelCache(); level > 0 {
+		domain = s.SharingCache(level)
+	} else {
+		// Retain only threads for one socket.
+		socket, err := s.Sockets(1)
+		errutil.Check(err)
+		domain = socket
+	}
 
 	// Retain only one thread per physical core.
 	// NB: The following filter prediccate closes over this int set.
-	temp := socket.AvailableCores()
+	temp := domain.AvailableCores()
 
-	return socket.Filter(func(t Thread) bool {
+	return domain.Filter(func(t Thread) bool {
 		retain := temp.Contains(t.Core())
 		temp.Remove(t.Core())
 		return retain
@@ -17,13 +17,22 @@ package topo
 import (
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
-// Discover CPU and basic NUMA topology.
+// Discover CPU, cache and NUMA topology.
+// Topology is read from sysfs; `lscpu -p` is used when sysfs is not available.
 func Discover() (ThreadSet, error) {
+	threadSet, err := ReadSysfsTopology(DefaultSysfsRoot)
+	if err == nil {
+		return threadSet, nil
+	}
+	logrus.Debugf("Cannot read topology from sysfs (falling back to lscpu): %s", err.Error())
+
 	out, err := exec.Command("lscpu", "-p").Output()
 	if err != nil {
 		return nil, errors.Wrapf(err, "could not execute %q", "lscpu -p")
@@ -32,7 +41,8 @@ func Discover() (ThreadSet, error) {
 }
 
 // ReadTopology attempts to create a ThreadSet that corresponds to the
-// supplied output from `lscpu -p`.
+// supplied output from `lscpu -p`. NUMA node and cache IDs are read when
+// present, but cache sizes are not available in the output.
 func ReadTopology(lscpuOutput []byte) (ThreadSet, error) {
 	threadSet := NewThreadSet()
 
@@ -61,9 +71,44 @@ func ReadTopology(lscpuOutput []byte) (ThreadSet, error) {
 			return nil, errors.Wrapf(err, "Sscanf failed")
 		}
 
+		node, caches := readOptionalColumns(strings.Split(line, ","))
+
 		// Construct a new thread and append it to the "set".
-		threadSet = append(threadSet, NewThread(cpu, core, socket))
+		threadSet = append(threadSet, NewThreadWithTopology(cpu, core, socket, node, caches))
 	}
 
 	return threadSet, nil
 }
+
+// lscpuCaches describes cache columns (starting from the sixth) of `lscpu -p` output.
+var lscpuCaches = []Cache{
+	{Level: 1, Type: CacheTypeData},
+	{Level: 1, Type: CacheTypeInstruction},
+	{Level: 2, Type: CacheTypeUnified},
+	{Level: 3, Type: CacheTypeUnified},
+}
+
+// readOptionalColumns returns NUMA node (-1 if absent) and caches from `lscpu -p` line fields.
+func readOptionalColumns(fields []string) (node int, caches []Cache) {
+	node = -1
+	if len(fields) > 3 {
+		if value, err := strconv.Atoi(fields[3]); err == nil {
+			node = value
+		}
+	}
+
+	caches = []Cache{}
+	for i, cache := range lscpuCaches {
+		column := 5 + i
+		if len(fields) <= column {
+			break
+		}
+		id, err := strconv.Atoi(fields[column])
+		if err != nil {
+			continue
+		}
+		cache.ID = id
+		caches = append(caches, cache)
+	}
+	return node, caches
+}
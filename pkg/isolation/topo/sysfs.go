@@ -0,0 +1,237 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topo
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	"github.com/pkg/errors"
+)
+
+// DefaultSysfsRoot is the directory where CPU and NUMA node topology is exposed by kernel.
+const DefaultSysfsRoot = "/sys/devices/system"
+
+// ReadSysfsTopology creates a ThreadSet from CPU (root/cpu/cpu*) and NUMA node (root/node/node*)
+// topology exposed in sysfs tree mounted in root. Offline CPUs are skipped.
+//
+// Core IDs in sysfs are unique only within a socket, so threads get core IDs unique across the
+// platform (assigned in order of thread IDs, like lscpu does).
+func ReadSysfsTopology(root string) (ThreadSet, error) {
+	cpuIDs, err := listIDs(path.Join(root, "cpu"), "cpu")
+	if err != nil {
+		return nil, err
+	}
+	if len(cpuIDs) == 0 {
+		return nil, errors.Errorf("no CPUs found in %q", path.Join(root, "cpu"))
+	}
+
+	nodes, err := readNUMANodes(path.Join(root, "node"))
+	if err != nil {
+		return nil, err
+	}
+
+	threadSet := NewThreadSet()
+	cores := map[[2]int]int{}
+	for _, cpuID := range cpuIDs {
+		directory := path.Join(root, "cpu", "cpu"+strconv.Itoa(cpuID))
+		if _, err := os.Stat(path.Join(directory, "topology")); os.IsNotExist(err) {
+			// Offline CPUs do not expose topology.
+			continue
+		}
+
+		socket, err := readInt(path.Join(directory, "topology", "physical_package_id"))
+		if err != nil {
+			return nil, err
+		}
+		coreInSocket, err := readInt(path.Join(directory, "topology", "core_id"))
+		if err != nil {
+			return nil, err
+		}
+		key := [2]int{socket, coreInSocket}
+		core, ok := cores[key]
+		if !ok {
+			core = len(cores)
+			cores[key] = core
+		}
+
+		caches, err := readCaches(path.Join(directory, "cache"))
+		if err != nil {
+			return nil, err
+		}
+
+		node, ok := nodes[cpuID]
+		if !ok {
+			node = -1
+		}
+
+		threadSet = append(threadSet, NewThreadWithTopology(cpuID, core, socket, node, caches))
+	}
+
+	return threadSet, nil
+}
+
+// readCaches reads caches from index* directories of cpu cache directory.
+func readCaches(directory string) ([]Cache, error) {
+	indexes, err := listIDs(directory, "index")
+	if err != nil {
+		return nil, err
+	}
+
+	caches := []Cache{}
+	for _, index := range indexes {
+		indexDirectory := path.Join(directory, "index"+strconv.Itoa(index))
+
+		level, err := readInt(path.Join(indexDirectory, "level"))
+		if err != nil {
+			return nil, err
+		}
+		cacheType, err := readString(path.Join(indexDirectory, "type"))
+		if err != nil {
+			return nil, err
+		}
+		size, err := readSize(path.Join(indexDirectory, "size"))
+		if err != nil {
+			return nil, err
+		}
+
+		// Older kernels do not expose cache id; the lowest CPU sharing the cache identifies it then.
+		id, err := readInt(path.Join(indexDirectory, "id"))
+		if err != nil {
+			if !os.IsNotExist(errors.Cause(err)) {
+				return nil, err
+			}
+			sharedCPUs, err := readString(path.Join(indexDirectory, "shared_cpu_list"))
+			if err != nil {
+				return nil, err
+			}
+			shared, err := isolation.NewIntSetFromRange(sharedCPUs)
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot parse shared CPUs of cache %q", indexDirectory)
+			}
+			first, err := shared.Take(1)
+			if err != nil {
+				return nil, errors.Errorf("no CPUs share cache %q", indexDirectory)
+			}
+			id = first.AsSlice()[0]
+		}
+
+		caches = append(caches, Cache{Level: level, Type: cacheType, ID: id, Size: size})
+	}
+	return caches, nil
+}
+
+// readNUMANodes returns NUMA node of every CPU listed in node*/cpulist files.
+// Platforms without NUMA support do not have node directory, so empty map is returned then.
+func readNUMANodes(directory string) (map[int]int, error) {
+	nodes := map[int]int{}
+	nodeIDs, err := listIDs(directory, "node")
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return nodes, nil
+		}
+		return nil, err
+	}
+
+	for _, nodeID := range nodeIDs {
+		cpuList, err := readString(path.Join(directory, "node"+strconv.Itoa(nodeID), "cpulist"))
+		if err != nil {
+			return nil, err
+		}
+		if cpuList == "" {
+			// Memory-only nodes have no CPUs.
+			continue
+		}
+		cpus, err := isolation.NewIntSetFromRange(cpuList)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse CPUs of NUMA node %d", nodeID)
+		}
+		for cpu := range cpus {
+			nodes[cpu] = nodeID
+		}
+	}
+	return nodes, nil
+}
+
+// listIDs returns sorted numeric suffixes of entries in directory named prefix<number> (e.g. cpu0, cpu1).
+func listIDs(directory, prefix string) ([]int, error) {
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot list %q", directory)
+	}
+
+	ids := []int{}
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), prefix) {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(file.Name(), prefix))
+		if err != nil {
+			// Other entries like cpufreq or cpuidle.
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func readString(filename string) (string, error) {
+	bytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read %q", filename)
+	}
+	return strings.TrimSpace(string(bytes)), nil
+}
+
+func readInt(filename string) (int, error) {
+	value, err := readString(filename)
+	if err != nil {
+		return 0, err
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot parse %q", filename)
+	}
+	return result, nil
+}
+
+// readSize reads size with optional K, M or G suffix (e.g. "32K") and returns it in bytes.
+func readSize(filename string) (int, error) {
+	value, err := readString(filename)
+	if err != nil {
+		return 0, err
+	}
+
+	multiplier := 1
+	switch {
+	case strings.HasSuffix(value, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(value, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(value, "G"):
+		multiplier = 1 << 30
+	}
+	size, err := strconv.Atoi(strings.TrimRight(value, "KMG"))
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot parse %q", filename)
+	}
+	return size * multiplier, nil
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+
+	"github.com/intelsdi-x/swan/pkg/isolation"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newSysfsFixture creates sysfs tree of single socket platform with 16 CPUs (without HyperThreads)
+// and sub-NUMA clustering: pairs of cores share L2 cache and every 8 cores share L3 cache slice
+// and NUMA node. CPU 16 is offline. Cache IDs are not exposed when withCacheIDs is false.
+func newSysfsFixture(withCacheIDs bool) string {
+	root, err := ioutil.TempDir("", "sysfs")
+	So(err, ShouldBeNil)
+
+	write := func(filename, content string) {
+		So(os.MkdirAll(path.Dir(filename), 0755), ShouldBeNil)
+		So(ioutil.WriteFile(filename, []byte(content+"\n"), 0644), ShouldBeNil)
+	}
+
+	for cpu := 0; cpu < 16; cpu++ {
+		directory := path.Join(root, "cpu", fmt.Sprintf("cpu%d", cpu))
+		write(path.Join(directory, "topology", "physical_package_id"), "0")
+		write(path.Join(directory, "topology", "core_id"), strconv.Itoa(cpu))
+
+		caches := []struct {
+			level     int
+			cacheType string
+			id        int
+			size      string
+			shared    string
+		}{
+			{1, CacheTypeData, cpu, "32K", strconv.Itoa(cpu)},
+			{1, CacheTypeInstruction, cpu, "32K", strconv.Itoa(cpu)},
+			{2, CacheTypeUnified, cpu / 2, "2048K", fmt.Sprintf("%d-%d", cpu/2*2, cpu/2*2+1)},
+			{3, CacheTypeUnified, cpu / 8, "16M", fmt.Sprintf("%d-%d", cpu/8*8, cpu/8*8+7)},
+		}
+		for index, cache := range caches {
+			indexDirectory := path.Join(directory, "cache", fmt.Sprintf("index%d", index))
+			write(path.Join(indexDirectory, "level"), strconv.Itoa(cache.level))
+			write(path.Join(indexDirectory, "type"), cache.cacheType)
+			write(path.Join(indexDirectory, "size"), cache.size)
+			write(path.Join(indexDirectory, "shared_cpu_list"), cache.shared)
+			if withCacheIDs {
+				write(path.Join(indexDirectory, "id"), strconv.Itoa(cache.id))
+			}
+		}
+	}
+	// Offline CPU.
+	So(os.MkdirAll(path.Join(root, "cpu", "cpu16"), 0755), ShouldBeNil)
+	write(path.Join(root, "cpu", "online"), "0-15")
+
+	write(path.Join(root, "node", "node0", "cpulist"), "0-7")
+	write(path.Join(root, "node", "node1", "cpulist"), "8-15")
+	// Memory-only node.
+	write(path.Join(root, "node", "node2", "cpulist"), "")
+
+	return root
+}
+
+func TestReadSysfsTopology(t *testing.T) {
+	Convey("When reading topology of platform with sub-NUMA clustering from sysfs", t, func() {
+		root := newSysfsFixture(true)
+		defer os.RemoveAll(root)
+
+		threadSet, err := ReadSysfsTopology(root)
+		So(err, ShouldBeNil)
+
+		Convey("Online threads should be discovered", func() {
+			So(threadSet.AvailableThreads().Equals(isolation.NewIntSet(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15)), ShouldBeTrue)
+			So(threadSet.AvailableSockets().Equals(isolation.NewIntSet(0)), ShouldBeTrue)
+			So(len(threadSet.AvailableCores()), ShouldEqual, 16)
+		})
+
+		Convey("NUMA nodes should be discovered", func() {
+			So(threadSet.AvailableNUMANodes().Equals(isolation.NewIntSet(0, 1)), ShouldBeTrue)
+			node, err := threadSet.FromNUMANodes(1)
+			So(err, ShouldBeNil)
+			So(node.AvailableThreads().Equals(isolation.NewIntSet(8, 9, 10, 11, 12, 13, 14, 15)), ShouldBeTrue)
+
+			_, err = threadSet.FromNUMANodes(2)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Caches should be discovered with their sizes", func() {
+			thread := threadSet[3]
+			So(thread.Caches(), ShouldHaveLength, 4)
+			So(thread.Caches()[3], ShouldResemble, Cache{Level: 3, Type: CacheTypeUnified, ID: 0, Size: 16 << 20})
+			So(thread.CacheID(1), ShouldEqual, 3)
+			So(thread.CacheID(2), ShouldEqual, 1)
+			So(thread.CacheID(4), ShouldEqual, -1)
+			So(threadSet.LastLevelCache(), ShouldEqual, 3)
+		})
+
+		Convey("Threads sharing caches should be found", func() {
+			So(threadSet.AvailableCaches(3).Equals(isolation.NewIntSet(0, 1)), ShouldBeTrue)
+			So(threadSet.SharingCache(3).AvailableThreads().Equals(isolation.NewIntSet(0, 1, 2, 3, 4, 5, 6, 7)), ShouldBeTrue)
+			So(threadSet.SharingCache(2).AvailableThreads().Equals(isolation.NewIntSet(0, 1)), ShouldBeTrue)
+
+			l2, err := threadSet.FromCaches(2, 7)
+			So(err, ShouldBeNil)
+			So(l2.AvailableThreads().Equals(isolation.NewIntSet(14, 15)), ShouldBeTrue)
+		})
+
+		Convey("Threads sharing last-level cache should be from single L3 slice, not whole socket", func() {
+			So(threadSet.SharedCacheThreads().AvailableThreads().Equals(isolation.NewIntSet(0, 1, 2, 3, 4, 5, 6, 7)), ShouldBeTrue)
+		})
+	})
+
+	Convey("When reading topology from sysfs without cache IDs", t, func() {
+		root := newSysfsFixture(false)
+		defer os.RemoveAll(root)
+
+		threadSet, err := ReadSysfsTopology(root)
+		So(err, ShouldBeNil)
+
+		Convey("Caches should be identified by the lowest CPU sharing them", func() {
+			So(threadSet.AvailableCaches(3).Equals(isolation.NewIntSet(0, 8)), ShouldBeTrue)
+			So(threadSet[5].CacheID(2), ShouldEqual, 4)
+		})
+	})
+
+	Convey("When reading topology from sysfs that does not exist", t, func() {
+		_, err := ReadSysfsTopology("/not/existing/sysfs")
+
+		Convey("It should fail", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
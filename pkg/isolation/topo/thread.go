@@ -25,13 +25,43 @@ type Thread interface {
 	ID() int
 	Core() int
 	Socket() int
+	// NUMANode returns ID of NUMA node the thread belongs to (-1 if unknown).
+	NUMANode() int
+	// Caches returns caches available to the thread (empty if unknown).
+	Caches() []Cache
+	// CacheID returns ID of data or unified cache of given level (-1 if unknown).
+	// Threads with the same cache ID share the cache.
+	CacheID(level int) int
 	Equals(Thread) bool
 }
 
+// Cache types as reported in sysfs.
+const (
+	CacheTypeData        = "Data"
+	CacheTypeInstruction = "Instruction"
+	CacheTypeUnified     = "Unified"
+)
+
+// Cache describes a CPU cache.
+type Cache struct {
+	Level int
+	Type  string
+	// ID identifies the cache among caches of the same level and type.
+	ID int
+	// Size is size of the cache in bytes.
+	Size int
+}
+
 // NewThread returns a new thread with the supplied thread, core, and
 // socket IDs.
 func NewThread(id int, core int, socket int) Thread {
-	return thread{id, core, socket}
+	return thread{id: id, core: core, socket: socket, node: -1}
+}
+
+// NewThreadWithTopology returns a new thread with the supplied thread, core, socket and NUMA node IDs
+// and caches available to the thread.
+func NewThreadWithTopology(id int, core int, socket int, node int, caches []Cache) Thread {
+	return thread{id: id, core: core, socket: socket, node: node, caches: caches}
 }
 
 // NewThreadFromID returns new Thread from ThreadID
@@ -59,6 +89,8 @@ type thread struct {
 	id     int
 	core   int
 	socket int
+	node   int
+	caches []Cache
 }
 
 func (t thread) ID() int {
@@ -73,6 +105,23 @@ func (t thread) Socket() int {
 	return t.socket
 }
 
+func (t thread) NUMANode() int {
+	return t.node
+}
+
+func (t thread) Caches() []Cache {
+	return t.caches
+}
+
+func (t thread) CacheID(level int) int {
+	for _, cache := range t.caches {
+		if cache.Level == level && cache.Type != CacheTypeInstruction {
+			return cache.ID
+		}
+	}
+	return -1
+}
+
 func (t thread) Equals(that Thread) bool {
 	return t.ID() == that.ID() &&
 		t.Core() == that.Core() &&
@@ -100,6 +100,44 @@ func (s ThreadSet) AvailableSockets() isolation.IntSet {
 	return sockets
 }
 
+// AvailableNUMANodes returns the set of NUMA node ids for threads in this
+// thread set.
+func (s ThreadSet) AvailableNUMANodes() isolation.IntSet {
+	nodes := isolation.NewIntSet()
+	for _, t := range s {
+		if t.NUMANode() >= 0 {
+			nodes.Add(t.NUMANode())
+		}
+	}
+	return nodes
+}
+
+// AvailableCaches returns the set of ids of data or unified caches of given
+// level for threads in this thread set.
+func (s ThreadSet) AvailableCaches(level int) isolation.IntSet {
+	caches := isolation.NewIntSet()
+	for _, t := range s {
+		if t.CacheID(level) >= 0 {
+			caches.Add(t.CacheID(level))
+		}
+	}
+	return caches
+}
+
+// LastLevelCache returns the highest cache level known for threads in this
+// thread set (0 if caches are unknown).
+func (s ThreadSet) LastLevelCache() int {
+	level := 0
+	for _, t := range s {
+		for _, cache := range t.Caches() {
+			if cache.Level > level {
+				level = cache.Level
+			}
+		}
+	}
+	return level
+}
+
 // Threads returns a newly allocated thread set containing `n` distinct
 // threads from this thread set. If there are fewer than `n` available,
 // returns an error.
@@ -165,6 +203,39 @@ func (s ThreadSet) FromSockets(socketIDs ...int) (ThreadSet, error) {
 	return s.Filter(func(t Thread) bool { return sockets.Contains(t.Socket()) }), nil
 }
 
+// FromNUMANodes returns a newly allocated thread set containing all threads from
+// the supplied NUMA nodes. If any of the supplied nodes are invalid, returns
+// an error.
+func (s ThreadSet) FromNUMANodes(nodeIDs ...int) (ThreadSet, error) {
+	nodes := isolation.NewIntSet(nodeIDs...)
+	if !nodes.Subset(s.AvailableNUMANodes()) {
+		return nil, fmt.Errorf("invalid NUMA node id(s): available NUMA nodes are %s", s.AvailableNUMANodes().AsRangeString())
+	}
+	return s.Filter(func(t Thread) bool { return nodes.Contains(t.NUMANode()) }), nil
+}
+
+// FromCaches returns a newly allocated thread set containing all threads sharing
+// the supplied caches of given level. If any of the supplied caches are invalid,
+// returns an error.
+func (s ThreadSet) FromCaches(level int, cacheIDs ...int) (ThreadSet, error) {
+	caches := isolation.NewIntSet(cacheIDs...)
+	if !caches.Subset(s.AvailableCaches(level)) {
+		return nil, fmt.Errorf("invalid L%d cache id(s): available caches are %s", level, s.AvailableCaches(level).AsRangeString())
+	}
+	return s.Filter(func(t Thread) bool { return caches.Contains(t.CacheID(level)) }), nil
+}
+
+// SharingCache returns a newly allocated thread set containing threads that
+// share the cache of given level with the first thread (the one with the lowest
+// cache ID) in this set. Returns an empty set if cache of given level is unknown.
+func (s ThreadSet) SharingCache(level int) ThreadSet {
+	caches, err := s.AvailableCaches(level).Take(1)
+	if err != nil {
+		return ThreadSet{}
+	}
+	return s.Filter(func(t Thread) bool { return caches.Contains(t.CacheID(level)) })
+}
+
 // Contains returns true iff this set contains the supplied thread.
 func (s ThreadSet) Contains(t Thread) bool {
 	for _, elem := range s {
@@ -0,0 +1,318 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package journal keeps track of resources created by an experiment outside of swan process
+// (cgroups, RDT assignments, pods, instances, Snap tasks) so they can be reclaimed
+// when the experiment crashes or is killed.
+//
+// Journal is an append-only file of JSON entries (one per line) stored in a directory shared
+// by all experiments and named after the experiment ID. Experiment holds a lock of its journal
+// while it is open, so journals of running experiments can be told apart (see InUse).
+// This package must not depend on other swan packages, because they record resources in it.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Kinds of resources recorded in journal.
+const (
+	// KindCgroup is a cgroup; ID is cgroup spec ("controllers:path").
+	KindCgroup = "cgroup"
+	// KindRDT is an RDT class of service assignment; ID is "cpu range:mask".
+	KindRDT = "rdt"
+	// KindResctrlGroup is a resctrl group; ID is group name and Location is resctrl mount root.
+	KindResctrlGroup = "resctrl_group"
+	// KindKubernetesPod is a pod; ID is pod name and Location is namespace.
+	KindKubernetesPod = "kubernetes_pod"
+	// KindOpenstackInstance is an OpenStack instance; ID is instance ID and Location is identity endpoint.
+	KindOpenstackInstance = "openstack_instance"
+	// KindDockerContainer is a Docker container; ID is container name and Location is Docker Engine API address.
+	KindDockerContainer = "docker_container"
+	// KindSnapTask is a Snap task; ID is task ID and Location is snapteld address.
+	KindSnapTask = "snap_task"
+)
+
+const (
+	actionCreated  = "created"
+	actionReleased = "released"
+
+	journalExtension = ".journal"
+)
+
+// DefaultDirectory is the directory where journals are stored by default.
+var DefaultDirectory = path.Join(os.TempDir(), "swan-journal")
+
+// Resource describes resource created by experiment.
+type Resource struct {
+	Kind       string            `json:"kind"`
+	ID         string            `json:"id"`
+	Location   string            `json:"location,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// String returns user-friendly description of resource.
+func (r Resource) String() string {
+	if r.Location != "" {
+		return r.Kind + " " + r.ID + " (" + r.Location + ")"
+	}
+	return r.Kind + " " + r.ID
+}
+
+func (r Resource) key() string {
+	return r.Kind + "\x00" + r.Location + "\x00" + r.ID
+}
+
+type entry struct {
+	Time     time.Time `json:"time"`
+	Action   string    `json:"action"`
+	Resource Resource  `json:"resource"`
+}
+
+// Journal records creation and release of resources for single experiment.
+type Journal struct {
+	mutex        sync.Mutex
+	experimentID string
+	file         *os.File
+}
+
+// Open opens (or creates) journal of experiment in given directory and locks it until it is closed.
+// Returns an error if journal is locked by another running experiment.
+func Open(directory, experimentID string) (*Journal, error) {
+	err := os.MkdirAll(directory, 0755)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot create journal directory %q", directory)
+	}
+
+	filename := Path(directory, experimentID)
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open journal %q", filename)
+	}
+	// Lock is released by the kernel when experiment is killed.
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err != nil {
+		file.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, errors.Errorf("journal %q is used by running experiment", filename)
+		}
+		return nil, errors.Wrapf(err, "cannot lock journal %q", filename)
+	}
+
+	return &Journal{experimentID: experimentID, file: file}, nil
+}
+
+// InUse returns true when journal of experiment in given directory is locked by running experiment.
+func InUse(directory, experimentID string) (bool, error) {
+	filename := Path(directory, experimentID)
+	file, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "cannot open journal %q", filename)
+	}
+	defer file.Close()
+
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return true, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "cannot lock journal %q", filename)
+	}
+	return false, syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
+
+// Path returns path of journal of experiment stored in given directory.
+func Path(directory, experimentID string) string {
+	return path.Join(directory, experimentID+journalExtension)
+}
+
+// ExperimentID returns ID of experiment the journal belongs to.
+func (j *Journal) ExperimentID() string {
+	return j.experimentID
+}
+
+// Created records that resource has been created.
+func (j *Journal) Created(resource Resource) error {
+	return j.append(actionCreated, resource)
+}
+
+// Released records that resource has been released.
+// Resource with empty ID releases all resources of its kind and location.
+func (j *Journal) Released(resource Resource) error {
+	return j.append(actionReleased, resource)
+}
+
+// Close closes journal file and releases its lock. Journal is kept on disk.
+func (j *Journal) Close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.file.Close()
+}
+
+func (j *Journal) append(action string, resource Resource) error {
+	line, err := json.Marshal(entry{Time: time.Now(), Action: action, Resource: resource})
+	if err != nil {
+		return errors.Wrapf(err, "cannot encode journal entry for %s", resource)
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	// Single write of whole line, so entries are not interleaved and survive crash of the process.
+	_, err = j.file.Write(append(line, '\n'))
+	if err != nil {
+		return errors.Wrapf(err, "cannot write journal entry for %s", resource)
+	}
+	return nil
+}
+
+// Outstanding returns resources from journal file that have been created, but not released (in order of creation).
+func Outstanding(filename string) ([]Resource, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open journal %q", filename)
+	}
+	defer file.Close()
+
+	outstanding := []Resource{}
+	created := map[string]bool{}
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var e entry
+		err = json.Unmarshal([]byte(line), &e)
+		if err != nil {
+			// Last entry might be truncated when experiment has been killed.
+			log.Warnf("Skipping malformed entry in journal %q at line %d: %s", filename, lineNumber, err.Error())
+			continue
+		}
+
+		switch e.Action {
+		case actionCreated:
+			key := e.Resource.key()
+			if !created[key] {
+				created[key] = true
+				outstanding = append(outstanding, e.Resource)
+			}
+		case actionReleased:
+			remaining := outstanding[:0]
+			for _, resource := range outstanding {
+				if resource.Kind == e.Resource.Kind && resource.Location == e.Resource.Location &&
+					(e.Resource.ID == "" || resource.ID == e.Resource.ID) {
+					delete(created, resource.key())
+					continue
+				}
+				remaining = append(remaining, resource)
+			}
+			outstanding = remaining
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "cannot read journal %q", filename)
+	}
+
+	return outstanding, nil
+}
+
+// List returns IDs of experiments that have journals in given directory.
+func List(directory string) ([]string, error) {
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, errors.Wrapf(err, "cannot list journals in %q", directory)
+	}
+
+	experimentIDs := []string{}
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), journalExtension) {
+			experimentIDs = append(experimentIDs, strings.TrimSuffix(file.Name(), journalExtension))
+		}
+	}
+	sort.Strings(experimentIDs)
+	return experimentIDs, nil
+}
+
+// Remove removes journal of experiment from given directory.
+func Remove(directory, experimentID string) error {
+	filename := Path(directory, experimentID)
+	err := os.Remove(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "cannot remove journal %q", filename)
+	}
+	return nil
+}
+
+var (
+	currentMutex sync.Mutex
+	current      *Journal
+)
+
+// SetCurrent sets journal used by package level Created and Released functions.
+// Passing nil disables journaling.
+func SetCurrent(journal *Journal) {
+	currentMutex.Lock()
+	defer currentMutex.Unlock()
+	current = journal
+}
+
+// Created records creation of resource in current journal (if any).
+// Errors are only logged, because journal must not break the experiment.
+func Created(resource Resource) {
+	currentMutex.Lock()
+	journal := current
+	currentMutex.Unlock()
+
+	if journal == nil {
+		return
+	}
+	if err := journal.Created(resource); err != nil {
+		log.Warnf("Journal: %s", err.Error())
+	}
+}
+
+// Released records release of resource in current journal (if any).
+func Released(resource Resource) {
+	currentMutex.Lock()
+	journal := current
+	currentMutex.Unlock()
+
+	if journal == nil {
+		return
+	}
+	if err := journal.Released(resource); err != nil {
+		log.Warnf("Journal: %s", err.Error())
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journal
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestJournal(t *testing.T) {
+	Convey("When having journal of experiment", t, func() {
+		directory, err := ioutil.TempDir("", "journal")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(directory)
+
+		journal, err := Open(directory, "experiment")
+		So(err, ShouldBeNil)
+
+		hp := Resource{Kind: KindCgroup, ID: "cpuset:/swan/hp"}
+		be := Resource{Kind: KindCgroup, ID: "cpuset:/swan/be"}
+		pod := Resource{Kind: KindKubernetesPod, ID: "swan-1234", Location: "default"}
+		rdt1 := Resource{Kind: KindRDT, ID: "0-3:0xf"}
+		rdt2 := Resource{Kind: KindRDT, ID: "4-7:0xf0"}
+		for _, resource := range []Resource{hp, be, pod, rdt1, rdt2} {
+			So(journal.Created(resource), ShouldBeNil)
+		}
+
+		Convey("Created resources should be outstanding in order of creation", func() {
+			So(journal.Close(), ShouldBeNil)
+			outstanding, err := Outstanding(Path(directory, "experiment"))
+			So(err, ShouldBeNil)
+			So(outstanding, ShouldResemble, []Resource{hp, be, pod, rdt1, rdt2})
+		})
+
+		Convey("Released resources should not be outstanding", func() {
+			So(journal.Released(be), ShouldBeNil)
+			So(journal.Released(Resource{Kind: KindKubernetesPod, ID: "swan-1234", Location: "other"}), ShouldBeNil)
+			So(journal.Close(), ShouldBeNil)
+
+			outstanding, err := Outstanding(Path(directory, "experiment"))
+			So(err, ShouldBeNil)
+			So(outstanding, ShouldResemble, []Resource{hp, pod, rdt1, rdt2})
+		})
+
+		Convey("Release without ID should release all resources of the kind", func() {
+			So(journal.Released(Resource{Kind: KindRDT}), ShouldBeNil)
+			So(journal.Close(), ShouldBeNil)
+
+			outstanding, err := Outstanding(Path(directory, "experiment"))
+			So(err, ShouldBeNil)
+			So(outstanding, ShouldResemble, []Resource{hp, be, pod})
+		})
+
+		Convey("Resource created again after release should be outstanding", func() {
+			So(journal.Released(hp), ShouldBeNil)
+			So(journal.Created(hp), ShouldBeNil)
+			So(journal.Close(), ShouldBeNil)
+
+			outstanding, err := Outstanding(Path(directory, "experiment"))
+			So(err, ShouldBeNil)
+			So(outstanding, ShouldHaveLength, 5)
+		})
+
+		Convey("Truncated entry should be skipped", func() {
+			So(journal.Close(), ShouldBeNil)
+			file, err := os.OpenFile(Path(directory, "experiment"), os.O_WRONLY|os.O_APPEND, 0644)
+			So(err, ShouldBeNil)
+			file.WriteString(`{"action":"released","resou`)
+			file.Close()
+
+			outstanding, err := Outstanding(Path(directory, "experiment"))
+			So(err, ShouldBeNil)
+			So(outstanding, ShouldHaveLength, 5)
+		})
+
+		Convey("Journal should be listed and removable", func() {
+			So(journal.Close(), ShouldBeNil)
+			experimentIDs, err := List(directory)
+			So(err, ShouldBeNil)
+			So(experimentIDs, ShouldResemble, []string{"experiment"})
+
+			So(Remove(directory, "experiment"), ShouldBeNil)
+			experimentIDs, err = List(directory)
+			So(err, ShouldBeNil)
+			So(experimentIDs, ShouldBeEmpty)
+		})
+
+		Convey("Journal should be in use until it is closed", func() {
+			inUse, err := InUse(directory, "experiment")
+			So(err, ShouldBeNil)
+			So(inUse, ShouldBeTrue)
+
+			_, err = Open(directory, "experiment")
+			So(err, ShouldNotBeNil)
+
+			So(journal.Close(), ShouldBeNil)
+			inUse, err = InUse(directory, "experiment")
+			So(err, ShouldBeNil)
+			So(inUse, ShouldBeFalse)
+
+			reopened, err := Open(directory, "experiment")
+			So(err, ShouldBeNil)
+			So(reopened.Close(), ShouldBeNil)
+		})
+
+		Convey("Missing journal should not be in use", func() {
+			inUse, err := InUse(directory, "missing")
+			So(err, ShouldBeNil)
+			So(inUse, ShouldBeFalse)
+		})
+	})
+}
@@ -15,6 +15,7 @@
 package kubernetes
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -23,7 +24,6 @@ import (
 	"github.com/intelsdi-x/swan/pkg/conf"
 	"github.com/intelsdi-x/swan/pkg/executor"
 	"github.com/intelsdi-x/swan/pkg/utils/err_collection"
-	"github.com/intelsdi-x/swan/pkg/utils/netutil"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
@@ -31,7 +31,7 @@ import (
 const (
 	serviceListenTimeout = 15 * time.Second
 
-	// waitForReadyNode configuration
+	// Configuration of probe waiting for kubelet to register (see readyNodesCheck).
 	waitForReadyNodeBackOffPeriod = 1 * time.Second
 	nodeCheckRetryCount           = 20
 	expectedKubeletNodesCount     = 1
@@ -121,8 +121,10 @@ type k8s struct {
 
 	k8sPodAPI // Private interface
 
-	isListening   netutil.IsListeningFunction // For mocking purposes.
-	getReadyNodes getReadyNodesFunc           // For mocking purposes.
+	probe         func(address string) executor.ReadinessProbe // For mocking purposes.
+	getReadyNodes getReadyNodesFunc                            // For mocking purposes.
+	// nodesProbe checks whether kubelet has registered in apiserver of launched cluster.
+	nodesProbe executor.ReadinessProbe
 
 	kubeletHost string // Filled by Kubelet TaskHandle
 }
@@ -131,15 +133,28 @@ type k8s struct {
 // In case of the same executor they will be on the same host (high risk of interferences).
 // NOTE: Currently we support only single-kubelet (single-minion) kubernetes.
 func New(master executor.Executor, minion executor.Executor, config Config) executor.Launcher {
-
-	return &k8s{
+	launcher := &k8s{
 		master:        master,
 		minion:        minion,
 		config:        config,
 		k8sPodAPI:     newK8sPodAPI(config),
-		isListening:   netutil.IsListening,
+		probe:         newServiceProbe,
 		getReadyNodes: getReadyNodes,
 	}
+	launcher.nodesProbe = executor.ReadinessProbe{
+		Check:            readyNodesCheck{launcher: launcher},
+		Interval:         waitForReadyNodeBackOffPeriod,
+		Timeout:          waitForReadyNodeBackOffPeriod,
+		FailureThreshold: nodeCheckRetryCount,
+	}
+	return launcher
+}
+
+// newServiceProbe returns probe checking that service accepts connections at address (for serviceListenTimeout).
+func newServiceProbe(address string) executor.ReadinessProbe {
+	probe := executor.NewTCPProbe(address)
+	probe.FailureThreshold = int(serviceListenTimeout / probe.Interval)
+	return probe
 }
 
 // String returns human readable name for job.
@@ -169,14 +184,10 @@ func (m *k8s) tryLaunchCluster() (executor.TaskHandle, error) {
 		return nil, err
 	}
 
-	apiServerAddress := fmt.Sprintf("%s:%d", handle.Address(), m.config.KubeAPIPort)
-	err = m.waitForReadyNode(apiServerAddress)
+	// Cluster is stopped when kubelet does not register in time.
+	err = executor.WaitUntilReady(context.Background(), handle, m.nodesProbe)
 	if err != nil {
-		stopErr := handle.Stop()
-		if stopErr != nil {
-			log.Warningf("Errors while stopping k8s cluster: %v", stopErr)
-		}
-		return nil, err
+		return nil, errors.Wrap(err, "kubelet could not register in time")
 	}
 	// Optional removal of the unwanted pods in swan's namespace
 	pods, err := m.getPodsFromNode(m.kubeletHost)
@@ -271,13 +282,9 @@ func (m *k8s) launchService(command kubeCommand) (executor.TaskHandle, error) {
 	}
 
 	address := fmt.Sprintf("%s:%d", handle.Address(), command.healthCheckPort)
-	if !m.isListening(address, serviceListenTimeout) {
-		defer handle.Stop()
-		ec, _ := handle.ExitCode()
-
-		return nil, errors.Errorf(
-			"failed to connect to service %q on %q: timeout on connection to %q; task status is %v and exit code is %d",
-			command.raw, command.exec, address, handle.Status(), ec)
+	err = executor.WaitUntilReady(context.Background(), handle, m.probe(address))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to service %q on %q", command.raw, command.exec)
 	}
 
 	return handle, nil
@@ -355,23 +362,24 @@ func (m *k8s) getKubeProxyCommand() kubeCommand {
 		), m.config.KubeProxyPort}
 }
 
-func (m *k8s) waitForReadyNode(apiServerAddress string) error {
-	for idx := 0; idx < nodeCheckRetryCount; idx++ {
-		nodes, err := m.getReadyNodes(apiServerAddress)
-		if err != nil {
-			log.Error(err)
-			return err
-		}
-
-		if len(nodes) == expectedKubeletNodesCount {
-			return nil
-		}
-		log.Debugf("Number of ready nodes equals %d while expected number was %d when querying apiserver at %s (attempt %d of %d)", len(nodes), expectedKubeletNodesCount, apiServerAddress, idx+1, nodeCheckRetryCount)
-		log.Debugf("Sleeping for %s", waitForReadyNodeBackOffPeriod)
-		time.Sleep(waitForReadyNodeBackOffPeriod)
+// readyNodesCheck passes when expected number of nodes is ready according to apiserver of launched cluster.
+type readyNodesCheck struct {
+	launcher *k8s
+}
 
+// Check implements executor.ProbeCheck interface.
+func (c readyNodesCheck) Check(ctx context.Context, handle executor.TaskHandle) error {
+	apiServerAddress := fmt.Sprintf("%s:%d", handle.Address(), c.launcher.config.KubeAPIPort)
+	nodes, err := c.launcher.getReadyNodes(apiServerAddress)
+	if err != nil {
+		return err
 	}
-	log.Debugf("Cannot register kubelet at %s", apiServerAddress)
+	if len(nodes) != expectedKubeletNodesCount {
+		return errors.Errorf("number of ready nodes equals %d while expected number was %d when querying apiserver at %s", len(nodes), expectedKubeletNodesCount, apiServerAddress)
+	}
+	return nil
+}
 
-	return errors.New("kubelet could not register in time")
+func (c readyNodesCheck) String() string {
+	return "ready nodes probe"
 }
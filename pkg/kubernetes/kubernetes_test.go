@@ -15,6 +15,7 @@
 package kubernetes
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -33,9 +34,25 @@ func getNodeListFunc(resultNodes []v1.Node, resultError error) getReadyNodesFunc
 	}
 }
 
-func getIsListeningFunc(result bool) func(address string, timeout time.Duration) bool {
-	return func(address string, timeout time.Duration) bool {
-		return result
+// mockedCheck is a readiness check passing when service is listening.
+type mockedCheck struct {
+	listening bool
+}
+
+func (c mockedCheck) Check(ctx context.Context, handle executor.TaskHandle) error {
+	if !c.listening {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func (c mockedCheck) String() string {
+	return "mocked check"
+}
+
+func getProbeFunc(listening bool) func(address string) executor.ReadinessProbe {
+	return func(address string) executor.ReadinessProbe {
+		return executor.ReadinessProbe{Check: mockedCheck{listening: listening}, Interval: time.Millisecond, FailureThreshold: 1}
 	}
 }
 
@@ -61,10 +78,13 @@ func TestKubernetesLauncher(t *testing.T) {
 		handle.On("Address").Return("127.0.0.1")
 		handle.On("Stop").Return(nil)
 		handle.On("ExitCode").Return(0, nil)
+		handle.On("String").Return("service")
 
 		// Prepare Kubernetes Launcher
 		var k8sLauncher *k8s
 		k8sLauncher = New(master, minion, config).(*k8s)
+		k8sLauncher.nodesProbe.Interval = time.Millisecond
+		k8sLauncher.nodesProbe.FailureThreshold = 3
 
 		Convey("When configuration is passed to Kubernetes Launcher", func() {
 			handle := new(executor.MockTaskHandle)
@@ -72,7 +92,7 @@ func TestKubernetesLauncher(t *testing.T) {
 
 			minion.On("Execute", mock.AnythingOfType("string")).Return(handle, nil)
 			master.On("Execute", mock.AnythingOfType("string")).Return(handle, nil)
-			k8sLauncher.isListening = getIsListeningFunc(true)
+			k8sLauncher.probe = getProbeFunc(true)
 			k8sLauncher.getReadyNodes = getNodeListFunc([]v1.Node{}, nil)
 
 			Convey("Privileged containers should be allowed to run by default", func() {
@@ -119,9 +139,10 @@ func TestKubernetesLauncher(t *testing.T) {
 		})
 
 		Convey("When everything succeed, on Launch method we should receive not-nil task handle and no error", func() {
+			handle.On("Status").Return(executor.RUNNING)
 			minion.On("Execute", mock.AnythingOfType("string")).Return(handle, nil)
 			master.On("Execute", mock.AnythingOfType("string")).Return(handle, nil)
-			k8sLauncher.isListening = getIsListeningFunc(true)
+			k8sLauncher.probe = getProbeFunc(true)
 			k8sLauncher.getReadyNodes = getNodeListFunc([]v1.Node{{}}, nil)
 
 			mockAPI := &mockK8sPodAPI{}
@@ -136,10 +157,11 @@ func TestKubernetesLauncher(t *testing.T) {
 			So(resultHandle, ShouldNotBeNil)
 		})
 		Convey("When Minion executor fails to execute, we should receive nil task handle and an error", func() {
+			handle.On("Status").Return(executor.RUNNING)
 			err := errors.New("mocked-error")
 			minion.On("Execute", mock.AnythingOfType("string")).Return(handle, err)
 			master.On("Execute", mock.AnythingOfType("string")).Return(handle, nil)
-			k8sLauncher.isListening = getIsListeningFunc(true)
+			k8sLauncher.probe = getProbeFunc(true)
 			k8sLauncher.getReadyNodes = getNodeListFunc([]v1.Node{{}}, nil)
 
 			resultHandle, err := k8sLauncher.Launch()
@@ -149,10 +171,11 @@ func TestKubernetesLauncher(t *testing.T) {
 		})
 
 		Convey("When Master executor fails to execute, we should receive nil task handle and an error", func() {
+			handle.On("Status").Return(executor.RUNNING)
 			err := errors.New("mocked-error")
 			minion.On("Execute", mock.AnythingOfType("string")).Return(handle, nil)
 			master.On("Execute", mock.AnythingOfType("string")).Return(handle, err)
-			k8sLauncher.isListening = getIsListeningFunc(true)
+			k8sLauncher.probe = getProbeFunc(true)
 			k8sLauncher.getReadyNodes = getNodeListFunc([]v1.Node{{}}, nil)
 
 			resultHandle, err := k8sLauncher.Launch()
@@ -165,7 +188,7 @@ func TestKubernetesLauncher(t *testing.T) {
 			minion.On("Execute", mock.AnythingOfType("string")).Return(handle, nil)
 			master.On("Execute", mock.AnythingOfType("string")).Return(handle, nil)
 			handle.On("Status").Return(executor.TERMINATED)
-			k8sLauncher.isListening = getIsListeningFunc(false)
+			k8sLauncher.probe = getProbeFunc(false)
 			k8sLauncher.getReadyNodes = getNodeListFunc([]v1.Node{{}}, nil)
 
 			resultHandle, err := k8sLauncher.Launch()
@@ -177,11 +200,35 @@ func TestKubernetesLauncher(t *testing.T) {
 			})
 		})
 
+		Convey("When Kubelet registers to Master after a while, cluster should be launched", func() {
+			handle.On("Status").Return(executor.RUNNING)
+			minion.On("Execute", mock.AnythingOfType("string")).Return(handle, nil)
+			master.On("Execute", mock.AnythingOfType("string")).Return(handle, nil)
+			k8sLauncher.probe = getProbeFunc(true)
+			checks := 0
+			k8sLauncher.getReadyNodes = func(k8sAPIAddress string) ([]v1.Node, error) {
+				checks++
+				if checks < 2 {
+					return []v1.Node{}, nil
+				}
+				return []v1.Node{{}}, nil
+			}
+			mockAPI := &mockK8sPodAPI{}
+			mockAPI.On("getPodsFromNode", mock.AnythingOfType("string")).Return(nil, nil)
+			k8sLauncher.k8sPodAPI = mockAPI
+
+			resultHandle, err := k8sLauncher.Launch()
+			So(err, ShouldBeNil)
+			So(resultHandle, ShouldNotBeNil)
+			So(checks, ShouldEqual, 2)
+		})
+
 		Convey("When Kubelet cannot register to Master, we should receive an error", func() {
+			handle.On("Status").Return(executor.RUNNING)
 			err := errors.New("mocked-error")
 			minion.On("Execute", mock.AnythingOfType("string")).Return(handle, nil)
 			master.On("Execute", mock.AnythingOfType("string")).Return(handle, nil)
-			k8sLauncher.isListening = getIsListeningFunc(true)
+			k8sLauncher.probe = getProbeFunc(true)
 			k8sLauncher.getReadyNodes = getNodeListFunc(nil, err)
 
 			resultHandle, err := k8sLauncher.Launch()
@@ -191,25 +191,33 @@ func (m *Cassandra) RecordMap(metadata map[string]string, kind string) error {
 }
 
 // GetByKind retrive signle kind from the database.
-// Returns error if no kind or too many groups found.
+// Metadata recorded several times with the same kind is merged (values recorded later take precedence).
+// Returns error if no kind found.
 func (m *Cassandra) GetByKind(kind string) (map[string]string, error) {
 	var metadata map[string]string
 
-	maps := []map[string]string{}
+	merged := map[string]string{}
+	groups := 0
 
+	// Rows are ordered from the newest one.
 	iter := m.session.Query(`SELECT metadata FROM metadata WHERE experiment_id = ? AND kind = ? ALLOW FILTERING`, m.experimentID, kind).Iter()
 	for iter.Scan(&metadata) {
-		maps = append(maps, metadata)
+		groups++
+		for key, value := range metadata {
+			if _, ok := merged[key]; !ok {
+				merged[key] = value
+			}
+		}
+		metadata = nil
 	}
 	if err := iter.Close(); err != nil {
 		return nil, err
 	}
 
-	// Make sure that only one map per experiment exists.
-	if len(maps) != 1 {
+	if groups == 0 {
 		return nil, fmt.Errorf("Cannot retrieve metadata for experiment ID  %q and %q kind", m.experimentID, kind)
 	}
-	return maps[0], nil
+	return merged, nil
 }
 
 // Clear deletes all metadata entries associated with the current experiment id.
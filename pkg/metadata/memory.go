@@ -0,0 +1,75 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Memory is Metadata kept in memory of the process. It is used when nothing should be stored in database, e.g. in dry run.
+type Memory struct {
+	experimentID string
+	mutex        sync.Mutex
+	kinds        map[string]map[string]string
+}
+
+// NewMemory returns in-memory metadata of the experiment.
+func NewMemory(experimentID string) Metadata {
+	return &Memory{experimentID: experimentID, kinds: map[string]map[string]string{}}
+}
+
+// Record stores a key and value and associates with the experiment id.
+func (m *Memory) Record(key, value, kind string) error {
+	return m.RecordMap(map[string]string{key: value}, kind)
+}
+
+// RecordMap stores a key and value map and associates with the experiment id.
+func (m *Memory) RecordMap(metadata map[string]string, kind string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.kinds[kind] == nil {
+		m.kinds[kind] = map[string]string{}
+	}
+	for key, value := range metadata {
+		m.kinds[kind][key] = value
+	}
+	return nil
+}
+
+// GetByKind retrieves metadata of single kind.
+// Returns error if no kind found.
+func (m *Memory) GetByKind(kind string) (map[string]string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	recorded, ok := m.kinds[kind]
+	if !ok {
+		return nil, errors.Errorf("no metadata of kind %q found for experiment %s", kind, m.experimentID)
+	}
+	metadata := make(map[string]string, len(recorded))
+	for key, value := range recorded {
+		metadata[key] = value
+	}
+	return metadata, nil
+}
+
+// Clear deletes all metadata entries associated with the current experiment id.
+func (m *Memory) Clear() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.kinds = map[string]map[string]string{}
+	return nil
+}
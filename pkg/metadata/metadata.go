@@ -41,14 +41,20 @@ type Metadata interface {
 	// RecordMap stores a key and value map and associates with the experiment id.
 	RecordMap(metadata map[string]string, kind string) error
 	// GetByKind retrives single metadata type from the database.
-	// Returns error if no kind or too many groups found.
+	// Metadata recorded several times with the same kind is merged (later values take precedence).
+	// Returns error if no kind found.
 	GetByKind(kind string) (map[string]string, error)
 	// Clear deletes all metadata entries associated with the current experiment id.
 	Clear() error
 }
 
 // NewDefault initialize metadata object which is configured via env. variable.
+// In dry run metadata are kept in memory only.
 func NewDefault(experimentID string) (Metadata, error) {
+	if conf.DryRunFlag.Value() {
+		return NewMemory(experimentID), nil
+	}
+
 	if conf.DefaultMetadataDB.Value() == "cassandra" {
 		return NewCassandra(experimentID, DefaultCassandraConfig())
 	}
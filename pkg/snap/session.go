@@ -15,6 +15,7 @@
 package snap
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
@@ -25,6 +26,7 @@ import (
 	"github.com/intelsdi-x/snap/scheduler/wmap"
 	"github.com/intelsdi-x/swan/pkg/conf"
 	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/intelsdi-x/swan/pkg/journal"
 	"github.com/pkg/errors"
 )
 
@@ -148,6 +150,7 @@ func (s *Session) Launch() (executor.TaskHandle, error) {
 
 	task.ID = r.ID
 	task.State = r.State
+	journal.Created(snapTaskResource(task.ID, task.Name, s.pClient.URL))
 
 	return &Handle{
 		task:    task,
@@ -240,6 +243,11 @@ func (s *Handle) getSnapTask() (*rbody.ScheduledTaskReturned, error) {
 // Stop blocks and stops Snap task.
 // When task is already stopped or ended, then it will immediately return.
 func (s *Handle) Stop() error {
+	return s.StopContext(context.Background())
+}
+
+// StopContext stops Snap task like Stop does, but gives up waiting when ctx is done.
+func (s *Handle) StopContext(ctx context.Context) error {
 	executorStatus, coreStatus, err := s.getStatus()
 	if err != nil {
 		return err
@@ -250,6 +258,7 @@ func (s *Handle) Stop() error {
 	}
 
 	if executorStatus == executor.TERMINATED {
+		journal.Released(snapTaskResource(s.task.ID, s.task.Name, s.pClient.URL))
 		return nil
 	}
 
@@ -258,10 +267,11 @@ func (s *Handle) Stop() error {
 		return errors.Wrapf(rs.Err, "could not stop snap task %q: %v", s.task.ID)
 	}
 
-	err = s.waitForStop()
+	err = s.waitForStop(ctx)
 	if err != nil {
 		return errors.Wrapf(err, "could not stop snap task %q", s.task.ID)
 	}
+	journal.Released(snapTaskResource(s.task.ID, s.task.Name, s.pClient.URL))
 
 	return nil
 }
@@ -304,6 +314,33 @@ func (s *Handle) Wait(timeout time.Duration) (bool, error) {
 	}
 }
 
+// WaitContext blocks until the Snap task is executed at least once or ctx is done.
+func (s *Handle) WaitContext(ctx context.Context) (bool, error) {
+	executorStatus, coreStatus, err := s.getStatus()
+	if err != nil {
+		return true, err
+	}
+
+	if coreStatus == core.TaskDisabled {
+		return true, errors.Errorf("snap task %q has been disabled because of errors: %q", s.task.Name, s.lastFailureMessage)
+	}
+
+	if executorStatus == executor.TERMINATED {
+		return true, nil
+	}
+
+	stopper := make(chan struct{})
+	taskCompletionInfo := s.waitForTaskCompletion(stopper)
+
+	select {
+	case err := <-taskCompletionInfo:
+		return true, err
+	case <-ctx.Done():
+		stopper <- struct{}{}
+		return false, ctx.Err()
+	}
+}
+
 // StdoutFile returns error for snap handles.
 func (s *Handle) StdoutFile() (*os.File, error) {
 	return nil, errors.New("snap tasks don't support stdout file")
@@ -365,7 +402,7 @@ func (s *Handle) waitForTaskCompletion(stopWaiting <-chan struct{}) (result <-ch
 	return errorChan
 }
 
-func (s *Handle) waitForStop() error {
+func (s *Handle) waitForStop(ctx context.Context) error {
 	for {
 		t := s.pClient.GetTask(s.task.ID)
 		if t.Err != nil {
@@ -380,6 +417,20 @@ func (s *Handle) waitForStop() error {
 			return errors.Errorf("snap task %q has been disabled because of errors: %q", t.Name, t.LastFailureMessage)
 		}
 
-		time.Sleep(100 * time.Millisecond)
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// snapTaskResource describes Snap task in experiment journal.
+func snapTaskResource(id, name, address string) journal.Resource {
+	return journal.Resource{
+		Kind:       journal.KindSnapTask,
+		ID:         id,
+		Location:   address,
+		Attributes: map[string]string{"name": name},
 	}
 }
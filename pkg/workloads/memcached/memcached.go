@@ -15,6 +15,7 @@
 package memcached
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -22,7 +23,6 @@ import (
 
 	"github.com/intelsdi-x/swan/pkg/conf"
 	"github.com/intelsdi-x/swan/pkg/executor"
-	"github.com/intelsdi-x/swan/pkg/utils/netutil"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -92,9 +92,9 @@ func DefaultMemcachedConfig() Config {
 
 // Memcached is a launcher for the memcached data caching application v 1.4.25.
 type Memcached struct {
-	exec          executor.Executor
-	conf          Config
-	isMemcachedUp netutil.IsListeningFunction // For mocking purposes.
+	exec  executor.Executor
+	conf  Config
+	probe executor.ReadinessProbe
 }
 
 // New is a constructor for Memcached.
@@ -102,12 +102,16 @@ func New(exec executor.Executor, config Config) Memcached {
 	if config.IP == "0.0.0.0" {
 		log.Panic("Memcached has to listen on actual device address, not '0.0.0.0'")
 	}
+
+	// Memcached is ready when it accepts connections (checked for config.Timeout seconds).
+	probe := executor.NewTCPProbe(fmt.Sprintf("%s:%d", config.IP, config.Port))
+	probe.FailureThreshold = int(time.Duration(config.Timeout) * time.Second / probe.Interval)
+
 	return Memcached{
-		exec:          exec,
-		conf:          config,
-		isMemcachedUp: netutil.IsListening,
+		exec:  exec,
+		conf:  config,
+		probe: probe,
 	}
-
 }
 
 // Build command to run mutilate
@@ -133,14 +137,8 @@ func (m Memcached) Launch() (executor.TaskHandle, error) {
 	if err != nil {
 		return nil, err
 	}
-	address := fmt.Sprintf("%s:%d", m.conf.IP, m.conf.Port)
-	if !m.isMemcachedUp(address, time.Second*time.Duration(m.conf.Timeout)) {
-		if err := task.Stop(); err != nil {
-			log.Errorf("failed to stop memcached instance. Error: %q", err.Error())
-		}
-
-		return nil, errors.Errorf("failed to connect to memcached instance. Timeout on connection to %q",
-			address)
+	if err := executor.WaitUntilReady(context.Background(), task, m.probe); err != nil {
+		return nil, errors.Wrap(err, "failed to connect to memcached instance")
 	}
 	return task, nil
 }
@@ -15,6 +15,7 @@
 package memcached
 
 import (
+	"context"
 	"errors"
 	"syscall"
 	"testing"
@@ -26,14 +27,22 @@ import (
 	. "github.com/smartystreets/goconvey/convey"
 )
 
-// IsEndpointListeningMockedSuccess is a mocked IsListeningFunction returning always true.
-func IsEndpointListeningMockedSuccess(address string, timeout time.Duration) bool {
-	return true
+// mockedCheck is a readiness check returning always given result.
+type mockedCheck struct {
+	err error
 }
 
-// IsEndpointListeningMockedFailure is a mocked IsListeningFunction returning always false.
-func IsEndpointListeningMockedFailure(address string, timeout time.Duration) bool {
-	return false
+func (c mockedCheck) Check(ctx context.Context, handle executor.TaskHandle) error {
+	return c.err
+}
+
+func (c mockedCheck) String() string {
+	return "mocked check"
+}
+
+// mockedProbe returns readiness probe with mocked check failing immediately when err is not nil.
+func mockedProbe(err error) executor.ReadinessProbe {
+	return executor.ReadinessProbe{Check: mockedCheck{err: err}, Interval: time.Millisecond, FailureThreshold: 1}
 }
 
 // TestMemcachedWithMockedExecutor runs a Memcached launcher with the mocked executor to simulate
@@ -61,10 +70,12 @@ func TestMemcachedWithMockedExecutor(t *testing.T) {
 			memcachedLauncher := New(
 				mockedExecutor,
 				config)
-			memcachedLauncher.isMemcachedUp = IsEndpointListeningMockedSuccess
+			memcachedLauncher.probe = mockedProbe(nil)
 			Convey("While simulating proper execution", func() {
 				mockedExecutor.On("Execute", expectedCommand).Return(mockedTaskHandle, nil).Once()
 				mockedTaskHandle.On("Address").Return(expectedHost)
+				mockedTaskHandle.On("Status").Return(executor.RUNNING)
+				mockedTaskHandle.On("String").Return("memcached")
 
 				Convey("Build command should create proper command", func() {
 					command := memcachedLauncher.buildCommand()
@@ -88,7 +99,9 @@ func TestMemcachedWithMockedExecutor(t *testing.T) {
 						mockedTaskHandle.On("Stop").Return(nil)
 						mockedTaskHandle.On("Clean").Return(nil)
 						mockedTaskHandle.On("EraseOutput").Return(nil)
-						memcachedLauncher.isMemcachedUp = IsEndpointListeningMockedFailure
+						mockedTaskHandle.On("StdoutFile").Return(nil, errors.New("no output"))
+						mockedTaskHandle.On("StderrFile").Return(nil, errors.New("no output"))
+						memcachedLauncher.probe = mockedProbe(errors.New("connection refused"))
 						task, err := memcachedLauncher.Launch()
 						So(err, ShouldNotBeNil)
 						So(task, ShouldBeNil)
@@ -99,7 +112,9 @@ func TestMemcachedWithMockedExecutor(t *testing.T) {
 						mockedTaskHandle.On("Stop").Return(errors.New("Test error code for stop"))
 						mockedTaskHandle.On("Clean").Return(nil)
 						mockedTaskHandle.On("EraseOutput").Return(nil)
-						memcachedLauncher.isMemcachedUp = IsEndpointListeningMockedFailure
+						mockedTaskHandle.On("StdoutFile").Return(nil, errors.New("no output"))
+						mockedTaskHandle.On("StderrFile").Return(nil, errors.New("no output"))
+						memcachedLauncher.probe = mockedProbe(errors.New("connection refused"))
 						task, err := memcachedLauncher.Launch()
 						So(err, ShouldNotBeNil)
 						So(task, ShouldBeNil)
@@ -110,7 +125,9 @@ func TestMemcachedWithMockedExecutor(t *testing.T) {
 						mockedTaskHandle.On("Stop").Return(nil)
 						mockedTaskHandle.On("Clean").Return(errors.New("Test error code for clean"))
 						mockedTaskHandle.On("EraseOutput").Return(nil)
-						memcachedLauncher.isMemcachedUp = IsEndpointListeningMockedFailure
+						mockedTaskHandle.On("StdoutFile").Return(nil, errors.New("no output"))
+						mockedTaskHandle.On("StderrFile").Return(nil, errors.New("no output"))
+						memcachedLauncher.probe = mockedProbe(errors.New("connection refused"))
 						task, err := memcachedLauncher.Launch()
 						So(err, ShouldNotBeNil)
 						So(task, ShouldBeNil)
@@ -121,7 +138,9 @@ func TestMemcachedWithMockedExecutor(t *testing.T) {
 						mockedTaskHandle.On("Stop").Return(nil)
 						mockedTaskHandle.On("Clean").Return(nil)
 						mockedTaskHandle.On("EraseOutput").Return(errors.New("Test error code for erasing output"))
-						memcachedLauncher.isMemcachedUp = IsEndpointListeningMockedFailure
+						mockedTaskHandle.On("StdoutFile").Return(nil, errors.New("no output"))
+						mockedTaskHandle.On("StderrFile").Return(nil, errors.New("no output"))
+						memcachedLauncher.probe = mockedProbe(errors.New("connection refused"))
 						task, err := memcachedLauncher.Launch()
 						So(err, ShouldNotBeNil)
 						So(task, ShouldBeNil)
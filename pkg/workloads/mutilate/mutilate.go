@@ -15,11 +15,10 @@
 package mutilate
 
 import (
+	"io"
 	"strconv"
 	"time"
 
-	"os"
-
 	"github.com/intelsdi-x/swan/pkg/conf"
 	"github.com/intelsdi-x/swan/pkg/executor"
 	"github.com/intelsdi-x/swan/pkg/workloads/memcached"
@@ -251,8 +250,8 @@ func (m mutilate) Populate() (err error) {
 	return nil
 }
 
-func (m mutilate) getQPSAndLatencyFrom(stdoutFile *os.File) (qps int, achievedSLI int, err error) {
-	results, err := parse.Parse(stdoutFile)
+func (m mutilate) getQPSAndLatencyFrom(stdout io.Reader) (qps int, achievedSLI int, err error) {
+	results, err := parse.Parse(stdout)
 	if err != nil {
 		return qps, achievedSLI, errors.Wrap(err, "could not retrieve QPS from Mutilate Tune output")
 	}
@@ -270,6 +269,32 @@ func (m mutilate) getQPSAndLatencyFrom(stdoutFile *os.File) (qps int, achievedSL
 	return int(rawQPS), int(rawSLI), nil
 }
 
+// ParseSLI returns 99th percentile latency (in microseconds) from mutilate output.
+// It can be used to read SLI of load generated by Load (e.g. by controller.LoadGeneratorSLI).
+func ParseSLI(reader io.Reader) (int, error) {
+	results, err := parse.Parse(reader)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not parse mutilate output")
+	}
+
+	rawSLI, ok := results.Raw[parse.MutilatePercentile99th]
+	if !ok {
+		return 0, errors.New("could not retrieve 99th percentile from mutilate parser")
+	}
+
+	return int(rawSLI), nil
+}
+
+// ParseMeasurement implements executor.LoadMeasurementParser interface: it returns QPS and SLI
+// achieved by load generated with Load, so mutilate can be tuned with executor.LoadTuner.
+func (m mutilate) ParseMeasurement(stdout io.Reader) (executor.LoadMeasurement, error) {
+	qps, sli, err := m.getQPSAndLatencyFrom(stdout)
+	if err != nil {
+		return executor.LoadMeasurement{}, err
+	}
+	return executor.LoadMeasurement{QPS: qps, SLI: sli}, nil
+}
+
 // Tune returns the maximum achieved QPS where SLI is below target SLO.
 func (m mutilate) Tune(slo int) (qps int, achievedSLI int, err error) {
 	// Run agents when specified.
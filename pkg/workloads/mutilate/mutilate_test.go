@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -390,6 +391,21 @@ func (s *MutilateTestSuite) TestPopulate() {
 	})
 }
 
+func (s *MutilateTestSuite) TestMutilateParseMeasurement() {
+	Convey("When output of Load is parsed", s.T(), func() {
+		measurement, err := s.mutilate.ParseMeasurement(strings.NewReader(correctMutilateOutput))
+		Convey("Achieved QPS and SLI should be returned", func() {
+			So(err, ShouldBeNil)
+			So(measurement, ShouldResemble, executor.LoadMeasurement{QPS: correctMutilateQPS, SLI: correctMutilateSLI})
+		})
+
+		Convey("Invalid output should be reported", func() {
+			_, err := s.mutilate.ParseMeasurement(strings.NewReader("Segmentation fault"))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
 func TestMutilateTestSuite(t *testing.T) {
 	suite.Run(t, new(MutilateTestSuite))
 }
@@ -1,11 +1,11 @@
 package redis
 
 import (
+	"context"
 	"fmt"
 	"github.com/intelsdi-x/swan/pkg/conf"
 	"github.com/intelsdi-x/swan/pkg/executor"
 	"github.com/intelsdi-x/swan/pkg/isolation"
-	"github.com/intelsdi-x/swan/pkg/utils/netutil"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"syscall"
@@ -74,18 +74,25 @@ func DefaultConfig() Config {
 
 // Redis is a launcher for the redis data caching application.
 type Redis struct {
-	exec      executor.Executor
-	conf      Config
-	isRedisUp netutil.IsListeningFunction
+	exec executor.Executor
+	conf Config
+	// probe returns readiness probe of Redis listening on address (known when task is launched).
+	probe func(address string) executor.ReadinessProbe
 }
 
 // New is a contructor for Redis.
 func New(exec executor.Executor, config Config) Redis {
+	// Redis is ready when it accepts connections (checked for config.Timeout seconds).
+	probe := func(address string) executor.ReadinessProbe {
+		probe := executor.NewTCPProbe(address)
+		probe.FailureThreshold = int(time.Duration(config.Timeout) * time.Second / probe.Interval)
+		return probe
+	}
 
 	return Redis{
-		exec:      exec,
-		conf:      config,
-		isRedisUp: netutil.IsListening,
+		exec:  exec,
+		conf:  config,
+		probe: probe,
 	}
 }
 
@@ -100,13 +107,8 @@ func (r Redis) Launch() (executor.TaskHandle, error) {
 	}
 
 	address := fmt.Sprintf("%s:%d", task.Address(), r.conf.Port)
-	if !r.isRedisUp(address, time.Second*time.Duration(r.conf.Timeout)) {
-
-		if err := task.Stop(); err != nil {
-			log.Errorf("failed to stop redis instance. Error: %q", err.Error())
-		}
-
-		return nil, errors.Errorf("Failed to connect to redis instance. Timeout on connection to %q !", address)
+	if err := executor.WaitUntilReady(context.Background(), task, r.probe(address)); err != nil {
+		return nil, errors.Wrap(err, "failed to connect to redis instance")
 	}
 
 	return task, nil
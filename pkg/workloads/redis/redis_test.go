@@ -15,6 +15,7 @@
 package redis
 
 import (
+	"context"
 	"errors"
 	"github.com/intelsdi-x/swan/pkg/executor"
 	"github.com/intelsdi-x/swan/pkg/isolation"
@@ -25,13 +26,24 @@ import (
 	"time"
 )
 
-// IsEndpointListeningMockedSuccess is a mocked Is
-func IsEndpointListeningMockedSuccess(address string, timeout time.Duration) bool {
-	return true
+// mockedCheck is a readiness check returning always given result.
+type mockedCheck struct {
+	err error
 }
 
-func IsEndpointListeningMockedFailure(address string, timeout time.Duration) bool {
-	return false
+func (c mockedCheck) Check(ctx context.Context, handle executor.TaskHandle) error {
+	return c.err
+}
+
+func (c mockedCheck) String() string {
+	return "mocked check"
+}
+
+// mockedProbe returns readiness probe with mocked check failing immediately when err is not nil.
+func mockedProbe(err error) func(string) executor.ReadinessProbe {
+	return func(address string) executor.ReadinessProbe {
+		return executor.ReadinessProbe{Check: mockedCheck{err: err}, Interval: time.Millisecond, FailureThreshold: 1}
+	}
 }
 
 func TestRedisWithMockedExecutor(t *testing.T) {
@@ -59,11 +71,13 @@ func TestRedisWithMockedExecutor(t *testing.T) {
 				mockedExecutor,
 				config)
 
-			redisLauncher.isRedisUp = IsEndpointListeningMockedSuccess
+			redisLauncher.probe = mockedProbe(nil)
 
 			Convey("While simulating proper execution", func() {
 				mockedExecutor.On("Execute", expectedCommand).Return(mockedTaskHandle, nil).Once()
 				mockedTaskHandle.On("Address").Return(expectedHost)
+				mockedTaskHandle.On("Status").Return(executor.RUNNING)
+				mockedTaskHandle.On("String").Return("redis")
 
 				Convey("Build command should create proper command", func() {
 					command := redisLauncher.buildCommand()
@@ -88,7 +102,9 @@ func TestRedisWithMockedExecutor(t *testing.T) {
 						mockedTaskHandle.On("Stop").Return(nil)
 						mockedTaskHandle.On("Clean").Return(nil)
 						mockedTaskHandle.On("EraseOutput").Return(nil)
-						redisLauncher.isRedisUp = IsEndpointListeningMockedFailure
+						mockedTaskHandle.On("StdoutFile").Return(nil, errors.New("no output"))
+						mockedTaskHandle.On("StderrFile").Return(nil, errors.New("no output"))
+						redisLauncher.probe = mockedProbe(errors.New("connection refused"))
 						task, err := redisLauncher.Launch()
 						So(err, ShouldNotBeNil)
 						So(task, ShouldBeNil)
@@ -100,7 +116,9 @@ func TestRedisWithMockedExecutor(t *testing.T) {
 						mockedTaskHandle.On("Stop").Return(errors.New("Test error code for stop"))
 						mockedTaskHandle.On("Clean").Return(nil)
 						mockedTaskHandle.On("EraseOutput").Return(nil)
-						redisLauncher.isRedisUp = IsEndpointListeningMockedFailure
+						mockedTaskHandle.On("StdoutFile").Return(nil, errors.New("no output"))
+						mockedTaskHandle.On("StderrFile").Return(nil, errors.New("no output"))
+						redisLauncher.probe = mockedProbe(errors.New("connection refused"))
 						task, err := redisLauncher.Launch()
 						So(err, ShouldNotBeNil)
 						So(task, ShouldBeNil)
@@ -112,7 +130,9 @@ func TestRedisWithMockedExecutor(t *testing.T) {
 						mockedTaskHandle.On("Stop").Return(nil)
 						mockedTaskHandle.On("Clean").Return(errors.New("Test error code for clean"))
 						mockedTaskHandle.On("EraseOutput").Return(nil)
-						redisLauncher.isRedisUp = IsEndpointListeningMockedFailure
+						mockedTaskHandle.On("StdoutFile").Return(nil, errors.New("no output"))
+						mockedTaskHandle.On("StderrFile").Return(nil, errors.New("no output"))
+						redisLauncher.probe = mockedProbe(errors.New("connection refused"))
 						task, err := redisLauncher.Launch()
 						So(err, ShouldNotBeNil)
 						So(task, ShouldBeNil)
@@ -124,7 +144,9 @@ func TestRedisWithMockedExecutor(t *testing.T) {
 						mockedTaskHandle.On("Stop").Return(nil)
 						mockedTaskHandle.On("Clean").Return(nil)
 						mockedTaskHandle.On("EraseOutput").Return(errors.New("Test error code for erasing output"))
-						redisLauncher.isRedisUp = IsEndpointListeningMockedFailure
+						mockedTaskHandle.On("StdoutFile").Return(nil, errors.New("no output"))
+						mockedTaskHandle.On("StderrFile").Return(nil, errors.New("no output"))
+						redisLauncher.probe = mockedProbe(errors.New("connection refused"))
 						task, err := redisLauncher.Launch()
 						So(err, ShouldNotBeNil)
 						So(task, ShouldBeNil)
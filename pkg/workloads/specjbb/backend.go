@@ -15,8 +15,11 @@
 package specjbb
 
 import (
+	"context"
 	"path"
+	"regexp"
 	"runtime"
+	"time"
 
 	"github.com/intelsdi-x/swan/pkg/conf"
 	"github.com/intelsdi-x/swan/pkg/executor"
@@ -26,6 +29,11 @@ import (
 const (
 	name         = "SPECjbb Backend"
 	backendJvmID = "specjbbbackend1"
+
+	// defaultBackendReadyPattern matches banner printed by SPECjbb when JVM of the Backend has started.
+	defaultBackendReadyPattern = "SPECjbb2015"
+	// backendReadyTimeout is time given to JVM of the Backend to start.
+	backendReadyTimeout = 30 * time.Second
 )
 
 var (
@@ -47,6 +55,7 @@ type BackendConfig struct {
 	ControllerAddress string // ControllerAddress is an address of a SPECjbb controller component ("-Dspecjbb.controller.host=")
 	JvmID             string // JvmId is an ID of a JVM dedicated for a Backend (-J <jvmid>)
 	WorkerCount       int    // Amount of threads in ForkJoinPool that will be serving requests.
+	ReadyPattern      string // ReadyPattern is matched against Backend output to check that it has started.
 }
 
 // DefaultSPECjbbBackendConfig is a constructor for BackendConfig with default parameters.
@@ -57,20 +66,28 @@ func DefaultSPECjbbBackendConfig() BackendConfig {
 		ControllerAddress: ControllerAddress.Value(),
 		JvmID:             backendJvmID,
 		WorkerCount:       workerCountFlag.Value(),
+		ReadyPattern:      defaultBackendReadyPattern,
 	}
 }
 
 // Backend is a launcher for the SPECjbb2015 Backend.
 type Backend struct {
-	exec executor.Executor
-	conf BackendConfig
+	exec  executor.Executor
+	conf  BackendConfig
+	probe executor.ReadinessProbe
 }
 
 // NewBackend is a constructor for Backend.
 func NewBackend(exec executor.Executor, config BackendConfig) Backend {
+	// Backend does not accept connections (it attaches to Controller launched later),
+	// so it is ready when its output shows that JVM has started.
+	probe := executor.NewOutputProbe(executor.Stdout, regexp.MustCompile(regexp.QuoteMeta(config.ReadyPattern)))
+	probe.FailureThreshold = int(backendReadyTimeout / probe.Interval)
+
 	return Backend{
-		exec: exec,
-		conf: config,
+		exec:  exec,
+		conf:  config,
+		probe: probe,
 	}
 }
 
@@ -82,6 +99,9 @@ func (b Backend) Launch() (executor.TaskHandle, error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "launch of SPECjbb backend failed. command: %q", command)
 	}
+	if err := executor.WaitUntilReady(context.Background(), task, b.probe); err != nil {
+		return nil, errors.Wrap(err, "SPECjbb backend has not started")
+	}
 	return task, nil
 }
 
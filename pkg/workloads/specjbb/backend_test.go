@@ -15,7 +15,11 @@
 package specjbb
 
 import (
+	"errors"
+	"io/ioutil"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/intelsdi-x/swan/pkg/executor"
 	log "github.com/sirupsen/logrus"
@@ -40,6 +44,20 @@ func TestBackendWithMockedExecutor(t *testing.T) {
 			expectedCommand := getBackendCommand(config)
 			mockedExecutor.On("Execute", expectedCommand).Return(mockedTaskHandle, nil).Once()
 			mockedTaskHandle.On("Address").Return(expectedHost)
+			mockedTaskHandle.On("Status").Return(executor.RUNNING)
+			mockedTaskHandle.On("String").Return("backend")
+
+			// Backend is ready when it prints SPECjbb banner.
+			stdout, err := ioutil.TempFile("", "backend")
+			So(err, ShouldBeNil)
+			defer os.Remove(stdout.Name())
+			_, err = stdout.WriteString("SPECjbb2015 Java Business Benchmark\n")
+			So(err, ShouldBeNil)
+			stdout.Close()
+			mockedTaskHandle.On("StdoutFile").Return(func() *os.File {
+				file, _ := os.Open(stdout.Name())
+				return file
+			}, nil)
 
 			Convey("Arguments passed to Executor should be a proper command", func() {
 				task, err := backendLauncher.Launch()
@@ -53,6 +71,20 @@ func TestBackendWithMockedExecutor(t *testing.T) {
 					So(address, ShouldEqual, expectedHost)
 				})
 			})
+
+			Convey("Backend which has not started should be stopped", func() {
+				mockedTaskHandle.On("Stop").Return(nil)
+				mockedTaskHandle.On("StderrFile").Return(nil, errors.New("no output"))
+				config.ReadyPattern = "never printed"
+				backendLauncher = NewBackend(mockedExecutor, config)
+				backendLauncher.probe.Interval = time.Millisecond
+				backendLauncher.probe.FailureThreshold = 1
+
+				task, err := backendLauncher.Launch()
+				So(err, ShouldNotBeNil)
+				So(task, ShouldBeNil)
+				mockedTaskHandle.AssertCalled(t, "Stop")
+			})
 		})
 
 	})
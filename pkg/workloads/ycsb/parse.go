@@ -0,0 +1,72 @@
+// Copyright (c) 2017 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ycsb
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	"github.com/pkg/errors"
+)
+
+const (
+	throughputMeasurement   = "Throughput(ops/sec)"
+	percentile99Measurement = "99thPercentileLatency(us)"
+)
+
+// ParseMeasurement implements executor.LoadMeasurementParser interface: it returns overall throughput
+// and the highest 99th percentile latency of all operations from YCSB output (lines like "[READ], 99thPercentileLatency(us), 310").
+func (y ycsb) ParseMeasurement(stdout io.Reader) (executor.LoadMeasurement, error) {
+	return parseMeasurement(stdout)
+}
+
+func parseMeasurement(stdout io.Reader) (measurement executor.LoadMeasurement, err error) {
+	throughputFound, latencyFound := false, false
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ",")
+		if len(fields) != 3 {
+			continue
+		}
+		operation, name := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1])
+		if name != throughputMeasurement && name != percentile99Measurement || operation == "[CLEANUP]" {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(fields[2]), 64)
+		if err != nil {
+			return measurement, errors.Wrapf(err, "invalid value of %s %s", operation, name)
+		}
+		if name == throughputMeasurement && operation == "[OVERALL]" {
+			measurement.QPS = int(value)
+			throughputFound = true
+		}
+		if name == percentile99Measurement && int(value) >= measurement.SLI {
+			measurement.SLI = int(value)
+			latencyFound = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return measurement, errors.Wrap(err, "cannot read YCSB output")
+	}
+
+	if !throughputFound || !latencyFound {
+		return measurement, errors.New("throughput or 99th percentile latency not found in YCSB output")
+	}
+	return measurement, nil
+}
@@ -135,13 +135,20 @@ func (y ycsb) Populate() (err error) {
 	return nil
 }
 
-// TODO: Implementation
+// Tune searches for peak load with short probes of YCSB load (YCSB has no built-in tuning).
 func (y ycsb) Tune(slo int) (qps int, achievedSLI int, err error) {
-	return 0, 0, nil
+	return y.TuneWithConfig(slo, executor.DefaultLoadTunerConfig())
 }
 
-func (y ycsb) Load(qps int, duration time.Duration) (executor.TaskHandle, error) {
+// TuneWithConfig implements executor.ConfigurableTuner.
+func (y ycsb) TuneWithConfig(slo int, config executor.LoadTunerConfig) (qps int, achievedSLI int, err error) {
+	return executor.NewLoadTuner(y, y, config).Tune(slo)
+}
 
+// Load runs YCSB with target throughput qps and number of operations lasting for duration.
+func (y ycsb) Load(qps int, duration time.Duration) (executor.TaskHandle, error) {
+	// y is a copy, so parameters of other loads are not affected.
+	CalculateWorkloadCommandParameters(qps, duration, &y.config)
 	loadCommand := y.buildLoadCommand()
 
 	taskHandle, err := y.executor.Execute(loadCommand)
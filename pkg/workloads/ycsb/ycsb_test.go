@@ -1 +1,46 @@
 package ycsb
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/swan/pkg/executor"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/mock"
+)
+
+const ycsbOutput = `[OVERALL], RunTime(ms), 10012
+[OVERALL], Throughput(ops/sec), 9987.9
+[READ], Operations, 49950
+[READ], AverageLatency(us), 120.5
+[READ], 95thPercentileLatency(us), 210
+[READ], 99thPercentileLatency(us), 310
+[READ], Return=OK, 49950
+[CLEANUP], Operations, 1
+[CLEANUP], 99thPercentileLatency(us), 5000
+[UPDATE], Operations, 50050
+[UPDATE], 99thPercentileLatency(us), 420
+`
+
+func TestYcsb(t *testing.T) {
+	Convey("YCSB output should be parsed", t, func() {
+		measurement, err := parseMeasurement(strings.NewReader(ycsbOutput))
+		So(err, ShouldBeNil)
+		So(measurement, ShouldResemble, executor.LoadMeasurement{QPS: 9987, SLI: 420})
+
+		_, err = parseMeasurement(strings.NewReader("[OVERALL], RunTime(ms), 10012"))
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Load should run YCSB with requested throughput and number of operations", t, func() {
+		mockExecutor := new(executor.MockExecutor)
+		mockExecutor.On("Execute", mock.AnythingOfType("string")).Return(new(executor.MockTaskHandle), nil)
+
+		_, err := New(mockExecutor, DefaultYcsbConfig()).Load(2000, 10*time.Second)
+		So(err, ShouldBeNil)
+		command := mockExecutor.Calls[0].Arguments.String(0)
+		So(command, ShouldContainSubstring, " -target 2000")
+		So(command, ShouldContainSubstring, " -p operationcount=20000")
+	})
+}